@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// `wt mod --date YYYY-MM-DD ...` corrects a day that was already archived.
+// The archived timer is loaded into a scratch root, where the regular mod
+// commands run on it unchanged; the result is written back to the archive
+// file and the day's lines in the daily report file are regenerated.
+
+// modArchivedDayCmd runs a mod on the archived day (or archive file name) day
+func modArchivedDayCmd(current *Timer, day string, args []string, force, yes bool) error {
+	archived, file, err := archivedDay(day)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Archive == filepath.Base(file) {
+		return fmt.Errorf("%s is the current timer's day. Use 'wt mod' without --date.", current.DayStart[:len(DATE_FORMAT)])
+	}
+	date := archived.DayStart[:len(DATE_FORMAT)]
+	if info, err := os.Stat(file); err == nil && info.Mode().Perm()&0200 == 0 && !archived.isClosed() {
+		archived.Closed = info.ModTime().Format(DT_FORMAT) // Closed before archives recorded it
+	}
+	if archived.isClosed() && !force {
+		return fmt.Errorf("Day %s is closed. Use 'wt mod --force --date %s %s' to change it anyway; the change is recorded.",
+			date, day, strings.Join(args, " "))
+	}
+	if archived.isClosed() {
+		archived.Archive = filepath.Base(file) // Where modClosedDayCmd rewrites it (in the scratch root)
+	}
+
+	changed, err := modInScratch(archived, args, yes)
+	if err != nil || changed == nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(changed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeArchiveFile(file, data); err != nil {
+		return err
+	}
+	if err := rewriteDailyReport(date); err != nil {
+		return err
+	}
+	dayStart, _ := parseTime(changed.DayStart)
+	if err := updateWeeklyReport(dayStart); err != nil {
+		return err
+	}
+
+	writeDebugEntry(DebugEntry{
+		Level:   LevelInfo,
+		Command: "mod",
+		Args:    append([]string{"--date", day}, args...),
+		Status:  changed.Status,
+		Minutes: map[string]int{"work": changed.Totals().Work - archived.Totals().Work},
+		Message: "Changed archived day " + date,
+	})
+	printMessageIfNotSilent(changed, fmt.Sprintf("Updated %s and the daily report. Work: %s -> %s",
+		filepath.Join(ArchiveFolder, filepath.Base(file)), minutesToHourMinuteStr(archived.Totals().Work), minutesToHourMinuteStr(changed.Totals().Work)))
+	return nil
+}
+
+// modInScratch applies a mod to a copy of timer in a scratch root and returns
+// the result, or nil if the mod was refused or changed nothing
+func modInScratch(timer *Timer, args []string, yes bool) (*Timer, error) {
+	after, err := inScratch(timer, func() error {
+		work, err := load()
+		if err != nil {
+			return err
+		}
+		if work.isClosed() {
+			return modClosedDayCmd(work, args, true, yes)
+		}
+		return modCmd(work, args, yes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if after.DayStart == timer.DayStart && reflect.DeepEqual(after.Timeline, timer.Timeline) {
+		return nil, nil
+	}
+	return after, nil
+}
+
+// inScratch saves a copy of timer in a scratch root, runs fn there, and
+// returns the timer fn left behind
+func inScratch(timer *Timer, fn func() error) (*Timer, error) {
+	scratch, err := os.MkdirTemp("", "wt-amend-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	config, err := configFilePath() // The scratch root has none
+	if err != nil {
+		return nil, err
+	}
+	restore := overrideEnv(map[string]string{
+		"WT_ROOT":               scratch,
+		"WT_CONFIG":             config,
+		"WT_REPORT_FILE":        filepath.Join(scratch, "daily-reports"),
+		"WT_WEEKLY_REPORT_FILE": filepath.Join(scratch, "weekly-reports"), // Not "", which falls back to the profile or config
+	})
+	defer restore()
+
+	// Closed days rewrite their archive as well, here the scratch one
+	archive, err := archiveFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return nil, err
+	}
+	// The scratch saves mustn't record a clock jump meant for the real one
+	pending := pendingClockJump
+	defer func() { pendingClockJump = pending }()
+	muted := linearMuted
+	linearMuted = true
+	defer func() { linearMuted = muted }()
+
+	work := *timer
+	work.Timeline = append([]TimelineEntry{}, timer.Timeline...)
+	if err := save(&work); err != nil {
+		return nil, err
+	}
+	if err := fn(); err != nil {
+		return nil, err
+	}
+	return load()
+}
+
+// rewriteDailyReport replaces the date's lines in the daily report file with
+// lines generated from its archives, each with the rate of its profile
+func rewriteDailyReport(date string) error {
+	files, err := archiveFilesOn(date)
+	if err != nil {
+		return err
+	}
+	var timers []*Timer
+	for _, file := range files {
+		data, err := readArchiveFile(file)
+		if err != nil {
+			return err
+		}
+		var timer Timer
+		if err := json.Unmarshal(data, &timer); err != nil {
+			return fmt.Errorf("Invalid archive file %s: %v", filepath.Base(file), err)
+		}
+		timers = append(timers, &timer)
+	}
+	sort.SliceStable(timers, func(i, j int) bool { return timers[i].DayStart < timers[j].DayStart }) // Oldest first, like the file
+
+	var lines []string
+	for _, timer := range timers {
+		restore := func() {}
+		if timer.Profile != "" {
+			restore = overrideEnv(map[string]string{"WT_PROFILE": timer.Profile})
+		}
+		lines = append(lines, dailyReportLine(timer))
+		restore()
+	}
+
+	filePath, err := dailyReportFilePath()
+	if err != nil {
+		return err
+	}
+	var existing []string
+	if data, err := readFile(filePath); err == nil && strings.TrimSpace(string(data)) != "" {
+		existing = strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	// The new lines go where the old ones were, or are appended like new days
+	var kept []string
+	at := -1
+	for _, line := range existing {
+		if strings.HasPrefix(line, date+" |") {
+			if at < 0 {
+				at = len(kept)
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if at < 0 {
+		at = len(kept)
+	}
+	result := append(append(append([]string{}, kept[:at]...), lines...), kept[at:]...)
+	return writeFile(filePath, []byte(strings.Join(result, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Finished days are archived by reset as $WT_ROOT/.out/archive/YYYY-MM-DD.json,
+// holding the day's timer with the active cycle closed. A second reset on the
+// same day gets a numbered suffix (YYYY-MM-DD.2.json). `wt prune --compress`
+// gzips old days in place (YYYY-MM-DD.json.gz); with WT_ARCHIVE_GZIP=1 days
+// are archived gzipped right away. Range views only open the files whose
+// name falls in the range, and decode them as they're read. Unlike the daily report
+// line, the archive keeps every cycle, so views spanning several days can be
+// drawn from it.
+
+const ArchiveFolder = "archive"
+
+func archiveFolderPath() (string, error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, ArchiveFolder), nil
+}
+
+// closed returns a copy of the timer with the running/paused cycle recorded
+// as if it had been stopped now
+func (t *Timer) closed() *Timer {
+	c := *t
+	c.Timeline = append([]TimelineEntry{}, t.Timeline...)
+	if t.Status != StatusRunning && t.Status != StatusPaused {
+		return &c
+	}
+
+	entries := buildLogEntries(t)
+	active := entries[len(entries)-1]
+	if n := len(c.Timeline); n > 0 && c.Timeline[n-1].Type == "work" {
+		c.Timeline[n-1].Pauses = append(slices.Clone(c.Timeline[n-1].Pauses), shiftPauses(active.Pauses, c.Timeline[n-1].Duration())...)
+		c.Timeline[n-1].Marks = append(slices.Clone(c.Timeline[n-1].Marks), shiftMarks(active.Marks, c.Timeline[n-1].Duration())...)
+		c.Timeline[n-1].Minutes += active.Minutes
+		c.Timeline[n-1].PausedMinutes += active.PausedMinutes
+		c.Timeline[n-1].Tags = mergeTags(c.Timeline[n-1].Tags, active.Tags)
+		c.Timeline[n-1].Location = cmp.Or(c.Timeline[n-1].Location, active.Location)
+		c.Timeline[n-1].Task = cmp.Or(c.Timeline[n-1].Task, active.Task)
+		c.Timeline[n-1].Rating = cmp.Or(active.Rating, c.Timeline[n-1].Rating)
+	} else {
+		c.Timeline = append(c.Timeline, TimelineEntry{Type: "work", Minutes: active.Minutes, PausedMinutes: active.PausedMinutes, Tags: active.Tags, Location: active.Location, Task: active.Task, Rating: active.Rating, Pauses: active.Pauses, Marks: active.Marks})
+	}
+
+	c.Status = StatusStopped
+	c.StopDatetimeStr = active.End.Format(DT_FORMAT)
+	c.PauseStartStr = ""
+	c.PausedMinutes = 0
+	c.PauseReason, c.Pauses, c.Marks, c.CycleTags = "", nil, nil, nil
+	return &c
+}
+
+// archiveDay saves the timer's day to the archive, tagged with the active
+// profile, and returns the file. Timers that never started are skipped.
+func archiveDay(timer *Timer) (string, error) {
+	if timer.DayStart == "" {
+		return "", nil
+	}
+	archived := timer.closed()
+	archived.Profile = activeProfile()
+	return writeArchive(archived)
+}
+
+// writeArchive saves a finished day as is, updates its week in the weekly
+// report, and returns the file
+func writeArchive(timer *Timer) (string, error) {
+	filePath, err := writeArchiveDay(timer)
+	if err != nil {
+		return "", err
+	}
+	dayStart, _ := parseTime(timer.DayStart)
+	return filePath, updateWeeklyReport(dayStart)
+}
+
+// writeArchiveDay saves a finished day as is and returns the file, leaving the
+// weekly report to the caller, e.g. once for all the days of an import
+func writeArchiveDay(timer *Timer) (string, error) {
+	dayStart, err := parseTime(timer.DayStart)
+	if err != nil {
+		return "", err
+	}
+
+	folder, err := archiveFolderPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", err
+	}
+
+	date := dayStart.Format(DATE_FORMAT)
+	name := date
+	for i := 2; archiveExists(folder, name); i++ {
+		name = fmt.Sprintf("%s.%d", date, i)
+	}
+
+	data, err := json.MarshalIndent(timer, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(folder, name+".json")
+	if setting("WT_ARCHIVE_GZIP") != "" {
+		filePath += ".gz"
+		if data, err = gzipBytes(data); err != nil {
+			return "", err
+		}
+	}
+	if err := writeFile(filePath, data, 0644); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// archiveExists reports whether an archive file by the name (YYYY-MM-DD or
+// YYYY-MM-DD.N) exists, compressed or not
+func archiveExists(folder, name string) bool {
+	for _, ext := range []string{".json", ".json.gz"} {
+		if _, err := os.Stat(filepath.Join(folder, name+ext)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// loadArchivedDays returns the archived timers whose day starts within
+// [from, to), ordered by day start
+func loadArchivedDays(from, to time.Time) ([]*Timer, error) {
+	folder, err := archiveFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(folder)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var timers []*Timer
+	for _, file := range files {
+		name := file.Name()
+		day, ok := archiveFileDate(name)
+		if file.IsDir() || !ok || day.Before(from.AddDate(0, 0, -1)) || !day.Before(to) {
+			continue
+		}
+
+		f, err := openArchiveFile(filepath.Join(folder, name))
+		if err != nil {
+			return nil, err
+		}
+		var timer Timer
+		err = json.NewDecoder(f).Decode(&timer)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid archive file %s: %v", name, err)
+		}
+		if start, err := parseTime(timer.DayStart); err == nil && !start.Before(from) && start.Before(to) {
+			timers = append(timers, &timer)
+		}
+	}
+
+	sort.SliceStable(timers, func(i, j int) bool { return timers[i].DayStart < timers[j].DayStart })
+	return timers, nil
+}
+
+// archiveFileDate returns the day an archive file (.json or .json.gz) is named after
+func archiveFileDate(name string) (time.Time, bool) {
+	if !strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".json.gz") || len(name) < len(DATE_FORMAT) {
+		return time.Time{}, false
+	}
+	day, err := time.ParseInLocation(DATE_FORMAT, name[:len(DATE_FORMAT)], time.Local)
+	return day, err == nil
+}
+
+// readArchiveFile reads an archive file, decompressing .gz files
+func readArchiveFile(filePath string) ([]byte, error) {
+	f, err := openArchiveFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// openArchiveFile opens an archive file for reading, decompressing .gz
+// files as they're read
+func openArchiveFile(filePath string) (io.ReadCloser, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	traceFile("read", filePath)
+	if !strings.HasSuffix(filePath, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipFileReader{gz, f}, nil
+}
+
+// gzipFileReader closes the file under the gzip reader as well
+type gzipFileReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r gzipFileReader) Close() error {
+	r.Reader.Close()
+	return r.file.Close()
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// archiveName is an archive file's name without extension (YYYY-MM-DD or YYYY-MM-DD.N)
+func archiveName(file string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(file, ".gz"), ".json")
+}
+
+// archivedDay returns the archived timer of a day (YYYY-MM-DD) or archive
+// file (YYYY-MM-DD.2), and its file. A day archived more than once must be
+// picked by file.
+func archivedDay(day string) (*Timer, string, error) {
+	files, err := archiveFilesOn(day)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case len(files) == 0:
+		return nil, "", fmt.Errorf("No archived day %s.", day)
+	case len(files) > 1:
+		var names []string
+		for _, file := range files {
+			names = append(names, archiveName(filepath.Base(file)))
+		}
+		return nil, "", fmt.Errorf("%s has %d archived timers: %s. Pick one with --date.", day, len(files), strings.Join(names, ", "))
+	}
+	data, err := readArchiveFile(files[0])
+	if err != nil {
+		return nil, "", err
+	}
+	var timer Timer
+	if err := json.Unmarshal(data, &timer); err != nil {
+		return nil, "", fmt.Errorf("Invalid archive file %s: %v", filepath.Base(files[0]), err)
+	}
+	return &timer, files[0], nil
+}
+
+// dayTimer returns the timer views with --date show: current without a date
+// or on its own day, else the archived day (today, yesterday, YYYY-MM-DD, or
+// an archive file name like YYYY-MM-DD.2). The settings of the profile the
+// day was archived with apply until restore is called.
+func dayTimer(current *Timer, date string) (timer *Timer, restore func(), err error) {
+	restore = func() {}
+	if r, err := parseDateRange(date); err == nil && r.To.Equal(r.From.AddDate(0, 0, 1)) {
+		date = r.From.Format(DATE_FORMAT)
+	}
+	if date == "" || current.DayStart != "" && current.DayStart[:len(DATE_FORMAT)] == date {
+		return current, restore, nil
+	}
+	if timer, _, err = archivedDay(date); err != nil {
+		return nil, restore, err
+	}
+	if timer.Profile != "" {
+		restore = overrideEnv(map[string]string{"WT_PROFILE": timer.Profile})
+	}
+	return timer, restore, nil
+}
+
+// archiveFilesOn returns the archive files of a day (YYYY-MM-DD), or the one
+// file given by name (YYYY-MM-DD.2 or YYYY-MM-DD.2.json)
+func archiveFilesOn(day string) ([]string, error) {
+	folder, err := archiveFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(folder)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, ok := archiveFileDate(name); !ok || entry.IsDir() {
+			continue
+		}
+		if name == day || archiveName(name) == day || (len(day) == len(DATE_FORMAT) && strings.HasPrefix(name, day+".")) {
+			files = append(files, filepath.Join(folder, name))
+		}
+	}
+	return files, nil
+}
+
+// writeArchiveFile replaces an archive file's content, compressing .gz files
+// and keeping its permissions (closed days are read-only)
+func writeArchiveFile(filePath string, data []byte) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(filePath, ".gz") {
+		if data, err = gzipBytes(data); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Chmod(filePath, 0644); err != nil {
+		return err
+	}
+	if err := writeFile(filePath, data, 0644); err != nil {
+		return err
+	}
+	return os.Chmod(filePath, info.Mode().Perm())
+}
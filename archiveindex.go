@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Views that only need a day's totals (`wt report --range` by day, week,
+// month, or project, `wt compare`, `wt stats --chart` and `--trend`) read
+// them from .out/archive-index.json instead of opening every archive file.
+// The index holds a summary per archive file along with the file's size and
+// modification time; files that changed since (or are new) are summarized
+// again and the index is saved, so nothing else has to keep it up to date.
+// Deleting it is safe.
+
+const ArchiveIndexFile = "archive-index.json"
+
+// DaySummary is what the range views need of a day
+type DaySummary struct {
+	DayStart string    `json:"day_start"`
+	Profile  string    `json:"profile,omitempty"` // Active when the day was archived
+	Totals   DayTotals `json:"totals"`
+	Cycles   int       `json:"cycles"` // Work cycles
+	Tags     []string  `json:"tags,omitempty"`
+	Size     int64     `json:"size,omitempty"`     // Of the archive file when summarized
+	ModTime  int64     `json:"mod_time,omitempty"` // Of the archive file, Unix nanoseconds
+}
+
+// summarizeDay returns the summary of a timer's day
+func summarizeDay(t *Timer) DaySummary {
+	summary := DaySummary{DayStart: t.DayStart, Profile: t.Profile, Totals: t.Totals()}
+	for _, entry := range buildLogEntries(t) {
+		if entry.Type == "work" {
+			summary.Cycles++
+			summary.Tags = mergeTags(summary.Tags, entry.Tags)
+		}
+	}
+	return summary
+}
+
+// loadDaySummaries returns the summaries of the archived days that start
+// within [from, to), ordered by day start, like loadArchivedDays
+func loadDaySummaries(from, to time.Time) ([]DaySummary, error) {
+	folder, err := archiveFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(folder)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out, err := outputFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	indexPath := filepath.Join(out, ArchiveIndexFile)
+	index := map[string]DaySummary{} // Archive file name -> summary
+	if data, err := readFile(indexPath); err == nil {
+		if json.Unmarshal(data, &index) != nil {
+			index = map[string]DaySummary{} // Rebuilt below
+		}
+	}
+
+	var summaries []DaySummary
+	changed := false
+	present := map[string]bool{}
+	for _, file := range files {
+		name := file.Name()
+		if _, ok := archiveFileDate(name); !ok || file.IsDir() {
+			continue
+		}
+		present[name] = true
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		summary, ok := index[name]
+		if !ok || summary.Size != info.Size() || summary.ModTime != info.ModTime().UnixNano() {
+			day, _ := archiveFileDate(name)
+			if day.Before(from.AddDate(0, 0, -1)) || !day.Before(to) {
+				continue // Summarized when a view needs it
+			}
+			summary, err = summarizeArchiveFile(filepath.Join(folder, name))
+			if err != nil {
+				return nil, err
+			}
+			summary.Size, summary.ModTime = info.Size(), info.ModTime().UnixNano()
+			index[name] = summary
+			changed = true
+		}
+		if start, err := parseTime(summary.DayStart); err == nil && !start.Before(from) && start.Before(to) {
+			summaries = append(summaries, summary)
+		}
+	}
+	for name := range index {
+		if !present[name] {
+			delete(index, name)
+			changed = true
+		}
+	}
+
+	if changed && !readOnly.Load() {
+		if data, err := json.Marshal(index); err == nil {
+			writeFile(indexPath, data, 0644) // Only a cache, rebuilt next time if this fails
+		}
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].DayStart < summaries[j].DayStart })
+	return summaries, nil
+}
+
+// summarizeArchiveFile reads an archive file and summarizes its day
+func summarizeArchiveFile(filePath string) (DaySummary, error) {
+	f, err := openArchiveFile(filePath)
+	if err != nil {
+		return DaySummary{}, err
+	}
+	defer f.Close()
+	var timer Timer
+	if err := json.NewDecoder(f).Decode(&timer); err != nil {
+		return DaySummary{}, fmt.Errorf("Invalid archive file %s: %v", filepath.Base(filePath), err)
+	}
+	return summarizeDay(&timer), nil
+}
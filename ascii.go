@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// With WT_ASCII set, output is guaranteed to be pure ASCII: the charts use
+// ASCII glyph sets, and everything written to stdout passes through
+// toASCII, which transliterates what it can (names in holiday calendars,
+// currency symbols, tags) and replaces the rest with "?".
+
+func asciiMode() bool {
+	return setting("WT_ASCII") != ""
+}
+
+// glyphs returns the ASCII variant in ASCII mode, the unicode one otherwise
+func glyphs(unicode, ascii string) string {
+	if asciiMode() {
+		return ascii
+	}
+	return unicode
+}
+
+var asciiReplacer = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss",
+	"á", "a", "à", "a", "â", "a", "å", "a", "ã", "a", "Á", "A", "À", "A", "Â", "A", "Å", "A",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "É", "E", "È", "E", "Ê", "E",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "Î", "I",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ø", "o", "Ô", "O", "Ø", "O",
+	"ú", "u", "ù", "u", "û", "u", "Û", "U",
+	"ç", "c", "Ç", "C", "ñ", "n", "Ñ", "N", "æ", "ae", "Æ", "AE", "œ", "oe", "Œ", "OE",
+	"€", "EUR", "£", "GBP", "¥", "JPY", "¢", "c",
+	"–", "-", "—", "-", "‘", "'", "’", "'", "“", "\"", "”", "\"", "…", "...",
+	"→", "->", "←", "<-", "⇒", "=>", "•", "*", "·", ".", "×", "x", "°", " deg",
+	"█", "#", "░", "=", "▁", "_", "▂", "_", "▃", ".", "▄", "-", "▅", "=", "▆", "+", "▇", "*",
+	"\u00a0", " ", // No-break space
+)
+
+// toASCII transliterates s, replacing what's left outside ASCII with "?"
+func toASCII(s string) string {
+	s = asciiReplacer.Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf {
+			return r
+		}
+		return '?'
+	}, s)
+}
+
+// asciiStdout is the filter installed over os.Stdout in ASCII mode
+var asciiStdout *asciiFilter
+
+type asciiFilter struct {
+	out  *os.File // The real stdout
+	pipe *os.File // Write end, installed as os.Stdout
+	done chan struct{}
+}
+
+// startASCIIOutput routes os.Stdout through toASCII if ASCII mode is on.
+// Calling it again (e.g. for commands dispatched in-process) does nothing.
+func startASCIIOutput() error {
+	if asciiStdout != nil || !asciiMode() {
+		return nil
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	filter := &asciiFilter{out: os.Stdout, pipe: w, done: make(chan struct{})}
+	go func() {
+		defer close(filter.done)
+		buf := make([]byte, 4096)
+		var pending []byte // An incomplete UTF-8 sequence from the last read
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				data := append(pending, buf[:n]...)
+				end := len(data)
+				for i := max(0, end-utf8.UTFMax+1); i < end; i++ {
+					if utf8.RuneStart(data[i]) && !utf8.FullRune(data[i:]) {
+						end = i
+						break
+					}
+				}
+				filter.out.WriteString(toASCII(string(data[:end])))
+				pending = append([]byte{}, data[end:]...)
+			}
+			if err != nil {
+				if len(pending) > 0 {
+					filter.out.WriteString(toASCII(string(pending)))
+				}
+				r.Close()
+				return
+			}
+		}
+	}()
+	os.Stdout = w
+	asciiStdout = filter
+	return nil
+}
+
+// stopASCIIOutput flushes the filter and restores stdout
+func stopASCIIOutput() {
+	if asciiStdout == nil {
+		return
+	}
+	os.Stdout = asciiStdout.out
+	asciiStdout.pipe.Close()
+	<-asciiStdout.done
+	asciiStdout = nil
+}
+
+// exit ends the program, flushing filtered output first
+func exit(code int) {
+	stopASCIIOutput()
+	os.Exit(code)
+}
+
+// printError prints a command's error to stderr, in ASCII if asked to
+func printError(err error) {
+	message := err.Error()
+	if asciiMode() {
+		message = toASCII(message)
+	}
+	fmt.Fprintln(os.Stderr, message)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Timeline entries get a stable ID (e1, e2, ... in the order they were
+// created that day) when they're first saved, with when and by which command
+// they were created and last changed. save() compares the timeline to the one
+// on disk to find out, so commands don't need to stamp entries themselves.
+// `wt log --audit` lists the trail; dropped entries only show up in
+// `wt log debug`. `wt mod` takes an ID wherever it takes a cycle number, so
+// scripts can address an entry after others were dropped or merged.
+
+// auditCommand is the command line the next save is attributed to, set by
+// the state lock and refined by logCommand
+var auditCommand string
+
+// sameEntry reports whether two entries are equal apart from their audit fields
+func sameEntry(a, b TimelineEntry) bool {
+	return a.Type == b.Type && a.Minutes == b.Minutes && a.PausedMinutes == b.PausedMinutes &&
+		a.Location == b.Location && a.Task == b.Task && a.Rating == b.Rating && a.Kind == b.Kind && slices.Equal(a.Tags, b.Tags) && slices.Equal(a.Marks, b.Marks) && slices.Equal(a.Meetings, b.Meetings) &&
+		slices.Equal(a.Pauses, b.Pauses)
+}
+
+// stampEntries gives new entries an ID and stamps created and changed ones,
+// comparing with the previously saved timeline. Without one (a fresh timer,
+// or a copy saved to a scratch root) entries only get IDs.
+func stampEntries(timer *Timer, previous *Timer) {
+	now := getCurrentTime().Format(DT_FORMAT)
+	saved := map[string]TimelineEntry{}
+	if previous != nil {
+		for _, entry := range previous.Timeline {
+			if entry.ID != "" {
+				saved[entry.ID] = entry
+			}
+		}
+	}
+
+	for i := range timer.Timeline {
+		entry := &timer.Timeline[i]
+		if entry.ID == "" {
+			timer.LastID++
+			entry.ID = fmt.Sprintf("e%d", timer.LastID)
+			// Entries saved before they had IDs aren't new
+			legacy := previous != nil && i < len(previous.Timeline) && previous.Timeline[i].ID == "" && sameEntry(previous.Timeline[i], *entry)
+			if previous != nil && !legacy {
+				entry.Created, entry.Command = now, auditCommand
+			}
+			continue
+		}
+		if old, ok := saved[entry.ID]; ok && !sameEntry(old, *entry) {
+			entry.Modified, entry.Command = now, auditCommand
+		}
+	}
+}
+
+// isEntryID reports whether s looks like an entry ID (e7)
+func isEntryID(s string) bool {
+	digits, ok := strings.CutPrefix(s, "e")
+	return ok && digits != "" && isDigits(digits)
+}
+
+// entryNumber returns the position (from 1) of the entry with the given ID
+func entryNumber(timer *Timer, id string) (int, bool) {
+	i := slices.IndexFunc(timer.Timeline, func(e TimelineEntry) bool { return e.ID == id })
+	return i + 1, i >= 0
+}
+
+// formatAuditEntry renders an entry's trail for `wt log --audit`
+func formatAuditEntry(entry LogEntry) string {
+	if entry.Active {
+		return fmt.Sprintf("%02d. %-4s [%s => .....] %s | Running", entry.Num, "-", entry.Start.Format(TIME_ONLY_FORMAT), entry.Label)
+	}
+	line := fmt.Sprintf("%02d. %-4s [%s => %s] %s", entry.Num, cmp.Or(entry.ID, "-"), entry.Start.Format(TIME_ONLY_FORMAT), entry.End.Format(TIME_ONLY_FORMAT), entry.Label)
+	if entry.Created != "" {
+		line += " | Created " + entry.Created
+	}
+	if entry.Modified != "" {
+		line += " | Modified " + entry.Modified
+	}
+	if entry.Command != "" {
+		line += " by " + entry.Command
+	}
+	return line
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Breaks can be given a kind: short (coffee), lunch, or errand. `wt start
+// --break lunch` sets the kind of the break the start ends, and
+// `wt mod N kind errand` changes it later. Breaks without a kind are lunch
+// when WT_LUNCH_WINDOW classifies them so, and short otherwise. Lunch breaks
+// count in the Lunch total, the others in Break; once a break was given a
+// kind, `wt report` lists the day's breaks by kind.
+
+const (
+	BreakShort  = "short"
+	BreakLunch  = "lunch"
+	BreakErrand = "errand"
+)
+
+var BreakKinds = []string{BreakShort, BreakLunch, BreakErrand}
+
+func validateBreakKind(kind string) error {
+	if !slices.Contains(BreakKinds, kind) {
+		return fmt.Errorf("Invalid break kind: %s. Use %s.", kind, strings.Join(BreakKinds, ", "))
+	}
+	return nil
+}
+
+// breakKind returns the kind of a break starting at start
+func breakKind(start time.Time, entry TimelineEntry) string {
+	if entry.Kind != "" {
+		return entry.Kind
+	}
+	if isLunchBreak(start, entry.Minutes) {
+		return BreakLunch
+	}
+	return BreakShort
+}
+
+// breakLabel returns the log label of a break kind
+func breakLabel(kind string) string {
+	switch kind {
+	case BreakLunch:
+		return "Lunch"
+	case BreakErrand:
+		return "Errand"
+	default:
+		return "Break"
+	}
+}
+
+// breakKindSummary renders the day's breaks by kind for `wt report`, or ""
+// if no break was given a kind
+func breakKindSummary(timer *Timer) string {
+	if !slices.ContainsFunc(timer.Timeline, func(e TimelineEntry) bool { return e.Kind != "" }) {
+		return ""
+	}
+	minutes := map[string]int{}
+	start, _ := parseTime(timer.DayStart)
+	for _, entry := range timer.Timeline {
+		if entry.Type == "break" {
+			minutes[breakKind(start, entry)] += entry.Minutes
+		}
+		start = start.Add(time.Duration(entry.Duration()) * time.Minute)
+	}
+	var parts []string
+	for _, kind := range BreakKinds {
+		if minutes[kind] > 0 {
+			parts = append(parts, kind+" "+minutesToHourMinuteStr(minutes[kind]))
+		}
+	}
+	return "Breaks: " + strings.Join(parts, ", ")
+}
+
+// modBreakKindCmd sets the kind of a break; "auto" clears it
+func modBreakKindCmd(timer *Timer, cycleNumStr, kind string) error {
+	if !isDigits(cycleNumStr) {
+		fmt.Printf("Invalid cycle number: %s\n", cycleNumStr)
+		return nil
+	}
+	cycleNum, _ := strconv.Atoi(cycleNumStr)
+	if cycleNum < 1 || cycleNum > len(timer.Timeline) {
+		fmt.Printf("Cycle %d does not exist. Valid range: 1-%d\n", cycleNum, len(timer.Timeline))
+		return nil
+	}
+	entry := &timer.Timeline[cycleNum-1]
+	if entry.Type != "break" {
+		fmt.Printf("Cycle %d is a work cycle. Only breaks have a kind.\n", cycleNum)
+		return nil
+	}
+	if kind == "auto" {
+		kind = ""
+	} else if err := validateBreakKind(kind); err != nil {
+		return err
+	}
+	entry.Kind = kind
+
+	logCommand(timer, "mod", []string{cycleNumStr, "kind", entry.Kind}, map[string]int{"cycle": cycleNum})
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	if kind == "" {
+		printMessageIfNotSilent(timer, fmt.Sprintf("Cycle %d is classified automatically again", cycleNum))
+	} else {
+		printMessageIfNotSilent(timer, fmt.Sprintf("Cycle %d is now marked %s", cycleNum, kind))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// `wt sync caldav` pushes the work cycles as events to a CalDAV calendar
+// (Nextcloud, Fastmail, Radicale, ...): WT_CALDAV_URL is the calendar's
+// collection URL, WT_CALDAV_USER and WT_CALDAV_PASSWORD (an app password)
+// its credentials. Each cycle is a resource named after its date, profile,
+// and entry ID, so pushing again replaces the events instead of adding
+// more; the running cycle is pushed once it's stopped. The resources pushed
+// per date are kept in .out/caldav.json, so events of cycles dropped or
+// merged since are deleted.
+
+const CalDAVStateFile = "caldav.json"
+
+// calDAVRequest sends a request for a resource of the calendar and checks
+// the response has one of the accepted statuses
+func calDAVRequest(method, resource, body string, accepted ...int) error {
+	url := strings.TrimRight(setting("WT_CALDAV_URL"), "/") + "/" + resource
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(setting("WT_CALDAV_USER"), setting("WT_CALDAV_PASSWORD"))
+	if body != "" {
+		req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	}
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("CalDAV server unreachable: %v", err)
+	}
+	resp.Body.Close()
+	if !slices.Contains(accepted, resp.StatusCode) {
+		return fmt.Errorf("CalDAV: %s %s returned %s", method, resource, resp.Status)
+	}
+	return nil
+}
+
+// syncCalDAVCmd pushes the work cycles of the range, or with dryRun only
+// lists them
+func syncCalDAVCmd(timer *Timer, rangeName string, dryRun bool) error {
+	if !dryRun && (setting("WT_CALDAV_URL") == "" || setting("WT_CALDAV_USER") == "") {
+		return fmt.Errorf("Set WT_CALDAV_URL (the calendar's URL), WT_CALDAV_USER, and WT_CALDAV_PASSWORD to push to a CalDAV calendar.")
+	}
+	if !dryRun && readOnly.Load() {
+		return errReadOnly("not pushing to CalDAV")
+	}
+	r, err := parseDateRange(rangeName)
+	if err != nil {
+		return err
+	}
+	timers, err := loadRange(timer, r)
+	if err != nil {
+		return err
+	}
+	events := cycleEvents(exportDays(timers, "work"))
+	if dryRun {
+		for _, event := range events {
+			fmt.Printf("%s | %s\n", event.Summary, event.Resource)
+		}
+		if len(events) == 0 {
+			fmt.Printf("No work cycles to push for %s.\n", r.Name)
+		}
+		return nil
+	}
+
+	folder, err := outputFolderPath()
+	if err != nil {
+		return err
+	}
+	statePath := filepath.Join(folder, CalDAVStateFile)
+	pushed := map[string][]string{} // Date -> resources
+	if data, err := readFile(statePath); err == nil {
+		if err := json.Unmarshal(data, &pushed); err != nil {
+			return fmt.Errorf("Invalid %s: %v", CalDAVStateFile, err)
+		}
+	}
+
+	current := map[string][]string{}
+	for _, event := range events {
+		if err := calDAVRequest(http.MethodPut, event.Resource, icsCalendar("caldav", event.Lines), http.StatusOK, http.StatusCreated, http.StatusNoContent); err != nil {
+			return err
+		}
+		current[event.Date] = append(current[event.Date], event.Resource)
+	}
+	removed := 0
+	for day := r.From; day.Before(r.To); day = day.AddDate(0, 0, 1) {
+		date := day.Format(DATE_FORMAT)
+		for _, resource := range pushed[date] {
+			if slices.Contains(current[date], resource) {
+				continue
+			}
+			if err := calDAVRequest(http.MethodDelete, resource, "", http.StatusOK, http.StatusNoContent, http.StatusNotFound); err != nil {
+				return err
+			}
+			removed++
+		}
+		if len(current[date]) > 0 {
+			pushed[date] = current[date]
+		} else {
+			delete(pushed, date)
+		}
+	}
+
+	data, err := json.MarshalIndent(pushed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(statePath, data, 0644); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Pushed %d work cycles to CalDAV", len(events))
+	if removed > 0 {
+		message += fmt.Sprintf(", removed %d", removed)
+	}
+	fmt.Println(message + ".")
+	return nil
+}
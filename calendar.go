@@ -0,0 +1,290 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// With WT_CALENDAR set to an iCalendar file or URL (e.g. Google Calendar's
+// "secret address in iCal format" or a CalDAV calendar's export URL), a work
+// cycle that overlaps a meeting is tagged +meeting when it's stopped, and the
+// titles of the overlapping events are kept with the cycle. All-day, free
+// (TRANSP:TRANSPARENT), and cancelled events don't count. Daily and weekly
+// recurring events are expanded (INTERVAL, COUNT, UNTIL, BYDAY, EXDATE);
+// other recurrence rules only match their first occurrence.
+
+const MeetingTag = "meeting"
+
+// calendarEvent is a VEVENT of an iCalendar file
+type calendarEvent struct {
+	UID          string
+	Summary      string
+	Start, End   time.Time
+	AllDay       bool
+	Free         bool // Transparent or cancelled
+	RRule        map[string]string
+	ExDates      []time.Time
+	RecurrenceID time.Time // Set on an event replacing one occurrence of UID
+}
+
+// icsWeekdays maps BYDAY codes to days after Monday
+var icsWeekdays = map[string]int{"MO": 0, "TU": 1, "WE": 2, "TH": 3, "FR": 4, "SA": 5, "SU": 6}
+
+// parseICSTime parses a DATE-TIME or DATE value with its TZID parameter
+func parseICSTime(value string, params map[string]string) (t time.Time, allDay bool, err error) {
+	loc := time.Local
+	if tzid := strings.Trim(params["TZID"], `"`); tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	return t, false, err
+}
+
+// parseICSDuration parses a DURATION like PT1H30M or P1D
+func parseICSDuration(value string) (time.Duration, error) {
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	var d time.Duration
+	number := ""
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'P' || c == 'T' || c == '+':
+		case units[c] != 0 && number != "":
+			n, _ := strconv.Atoi(number)
+			d += time.Duration(n) * units[c]
+			number = ""
+		default:
+			return 0, fmt.Errorf("Invalid duration: %s", value)
+		}
+	}
+	return d, nil
+}
+
+// unescapeICS undoes the TEXT escaping of iCalendar
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// escapeICS applies the TEXT escaping of iCalendar
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`).Replace(s)
+}
+
+// parseICS reads the events of an iCalendar file
+func parseICS(data []byte) ([]calendarEvent, error) {
+	// Long lines are folded: continuation lines start with a space or tab
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	var events []calendarEvent
+	var event *calendarEvent
+	var duration time.Duration
+	for _, line := range lines {
+		head, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(head, ";")
+		name := strings.ToUpper(parts[0])
+		params := map[string]string{}
+		for _, param := range parts[1:] {
+			if key, v, ok := strings.Cut(param, "="); ok {
+				params[strings.ToUpper(key)] = v
+			}
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event, duration = &calendarEvent{}, 0
+			continue
+		case event == nil:
+			continue
+		case name == "END" && value == "VEVENT":
+			if event.End.IsZero() {
+				event.End = event.Start.Add(duration)
+			}
+			events = append(events, *event)
+			event = nil
+			continue
+		}
+
+		var err error
+		switch name {
+		case "UID":
+			event.UID = value
+		case "SUMMARY":
+			event.Summary = unescapeICS(value)
+		case "DTSTART":
+			event.Start, event.AllDay, err = parseICSTime(value, params)
+		case "DTEND":
+			event.End, _, err = parseICSTime(value, params)
+		case "DURATION":
+			duration, err = parseICSDuration(value)
+		case "RECURRENCE-ID":
+			event.RecurrenceID, _, err = parseICSTime(value, params)
+		case "TRANSP":
+			event.Free = event.Free || value == "TRANSPARENT"
+		case "STATUS":
+			event.Free = event.Free || value == "CANCELLED"
+		case "RRULE":
+			event.RRule = map[string]string{}
+			for _, rule := range strings.Split(value, ";") {
+				if key, v, ok := strings.Cut(rule, "="); ok {
+					event.RRule[strings.ToUpper(key)] = v
+				}
+			}
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				exdate, _, err := parseICSTime(v, params)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid EXDATE %s: %v", v, err)
+				}
+				event.ExDates = append(event.ExDates, exdate)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %s: %v", name, value, err)
+		}
+	}
+	return events, nil
+}
+
+// occurrences returns the start times of the event up to until
+func (e calendarEvent) occurrences(until time.Time) []time.Time {
+	freq := e.RRule["FREQ"]
+	if freq != "DAILY" && freq != "WEEKLY" {
+		return []time.Time{e.Start}
+	}
+	interval, _ := strconv.Atoi(e.RRule["INTERVAL"])
+	interval = max(interval, 1)
+	count, _ := strconv.Atoi(e.RRule["COUNT"])
+	if v := e.RRule["UNTIL"]; v != "" {
+		if end, allDay, err := parseICSTime(v, map[string]string{}); err == nil {
+			if allDay {
+				end = end.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			if end.Before(until) {
+				until = end
+			}
+		}
+	}
+
+	// Days after Monday the event happens on: every day, or its BYDAY days
+	weekStart := e.Start.AddDate(0, 0, -((int(e.Start.Weekday()) + 6) % 7))
+	days := []int{(int(e.Start.Weekday()) + 6) % 7}
+	step := 7 * interval
+	if freq == "DAILY" {
+		weekStart, days, step = e.Start, []int{0}, interval
+	} else if byDay := e.RRule["BYDAY"]; byDay != "" {
+		days = nil
+		for _, code := range strings.Split(byDay, ",") {
+			if day, ok := icsWeekdays[code]; ok {
+				days = append(days, day)
+			}
+		}
+		slices.Sort(days)
+	}
+
+	var starts []time.Time
+	n := 0
+	for base := weekStart; !base.After(until); base = base.AddDate(0, 0, step) {
+		for _, day := range days {
+			start := base.AddDate(0, 0, day)
+			if start.Before(e.Start) {
+				continue
+			}
+			if start.After(until) || (count > 0 && n >= count) {
+				return starts
+			}
+			n++
+			if !slices.ContainsFunc(e.ExDates, start.Equal) {
+				starts = append(starts, start)
+			}
+		}
+	}
+	return starts
+}
+
+// readCalendar reads an iCalendar file or URL
+func readCalendar(source string) ([]byte, error) {
+	if url, ok := strings.CutPrefix(source, "webcal://"); ok {
+		source = "https://" + url
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return download(source)
+	}
+	return readFile(source)
+}
+
+// calendarMeetings returns the titles of the WT_CALENDAR events overlapping
+// from..to, or nil if there are none or no calendar is set
+func calendarMeetings(from, to time.Time) ([]string, error) {
+	source := setting("WT_CALENDAR")
+	if source == "" {
+		return nil, nil
+	}
+	data, err := readCalendar(source)
+	if err != nil {
+		return nil, err
+	}
+	events, err := parseICS(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Occurrences replaced by an event of their own
+	replaced := map[string][]time.Time{}
+	for _, event := range events {
+		if !event.RecurrenceID.IsZero() {
+			replaced[event.UID] = append(replaced[event.UID], event.RecurrenceID)
+		}
+	}
+
+	var titles []string
+	for _, event := range events {
+		if event.AllDay || event.Free {
+			continue
+		}
+		length := event.End.Sub(event.Start)
+		for _, start := range event.occurrences(to) {
+			if event.RecurrenceID.IsZero() && slices.ContainsFunc(replaced[event.UID], start.Equal) {
+				continue
+			}
+			if start.Before(to) && start.Add(length).After(from) {
+				titles = mergeTags(titles, []string{cmp.Or(event.Summary, "Untitled")})
+				break
+			}
+		}
+	}
+	return titles, nil
+}
+
+// formatMeetings renders meeting titles as ` (Standup, Planning)`, or ""
+func formatMeetings(meetings []string) string {
+	if len(meetings) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(meetings, ", ") + ")"
+}
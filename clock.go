@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Everything that asks what time it is on the timer's behalf goes through the
+// clock. The default is the system clock, which $WT_MOCK_TIME overrides for
+// the integration tests.
+//
+// The commands read the clock of the process (getCurrentTime) rather than
+// take one as an argument. What drives them is given its Clock instead: the
+// daemon's is a field (daemon.clock, which tests set to a FrozenClock), and
+// replay, simulate, selftest, tutorial, and demo make a FrozenClock per run.
+// They install it with useClock for the commands they run, which the
+// daemon's ticks and the API's handlers do one at a time under apiMu.
+//
+// Durations of the process itself (command traces, lock timeouts, daemon
+// uptime) stay on time.Now.
+
+// Clock tells the time
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the wall clock, or $WT_MOCK_TIME ("YYYY-MM-DD HH:MM") if set
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	if mockTime := os.Getenv("WT_MOCK_TIME"); mockTime != "" {
+		if t, err := time.ParseInLocation(DT_FORMAT, mockTime, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// FrozenClock stands still until it's set or advanced
+type FrozenClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFrozenClock(now time.Time) *FrozenClock {
+	return &FrozenClock{now: now}
+}
+
+func (c *FrozenClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to now
+func (c *FrozenClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by d and returns the new time
+func (c *FrozenClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+var (
+	clockMu sync.RWMutex
+	clock   Clock = systemClock{}
+)
+
+// useClock makes c the clock and returns a function restoring the previous one
+func useClock(c Clock) func() {
+	clockMu.Lock()
+	previous := clock
+	clock = c
+	clockMu.Unlock()
+	return func() {
+		clockMu.Lock()
+		clock = previous
+		clockMu.Unlock()
+	}
+}
+
+func currentClock() Clock {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Every save records when it happened (Timer.LastCommand), so the next
+// command notices when the system clock jumped in between: an NTP correction,
+// a manual change, or a VM resumed from an old snapshot. If the clock is
+// behind the last command, time is held at the last command until the clock
+// catches up, so no cycle gets a negative length. If the timer ran for
+// WT_CLOCK_JUMP (HHMM, default 24 hours) without any command, the clock may
+// have jumped ahead, or the timer was forgotten; wt can't tell, so it only
+// warns. Both are recorded in Timer.ClockJumps and the debug log, and
+// `wt log` lists them.
+
+const (
+	ClockToleranceMinutes = 5       // Smaller jumps back are held silently
+	DefaultClockJump      = 24 * 60 // Minutes the timer may run without a command before wt warns
+)
+
+// ClockJump is a jump of the clock noticed between two commands
+type ClockJump struct {
+	At      string `json:"at"`      // When the command before the jump ran
+	Now     string `json:"now"`     // What the clock said at the next command
+	Minutes int    `json:"minutes"` // Negative for a jump back
+}
+
+// pendingClockJump is noticed by guardClock and recorded by the next save
+var pendingClockJump *ClockJump
+
+// heldClock is a clock that doesn't go back before floor
+type heldClock struct {
+	Clock
+	floor time.Time
+}
+
+func (c heldClock) Now() time.Time {
+	if now := c.Clock.Now(); now.After(c.floor) {
+		return now
+	}
+	return c.floor
+}
+
+// formatClockJump describes a jump for `wt log`
+func formatClockJump(jump ClockJump) string {
+	if jump.Minutes < 0 {
+		return fmt.Sprintf("Clock went back %s at %s (it said %s); times were held at %s.", minutesToHourMinuteStr(-jump.Minutes), jump.At, jump.Now, jump.At[len(DATE_FORMAT)+1:])
+	}
+	return fmt.Sprintf("Timer ran %s without a command from %s to %s.", minutesToHourMinuteStr(jump.Minutes), jump.At, jump.Now)
+}
+
+// guardClock compares the clock with the timer's last command. A clock
+// that's behind is held at the last command until the returned function
+// is called. With warn, jumps are also reported and recorded.
+func guardClock(command string, warn bool) func() {
+	timer, err := load()
+	if err != nil || timer.LastCommand == "" || timer.DayStart == "" { // A reset timer has no times to keep in order
+		return func() {}
+	}
+	last, err := parseTime(timer.LastCommand)
+	if err != nil {
+		return func() {}
+	}
+	now := getCurrentTime()
+	minutes := deltaMinutes(last, now)
+	restore := func() {}
+	if now.Before(last) {
+		restore = useClock(heldClock{currentClock(), last})
+	}
+	if !warn {
+		return restore
+	}
+
+	var message string
+	switch {
+	case minutes <= -ClockToleranceMinutes:
+		message = fmt.Sprintf("The clock went back %s since the last command (%s). Times are held at %s until it catches up.",
+			minutesToHourMinuteStr(-minutes), timer.LastCommand, last.Format(TIME_ONLY_FORMAT))
+	case minutes >= envMinutes("WT_CLOCK_JUMP", DefaultClockJump) && (timer.Status == StatusRunning || timer.Status == StatusPaused):
+		message = fmt.Sprintf("The timer ran %s without a command since %s. If the clock jumped ahead, correct the cycle with wt mod.",
+			minutesToHourMinuteStr(minutes), timer.LastCommand)
+	default:
+		return restore
+	}
+	fmt.Fprintln(os.Stderr, "Warning: "+message)
+	// A clock that stays behind is reported on every command, but recorded once
+	recorded := len(timer.ClockJumps) > 0 && timer.ClockJumps[len(timer.ClockJumps)-1].At == timer.LastCommand
+	if !recorded {
+		logWarning(timer, command, nil, message)
+		pendingClockJump = &ClockJump{At: timer.LastCommand, Now: now.Format(DT_FORMAT), Minutes: minutes}
+	}
+	return restore
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// `wt close` finalizes a day, e.g. once it's been reported to payroll: the
+// timer is stopped, the daily report and archive are written, and the archive
+// file is made read-only. Afterwards only `wt mod --force` changes the day;
+// each such change is recorded in the timer's Amended list (kept in the
+// archive) and in the debug log, and the archive and the day's daily report
+// line are rewritten.
+
+func (t *Timer) isClosed() bool {
+	return t.Closed != ""
+}
+
+// requireOpen refuses commands that would change a closed day
+func (t *Timer) requireOpen() error {
+	if !t.isClosed() {
+		return nil
+	}
+	return fmt.Errorf("Day %s is closed. Run 'wt new' to start the next day.", t.DayStart[:len(DATE_FORMAT)])
+}
+
+func closeCmd(timer *Timer, note string) error {
+	if timer.isClosed() {
+		return fmt.Errorf("Day %s is already closed.", timer.DayStart[:len(DATE_FORMAT)])
+	}
+	if timer.DayStart == "" {
+		return fmt.Errorf("Nothing to close: the timer hasn't started today.")
+	}
+
+	if timer.Status != StatusStopped {
+		commandVia = "close"
+		err := stopCmd(timer)
+		commandVia = ""
+		if err != nil {
+			return err
+		}
+		var loadErr error
+		if timer, loadErr = load(); loadErr != nil {
+			return loadErr
+		}
+	}
+
+	addRetroNote(timer, note)
+	saveDailyReport(timer)
+	timer.Closed = getCurrentTime().Format(DT_FORMAT) // Recorded in the archive too
+	archivePath, err := archiveDay(timer)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(archivePath, 0444); err != nil {
+		return err
+	}
+
+	timer.Archive = filepath.Base(archivePath)
+	logCommand(timer, "close", nil, map[string]int{"work": timer.Totals().Work})
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	printMessageIfNotSilent(timer, fmt.Sprintf("Day %s closed. Work: %s, archived as %s.",
+		timer.DayStart[:len(DATE_FORMAT)], minutesToHourMinuteStr(timer.Totals().Work), filepath.Join(ArchiveFolder, timer.Archive)))
+	return nil
+}
+
+// modClosedDayCmd runs a mod on a closed day, which needs force, and records it
+func modClosedDayCmd(timer *Timer, args []string, force, yes bool) error {
+	if !force {
+		return fmt.Errorf("Day %s is closed. Use 'wt mod --force %s' to change it anyway; the change is recorded.",
+			timer.DayStart[:len(DATE_FORMAT)], strings.Join(args, " "))
+	}
+
+	dayStart, timeline := timer.DayStart, append([]TimelineEntry{}, timer.Timeline...)
+	if err := modCmd(timer, args, yes); err != nil {
+		return err
+	}
+	after, err := load()
+	if err != nil {
+		return err
+	}
+	if after.DayStart == dayStart && reflect.DeepEqual(after.Timeline, timeline) {
+		return nil // Refused or no-op, nothing to record
+	}
+
+	amendment := fmt.Sprintf("%s wt mod %s", getCurrentTime().Format(DT_FORMAT), strings.Join(args, " "))
+	after.Amended = append(after.Amended, amendment)
+	logWarning(after, "mod", append([]string{"--force"}, args...), "Changed closed day "+after.DayStart[:len(DATE_FORMAT)])
+	if err := rewriteArchive(after); err != nil {
+		return err
+	}
+	if err := save(after); err != nil {
+		return err
+	}
+	return rewriteDailyReport(after.DayStart[:len(DATE_FORMAT)])
+}
+
+// rewriteArchive replaces a closed day's read-only archive file with the timer
+func rewriteArchive(timer *Timer) error {
+	folder, err := archiveFolderPath()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(folder, timer.Archive)
+
+	archived := timer.closed()
+	archived.Profile = activeProfile()
+	data, err := json.MarshalIndent(archived, "", "  ")
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(path, ".gz") {
+		if data, err = gzipBytes(data); err != nil {
+			return err
+		}
+	}
+	os.Chmod(path, 0644)
+	if err := writeFile(path, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0444); err != nil {
+		return err
+	}
+	dayStart, _ := parseTime(timer.DayStart)
+	return updateWeeklyReport(dayStart)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// RangeStats aggregates the days of a DateRange
+type RangeStats struct {
+	Days        int // Days with tracked time
+	Work        int
+	Break       int // Including lunch
+	Paused      int
+	Cycles      int // Work cycles
+	StartMinute int // Average day start, minutes after midnight (-1 if no days)
+}
+
+// WorkPerDay returns the average work time per tracked day
+func (s RangeStats) WorkPerDay() int {
+	if s.Days == 0 {
+		return 0
+	}
+	return s.Work / s.Days
+}
+
+// rangeStats aggregates days. Several timers on the same date count as
+// one day, starting at the earliest.
+func rangeStats(days []DaySummary) RangeStats {
+	stats := RangeStats{StartMinute: -1}
+	dayStarts := map[string]int{}
+
+	for _, day := range days {
+		start, err := parseTime(day.DayStart)
+		if err != nil {
+			continue
+		}
+		date := start.Format(DATE_FORMAT)
+		minute := start.Hour()*60 + start.Minute()
+		if earliest, ok := dayStarts[date]; !ok || minute < earliest {
+			dayStarts[date] = minute
+		}
+
+		stats.Work += day.Totals.Work
+		stats.Break += day.Totals.Break + day.Totals.Lunch
+		stats.Paused += day.Totals.Paused
+		stats.Cycles += day.Cycles
+	}
+
+	stats.Days = len(dayStarts)
+	if stats.Days > 0 {
+		sum := 0
+		for _, minute := range dayStarts {
+			sum += minute
+		}
+		stats.StartMinute = sum / stats.Days
+	}
+	return stats
+}
+
+// signedDuration formats a minute delta as "+1h:05m" or "-0h:30m"
+func signedDuration(minutes int) string {
+	if minutes < 0 {
+		return "-" + minutesToHourMinuteStr(-minutes)
+	}
+	return "+" + minutesToHourMinuteStr(minutes)
+}
+
+func clockStr(minute int) string {
+	if minute < 0 {
+		return "--:--"
+	}
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func compareCmd(timer *Timer, a, b string) error {
+	rangeA, err := parseDateRange(a)
+	if err != nil {
+		return err
+	}
+	rangeB, err := parseDateRange(b)
+	if err != nil {
+		return err
+	}
+
+	daysA, err := loadRangeSummaries(timer, rangeA)
+	if err != nil {
+		return err
+	}
+	daysB, err := loadRangeSummaries(timer, rangeB)
+	if err != nil {
+		return err
+	}
+	statsA, statsB := rangeStats(daysA), rangeStats(daysB)
+
+	width := max(12, len(rangeA.Name)+1, len(rangeB.Name)+1)
+	row := func(label, valueA, valueB, delta string) {
+		fmt.Printf("%-10s %-*s %-*s %s\n", label, width, valueA, width, valueB, delta)
+	}
+	duration := func(label string, valueA, valueB int) {
+		row(label, minutesToHourMinuteStr(valueA), minutesToHourMinuteStr(valueB), signedDuration(valueB-valueA))
+	}
+	count := func(label string, valueA, valueB int) {
+		row(label, strconv.Itoa(valueA), strconv.Itoa(valueB), fmt.Sprintf("%+d", valueB-valueA))
+	}
+
+	row("", rangeA.Name, rangeB.Name, "Delta")
+	count("Days", statsA.Days, statsB.Days)
+	duration("Work", statsA.Work, statsB.Work)
+	duration("Work/day", statsA.WorkPerDay(), statsB.WorkPerDay())
+	duration("Break", statsA.Break, statsB.Break)
+	duration("Paused", statsA.Paused, statsB.Paused)
+	count("Cycles", statsA.Cycles, statsB.Cycles)
+
+	startDelta := ""
+	if statsA.StartMinute >= 0 && statsB.StartMinute >= 0 {
+		startDelta = signedDuration(statsB.StartMinute - statsA.StartMinute)
+	}
+	row("Avg start", clockStr(statsA.StartMinute), clockStr(statsB.StartMinute), startDelta)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The config file holds the defaults of WT_* settings for a root, so they
+// don't have to be exported in every shell: $WT_ROOT/.out/config (shared by
+// the root's named timers), or the file $WT_CONFIG names, e.g.
+// ~/.config/wt/config for all roots. It has the KEY=VALUE lines of a
+// profile. Environment variables win over the active profile, which wins
+// over the config file. `wt config get/set/unset` manage it.
+
+const ConfigFileName = "config"
+
+// envOnlySettings pick the root, timer, profile, and config file, so they
+// can't come from the config file itself
+var envOnlySettings = []string{"WT_ROOT", "WT_TIMER", "WT_PROFILE", "WT_CONFIG", "WT_MOCK_TIME"}
+
+func configFilePath() (string, error) {
+	if path := os.Getenv("WT_CONFIG"); path != "" {
+		return path, nil
+	}
+	folder, err := timerFolderPath("")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, ConfigFileName), nil
+}
+
+// readConfig returns the config file's settings, none if it doesn't exist
+// or can't be read
+func readConfig() map[string]string {
+	filePath, err := configFilePath()
+	if err != nil {
+		return nil
+	}
+	data, err := readFile(filePath)
+	if err != nil {
+		return nil
+	}
+	values, _ := parseSettings(data)
+	return values
+}
+
+// validateConfigSetting refuses keys that aren't settings and values a
+// setting can't take
+func validateConfigSetting(key, value string) error {
+	if !strings.HasPrefix(key, "WT_") {
+		return fmt.Errorf("Invalid setting: %s. Settings are WT_* variables, e.g. WT_DAILY_GOAL", key)
+	}
+	if slices.Contains(envOnlySettings, key) {
+		return fmt.Errorf("%s cannot be set in the config file; set it in the environment.", key)
+	}
+	if key == "WT_MODE" && value != ModeSilent && value != ModeNormal && value != ModeVerbose {
+		return fmt.Errorf("Invalid WT_MODE: %s. Use %s, %s, or %s.", value, ModeSilent, ModeNormal, ModeVerbose)
+	}
+	return nil
+}
+
+func configGetCmd(key string) error {
+	filePath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	values := readConfig()
+	if key == "" {
+		if len(values) == 0 {
+			fmt.Printf("No settings in %s. Add one with: wt config set <KEY> <value>\n", filePath)
+			return nil
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s=%s\n", key, values[key])
+		}
+		return nil
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return fmt.Errorf("%s isn't set in %s.", key, filePath)
+	}
+	fmt.Println(value)
+	if effective := setting(key); effective != value {
+		source := "the environment"
+		if os.Getenv(key) == "" {
+			source = "profile " + activeProfile()
+		}
+		fmt.Printf("(%s overrides it with %s)\n", source, effective)
+	}
+	return nil
+}
+
+func configSetCmd(key, value string) error {
+	if err := validateConfigSetting(key, value); err != nil {
+		return err
+	}
+	filePath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	values := readConfig()
+	if values == nil {
+		values = map[string]string{}
+	}
+	values[key] = value
+	if err := writeSettings(filePath, values); err != nil {
+		return err
+	}
+	fmt.Printf("Config: %s=%s\n", key, value)
+	return nil
+}
+
+func configUnsetCmd(key string) error {
+	filePath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	values := readConfig()
+	if _, ok := values[key]; !ok {
+		return fmt.Errorf("%s isn't set in %s.", key, filePath)
+	}
+	delete(values, key)
+	if err := writeSettings(filePath, values); err != nil {
+		return err
+	}
+	fmt.Printf("Config: %s removed.\n", key)
+	return nil
+}
+
+// defaultMode returns the mode of a root's first timer, $WT_MODE or silent
+func defaultMode() string {
+	if mode := setting("WT_MODE"); validateConfigSetting("WT_MODE", mode) == nil {
+		return mode
+	}
+	return ModeSilent
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CSVDialect controls how CSV is written and read, so exports open cleanly in
+// spreadsheets of different locales. Configured with $WT_CSV_DELIMITER (default
+// ","), $WT_CSV_DECIMAL (default ".") and $WT_CSV_DATE_FORMAT: iso (default,
+// 2026-01-20 09:00), eu (20.01.2026 09:00), us (01/20/2026 09:00), or a Go
+// time layout.
+type CSVDialect struct {
+	Delimiter  rune
+	Decimal    string
+	DateLayout string
+}
+
+var csvDateFormats = map[string]string{
+	"iso": DT_FORMAT,
+	"eu":  "02.01.2006 15:04",
+	"us":  "01/02/2006 15:04",
+}
+
+func csvDialect() (CSVDialect, error) {
+	dialect := CSVDialect{Delimiter: ',', Decimal: ".", DateLayout: DT_FORMAT}
+
+	if value := setting("WT_CSV_DELIMITER"); value != "" {
+		if value == `\t` || value == "tab" {
+			value = "\t"
+		}
+		r, size := utf8.DecodeRuneInString(value)
+		if size != len(value) || r == '"' || r == '\n' || r == '\r' {
+			return CSVDialect{}, fmt.Errorf("Invalid WT_CSV_DELIMITER: %q. Use a single character such as ';' or 'tab'", value)
+		}
+		dialect.Delimiter = r
+	}
+
+	if value := setting("WT_CSV_DECIMAL"); value != "" {
+		if value != "." && value != "," {
+			return CSVDialect{}, fmt.Errorf("Invalid WT_CSV_DECIMAL: %q. Use '.' or ','", value)
+		}
+		dialect.Decimal = value
+	}
+	if dialect.Decimal == "," && dialect.Delimiter == ',' {
+		return CSVDialect{}, fmt.Errorf("WT_CSV_DECIMAL ',' needs a different WT_CSV_DELIMITER, e.g. ';'")
+	}
+
+	if value := setting("WT_CSV_DATE_FORMAT"); value != "" {
+		if layout, ok := csvDateFormats[value]; ok {
+			dialect.DateLayout = layout
+		} else if strings.Contains(value, "2006") {
+			dialect.DateLayout = value
+		} else {
+			return CSVDialect{}, fmt.Errorf("Invalid WT_CSV_DATE_FORMAT: %q. Use iso, eu, us, or a Go time layout", value)
+		}
+	}
+
+	return dialect, nil
+}
+
+func (d CSVDialect) NewWriter(w io.Writer) *csv.Writer {
+	writer := csv.NewWriter(w)
+	writer.Comma = d.Delimiter
+	return writer
+}
+
+func (d CSVDialect) NewReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = d.Delimiter
+	return reader
+}
+
+func (d CSVDialect) FormatTime(t time.Time) string {
+	return t.Format(d.DateLayout)
+}
+
+func (d CSVDialect) ParseTime(s string) (time.Time, error) {
+	return time.ParseInLocation(d.DateLayout, s, time.Local)
+}
+
+// FormatAmount formats a monetary amount with two decimals
+func (d CSVDialect) FormatAmount(amount float64) string {
+	return strings.Replace(strconv.FormatFloat(amount, 'f', 2, 64), ".", d.Decimal, 1)
+}
+
+func (d CSVDialect) ParseAmount(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(s, d.Decimal, ".", 1), 64)
+}
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// The daemon runs what otherwise needs cron: reminders and the WT_SCHEDULE
+// start, and optionally the HTTP API and the Telegram bot. It's controlled
+// over HTTP on a unix socket in .out (`wt daemon status|stop|restart|logs`)
+// and logs to .out/daemon.log. The socket also serves the timer API under
+// /api, which `wt --remote daemon` uses (remote.go). The daemon keeps the
+// timer in memory (stateCache) and only reads wt.json again after another
+// process changed it. Other CLI commands still run in their own process,
+// against wt.json under the state lock.
+
+const (
+	DaemonSocketFile      = "daemon.sock"
+	DaemonLogFile         = "daemon.log"
+	DefaultDaemonInterval = time.Minute
+	DaemonRenotifyAfter   = 15 * time.Minute       // A reminder for an unchanged timer is repeated after this long
+	DaemonWatchInterval   = 500 * time.Millisecond // How often .out/wt.changed is checked
+)
+
+var errDaemonNotRunning = errors.New("Daemon not running. Start it with 'wt daemon start'.")
+
+// DaemonStatus is what GET /status on the daemon socket returns
+type DaemonStatus struct {
+	PID      int      `json:"pid"`
+	Started  string   `json:"started"`
+	Uptime   int      `json:"uptime_seconds"`
+	Features []string `json:"features"`
+	Args     []string `json:"args"`        // `wt daemon run` arguments, reused by restart
+	Reads    int      `json:"state_reads"` // Times wt.json was read, see stateCache
+}
+
+// stateCache holds the timer as the daemon last read or saved it, so its API,
+// reminders, and bot don't read wt.json again until it changed on disk (by
+// size and modification time). It's nil outside the daemon, which load and
+// save then ignore.
+var stateCache *cachedState
+
+type cachedState struct {
+	mu      sync.Mutex
+	path    string
+	data    []byte
+	size    int64
+	modTime time.Time
+	reads   int
+}
+
+// get returns the content of path if it's cached and unchanged on disk
+func (c *cachedState) get(path string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(path)
+	if err != nil || path != c.path || info.Size() != c.size || !info.ModTime().Equal(c.modTime) {
+		return nil, false
+	}
+	return c.data, true
+}
+
+// put caches data as the content of path, read from disk (counted) or just
+// written, unless the file changed since stat
+func (c *cachedState) put(path string, data []byte, read bool, stat os.FileInfo) {
+	if c == nil || stat == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if read {
+		c.reads++
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != stat.Size() || !info.ModTime().Equal(stat.ModTime()) {
+		c.path = "" // Changed meanwhile
+		return
+	}
+	c.path, c.data, c.size, c.modTime = path, data, stat.Size(), stat.ModTime()
+}
+
+func (c *cachedState) readCount() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reads
+}
+
+// DaemonOptions are the flags of `wt daemon run`
+type DaemonOptions struct {
+	Interval time.Duration // How often reminders and the schedule are checked
+	API      string        // Also serve the HTTP API on this address, if set
+}
+
+func daemonFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{Name: "interval", Value: DefaultDaemonInterval, Usage: "How often to check reminders and the schedule"},
+		&cli.StringFlag{Name: "api", Usage: "Also serve the HTTP API on this address (see 'wt serve')"},
+	}
+}
+
+func daemonOptions(cmd *cli.Command) DaemonOptions {
+	return DaemonOptions{Interval: cmd.Duration("interval"), API: cmd.String("api")}
+}
+
+// args returns the command line that runs a daemon with these options
+func (o DaemonOptions) args() []string {
+	args := []string{"daemon", "run", "--interval", o.Interval.String()}
+	if o.API != "" {
+		args = append(args, "--api", o.API)
+	}
+	return args
+}
+
+type daemon struct {
+	opts     DaemonOptions
+	clock    Clock // The time its ticks run at, see clock.go
+	started  time.Time
+	log      io.Writer
+	logMu    sync.Mutex
+	lastKey  string // Timer state the last reminder was sent for
+	lastSent time.Time
+	retained string // Date retention was last applied on
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func daemonFilePath(name string) (string, error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, name), nil
+}
+
+func (d *daemon) logf(format string, args ...any) {
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+	fmt.Fprintf(d.log, "[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
+
+func (d *daemon) features() []string {
+	features := []string{"reminders"}
+	if setting("WT_SCHEDULE") != "" {
+		features = append(features, "schedule")
+	}
+	if d.opts.API != "" {
+		features = append(features, "api "+d.opts.API)
+	}
+	if setting("WT_TELEGRAM_TOKEN") != "" {
+		features = append(features, "telegram")
+	}
+	if setting("WT_RETENTION") != "" {
+		features = append(features, "retention")
+	}
+	return features
+}
+
+// notify logs a reminder and sends it to the enabled notifiers (notify.go)
+func (d *daemon) notify(message string) {
+	d.logf("Reminder: %s", message)
+	if err := sendNotification(NotificationTitle, message); err != nil {
+		d.logf("Error: %v", err)
+	}
+}
+
+// tick applies the schedule and sends the due reminder, once per timer state
+// (repeated after DaemonRenotifyAfter), at the time on the daemon's clock
+func (d *daemon) tick() {
+	apiMu.Lock() // Commands capture os.Stdout, like the API's handlers
+	defer apiMu.Unlock()
+	defer useClock(d.clock)()
+	d.retain()
+
+	var timer *Timer
+	var message, output string
+	err := withStateLock("wt daemon", func() error {
+		var err error
+		if timer, err = load(); err != nil {
+			return err
+		}
+		output, err = captureOutput(func() error {
+			message, err = scheduleCmd(timer)
+			return err
+		})
+		return err
+	})
+	if err != nil {
+		d.logf("Error: %v", err)
+		return
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		d.logf("%s", output)
+	}
+	if message == "" {
+		if timer, err = load(); err == nil {
+			message = reminderMessage(timer)
+		}
+	}
+	if message == "" {
+		return
+	}
+
+	key := strings.Join([]string{timer.Status, timer.DayStart, timer.PauseStartStr, timer.StopDatetimeStr}, "|")
+	now := getCurrentTime()
+	if key == d.lastKey && now.Sub(d.lastSent) < DaemonRenotifyAfter {
+		return
+	}
+	d.lastKey, d.lastSent = key, now
+	d.notify(message)
+}
+
+// retain applies $WT_RETENTION on the first tick of each day
+func (d *daemon) retain() {
+	today := getCurrentTime().Format(DATE_FORMAT)
+	if today == d.retained {
+		return
+	}
+	d.retained = today
+	if err := withStateLock("wt daemon", applyRetention); err != nil {
+		d.logf("Error: %v", err)
+	}
+}
+
+func (d *daemon) stop() {
+	d.stopOnce.Do(func() { close(d.done) })
+}
+
+func (d *daemon) handler(logPath string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, DaemonStatus{
+			PID:      os.Getpid(),
+			Started:  d.started.Format(time.RFC3339),
+			Uptime:   int(time.Since(d.started).Seconds()),
+			Features: d.features(),
+			Args:     d.opts.args(),
+			Reads:    stateCache.readCount(),
+		})
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"stopping": true})
+		d.stop()
+	})
+	mux.HandleFunc("GET /logs", func(w http.ResponseWriter, r *http.Request) {
+		lines, _ := strconv.Atoi(r.URL.Query().Get("lines"))
+		streamLog(r.Context(), w, logPath, lines, r.URL.Query().Get("follow") != "")
+	})
+	mux.Handle("/api/", apiHandler(nil)) // The socket is the owner's only, so no tokens
+	return mux
+}
+
+// streamLog writes the last lines of the log (all if lines <= 0) and, when
+// following, whatever is appended until the client goes away
+func streamLog(ctx context.Context, w http.ResponseWriter, path string, lines int, follow bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer f.Close()
+
+	var all []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		all = append(all, scanner.Text())
+	}
+	if lines > 0 && len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range all {
+		fmt.Fprintln(w, line)
+	}
+	flusher, _ := w.(http.Flusher)
+	if !follow || flusher == nil {
+		return
+	}
+	flusher.Flush()
+
+	offset, _ := f.Seek(0, io.SeekEnd)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(200 * time.Millisecond):
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.Size() <= offset {
+			continue
+		}
+		f.Seek(offset, io.SeekStart)
+		n, _ := io.Copy(w, f)
+		offset += n
+		flusher.Flush()
+	}
+}
+
+// listenDaemonSocket listens on the daemon socket, replacing a stale one
+func listenDaemonSocket(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("Daemon already running.")
+	}
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func daemonRunCmd(opts DaemonOptions) error {
+	socketPath, err := daemonFilePath(DaemonSocketFile)
+	if err != nil {
+		return err
+	}
+	logPath, err := daemonFilePath(DaemonLogFile)
+	if err != nil {
+		return err
+	}
+	if opts.API != "" {
+		// Fail early rather than from the API goroutine
+		tokens, err := apiTokens()
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 && !isLoopback(opts.API) {
+			return fmt.Errorf("Refusing to serve on %s without authentication. Set WT_API_TOKENS or listen on 127.0.0.1.", opts.API)
+		}
+	}
+
+	bot, err := telegramConfig()
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(socketPath), 0755)
+	listener, err := listenDaemonSocket(socketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	d := &daemon{opts: opts, clock: systemClock{}, started: time.Now(), log: io.MultiWriter(logFile, os.Stdout), done: make(chan struct{})}
+	stateCache = &cachedState{}
+	defer func() { stateCache = nil }()
+	server := &http.Server{Handler: d.handler(logPath)}
+	go server.Serve(listener)
+
+	if opts.API != "" {
+		tokens, _ := apiTokens()
+		go func() {
+			if err := listenAndServe(opts.API, apiHandler(tokens), TLSOptions{}); err != nil {
+				d.logf("API stopped: %v", err)
+			}
+		}()
+	}
+
+	if bot != nil {
+		go bot.run(d)
+	}
+
+	d.logf("Daemon started (pid %d). Features: %s", os.Getpid(), strings.Join(d.features(), ", "))
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	watcher := time.NewTicker(DaemonWatchInterval)
+	defer watcher.Stop()
+	folder := filepath.Dir(socketPath)
+	lastChange := readStateChange(folder).Seq
+	d.tick()
+	for {
+		select {
+		case <-ticker.C:
+			d.tick()
+		case <-watcher.C:
+			// Re-check right away when another process changed the timer
+			change := readStateChange(folder)
+			if change.Seq == lastChange {
+				continue
+			}
+			lastChange = change.Seq
+			if change.PID != os.Getpid() {
+				d.logf("Timer changed by '%s' (pid %d).", change.Command, change.PID)
+				d.tick()
+			}
+		case <-d.done:
+			d.logf("Daemon stopped.")
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			return server.Shutdown(ctx)
+		}
+	}
+}
+
+// daemonClient talks HTTP over the daemon socket
+func daemonClient() (*http.Client, error) {
+	socketPath, err := daemonFilePath(DaemonSocketFile)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}}, nil
+}
+
+// daemonRequest sends a request to the daemon, returning errDaemonNotRunning if nothing listens
+func daemonRequest(method, path string) (*http.Response, error) {
+	client, err := daemonClient()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, "http://daemon"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errDaemonNotRunning
+	}
+	return resp, nil
+}
+
+// daemonAPIRequest calls the timer API on the daemon socket, decoding the
+// JSON response into out
+func daemonAPIRequest(method, path string, body, out any) error {
+	socketPath, err := daemonFilePath(DaemonSocketFile)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(socketPath); err != nil {
+		return errDaemonNotRunning
+	}
+	client, err := daemonClient()
+	if err != nil {
+		return err
+	}
+	return jsonRequestVia(client, "wt daemon", method, "http://daemon/api"+path, "", body, out)
+}
+
+func daemonStatus() (DaemonStatus, error) {
+	var status DaemonStatus
+	resp, err := daemonRequest(http.MethodGet, "/status")
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// waitForDaemon polls until the daemon is up (or down), for up to 5 seconds
+func waitForDaemon(up bool) error {
+	for i := 0; i < 50; i++ {
+		_, err := daemonStatus()
+		if (err == nil) == up {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if up {
+		return fmt.Errorf("Daemon didn't start. See 'wt daemon logs'.")
+	}
+	return fmt.Errorf("Daemon didn't stop.")
+}
+
+func daemonStartCmd(args []string) error {
+	if _, err := daemonStatus(); err == nil {
+		fmt.Println("Daemon already running.")
+		return nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	logPath, err := daemonFilePath(DaemonLogFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(logPath), 0755)
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stderr = logFile // Panics and early errors; regular output goes to the log directly
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	cmd.Process.Release()
+
+	if err := waitForDaemon(true); err != nil {
+		return err
+	}
+	fmt.Println("Daemon started.")
+	return nil
+}
+
+func daemonStopCmd() error {
+	resp, err := daemonRequest(http.MethodPost, "/stop")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if err := waitForDaemon(false); err != nil {
+		return err
+	}
+	fmt.Println("Daemon stopped.")
+	return nil
+}
+
+func daemonRestartCmd() error {
+	status, err := daemonStatus()
+	if err != nil {
+		return err
+	}
+	if err := daemonStopCmd(); err != nil {
+		return err
+	}
+	return daemonStartCmd(status.Args)
+}
+
+func daemonStatusCmd() error {
+	status, err := daemonStatus()
+	if err == errDaemonNotRunning {
+		fmt.Println("Daemon not running.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Daemon running (pid %d), up %s since %s\n", status.PID,
+		hourMinuteStrFromMinutes(status.Uptime/60), strings.Replace(status.Started[:16], "T", " ", 1))
+	fmt.Printf("Timer held in memory, disk reads: %d\n", status.Reads)
+	fmt.Printf("Features: %s\n", strings.Join(status.Features, ", "))
+	return nil
+}
+
+func daemonLogsCmd(lines int, follow bool) error {
+	path := fmt.Sprintf("/logs?lines=%d", lines)
+	if follow {
+		path += "&follow=1"
+	}
+	resp, err := daemonRequest(http.MethodGet, path)
+	if err == errDaemonNotRunning && !follow {
+		// The log outlives the daemon
+		return printLogTail(lines)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+func printLogTail(lines int) error {
+	logPath, err := daemonFilePath(DaemonLogFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(logPath)
+	if os.IsNotExist(err) {
+		fmt.Println("No daemon log yet.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	all := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if lines > 0 && len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	fmt.Println(strings.Join(all, "\n"))
+	return nil
+}
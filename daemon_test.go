@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The daemon's ticks run at the time on its clock, so a FrozenClock drives
+// its reminders without WT_MOCK_TIME or waiting
+func TestDaemonRemindersOnFrozenClock(t *testing.T) {
+	t.Setenv("WT_ROOT", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WT_MOCK_TIME", "")
+	t.Setenv("WT_NOTIFY_COMMAND", "true")
+	t.Setenv("WT_REMIND_RUNNING", "90")
+
+	clock := NewFrozenClock(time.Date(2026, 1, 20, 9, 0, 0, 0, time.Local))
+	restore := useClock(clock)
+	for _, args := range [][]string{{"new"}, {"start"}} {
+		if _, err := captureOutput(func() error { return newApp().Run(context.Background(), append([]string{"wt"}, args...)) }); err != nil {
+			restore()
+			t.Fatalf("wt %s: %v", args[0], err)
+		}
+	}
+	restore()
+
+	var log bytes.Buffer
+	d := &daemon{clock: clock, log: &log, done: make(chan struct{})}
+	reminders := func() int { return strings.Count(log.String(), "Reminder: ") }
+
+	clock.Advance(80 * time.Minute)
+	d.tick()
+	if n := reminders(); n != 0 {
+		t.Fatalf("reminded after 80 minutes:\n%s", log.String())
+	}
+
+	clock.Advance(10 * time.Minute)
+	d.tick()
+	if n := reminders(); n != 1 || !strings.Contains(log.String(), "Reminder: 1h 30m on current cycle - consider a break.") {
+		t.Fatalf("expected one reminder after 90 minutes:\n%s", log.String())
+	}
+
+	clock.Advance(DaemonRenotifyAfter - time.Minute)
+	d.tick()
+	if n := reminders(); n != 1 {
+		t.Fatalf("repeated the reminder before %s:\n%s", DaemonRenotifyAfter, log.String())
+	}
+
+	clock.Advance(time.Minute)
+	d.tick()
+	if n := reminders(); n != 2 {
+		t.Fatalf("expected the reminder again after %s:\n%s", DaemonRenotifyAfter, log.String())
+	}
+	if now := getCurrentTime(); now.Equal(clock.Now()) {
+		t.Fatalf("the daemon's clock stayed installed after its tick")
+	}
+}
+
+// The daemon applies WT_RETENTION on its first tick of each day and logs
+// what goes wrong
+func TestDaemonRetentionOncePerDay(t *testing.T) {
+	t.Setenv("WT_ROOT", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WT_MOCK_TIME", "")
+	t.Setenv("WT_NOTIFY_COMMAND", "true")
+	t.Setenv("WT_RETENTION", "10d")
+
+	folder, err := archiveFolderPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	archive := func(date string) string {
+		path := filepath.Join(folder, date+".json")
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	clock := NewFrozenClock(time.Date(2026, 1, 20, 9, 0, 0, 0, time.Local))
+	var log bytes.Buffer
+	d := &daemon{clock: clock, log: &log, done: make(chan struct{})}
+
+	old, recent := archive("2026-01-05"), archive("2026-01-15")
+	d.tick()
+	if exists(old) || !exists(recent) {
+		t.Fatalf("first tick: old day kept %v, recent day kept %v", exists(old), exists(recent))
+	}
+
+	archive("2026-01-05")
+	clock.Advance(time.Hour)
+	d.tick()
+	if !exists(old) {
+		t.Fatalf("retention applied twice on one day")
+	}
+
+	clock.Advance(24 * time.Hour)
+	d.tick()
+	if exists(old) {
+		t.Fatalf("retention not applied on the next day")
+	}
+
+	t.Setenv("WT_RETENTION", "soon")
+	clock.Advance(24 * time.Hour)
+	d.tick()
+	if !strings.Contains(log.String(), "Error: WT_RETENTION: Invalid age: soon.") {
+		t.Fatalf("retention error not logged:\n%s", log.String())
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// `wt serve --ui` also serves a single-page dashboard at / (dashboard.html,
+// embedded in the binary): status, today's cycles, a chart of the week, and
+// buttons for toggle, next, and stop. The page only uses apiRoutes, so it
+// needs a token like any other client; it takes one from /#token=<token>.
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// APIDay is a day of the week returned by GET /api/week
+type APIDay struct {
+	Date    string `json:"date"`
+	Weekday string `json:"weekday"` // Mon ... Sun
+	Work    int    `json:"work_minutes"`
+}
+
+func apiWeek(body map[string]string) (any, error) {
+	timer, err := load()
+	if err != nil {
+		return nil, err
+	}
+	week, err := loadWeek(timer)
+	if err != nil {
+		return nil, err
+	}
+	days := []APIDay{}
+	for _, day := range week {
+		days = append(days, APIDay{Date: day.Date.Format(DATE_FORMAT), Weekday: day.Date.Format("Mon"), Work: day.workMinutes()})
+	}
+	return days, nil
+}
+
+// dashboardHandler serves the dashboard page in front of the API
+func dashboardHandler(api http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardPage)
+	})
+	mux.Handle("/", api)
+	return mux
+}
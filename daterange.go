@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateRange is a span of whole days, [From, To)
+type DateRange struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// parseDateRange accepts today, yesterday, thisweek, lastweek, thismonth,
+// lastmonth, thisperiod, lastperiod (pay periods, see payperiod.go), a date
+// (YYYY-MM-DD), or two dates joined by ".." (inclusive)
+func parseDateRange(value string) (DateRange, error) {
+	now := getCurrentTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := weekStart(now)
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	r := DateRange{Name: value}
+	switch value {
+	case "today":
+		r.From, r.To = today, today.AddDate(0, 0, 1)
+	case "yesterday":
+		r.From, r.To = today.AddDate(0, 0, -1), today
+	case "thisweek":
+		r.From, r.To = monday, monday.AddDate(0, 0, 7)
+	case "lastweek":
+		r.From, r.To = monday.AddDate(0, 0, -7), monday
+	case "thismonth":
+		r.From, r.To = firstOfMonth, firstOfMonth.AddDate(0, 1, 0)
+	case "lastmonth":
+		r.From, r.To = firstOfMonth.AddDate(0, -1, 0), firstOfMonth
+	case "thisperiod", "lastperiod":
+		period, err := payPeriodRange(value == "lastperiod")
+		if err != nil {
+			return DateRange{}, err
+		}
+		r.From, r.To = period.From, period.To
+	default:
+		fromStr, toStr, isSpan := strings.Cut(value, "..")
+		if !isSpan {
+			toStr = fromStr
+		}
+		from, err1 := time.ParseInLocation(DATE_FORMAT, fromStr, time.Local)
+		to, err2 := time.ParseInLocation(DATE_FORMAT, toStr, time.Local)
+		if err1 != nil || err2 != nil || to.Before(from) {
+			return DateRange{}, fmt.Errorf("Invalid range: %s. Use today, yesterday, thisweek, lastweek, thismonth, lastmonth, thisperiod, lastperiod, YYYY-MM-DD, or YYYY-MM-DD..YYYY-MM-DD", value)
+		}
+		r.From, r.To = from, to.AddDate(0, 0, 1)
+	}
+	return r, nil
+}
+
+// loadRange returns the archived days in the range plus (a copy of) the
+// current timer if its day falls inside it, ordered by day start
+func loadRange(timer *Timer, r DateRange) ([]*Timer, error) {
+	timers, err := loadArchivedDays(r.From, r.To)
+	if err != nil {
+		return nil, err
+	}
+	if start, err := parseTime(timer.DayStart); err == nil && !start.Before(r.From) && start.Before(r.To) {
+		// The current day isn't archived yet, it belongs to the active profile
+		current := *timer
+		current.Profile = activeProfile()
+		timers = append(timers, &current)
+	}
+	return timers, nil
+}
+
+// loadRangeSummaries is loadRange for views that only need each day's
+// totals, read from the archive index (archiveindex.go)
+func loadRangeSummaries(timer *Timer, r DateRange) ([]DaySummary, error) {
+	summaries, err := loadDaySummaries(r.From, r.To)
+	if err != nil {
+		return nil, err
+	}
+	if start, err := parseTime(timer.DayStart); err == nil && !start.Before(r.From) && start.Before(r.To) {
+		current := summarizeDay(timer)
+		current.Profile = activeProfile()
+		summaries = append(summaries, current)
+	}
+	return summaries, nil
+}
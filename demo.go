@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// `wt demo [path]` fills a root with made-up but realistic days: a couple of
+// weeks of archived weekdays with work cycles, short breaks, lunch, the odd
+// pause, and tagged deep work and meeting cycles, plus today's timer up to
+// now. The days are produced by running the real commands with the mock
+// clock, so the archive and report files are exactly what wt would write.
+
+const (
+	DemoProfile     = "demo"
+	DefaultDemoDays = 10
+)
+
+// demoSettings go into the demo root's profile, so reports show earnings,
+// goals, and tags
+var demoSettings = [][2]string{
+	{"WT_RATE", "85 EUR"},
+	{"WT_DAILY_GOAL", "730"},
+	{"WT_PRESET_DEEPWORK", "90/15 +deepwork"},
+	{"WT_PRESET_MEETINGS", "untimed +meeting"},
+}
+
+// demoDaySteps returns the commands of a made-up work day
+func demoDaySteps(rng *rand.Rand, day time.Time) []ReplayStep {
+	// minutes returns a random duration from lo to hi minutes, in 5 minute steps
+	minutes := func(lo, hi int) time.Duration {
+		return time.Duration(lo+rng.IntN((hi-lo)/5+1)*5) * time.Minute
+	}
+	at := day.Add(8*time.Hour + minutes(0, 90))
+	var steps []ReplayStep
+	add := func(args ...string) {
+		steps = append(steps, ReplayStep{Time: at, Args: args})
+	}
+
+	add("new")
+	var worked time.Duration
+	goal, preset, lunch := minutes(330, 450), "", false
+	for cycle := 0; worked < goal; cycle++ {
+		next := ""
+		switch {
+		case cycle == 0 && rng.IntN(2) == 0:
+			next = "deepwork"
+		case cycle > 0 && rng.IntN(5) == 0:
+			next = "meetings"
+		case preset != "":
+			next = "none"
+		}
+		if next != "" && next != preset {
+			add("start", "--preset", next)
+			preset = next
+			if next == "none" {
+				preset = ""
+			}
+		} else {
+			add("start")
+		}
+
+		length := min(minutes(40, 100), max(goal-worked, 15*time.Minute))
+		if rng.IntN(4) == 0 { // Interrupted
+			// Resumed before the end of even a 15 minute cycle
+			before := min(minutes(15, 30), length-5*time.Minute)
+			at = at.Add(before)
+			add("pause")
+			at = at.Add(minutes(5, 15))
+			add("start")
+			length -= before
+			worked += before
+		}
+		at = at.Add(length)
+		worked += length
+		add("stop")
+
+		if !lunch && at.Hour() >= 12 {
+			at = at.Add(minutes(30, 60))
+			lunch = true
+		} else {
+			at = at.Add(minutes(5, 15))
+		}
+	}
+	return steps
+}
+
+// demoDays returns the count weekdays before today, oldest first
+func demoDays(today time.Time, count int) []time.Time {
+	var days []time.Time
+	for day := today.AddDate(0, 0, -1); len(days) < count; day = day.AddDate(0, 0, -1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			days = append([]time.Time{day}, days...)
+		}
+	}
+	return days
+}
+
+func demoCmd(path string, count int, seed uint64) error {
+	if count < 1 {
+		return fmt.Errorf("Invalid --days: %d. Use 1 or more.", count)
+	}
+	root := path
+	if root == "" {
+		var err error
+		if root, err = os.MkdirTemp("", "wt-demo-"); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(filepath.Join(root, OutputFolder, OutputFileName)); err == nil {
+		return fmt.Errorf("%s already has a timer. Pick another folder for the demo.", root)
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+
+	now := getCurrentTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	days := demoDays(today, count)
+	rng := rand.New(rand.NewPCG(seed, seed))
+	var steps []ReplayStep
+	for _, day := range days {
+		steps = append(steps, demoDaySteps(rng, day)...)
+	}
+	var todaySteps []ReplayStep
+	for _, step := range demoDaySteps(rng, today) {
+		if !step.Time.After(now) {
+			todaySteps = append(todaySteps, step)
+		}
+	}
+	if len(todaySteps) == 0 {
+		todaySteps = []ReplayStep{{Time: now, Args: []string{"new"}}}
+	}
+	steps = append(steps, todaySteps...)
+
+	// The user's settings would change the days; the demo profile replaces them
+	env := selftestEnv(root)
+	env["WT_REPORT_FILE"] = ""
+	env["WT_RATE"] = ""
+	restore := overrideEnv(env)
+	defer restore()
+	clock := NewFrozenClock(now)
+	defer useClock(clock)()
+
+	_, err = captureOutput(func() error {
+		for i, step := range steps {
+			clock.Set(step.Time)
+			if err := newApp().Run(context.Background(), append([]string{"wt"}, step.Args...)); err != nil {
+				return fmt.Errorf("[%s] wt %s: %v", step.Time.Format(DT_FORMAT), strings.Join(step.Args, " "), err)
+			}
+			if i == 0 {
+				for _, s := range demoSettings {
+					if err := profileSetCmd(DemoProfile, s[0], s[1]); err != nil {
+						return err
+					}
+				}
+				if err := profileUseCmd(DemoProfile); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %d demo days (%s to %s) and today's timer in %s\n",
+		len(days), days[0].Format(DATE_FORMAT), days[len(days)-1].Format(DATE_FORMAT), root)
+	fmt.Printf("Try it: eval \"$(wt env %s)\" && wt week --grid\n", shellQuote(root))
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// `wt env [path]` prints the settings that point wt at a root as shell
+// exports, so .envrc files and shell hooks are generated the same way:
+//
+//	eval "$(wt env ~/work/projX)"
+//	wt env >> .envrc
+//
+// Without a path the root is discovered: the nearest folder at or above the
+// working directory holding a timer, else $WT_ROOT.
+
+// discoverRoot returns the nearest folder at or above dir with a timer
+func discoverRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, OutputFolder, OutputFileName)); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func envCmd(path string) error {
+	root := path
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		root = cmp.Or(discoverRoot(cwd), os.Getenv("WT_ROOT"))
+		if root == "" {
+			return fmt.Errorf("No timer found in %s or above, and $WT_ROOT isn't set. Pass a path: wt env <path>", cwd)
+		}
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a folder.", root)
+	}
+
+	// Resolve the other paths as wt would with this root (and its profile),
+	// not with the report files exported for the current one
+	restore := overrideEnv(map[string]string{"WT_ROOT": root, "WT_REPORT_FILE": "", "WT_WEEKLY_REPORT_FILE": ""})
+	defer restore()
+	report, err := dailyReportFilePath()
+	if err != nil {
+		return err
+	}
+	weekly, err := weeklyReportFilePath()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("export WT_ROOT=%s\n", shellQuote(root))
+	if name := timerName(); name != "" {
+		fmt.Printf("export WT_TIMER=%s\n", shellQuote(name))
+	}
+	fmt.Printf("export WT_REPORT_FILE=%s\n", shellQuote(report))
+	fmt.Printf("export WT_WEEKLY_REPORT_FILE=%s\n", shellQuote(weekly))
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ExportDay is one day of tracked time as written by `wt export`
+type ExportDay struct {
+	Date    string      `json:"date"`
+	Profile string      `json:"profile,omitempty"`
+	Start   string      `json:"start"`
+	End     string      `json:"end"`
+	Work    int         `json:"work_minutes"`
+	Break   int         `json:"break_minutes"`
+	Lunch   int         `json:"lunch_minutes"`
+	Paused  int         `json:"paused_minutes"`
+	Entries []LogRecord `json:"entries"`
+	Note    string      `json:"note,omitempty"` // Retrospective note
+
+	logEntries []LogEntry // Entries with their times, for formats that need them
+}
+
+// ExportFormat is an output format of `wt export`. New formats only need an
+// entry in exportFormats.
+type ExportFormat struct {
+	Name        string
+	Description string
+	Binary      bool // Refuses to write to a terminal
+	Write       func(w io.Writer, days []ExportDay) error
+	WriteDir    func(dir string, days []ExportDay) error // Instead of Write, for formats of several files
+}
+
+// exportFormats is the registry of export formats, in the order help lists them
+var exportFormats = []ExportFormat{
+	{Name: "json", Description: "Days with totals and cycles (default)", Write: writeExportJSON},
+	{Name: "csv", Description: "One row per cycle, honoring the WT_CSV_* settings", Write: writeExportCSV},
+	{Name: "payroll", Description: "One row per date with regular, overtime, and break hours, rounded", Write: writeExportPayroll},
+	{Name: "md", Description: "Markdown table, one row per day", Write: writeExportMarkdown},
+	{Name: "ics", Description: "iCalendar with one event per work cycle", Write: writeExportICS},
+	{Name: "timeclock", Description: "ledger/hledger timeclock check-ins and check-outs", Write: writeExportTimeclock},
+	{Name: "org", Description: "Org-mode headings with CLOCK entries", Write: writeExportOrg},
+	{Name: "influx", Description: "InfluxDB line protocol, per cycle and per day", Write: writeExportInflux},
+	{Name: "xlsx", Description: "Excel workbook, one row per cycle", Binary: true, Write: writeExportXLSX},
+	{Name: "site", Description: "Static HTML site with a calendar and a page per day, into a directory", WriteDir: writeExportSite},
+}
+
+func findExportFormat(name string) (ExportFormat, error) {
+	var names []string
+	for _, format := range exportFormats {
+		if format.Name == name {
+			return format, nil
+		}
+		names = append(names, format.Name)
+	}
+	return ExportFormat{}, fmt.Errorf("Unknown export format: %s. Use one of: %s", name, strings.Join(names, ", "))
+}
+
+// exportFormatsHelp lists the formats for the command description
+func exportFormatsHelp() string {
+	var b strings.Builder
+	for _, format := range exportFormats {
+		fmt.Fprintf(&b, "\n     %-10s %s", format.Name, format.Description)
+	}
+	return b.String()
+}
+
+// ExportOptions are the filters shared by all export formats
+type ExportOptions struct {
+	Range     string
+	Type      string // Only "work" or "break" cycles
+	Anonymize bool
+	Output    string // File to write to; stdout if empty or "-"
+}
+
+// exportDays converts the timers to ExportDays, keeping only cycles of the given type (if any)
+func exportDays(timers []*Timer, entryType string) []ExportDay {
+	days := []ExportDay{}
+	for _, t := range timers {
+		start, err := parseTime(t.DayStart)
+		if err != nil {
+			continue
+		}
+		entries := buildLogEntries(t)
+		totals := t.Totals()
+
+		day := ExportDay{
+			Date:    start.Format(DATE_FORMAT),
+			Profile: t.Profile,
+			Start:   start.Format(DT_FORMAT),
+			End:     start.Format(DT_FORMAT),
+			Work:    totals.Work,
+			Break:   totals.Break,
+			Lunch:   totals.Lunch,
+			Paused:  totals.Paused,
+			Entries: []LogRecord{},
+			Note:    t.Note,
+		}
+		if len(entries) > 0 {
+			day.End = entries[len(entries)-1].End.Format(DT_FORMAT)
+		}
+		for _, entry := range entries {
+			if entryType != "" && entry.Type != entryType {
+				continue
+			}
+			day.Entries = append(day.Entries, entry.Record())
+			day.logEntries = append(day.logEntries, entry)
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// anonymizeDays strips everything that could identify a client or task while
+// keeping durations and structure. Profiles become "profile-1", "profile-2", ...
+// in order of appearance; tags, tasks, notes, markers, meeting titles,
+// locations, pause reasons, ratings, command arguments, and earnings (which
+// reveal rates) are dropped, from the records and from the entries the other
+// formats render.
+func anonymizeDays(days []ExportDay) {
+	aliases := map[string]string{}
+	for i := range days {
+		if profile := days[i].Profile; profile != "" {
+			if _, ok := aliases[profile]; !ok {
+				aliases[profile] = fmt.Sprintf("profile-%d", len(aliases)+1)
+			}
+			days[i].Profile = aliases[profile]
+		}
+		days[i].Note = ""
+		for j := range days[i].Entries {
+			days[i].Entries[j].Earned = 0
+			days[i].Entries[j].Tags = nil
+			days[i].Entries[j].Marks = nil
+			days[i].Entries[j].Task = ""
+			days[i].Entries[j].Meetings = nil
+			days[i].Entries[j].Location = ""
+			days[i].Entries[j].Rating = 0
+			days[i].Entries[j].Command = anonymizeCommand(days[i].Entries[j].Command)
+			days[i].Entries[j].PauseReasons = nil
+			for k := range days[i].Entries[j].Pauses {
+				days[i].Entries[j].Pauses[k].Reason = ""
+			}
+		}
+		for j := range days[i].logEntries {
+			days[i].logEntries[j].Tags = nil
+			days[i].logEntries[j].Rating = 0
+			days[i].logEntries[j].Marks = nil
+			days[i].logEntries[j].Task = ""
+			days[i].logEntries[j].Meetings = nil
+			days[i].logEntries[j].Location = ""
+			days[i].logEntries[j].Command = anonymizeCommand(days[i].logEntries[j].Command)
+			pauses := slices.Clone(days[i].logEntries[j].Pauses) // Shared with the timer
+			for k := range pauses {
+				pauses[k].Reason = ""
+			}
+			days[i].logEntries[j].Pauses = pauses
+		}
+	}
+}
+
+// anonymizeCommand keeps only the name of an audit trail command, whose
+// arguments can name the location or task ("wt start @office -m ...")
+func anonymizeCommand(command string) string {
+	fields := strings.Fields(command)
+	return strings.Join(fields[:min(2, len(fields))], " ")
+}
+
+func exportCmd(timer *Timer, formatName string, opts ExportOptions) error {
+	if formatName == "" {
+		formatName = "json"
+	}
+	format, err := findExportFormat(formatName)
+	if err != nil {
+		return err
+	}
+	if opts.Type != "" && opts.Type != "work" && opts.Type != "break" {
+		return fmt.Errorf("Invalid type: %s. Use 'work' or 'break'", opts.Type)
+	}
+
+	r, err := parseDateRange(opts.Range)
+	if err != nil {
+		return err
+	}
+	timers, err := loadRange(timer, r)
+	if err != nil {
+		return err
+	}
+
+	days := exportDays(timers, opts.Type)
+	if opts.Anonymize {
+		anonymizeDays(days)
+	}
+
+	if format.WriteDir != nil {
+		if opts.Output == "" || opts.Output == "-" {
+			return fmt.Errorf("%s writes a directory. Use wt export %s <dir>", format.Name, format.Name)
+		}
+		if err := format.WriteDir(opts.Output, days); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d days to %s.\n", len(days), opts.Output)
+		return nil
+	}
+
+	if opts.Output == "" || opts.Output == "-" {
+		if format.Binary && isTerminal(os.Stdout) {
+			return fmt.Errorf("%s is a binary format. Use --output <file>", format.Name)
+		}
+		return format.Write(os.Stdout, days)
+	}
+
+	f, err := createFile(opts.Output)
+	if err != nil {
+		return err
+	}
+	if err := format.Write(f, days); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d days to %s.\n", len(days), opts.Output)
+	return nil
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writers for the formats in exportFormats
+
+func writeExportJSON(w io.Writer, days []ExportDay) error {
+	data, err := json.MarshalIndent(days, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// exportRows flattens the days into one row per cycle (shared by csv and xlsx)
+func exportRows(days []ExportDay, formatTime func(time.Time) string, formatAmount func(float64) string) (header []string, rows [][]string) {
+	_, billing := billingRate()
+	header = []string{"date", "profile", "num", "type", "label", "start", "end", "minutes", "paused_minutes", "active"}
+	if billing {
+		header = append(header, "earned")
+	}
+
+	for _, day := range days {
+		for i, entry := range day.logEntries {
+			record := day.Entries[i]
+			row := []string{
+				day.Date, day.Profile, strconv.Itoa(entry.Num), entry.Type, entry.Label,
+				formatTime(entry.Start), formatTime(entry.End),
+				strconv.Itoa(entry.Minutes), strconv.Itoa(entry.PausedMinutes), strconv.FormatBool(entry.Active),
+			}
+			if billing {
+				row = append(row, formatAmount(record.Earned))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return header, rows
+}
+
+func writeExportCSV(w io.Writer, days []ExportDay) error {
+	dialect, err := csvDialect()
+	if err != nil {
+		return err
+	}
+	header, rows := exportRows(days, dialect.FormatTime, dialect.FormatAmount)
+
+	writer := dialect.NewWriter(w)
+	writer.Write(header)
+	for _, row := range rows {
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeExportMarkdown(w io.Writer, days []ExportDay) error {
+	fmt.Fprintln(w, "| Date | Start | End | Work | Break | Paused | Total |")
+	fmt.Fprintln(w, "|------|-------|-----|------|-------|--------|-------|")
+
+	var total DayTotals
+	for _, day := range days {
+		totals := DayTotals{Work: day.Work, Break: day.Break, Lunch: day.Lunch, Paused: day.Paused}
+		total.Add(totals)
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			day.Date, day.Start[len(DATE_FORMAT)+1:], day.End[len(DATE_FORMAT)+1:],
+			minutesToHourMinuteStr(totals.Work), minutesToHourMinuteStr(totals.Break+totals.Lunch),
+			minutesToHourMinuteStr(totals.Paused), minutesToHourMinuteStr(totals.Total()))
+	}
+	_, err := fmt.Fprintf(w, "| **Total** | | | **%s** | %s | %s | %s |\n",
+		minutesToHourMinuteStr(total.Work), minutesToHourMinuteStr(total.Break+total.Lunch),
+		minutesToHourMinuteStr(total.Paused), minutesToHourMinuteStr(total.Total()))
+
+	// Retrospective notes and markers below the table, which can't hold them nicely
+	heading := false
+	for _, day := range days {
+		marks := dayMarks(day)
+		if day.Note == "" && len(marks) == 0 {
+			continue
+		}
+		if !heading {
+			fmt.Fprint(w, "\n## Notes\n\n")
+			heading = true
+		}
+		if day.Note != "" {
+			_, err = fmt.Fprintf(w, "- **%s:** %s\n", day.Date, day.Note)
+		}
+		for _, mark := range marks {
+			_, err = fmt.Fprintf(w, "- **%s:** %s\n", mark.At, mark.Text)
+		}
+	}
+	return err
+}
+
+// dayMarks returns the markers of a day's exported cycles, with clock times
+func dayMarks(day ExportDay) []MarkRecord {
+	var marks []MarkRecord
+	for _, entry := range day.logEntries {
+		marks = append(marks, markRecords(entry.Start, entry.Marks)...)
+	}
+	return marks
+}
+
+// workSummary names an exported work cycle, e.g. "Work (acme)"
+func workSummary(day ExportDay) string {
+	if day.Profile == "" {
+		return "Work"
+	}
+	return fmt.Sprintf("Work (%s)", day.Profile)
+}
+
+// icsEvent renders a work cycle as the lines of a VEVENT
+func icsEvent(uid string, day ExportDay, entry LogEntry) []string {
+	const stamp = "20060102T150405Z"
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTAMP:" + entry.Start.UTC().Format(stamp),
+		"DTSTART:" + entry.Start.UTC().Format(stamp),
+		"DTEND:" + entry.End.UTC().Format(stamp),
+		"SUMMARY:" + workSummary(day)}
+	if len(entry.Marks) > 0 {
+		var marks []string
+		for _, m := range entry.Marks {
+			marks = append(marks, markTime(entry.Start, m).Format(TIME_ONLY_FORMAT)+" "+escapeICS(m.Text))
+		}
+		lines = append(lines, "DESCRIPTION:"+strings.Join(marks, "\\n"))
+	}
+	return append(lines, "END:VEVENT")
+}
+
+// icsCalendar wraps the lines of events in a VCALENDAR produced by product
+func icsCalendar(product string, events []string, extra ...string) string {
+	lines := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//wt//" + product + "//EN"}, extra...)
+	lines = append(append(lines, events...), "END:VCALENDAR")
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// cycleEvent is a stopped work cycle's event, named after its date,
+// profile, and entry ID, which stay the same when other cycles change
+type cycleEvent struct {
+	Date     string
+	Resource string // Name of the event's .ics resource
+	Summary  string // e.g. 2026-01-20 09:00-10:00 Work
+	Lines    []string
+}
+
+// cycleEvents renders the stopped work cycles of the days, oldest first
+func cycleEvents(days []ExportDay) []cycleEvent {
+	var events []cycleEvent
+	for _, day := range days {
+		for _, entry := range day.logEntries {
+			if entry.Type != "work" || entry.Active {
+				continue
+			}
+			id := entry.ID
+			if id == "" {
+				id = fmt.Sprintf("c%d", entry.Num) // Archived before entries had IDs
+			}
+			name := "wt-" + day.Date
+			if day.Profile != "" {
+				name += "-" + day.Profile
+			}
+			name += "-" + id
+			summary := fmt.Sprintf("%s %s-%s %s", day.Date, entry.Start.Format(TIME_ONLY_FORMAT), entry.End.Format(TIME_ONLY_FORMAT), workSummary(day))
+			events = append(events, cycleEvent{Date: day.Date, Resource: name + ".ics", Summary: summary, Lines: icsEvent(name+"@wt", day, entry)})
+		}
+	}
+	return events
+}
+
+func writeExportICS(w io.Writer, days []ExportDay) error {
+	var events []string
+	for _, day := range days {
+		for _, entry := range day.logEntries {
+			if entry.Type == "work" {
+				events = append(events, icsEvent(fmt.Sprintf("%s-%d@wt", day.Date, entry.Num), day, entry)...)
+			}
+		}
+	}
+	_, err := io.WriteString(w, icsCalendar("export", events))
+	return err
+}
+
+// writeExportTimeclock writes check-ins and check-outs for ledger/hledger.
+// Paused time isn't timestamped, so each check-out is the cycle's work time after its check-in.
+func writeExportTimeclock(w io.Writer, days []ExportDay) error {
+	const layout = "2006-01-02 15:04:05"
+	for _, day := range days {
+		account := day.Profile
+		if account == "" {
+			account = "work"
+		}
+		for _, entry := range day.logEntries {
+			if entry.Type != "work" {
+				continue
+			}
+			end := entry.Start.Add(time.Duration(entry.Minutes) * time.Minute)
+			fmt.Fprintf(w, "i %s %s\n", entry.Start.Format(layout), account)
+			if _, err := fmt.Fprintf(w, "o %s\n", end.Format(layout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeExportOrg writes one heading per day with a logbook, newest clock first as org-mode does
+func writeExportOrg(w io.Writer, days []ExportDay) error {
+	const layout = "2006-01-02 Mon 15:04"
+	for _, day := range days {
+		title := day.Date
+		if day.Profile != "" {
+			title += " :" + day.Profile + ":"
+		}
+		fmt.Fprintf(w, "* %s\n  :LOGBOOK:\n", title)
+		for i := len(day.logEntries) - 1; i >= 0; i-- {
+			entry := day.logEntries[i]
+			if entry.Type != "work" {
+				continue
+			}
+			end := entry.Start.Add(time.Duration(entry.Minutes) * time.Minute)
+			fmt.Fprintf(w, "  CLOCK: [%s]--[%s] => %2d:%02d\n",
+				entry.Start.Format(layout), end.Format(layout), entry.Minutes/60, entry.Minutes%60)
+		}
+		if _, err := fmt.Fprintln(w, "  :END:"); err != nil {
+			return err
+		}
+		if day.Note != "" {
+			fmt.Fprintf(w, "  %s\n", day.Note)
+		}
+		for _, entry := range day.logEntries {
+			for _, m := range entry.Marks {
+				fmt.Fprintf(w, "  - [%s] %s\n", markTime(entry.Start, m).Format(layout), m.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// influxTags renders the non-empty tags of a line protocol point, in key order
+func influxTags(tags ...[2]string) string {
+	escape := strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	var b strings.Builder
+	for _, tag := range tags {
+		if tag[1] != "" {
+			fmt.Fprintf(&b, ",%s=%s", tag[0], escape.Replace(tag[1]))
+		}
+	}
+	return b.String()
+}
+
+// writeExportInflux writes InfluxDB line protocol: a wt_cycle point per cycle
+// at its start, and a wt_day point per day at the day start, with
+// nanosecond timestamps. Points keep their series and time, so exporting a
+// range again overwrites them with the latest totals.
+func writeExportInflux(w io.Writer, days []ExportDay) error {
+	quote := strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+	for _, day := range days {
+		for _, entry := range day.logEntries {
+			fields := fmt.Sprintf("minutes=%di,paused_minutes=%di", entry.Minutes, entry.PausedMinutes)
+			if entry.Task != "" {
+				fields += fmt.Sprintf(`,task="%s"`, quote.Replace(entry.Task))
+			}
+			fmt.Fprintf(w, "wt_cycle%s %s %d\n",
+				influxTags([2]string{"label", entry.Label}, [2]string{"location", entry.Location}, [2]string{"profile", day.Profile}, [2]string{"type", entry.Type}),
+				fields, entry.Start.UnixNano())
+		}
+		start, err := parseTime(day.Start)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "wt_day%s work_minutes=%di,break_minutes=%di,lunch_minutes=%di,paused_minutes=%di %d\n",
+			influxTags([2]string{"profile", day.Profile}), day.Work, day.Break, day.Lunch, day.Paused, start.UnixNano()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExportXLSX writes a minimal single-sheet workbook with the csv rows.
+// Numeric columns are stored as numbers so spreadsheets can sum them.
+func writeExportXLSX(w io.Writer, days []ExportDay) error {
+	header, rows := exportRows(days,
+		func(t time.Time) string { return t.Format(DT_FORMAT) },
+		func(amount float64) string { return strconv.FormatFloat(amount, 'f', 2, 64) })
+	numeric := map[string]bool{"num": true, "minutes": true, "paused_minutes": true, "earned": true}
+
+	var sheet strings.Builder
+	cell := func(ref, value string, number bool) {
+		if number {
+			fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+			return
+		}
+		sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t>`)
+		xml.EscapeText(&sheet, []byte(value))
+		sheet.WriteString(`</t></is></c>`)
+	}
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range append([][]string{header}, rows...) {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, value := range row {
+			cell(fmt.Sprintf("%c%d", 'A'+c, r+1), value, r > 0 && numeric[header[c]])
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	const relsNS = "http://schemas.openxmlformats.org/package/2006/relationships"
+	const docNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="` + relsNS + `">` +
+			`<Relationship Id="rId1" Type="` + docNS + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + docNS + `">` +
+			`<sheets><sheet name="wt" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="` + relsNS + `">` +
+			`<Relationship Id="rId1" Type="` + docNS + `/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
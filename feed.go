@@ -0,0 +1,63 @@
+package main
+
+import (
+	"cmp"
+	"io"
+	"net/http"
+	"time"
+)
+
+// `wt serve` also publishes the stopped work cycles as an iCalendar feed at
+// /api/feed.ics, for calendar apps to subscribe to, so tracked time shows
+// up next to the meetings. Calendar apps can't send headers, so the token
+// may be given as ?token= instead; any token can read the feed. It covers
+// the last WT_FEED_DAYS days (default 30), today included, with the same
+// events `wt sync caldav` pushes.
+
+const (
+	FeedPath        = "/api/feed.ics"
+	DefaultFeedDays = 30
+)
+
+// workFeed renders the feed's calendar
+func workFeed() (string, error) {
+	timer, err := load()
+	if err != nil {
+		return "", err
+	}
+	days := envInt("WT_FEED_DAYS", DefaultFeedDays)
+	if days < 1 {
+		days = DefaultFeedDays
+	}
+	now := getCurrentTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	timers, err := loadRange(timer, DateRange{From: today.AddDate(0, 0, 1-days), To: today.AddDate(0, 0, 1)})
+	if err != nil {
+		return "", err
+	}
+	var events []string
+	for _, event := range cycleEvents(exportDays(timers, "work")) {
+		events = append(events, event.Lines...)
+	}
+	return icsCalendar("feed", events, "X-WR-CALNAME:wt", "REFRESH-INTERVAL;VALUE=DURATION:PT1H", "X-PUBLISHED-TTL:PT1H"), nil
+}
+
+func feedHandler(tokens map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(tokens) > 0 {
+			if _, ok := lookupToken(tokens, cmp.Or(r.URL.Query().Get("token"), bearerToken(r))); !ok {
+				writeJSONError(w, http.StatusUnauthorized, "Invalid or missing token. Subscribe to "+FeedPath+"?token= with an API token.")
+				return
+			}
+		}
+		apiMu.Lock()
+		feed, err := workFeed()
+		apiMu.Unlock()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		io.WriteString(w, feed)
+	}
+}
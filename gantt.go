@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const GanttMinutesPerChar = 2 // One character covers this many minutes (30 per hour row)
+
+// Segment kinds drawn by the gantt chart
+const (
+	ganttNone = iota
+	ganttWork
+	ganttPaused
+	ganttBreak
+)
+
+// ganttChars returns the glyph of each segment kind (see asciiMode)
+func ganttChars() map[int]string {
+	return map[int]string{
+		ganttNone:   " ",
+		ganttWork:   glyphs("█", "#"),
+		ganttPaused: glyphs("░", "="),
+		ganttBreak:  glyphs("·", "."),
+	}
+}
+
+var ganttColors = map[int]string{
+	ganttWork:   "\033[32m", // Green
+	ganttPaused: "\033[33m", // Yellow
+	ganttBreak:  "\033[90m", // Grey
+}
+
+const ansiReset = "\033[0m"
+
+// useColor reports whether stdout is a terminal and $NO_COLOR is unset
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// renderGantt draws the entries as one bar per hour, with each pause where
+// it happened in its work cycle
+func renderGantt(entries []LogEntry, color bool) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	first := entries[0].Start.Truncate(time.Hour)
+	last := entries[len(entries)-1].End
+	if rounded := last.Truncate(time.Hour); rounded.Before(last) {
+		last = rounded.Add(time.Hour)
+	}
+	if !last.After(first) {
+		last = first.Add(time.Hour)
+	}
+
+	// Kind of each minute from the first hour on
+	minutes := make([]int, int(last.Sub(first).Minutes()))
+	mark := func(from time.Time, length, kind int) {
+		offset := int(from.Sub(first).Minutes())
+		for i := max(offset, 0); i < offset+length && i < len(minutes); i++ {
+			minutes[i] = kind
+		}
+	}
+	for _, entry := range entries {
+		if entry.Type == "work" {
+			duration := entry.Minutes + entry.PausedMinutes
+			mark(entry.Start, duration, ganttWork)
+			// Logs from servers without pause intervals only have the total
+			for _, p := range syncPauses(entry.Pauses, entry.PausedMinutes, duration) {
+				mark(entry.Start.Add(time.Duration(p.Start)*time.Minute), p.Minutes(), ganttPaused)
+			}
+		} else {
+			mark(entry.Start, entry.Minutes, ganttBreak)
+		}
+	}
+
+	chars := ganttChars()
+	paint := func(kind int) string {
+		if color && kind != ganttNone {
+			return ganttColors[kind] + chars[kind] + ansiReset
+		}
+		return chars[kind]
+	}
+
+	var b strings.Builder
+	for hour := 0; hour*60 < len(minutes); hour++ {
+		fmt.Fprintf(&b, "%s |", first.Add(time.Duration(hour)*time.Hour).Format(TIME_ONLY_FORMAT))
+		for m := hour * 60; m < (hour+1)*60; m += GanttMinutesPerChar {
+			b.WriteString(paint(minutes[m]))
+		}
+		b.WriteString("|\n")
+	}
+	fmt.Fprintf(&b, "%s work  %s paused  %s break (1 char = %d min)\n",
+		paint(ganttWork), paint(ganttPaused), paint(ganttBreak), GanttMinutesPerChar)
+
+	return b.String()
+}
@@ -2,4 +2,4 @@ module wt
 
 go 1.25.3
 
-require github.com/urfave/cli/v3 v3.6.2 // indirect
+require github.com/urfave/cli/v3 v3.6.2
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// `wt grep <pattern>` searches what was written about the work: the task,
+// tags, location, markers, and pause reasons of each work cycle, and the
+// day's retrospective note, in today's timer and the whole archive (or
+// --range). The pattern is a regular expression; -i ignores case. Each
+// matching cycle is printed with its date, times, and length, followed by
+// the fields that matched.
+
+// grepMatches returns the fields of a work cycle that match re, labeled
+func grepMatches(re *regexp.Regexp, entry LogEntry) []string {
+	var matches []string
+	if entry.Task != "" && re.MatchString(entry.Task) {
+		matches = append(matches, fmt.Sprintf("task %q", entry.Task))
+	}
+	for _, tag := range entry.Tags {
+		if re.MatchString(tag) {
+			matches = append(matches, "tag +"+tag)
+		}
+	}
+	if entry.Location != "" && re.MatchString(entry.Location) {
+		matches = append(matches, "location @"+entry.Location)
+	}
+	for _, mark := range entry.Marks {
+		if re.MatchString(mark.Text) {
+			at := entry.Start.Add(time.Duration(mark.At) * time.Minute)
+			matches = append(matches, fmt.Sprintf("mark %s %q", at.Format(TIME_ONLY_FORMAT), mark.Text))
+		}
+	}
+	for _, pause := range entry.Pauses {
+		if pause.Reason != "" && re.MatchString(pause.Reason) {
+			at := entry.Start.Add(time.Duration(pause.Start) * time.Minute)
+			matches = append(matches, fmt.Sprintf("pause %s %q", at.Format(TIME_ONLY_FORMAT), pause.Reason))
+		}
+	}
+	return matches
+}
+
+func grepCmd(timer *Timer, pattern, rangeName string, ignoreCase bool) error {
+	if pattern == "" {
+		return fmt.Errorf("Usage: wt grep <pattern> [--range <range>] [-i]")
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("Invalid pattern: %v", err)
+	}
+	r := DateRange{Name: "the archive", To: getCurrentTime().AddDate(0, 0, 1)}
+	if rangeName != "" {
+		if r, err = parseDateRange(rangeName); err != nil {
+			return err
+		}
+	}
+	timers, err := loadRange(timer, r)
+	if err != nil {
+		return err
+	}
+
+	cycles, minutes, notes := 0, 0, 0
+	for _, t := range timers {
+		date := t.DayStart[:len(DATE_FORMAT)]
+		for _, entry := range buildLogEntries(t) {
+			if entry.Type != "work" {
+				continue
+			}
+			matches := grepMatches(re, entry)
+			if len(matches) == 0 {
+				continue
+			}
+			end := entry.End.Format(TIME_ONLY_FORMAT)
+			if entry.Active {
+				end = "....."
+			}
+			fmt.Printf("%s | %s -> %s | Work: %s | %s\n", date, entry.Start.Format(TIME_ONLY_FORMAT), end,
+				minutesToHourMinuteStr(entry.Minutes), strings.Join(matches, ", "))
+			cycles++
+			minutes += entry.Minutes
+		}
+		if t.Note != "" && re.MatchString(t.Note) {
+			fmt.Printf("%s | note %q\n", date, t.Note)
+			notes++
+		}
+	}
+
+	switch {
+	case cycles+notes == 0:
+		fmt.Printf("Nothing matching %s in %s.\n", strings.TrimPrefix(pattern, "(?i)"), r.Name)
+		return nil
+	case cycles == 0:
+		return nil
+	}
+	fmt.Printf("%d cycles, %s of work.\n", cycles, minutesToHourMinuteStr(minutes))
+	return nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Public holidays come from WT_HOLIDAYS: a comma-separated list of country
+// codes (see holidayCountries) and/or paths to .ics calendars, e.g.
+//
+//	WT_HOLIDAYS="DE"
+//	WT_HOLIDAYS="US,~/company-holidays.ics"
+//
+// Holidays have no scheduled start (WT_SCHEDULE) and no daily goal, and are
+// marked in `wt week`.
+
+// Holiday is a day off with its name
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// holidayRule yields a holiday's date in a given year
+type holidayRule struct {
+	Name string
+	Date func(year int) time.Time
+}
+
+func fixedDate(month time.Month, day int) func(int) time.Time {
+	return func(year int) time.Time { return time.Date(year, month, day, 0, 0, 0, 0, time.Local) }
+}
+
+// easterOffset is a date relative to Easter Sunday
+func easterOffset(days int) func(int) time.Time {
+	return func(year int) time.Time { return easterSunday(year).AddDate(0, 0, days) }
+}
+
+// nthWeekday is the nth weekday of the month; n = -1 is the last one
+func nthWeekday(month time.Month, weekday time.Weekday, n int) func(int) time.Time {
+	return func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local)
+			return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+		return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+7*(n-1))
+	}
+}
+
+// easterSunday computes Easter in the Gregorian calendar (anonymous algorithm)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+}
+
+// Weekend handling for holidays falling on a Saturday or Sunday
+const (
+	weekendKeep       = iota // The day off is lost
+	weekendObserved          // Saturday moves to Friday, Sunday to Monday (US)
+	weekendSubstitute        // Moves to the next free weekday (UK)
+)
+
+type holidayCountry struct {
+	Name    string
+	Weekend int
+	Rules   []holidayRule
+}
+
+// holidayCountries are the built-in national public holidays. Regional
+// holidays aren't included; add them with an .ics file.
+var holidayCountries = map[string]holidayCountry{
+	"AT": {Name: "Austria", Rules: []holidayRule{
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Epiphany", fixedDate(time.January, 6)},
+		{"Easter Monday", easterOffset(1)},
+		{"Labour Day", fixedDate(time.May, 1)},
+		{"Ascension Day", easterOffset(39)},
+		{"Whit Monday", easterOffset(50)},
+		{"Corpus Christi", easterOffset(60)},
+		{"Assumption Day", fixedDate(time.August, 15)},
+		{"National Day", fixedDate(time.October, 26)},
+		{"All Saints' Day", fixedDate(time.November, 1)},
+		{"Immaculate Conception", fixedDate(time.December, 8)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+		{"St. Stephen's Day", fixedDate(time.December, 26)},
+	}},
+	"DE": {Name: "Germany", Rules: []holidayRule{
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Good Friday", easterOffset(-2)},
+		{"Easter Monday", easterOffset(1)},
+		{"Labour Day", fixedDate(time.May, 1)},
+		{"Ascension Day", easterOffset(39)},
+		{"Whit Monday", easterOffset(50)},
+		{"German Unity Day", fixedDate(time.October, 3)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+		{"Boxing Day", fixedDate(time.December, 26)},
+	}},
+	"FR": {Name: "France", Rules: []holidayRule{
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Easter Monday", easterOffset(1)},
+		{"Labour Day", fixedDate(time.May, 1)},
+		{"Victory in Europe Day", fixedDate(time.May, 8)},
+		{"Ascension Day", easterOffset(39)},
+		{"Whit Monday", easterOffset(50)},
+		{"Bastille Day", fixedDate(time.July, 14)},
+		{"Assumption Day", fixedDate(time.August, 15)},
+		{"All Saints' Day", fixedDate(time.November, 1)},
+		{"Armistice Day", fixedDate(time.November, 11)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+	}},
+	"GB": {Name: "England and Wales", Weekend: weekendSubstitute, Rules: []holidayRule{
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Good Friday", easterOffset(-2)},
+		{"Easter Monday", easterOffset(1)},
+		{"Early May bank holiday", nthWeekday(time.May, time.Monday, 1)},
+		{"Spring bank holiday", nthWeekday(time.May, time.Monday, -1)},
+		{"Summer bank holiday", nthWeekday(time.August, time.Monday, -1)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+		{"Boxing Day", fixedDate(time.December, 26)},
+	}},
+	"US": {Name: "United States (federal)", Weekend: weekendObserved, Rules: []holidayRule{
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Martin Luther King Jr. Day", nthWeekday(time.January, time.Monday, 3)},
+		{"Washington's Birthday", nthWeekday(time.February, time.Monday, 3)},
+		{"Memorial Day", nthWeekday(time.May, time.Monday, -1)},
+		{"Juneteenth", fixedDate(time.June, 19)},
+		{"Independence Day", fixedDate(time.July, 4)},
+		{"Labor Day", nthWeekday(time.September, time.Monday, 1)},
+		{"Columbus Day", nthWeekday(time.October, time.Monday, 2)},
+		{"Veterans Day", fixedDate(time.November, 11)},
+		{"Thanksgiving Day", nthWeekday(time.November, time.Thursday, 4)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+	}},
+}
+
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+// countryHolidays returns the country's holidays in the year, moved off weekends as the country does
+func countryHolidays(country holidayCountry, year int) []Holiday {
+	var holidays []Holiday
+	taken := map[string]bool{}
+	for _, rule := range country.Rules {
+		date := rule.Date(year)
+		taken[date.Format(DATE_FORMAT)] = true
+		holidays = append(holidays, Holiday{Date: date, Name: rule.Name})
+	}
+
+	for i, holiday := range holidays {
+		if !isWeekend(holiday.Date) {
+			continue
+		}
+		switch country.Weekend {
+		case weekendObserved:
+			if holiday.Date.Weekday() == time.Saturday {
+				holidays[i].Date = holiday.Date.AddDate(0, 0, -1)
+			} else {
+				holidays[i].Date = holiday.Date.AddDate(0, 0, 1)
+			}
+			holidays[i].Name += " (observed)"
+		case weekendSubstitute:
+			date := holiday.Date
+			for isWeekend(date) || taken[date.Format(DATE_FORMAT)] {
+				date = date.AddDate(0, 0, 1)
+			}
+			taken[date.Format(DATE_FORMAT)] = true
+			holidays[i].Date = date
+			holidays[i].Name += " (substitute day)"
+		}
+	}
+	return holidays
+}
+
+// icsHolidays reads the events of an .ics file, parsed like WT_CALENDAR
+// (parseICS), as holidays on their dates. Multi-day events yield one holiday
+// per day, and yearly recurring events repeat in the year.
+func icsHolidays(path string, year int) ([]Holiday, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = home + path[1:]
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read holiday calendar: %v", err)
+	}
+	events, err := parseICS(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid holiday calendar %s: %v", path, err)
+	}
+
+	// The dates of the events, as written: a holiday is a whole local day
+	date := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+	var holidays []Holiday
+	for _, event := range events {
+		if event.Start.IsZero() {
+			continue
+		}
+		start, end := date(event.Start), date(event.End)
+		if event.RRule["FREQ"] == "YEARLY" && start.Year() < year {
+			shift := year - start.Year()
+			start, end = start.AddDate(shift, 0, 0), end.AddDate(shift, 0, 0)
+		}
+		if !end.After(start) {
+			end = start.AddDate(0, 0, 1) // DTEND is exclusive
+		}
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			if day.Year() == year {
+				holidays = append(holidays, Holiday{Date: day, Name: event.Summary})
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// holidaysInYear returns the WT_HOLIDAYS holidays in the year, ordered by date
+func holidaysInYear(year int) ([]Holiday, error) {
+	var holidays []Holiday
+	for _, source := range strings.Split(setting("WT_HOLIDAYS"), ",") {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			continue
+		}
+		if country, ok := holidayCountries[strings.ToUpper(source)]; ok {
+			holidays = append(holidays, countryHolidays(country, year)...)
+			continue
+		}
+		if !strings.HasSuffix(strings.ToLower(source), ".ics") {
+			var codes []string
+			for code := range holidayCountries {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+			return nil, fmt.Errorf("Unknown holiday calendar: %s. Use a country code (%s) or an .ics file", source, strings.Join(codes, ", "))
+		}
+		fromFile, err := icsHolidays(source, year)
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, fromFile...)
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+	return holidays, nil
+}
+
+// holidayOn returns the name of the holiday on the given day, or "" if it's a regular day
+func holidayOn(day time.Time) (string, error) {
+	holidays, err := holidaysInYear(day.Year())
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, holiday := range holidays {
+		if holiday.Date.Format(DATE_FORMAT) == day.Format(DATE_FORMAT) {
+			names = append(names, holiday.Name)
+		}
+	}
+	return strings.Join(names, ", "), nil
+}
+
+func holidaysCmd(year int) error {
+	if setting("WT_HOLIDAYS") == "" {
+		fmt.Println("No holiday calendar set. Set WT_HOLIDAYS to a country code or an .ics file.")
+		return nil
+	}
+	holidays, err := holidaysInYear(year)
+	if err != nil {
+		return err
+	}
+	if len(holidays) == 0 {
+		fmt.Printf("No holidays in %d.\n", year)
+		return nil
+	}
+	for _, holiday := range holidays {
+		fmt.Printf("%s | %s\n", holiday.Date.Format("Mon "+DATE_FORMAT), holiday.Name)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportFormat is an input format of `wt import`, mirroring exportFormats.
+// Read returns one timer per day.
+type ImportFormat struct {
+	Name        string
+	Description string
+	Read        func(r io.Reader) ([]*Timer, error)
+}
+
+// importFormats is the registry of import formats, in the order help lists them
+var importFormats = []ImportFormat{
+	{Name: "json", Description: "wt's own export/backup (wt export json)", Read: readImportJSON},
+	{Name: "csv", Description: "wt's cycle csv (wt export csv), honoring the WT_CSV_* settings", Read: readImportCSV},
+	{Name: "timeclock", Description: "ledger/hledger timeclock check-ins and check-outs", Read: readImportTimeclock},
+}
+
+func findImportFormat(name string) (ImportFormat, error) {
+	var names []string
+	for _, format := range importFormats {
+		if format.Name == name {
+			return format, nil
+		}
+		names = append(names, format.Name)
+	}
+	return ImportFormat{}, fmt.Errorf("Unknown import format: %s. Use one of: %s", name, strings.Join(names, ", "))
+}
+
+// importFormatsHelp lists the formats for the command description
+func importFormatsHelp() string {
+	var b strings.Builder
+	for _, format := range importFormats {
+		fmt.Fprintf(&b, "\n     %-10s %s", format.Name, format.Description)
+	}
+	return b.String()
+}
+
+// importCycle is a work or break period read from another format
+type importCycle struct {
+	Start   time.Time
+	Type    string
+	Minutes int
+	Paused  int
+	Tags    []string
+}
+
+// timerFromCycles builds a stopped timer from one day's cycles. Gaps between
+// cycles become breaks, and back-to-back work cycles are merged like stop does.
+func timerFromCycles(cycles []importCycle, profile string) (*Timer, error) {
+	sort.SliceStable(cycles, func(i, j int) bool { return cycles[i].Start.Before(cycles[j].Start) })
+
+	timer := &Timer{Status: StatusStopped, Mode: ModeSilent, Timeline: []TimelineEntry{}, Profile: profile}
+	timer.DayStart = cycles[0].Start.Format(DT_FORMAT)
+
+	add := func(entry TimelineEntry) {
+		if n := len(timer.Timeline); n > 0 && timer.Timeline[n-1].Type == entry.Type {
+			timer.Timeline[n-1].Minutes += entry.Minutes
+			timer.Timeline[n-1].PausedMinutes += entry.PausedMinutes
+			timer.Timeline[n-1].Tags = mergeTags(timer.Timeline[n-1].Tags, entry.Tags)
+			return
+		}
+		timer.Timeline = append(timer.Timeline, entry)
+	}
+
+	cursor := cycles[0].Start
+	for _, cycle := range cycles {
+		if cycle.Type != "work" && cycle.Type != "break" {
+			return nil, fmt.Errorf("Invalid cycle type: %q", cycle.Type)
+		}
+		if cycle.Start.Before(cursor) {
+			return nil, fmt.Errorf("Overlapping cycles at %s", cycle.Start.Format(DT_FORMAT))
+		}
+		if gap := deltaMinutes(cursor, cycle.Start); gap > 0 {
+			add(TimelineEntry{Type: "break", Minutes: gap})
+		}
+		entry := TimelineEntry{Type: cycle.Type, Minutes: cycle.Minutes}
+		if cycle.Type == "work" {
+			entry.PausedMinutes = cycle.Paused
+			entry.Tags = cycle.Tags
+		}
+		add(entry)
+		cursor = cycle.Start.Add(time.Duration(entry.Duration()) * time.Minute)
+	}
+
+	timer.StopDatetimeStr = cursor.Format(DT_FORMAT)
+	return timer, nil
+}
+
+// daysFromCycles groups cycles by date (and profile) into timers, ordered by day
+func daysFromCycles(cycles []importCycle, profiles []string) ([]*Timer, error) {
+	type dayKey struct{ date, profile string }
+	var keys []dayKey
+	groups := map[dayKey][]importCycle{}
+	for i, cycle := range cycles {
+		key := dayKey{cycle.Start.Format(DATE_FORMAT), profiles[i]}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], cycle)
+	}
+
+	var timers []*Timer
+	for _, key := range keys {
+		timer, err := timerFromCycles(groups[key], key.profile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key.date, err)
+		}
+		timers = append(timers, timer)
+	}
+	sort.SliceStable(timers, func(i, j int) bool { return timers[i].DayStart < timers[j].DayStart })
+	return timers, nil
+}
+
+func readImportJSON(r io.Reader) ([]*Timer, error) {
+	var days []ExportDay
+	if err := json.NewDecoder(r).Decode(&days); err != nil {
+		return nil, fmt.Errorf("Invalid JSON: %v", err)
+	}
+
+	var cycles []importCycle
+	var profiles []string
+	for _, day := range days {
+		for _, record := range day.Entries {
+			start, err := parseTime(record.Start)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid start %q", day.Date, record.Start)
+			}
+			cycles = append(cycles, importCycle{Start: start, Type: record.Type, Minutes: record.Minutes, Paused: record.PausedMinutes, Tags: record.Tags})
+			profiles = append(profiles, day.Profile)
+		}
+	}
+	return daysFromCycles(cycles, profiles)
+}
+
+func readImportCSV(r io.Reader) ([]*Timer, error) {
+	dialect, err := csvDialect()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := dialect.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"type", "start", "minutes"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("Missing csv column: %s", name)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var cycles []importCycle
+	var profiles []string
+	for n, row := range rows[1:] {
+		start, err := dialect.ParseTime(field(row, "start"))
+		if err != nil {
+			return nil, fmt.Errorf("Row %d: invalid start %q", n+2, field(row, "start"))
+		}
+		minutes, err := strconv.Atoi(field(row, "minutes"))
+		if err != nil {
+			return nil, fmt.Errorf("Row %d: invalid minutes %q", n+2, field(row, "minutes"))
+		}
+		paused, _ := strconv.Atoi(field(row, "paused_minutes"))
+		cycles = append(cycles, importCycle{Start: start, Type: field(row, "type"), Minutes: minutes, Paused: paused})
+		profiles = append(profiles, field(row, "profile"))
+	}
+	return daysFromCycles(cycles, profiles)
+}
+
+// readImportTimeclock reads "i <date> <time> [account]" / "o <date> <time>"
+// pairs. The account becomes the day's profile ("work" means none).
+func readImportTimeclock(r io.Reader) ([]*Timer, error) {
+	const layout = "2006-01-02 15:04:05"
+
+	var cycles []importCycle
+	var profiles []string
+	var in *time.Time
+	account := ""
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], ";") || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("Line %d: expected \"i|o YYYY-MM-DD HH:MM:SS\"", n)
+		}
+		t, err := time.ParseInLocation(layout, fields[1]+" "+fields[2], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: invalid time %s %s", n, fields[1], fields[2])
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "i":
+			if in != nil {
+				return nil, fmt.Errorf("Line %d: check-in without check-out", n)
+			}
+			in = &t
+			account = strings.Join(fields[3:], " ")
+			if account == "work" {
+				account = ""
+			}
+		case "o":
+			if in == nil {
+				return nil, fmt.Errorf("Line %d: check-out without check-in", n)
+			}
+			cycles = append(cycles, importCycle{Start: *in, Type: "work", Minutes: deltaMinutes(*in, t)})
+			profiles = append(profiles, account)
+			in = nil
+		default:
+			return nil, fmt.Errorf("Line %d: unsupported entry %q", n, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return daysFromCycles(cycles, profiles)
+}
+
+// archivedDates returns the dates that already have an archived day
+func archivedDates() (map[string]bool, error) {
+	timers, err := loadArchivedDays(time.Time{}, getCurrentTime().AddDate(100, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+	dates := map[string]bool{}
+	for _, t := range timers {
+		dates[t.DayStart[:len(DATE_FORMAT)]] = true
+	}
+	return dates, nil
+}
+
+func importCmd(timer *Timer, formatName, filePath string, dryRun bool) error {
+	format, err := findImportFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if filePath != "-" {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		traceFile("read", filePath)
+		in = f
+	}
+
+	days, err := format.Read(in)
+	if err != nil {
+		return err
+	}
+
+	existing, err := archivedDates()
+	if err != nil {
+		return err
+	}
+	if timer.DayStart != "" {
+		existing[timer.DayStart[:len(DATE_FORMAT)]] = true
+	}
+
+	added := 0
+	var imported []time.Time
+	for _, day := range days {
+		date := day.DayStart[:len(DATE_FORMAT)]
+		totals := day.Totals()
+		if existing[date] {
+			fmt.Printf("skip %s (already tracked)\n", date)
+			continue
+		}
+		fmt.Printf("add  %s | Work: %s | Break: %s | Paused: %s\n", date,
+			minutesToHourMinuteStr(totals.Work), minutesToHourMinuteStr(totals.Break+totals.Lunch), minutesToHourMinuteStr(totals.Paused))
+		existing[date] = true
+		added++
+
+		if !dryRun {
+			if _, err := writeArchiveDay(day); err != nil {
+				return err
+			}
+			dayStart, _ := parseTime(day.DayStart)
+			imported = append(imported, dayStart)
+		}
+	}
+	if err := updateWeeklyReport(imported...); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d days would be imported.\n", added)
+	} else {
+		fmt.Printf("Imported %d days.\n", added)
+	}
+	return nil
+}
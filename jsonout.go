@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+// `wt --json check|status|report|log` prints JSON instead of text, for
+// status bars and scripts. check and status print the timer's state (the
+// object GET /api/status returns), report the day with its totals and
+// entries, and log the entries as `wt log --format json` does. Minutes are
+// numbers and times are DT_FORMAT strings in local time.
+
+// jsonCommands are the top-level commands that print JSON with --json
+var jsonCommands = map[string]bool{"check": true, "status": true, "report": true, "log": true}
+
+// jsonOutput is set by the global --json flag while one of jsonCommands runs
+var jsonOutput bool
+
+// jsonActions sets jsonOutput for the actions of jsonCommands (and the
+// default check), restoring it afterwards as 'wt serve' runs commands
+// in-process, and makes the other commands refuse --json
+func jsonActions(app *cli.Command) {
+	wrap := func(action cli.ActionFunc) cli.ActionFunc {
+		return func(ctx context.Context, cmd *cli.Command) error {
+			previous := jsonOutput
+			defer func() { jsonOutput = previous }()
+			jsonOutput = cmd.Bool("json")
+			return action(ctx, cmd)
+		}
+	}
+	refuse := func(action cli.ActionFunc) cli.ActionFunc {
+		return func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Bool("json") {
+				return fmt.Errorf("--json works with check, status, report, and log.")
+			}
+			return action(ctx, cmd)
+		}
+	}
+	app.Action = wrap(app.Action)
+	for _, command := range app.Commands {
+		switch {
+		case command.Action == nil:
+		case jsonCommands[command.Name]:
+			command.Action = wrap(command.Action)
+		default:
+			command.Action = refuse(command.Action)
+		}
+	}
+}
+
+// DayReport is what `wt --json report` prints
+type DayReport struct {
+	Date     string      `json:"date,omitempty"` // Empty if no work was recorded
+	Start    string      `json:"start,omitempty"`
+	End      string      `json:"end,omitempty"` // Now for a running or paused cycle
+	Status   string      `json:"status"`
+	Totals   DayTotals   `json:"totals"`
+	Earned   float64     `json:"earned,omitempty"` // Work at $WT_RATE, if set
+	Currency string      `json:"currency,omitempty"`
+	Entries  []LogRecord `json:"entries"`
+}
+
+// printJSON prints v indented, like `wt log --format json`
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// timerStatus returns the state of the timer as `wt --json check` and
+// GET /api/status show it
+func timerStatus(timer *Timer) APIStatus {
+	status := APIStatus{Status: timer.Status, DayStart: timer.DayStart, Totals: timer.Totals(), Task: timer.Task}
+	status.Check, _ = checkLine(timer)
+	if timer.Status == StatusRunning || timer.Status == StatusPaused {
+		status.CycleStart = timer.CurrentCycleStart().Format(DT_FORMAT)
+		status.CycleMinutes = calculateCurrentMinutes(timer)
+		status.CycleTarget = workTargetMinutes(timer, 0)
+	}
+	if timer.Status == StatusPaused {
+		status.PauseStart = timer.PauseStartStr
+	}
+	return status
+}
+
+// dayReport returns the day of the timer as `wt --json report` shows it
+func dayReport(timer *Timer) DayReport {
+	report := DayReport{Status: timer.Status, Totals: timer.Totals(), Entries: []LogRecord{}}
+	if timer.DayStart == "" {
+		return report
+	}
+	entries := buildLogEntries(timer)
+	start, _ := parseTime(timer.DayStart)
+	end := timer.CurrentCycleStart()
+	if len(entries) > 0 {
+		end = entries[len(entries)-1].End
+	}
+	report.Date, report.Start, report.End = start.Format(DATE_FORMAT), start.Format(DT_FORMAT), end.Format(DT_FORMAT)
+	if earned, rate, ok := dayEarnings(timer); ok {
+		report.Earned, report.Currency = earned, rate.Currency
+	}
+	for _, entry := range entries {
+		report.Entries = append(report.Entries, entry.Record())
+	}
+	return report
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// With WT_LINEAR_TOKEN (a personal API key) set, each stopped work cycle is
+// posted as a comment to the Linear issue it was spent on. The issue is the
+// first key (ENG-123) in the cycle's task (`wt start -m "ENG-123 parser"`),
+// or else in the git branch checked out in $WT_ROOT
+// (feature/eng-123-parser); a cycle without a task gets the key as its task,
+// so `wt report --group-by task` and `wt grep` find it. Posting happens
+// after the stop is saved, and a failure only prints a warning.
+
+const DefaultLinearAPI = "https://api.linear.app/graphql"
+
+// linearMuted is set while commands run on a scratch copy of the timer
+// (mods, replays), whose stops mustn't be posted
+var linearMuted bool
+
+var linearKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]{0,6}-[0-9]+\b`)
+
+// linearKey returns the first issue key in s, or ""
+func linearKey(s string) string {
+	return linearKeyPattern.FindString(s)
+}
+
+// gitBranch returns the branch checked out in $WT_ROOT, or ""
+func gitBranch() string {
+	root, err := projectRootPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".git", "HEAD"))
+	if err != nil {
+		return ""
+	}
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/heads/")
+	if !ok {
+		return "" // Detached HEAD
+	}
+	return branch
+}
+
+// linearIssue returns the issue key a cycle with this task was spent on,
+// or "" if Linear isn't set up or there's no key
+func linearIssue(task string) string {
+	if setting("WT_LINEAR_TOKEN") == "" {
+		return ""
+	}
+	if key := linearKey(task); key != "" {
+		return key
+	}
+	return linearKey(strings.ToUpper(gitBranch())) // Branches are lower case
+}
+
+// linearRequest runs a GraphQL query against the Linear API
+func linearRequest(query string, variables map[string]any, out any) error {
+	data, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	api := setting("WT_LINEAR_API")
+	if api == "" {
+		api = DefaultLinearAPI
+	}
+	req, err := http.NewRequest(http.MethodPost, api, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", setting("WT_LINEAR_TOKEN")) // Personal API keys go without "Bearer"
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("Linear unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("Linear: %v", err)
+	}
+	switch {
+	case len(result.Errors) > 0:
+		return fmt.Errorf("Linear: %s", result.Errors[0].Message)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("Linear: %s", resp.Status)
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// postLinearCycle comments the work cycle from start to end on the issue
+func postLinearCycle(key string, start, end time.Time, minutes int) error {
+	var issue struct {
+		Issue struct {
+			ID string `json:"id"`
+		} `json:"issue"`
+	}
+	if err := linearRequest(`query($id: String!) { issue(id: $id) { id } }`, map[string]any{"id": key}, &issue); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Tracked %s with wt (%s %s-%s).", minutesToHourMinuteStr(minutes),
+		start.Format(DATE_FORMAT), start.Format(TIME_ONLY_FORMAT), end.Format(TIME_ONLY_FORMAT))
+	var comment struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}
+	err := linearRequest(`mutation($issueId: String!, $body: String!) { commentCreate(input: {issueId: $issueId, body: $body}) { success } }`,
+		map[string]any{"issueId": issue.Issue.ID, "body": body}, &comment)
+	if err == nil && !comment.CommentCreate.Success {
+		err = fmt.Errorf("Linear: the comment on %s wasn't created", key)
+	}
+	return err
+}
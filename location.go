@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cmp"
+	"strings"
+	"time"
+)
+
+// Work cycles can be marked with where they happened, e.g. home, office, or
+// travel, for hybrid-work expense and tax reporting. `wt start @office` sets
+// the location for this and the following cycles of the day; otherwise
+// cycles get the default for their weekday (WT_LOCATION_MON ... _SUN) or
+// WT_LOCATION. Breaks count for the location of the work cycle before them.
+// `wt report --group-by location` sums up the work per location.
+
+const NoLocation = "(no location)"
+
+// cycleLocation returns the location of a work cycle starting at start
+func cycleLocation(timer *Timer, start time.Time) string {
+	weekday := strings.ToUpper(start.Weekday().String()[:3])
+	return cmp.Or(timer.Location, setting("WT_LOCATION_"+weekday), setting("WT_LOCATION"))
+}
+
+// formatLocation renders a location as ` @office`, or ""
+func formatLocation(location string) string {
+	if location == "" {
+		return ""
+	}
+	return " @" + location
+}
+
+// locationTotals splits the day's totals by location, like Totals
+func (t *Timer) locationTotals() map[string]DayTotals {
+	return t.cycleTotals(func(e TimelineEntry) []string { return []string{cmp.Or(e.Location, NoLocation)} })
+}
+
+// cycleTotals splits the day's totals by a property of the work cycles, like
+// Totals. Breaks count for the work cycle before them; cycles in several
+// groups (tags) count for each.
+func (t *Timer) cycleTotals(keys func(TimelineEntry) []string) map[string]DayTotals {
+	groups := map[string]DayTotals{}
+	add := func(group []string, work, paused, lunch, breaks int) {
+		for _, key := range group {
+			totals := groups[key]
+			totals.Work += work
+			totals.Paused += paused
+			totals.Lunch += lunch
+			totals.Break += breaks
+			groups[key] = totals
+		}
+	}
+	group := keys(TimelineEntry{}) // Of the last work cycle
+	start, _ := parseTime(t.DayStart)
+	for _, entry := range t.Timeline {
+		switch {
+		case entry.Type == "work":
+			group = keys(entry)
+			add(group, entry.Minutes, entry.PausedMinutes, 0, 0)
+		case breakKind(start, entry) == BreakLunch:
+			add(group, 0, 0, entry.Minutes, 0)
+		default:
+			add(group, 0, 0, 0, entry.Minutes)
+		}
+		start = start.Add(time.Duration(entry.Duration()) * time.Minute)
+	}
+
+	if t.Status == StatusRunning || t.Status == StatusPaused {
+		entries := buildLogEntries(t)
+		active := entries[len(entries)-1]
+		add(keys(TimelineEntry{Type: "work", Location: active.Location, Task: active.Task, Tags: active.Tags}), active.Minutes, active.PausedMinutes, 0, 0)
+	}
+	return groups
+}
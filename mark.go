@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// `wt mark "finished parser rewrite"` records a timestamped marker in the
+// current work cycle, for tracing what happened within a long block without
+// splitting it. Like pauses, markers are kept in minutes from the start of
+// the cycle: in Timer.Marks while it runs, then with its timeline entry.
+// `wt log --notes` lists them, and exports include them.
+
+// Mark is a marker within a work cycle
+type Mark struct {
+	At   int    `json:"at"` // Minutes from the start of the cycle
+	Text string `json:"text"`
+}
+
+// MarkRecord is the machine-readable form of a Mark, with its clock time
+type MarkRecord struct {
+	At   string `json:"at"`
+	Text string `json:"text"`
+}
+
+// shiftMarks moves marks by the given minutes, for cycles merged after
+// another one
+func shiftMarks(marks []Mark, minutes int) []Mark {
+	shifted := make([]Mark, 0, len(marks))
+	for _, m := range marks {
+		shifted = append(shifted, Mark{At: m.At + minutes, Text: m.Text})
+	}
+	return shifted
+}
+
+// markRecords converts marks of a cycle starting at start to clock times
+func markRecords(start time.Time, marks []Mark) []MarkRecord {
+	var records []MarkRecord
+	for _, m := range marks {
+		records = append(records, MarkRecord{At: start.Add(time.Duration(m.At) * time.Minute).Format(DT_FORMAT), Text: m.Text})
+	}
+	return records
+}
+
+// recordMarks converts mark records back, relative to start
+func recordMarks(start time.Time, records []MarkRecord) ([]Mark, error) {
+	var marks []Mark
+	for _, r := range records {
+		at, err := parseTime(r.At)
+		if err != nil {
+			return nil, err
+		}
+		marks = append(marks, Mark{At: deltaMinutes(start, at), Text: r.Text})
+	}
+	return marks, nil
+}
+
+// markTime returns the clock time of a mark in a cycle starting at start
+func markTime(start time.Time, m Mark) time.Time {
+	return start.Add(time.Duration(m.At) * time.Minute)
+}
+
+// formatMarks renders a cycle's marks as indented lines of `wt log --notes`
+func formatMarks(entry LogEntry) string {
+	var b strings.Builder
+	for _, m := range entry.Marks {
+		fmt.Fprintf(&b, "    %s %s\n", markTime(entry.Start, m).Format(TIME_ONLY_FORMAT), m.Text)
+	}
+	return b.String()
+}
+
+func markCmd(timer *Timer, text string) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("Nothing to mark. Usage: wt mark \"finished parser rewrite\"")
+	}
+	if timer.Status == StatusStopped {
+		fmt.Println("No running cycle to mark. Start the timer first.")
+		logWarning(timer, "mark", []string{text}, "No running cycle to mark.")
+		return nil
+	}
+
+	now := getCurrentTime()
+	timer.Marks = append(timer.Marks, Mark{At: deltaMinutes(timer.CurrentCycleStart(), now), Text: text})
+
+	logCommand(timer, "mark", []string{text}, nil)
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	printMessageIfNotSilent(timer, fmt.Sprintf("Marked %s: %s", now.Format(TIME_ONLY_FORMAT), text))
+	return nil
+}
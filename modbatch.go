@@ -0,0 +1,160 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// `wt mod --file edits.wt` applies a list of mods as one change: one mod per
+// line, written as its arguments ("3 add 15") or as the whole command
+// ("wt mod 3 add 15"), with blank lines and # comments skipped. The mods run
+// one after another on a copy of the timer in a scratch root (like
+// `wt mod --date`), and only if every one of them was accepted and changed
+// something is the result saved, once, after the timer as it was is kept in
+// .out/wt.json.bak. "-" reads the mods from stdin.
+
+const ModBackupSuffix = ".bak"
+
+// modFileLine is a mod read from a mod file
+type modFileLine struct {
+	Line int
+	Args []string
+}
+
+// parseModFile reads the mods of a mod file
+func parseModFile(content string) []modFileLine {
+	var mods []modFileLine
+	for i, line := range strings.Split(content, "\n") {
+		if hash := strings.Index(line, "#"); hash >= 0 {
+			line = line[:hash]
+		}
+		args := strings.Fields(line)
+		if len(args) > 0 && args[0] == "wt" {
+			args = args[1:]
+		}
+		if len(args) > 0 && args[0] == "mod" {
+			args = args[1:]
+		}
+		if len(args) > 0 {
+			mods = append(mods, modFileLine{Line: i + 1, Args: args})
+		}
+	}
+	return mods
+}
+
+// modFileCmd applies the mods of the file at path to timer, all or none
+func modFileCmd(timer *Timer, path string, force, yes bool) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		traceFile("read", path)
+		in = f
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+	mods := parseModFile(string(data))
+	if len(mods) == 0 {
+		return fmt.Errorf("No mods in %s.", name)
+	}
+	closed := timer.isClosed()
+	if closed && !force {
+		return fmt.Errorf("Day %s is closed. Use 'wt mod --force --file %s' to change it anyway; the changes are recorded.",
+			timer.DayStart[:len(DATE_FORMAT)], path)
+	}
+
+	after, err := inScratch(timer, func() error {
+		for _, mod := range mods {
+			before, err := readTimerFile()
+			if err != nil {
+				return err
+			}
+			output, err := captureOutput(func() error {
+				work, err := load()
+				if err != nil {
+					return err
+				}
+				if closed {
+					return modClosedDayCmd(work, mod.Args, true, yes)
+				}
+				return modCmd(work, mod.Args, yes)
+			})
+			if err == nil {
+				if now, readErr := readTimerFile(); readErr == nil && string(now) == string(before) {
+					err = fmt.Errorf("%s", cmp.Or(strings.TrimSpace(output), "changed nothing"))
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("%s:%d (%s): %s Nothing was changed.", name, mod.Line, strings.Join(mod.Args, " "), strings.TrimRight(err.Error(), ".")+".")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	backup, err := backupTimerFile()
+	if err != nil {
+		return err
+	}
+	args := []string{"--file", path}
+	if closed {
+		logWarning(after, "mod", append([]string{"--force"}, args...), "Changed closed day "+after.DayStart[:len(DATE_FORMAT)])
+		if err := rewriteArchive(after); err != nil {
+			return err
+		}
+	}
+	logCommand(after, "mod", args, map[string]int{"mods": len(mods), "work": after.Totals().Work - timer.Totals().Work})
+	// The file may be stdin or gone by the time of a replay, so it replays
+	// the mods themselves
+	for _, mod := range mods {
+		writeDebugEntry(DebugEntry{Level: LevelInfo, Command: "mod", Args: mod.Args, Status: after.Status})
+	}
+	if err := save(after); err != nil {
+		return err
+	}
+	printMessageIfNotSilent(after, fmt.Sprintf("Applied %d mods from %s. Work: %s -> %s (the timer before is in %s).", len(mods), name,
+		minutesToHourMinuteStr(timer.Totals().Work), minutesToHourMinuteStr(after.Totals().Work), backup))
+	return nil
+}
+
+// readTimerFile returns the saved timer as it is on disk
+func readTimerFile() ([]byte, error) {
+	path, err := outputFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return readFile(path)
+}
+
+// backupTimerFile keeps the saved timer next to it and returns the backup's
+// path relative to the root
+func backupTimerFile() (string, error) {
+	data, err := readTimerFile()
+	if err != nil {
+		return "", err
+	}
+	path, err := outputFilePath()
+	if err != nil {
+		return "", err
+	}
+	if err := writeFile(path+ModBackupSuffix, data, 0644); err != nil {
+		return "", err
+	}
+	root, err := projectRootPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(root, path+ModBackupSuffix)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Reminders from the daemon and `wt remind --notify` go to every enabled
+// notifier. WT_NOTIFY_COMMAND replaces the desktop notifier with a command
+// template, e.g. `notify-send {title} {body}`, for setups the built-in one
+// doesn't cover (WSL, SSH, custom scripts). WT_NTFY_URL additionally pushes
+// to an ntfy topic, which reaches a phone away from the desk, and the Telegram
+// bot (telegram.go) messages its user.
+
+const (
+	NotificationTitle      = "wt"
+	NotifyCommandTimeout   = 10 * time.Second
+	notifyTitlePlaceholder = "{title}"
+	notifyBodyPlaceholder  = "{body}"
+)
+
+// Notifier delivers a notification somewhere
+type Notifier struct {
+	Name    string
+	Enabled func() bool
+	Notify  func(title, body string) error
+}
+
+var notifiers = []Notifier{
+	{Name: "command", Enabled: func() bool { return setting("WT_NOTIFY_COMMAND") != "" }, Notify: notifyCommand},
+	{Name: "desktop", Enabled: func() bool { return setting("WT_NOTIFY_COMMAND") == "" && desktopNotifyArgs("", "") != nil }, Notify: notifyDesktop},
+	{Name: "ntfy", Enabled: func() bool { return setting("WT_NTFY_URL") != "" }, Notify: notifyNtfy},
+	{Name: "telegram", Enabled: func() bool { return setting("WT_TELEGRAM_TOKEN") != "" }, Notify: notifyTelegram},
+}
+
+func enabledNotifiers() []Notifier {
+	var enabled []Notifier
+	for _, notifier := range notifiers {
+		if notifier.Enabled() {
+			enabled = append(enabled, notifier)
+		}
+	}
+	return enabled
+}
+
+// sendNotification notifies through every enabled notifier, returning their errors
+func sendNotification(title, body string) error {
+	var errs []error
+	for _, notifier := range enabledNotifiers() {
+		if err := notifier.Notify(title, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s notifier: %v", notifier.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitCommandLine splits a command template into arguments, honoring single
+// and double quotes and backslash escapes (outside single quotes)
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", line)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// notifyCommand runs WT_NOTIFY_COMMAND with {title} and {body} replaced in
+// each argument. The command isn't run through a shell, so the message can't
+// inject anything; wrap it in `sh -c '...'` for pipes. The values are also in
+// WT_NOTIFY_TITLE and WT_NOTIFY_BODY.
+func notifyCommand(title, body string) error {
+	args, err := splitCommandLine(setting("WT_NOTIFY_COMMAND"))
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("WT_NOTIFY_COMMAND is empty")
+	}
+	replacer := strings.NewReplacer(notifyTitlePlaceholder, title, notifyBodyPlaceholder, body)
+	for i := range args {
+		args[i] = replacer.Replace(args[i])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), NotifyCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "WT_NOTIFY_TITLE="+title, "WT_NOTIFY_BODY="+body)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("%v: %s", err, text)
+		}
+		return err
+	}
+	return nil
+}
+
+// desktopNotifyArgs returns the command showing a desktop notification on
+// this system, or nil if there is none
+func desktopNotifyArgs(title, body string) []string {
+	switch runtime.GOOS {
+	case "darwin":
+		quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+		return []string{"osascript", "-e", fmt.Sprintf(`display notification "%s" with title "%s"`, quote.Replace(body), quote.Replace(title))}
+	case "windows":
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, body)}
+	}
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return nil
+	}
+	return []string{"notify-send", title, body}
+}
+
+// windowsToastScript returns the PowerShell script showing a toast with the
+// title and body. Toasts need a registered app ID, so it borrows PowerShell's.
+func windowsToastScript(title, body string) string {
+	quote := strings.NewReplacer("'", "''")
+	return fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode('%s')) | Out-Null
+$text.Item(1).AppendChild($xml.CreateTextNode('%s')) | Out-Null
+$appID = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($appID).Show([Windows.UI.Notifications.ToastNotification]::new($xml))`,
+		quote.Replace(title), quote.Replace(body))
+}
+
+func notifyDesktop(title, body string) error {
+	args := desktopNotifyArgs(title, body)
+	if args == nil {
+		return fmt.Errorf("no desktop notifications on this system")
+	}
+	return exec.Command(args[0], args[1:]...).Run()
+}
+
+// notifyNtfy publishes to the ntfy topic at WT_NTFY_URL (e.g.
+// https://ntfy.sh/my-wt-topic), with WT_NTFY_TOKEN for protected topics and
+// WT_NTFY_PRIORITY (min, low, default, high, urgent)
+func notifyNtfy(title, body string) error {
+	req, err := http.NewRequest(http.MethodPost, setting("WT_NTFY_URL"), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	req.Header.Set("Tags", "hourglass")
+	if priority := setting("WT_NTFY_PRIORITY"); priority != "" {
+		req.Header.Set("Priority", priority)
+	}
+	if token := setting("WT_NTFY_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// `wt sync notion` writes a row per day (date, work, break, tags, notes) to
+// a Notion database, for teams whose reporting lives in Notion. It uses an
+// internal integration's token (WT_NOTION_TOKEN) and the id of a database
+// shared with it (WT_NOTION_DATABASE). The database needs the properties
+// Name (title), Date (date), Work and Break (numbers, in hours), Tags
+// (multi-select), and Notes (text). The page of each synced date is kept
+// in .out/notion.json, so syncing again updates the row instead of adding one.
+// WT_NOTION_API points at another API host (for tests).
+
+const (
+	DefaultNotionAPI  = "https://api.notion.com"
+	NotionVersion     = "2022-06-28"
+	NotionStateFile   = "notion.json"
+	notionMaxTextSize = 2000 // Characters of a rich text value
+)
+
+// notionRow is a date's row: all the days (profiles) of a date summed up
+type notionRow struct {
+	Date  string
+	Work  int
+	Break int
+	Tags  []string
+	Notes []string
+}
+
+// notionRows groups the exported days by date, oldest first
+func notionRows(days []ExportDay) []*notionRow {
+	var rows []*notionRow
+	byDate := map[string]*notionRow{}
+	for _, day := range days {
+		row, ok := byDate[day.Date]
+		if !ok {
+			row = &notionRow{Date: day.Date}
+			byDate[day.Date] = row
+			rows = append(rows, row)
+		}
+		row.Work += day.Work
+		row.Break += day.Break + day.Lunch
+		for _, entry := range day.logEntries {
+			for _, tag := range entry.Tags {
+				if !slices.Contains(row.Tags, tag) {
+					row.Tags = append(row.Tags, tag)
+				}
+			}
+		}
+		for _, mark := range dayMarks(day) {
+			row.Notes = append(row.Notes, mark.At[len(DATE_FORMAT)+1:]+" "+mark.Text)
+		}
+		if day.Note != "" {
+			row.Notes = append(row.Notes, day.Note)
+		}
+	}
+	for _, row := range rows {
+		slices.Sort(row.Tags)
+	}
+	return rows
+}
+
+// notionHours converts minutes to hours with two decimals
+func notionHours(minutes int) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}
+
+// properties renders the row as Notion page properties
+func (row *notionRow) properties() map[string]any {
+	text := func(s string) []map[string]any {
+		if runes := []rune(s); len(runes) > notionMaxTextSize {
+			s = string(runes[:notionMaxTextSize])
+		}
+		return []map[string]any{{"text": map[string]string{"content": s}}}
+	}
+	tags := []map[string]string{}
+	for _, tag := range row.Tags {
+		tags = append(tags, map[string]string{"name": tag})
+	}
+	notes := []map[string]any{}
+	if len(row.Notes) > 0 {
+		notes = text(strings.Join(row.Notes, "\n"))
+	}
+	return map[string]any{
+		"Name":  map[string]any{"title": text(row.Date)},
+		"Date":  map[string]any{"date": map[string]string{"start": row.Date}},
+		"Work":  map[string]any{"number": notionHours(row.Work)},
+		"Break": map[string]any{"number": notionHours(row.Break)},
+		"Tags":  map[string]any{"multi_select": tags},
+		"Notes": map[string]any{"rich_text": notes},
+	}
+}
+
+// notionRequest calls the Notion API, decoding the JSON response into out.
+// Notion reports errors as {"message": ...}.
+func notionRequest(api, token, method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, api+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Notion-Version", NotionVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("Notion unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return fmt.Errorf("Notion: %s", apiErr.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// notionPages reads .out/notion.json: database id -> date -> page id
+func notionPages(path string) (map[string]map[string]string, error) {
+	pages := map[string]map[string]string{}
+	data, err := readFile(path)
+	if err != nil {
+		return pages, nil
+	}
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return nil, fmt.Errorf("Invalid %s: %v", NotionStateFile, err)
+	}
+	return pages, nil
+}
+
+// syncNotionCmd adds or updates a row per date of the range, or with dryRun
+// only prints the rows
+func syncNotionCmd(timer *Timer, rangeName string, dryRun bool) error {
+	token, database := setting("WT_NOTION_TOKEN"), setting("WT_NOTION_DATABASE")
+	if !dryRun && (token == "" || database == "") {
+		return fmt.Errorf("Set WT_NOTION_TOKEN (an integration's secret) and WT_NOTION_DATABASE (the id of a database shared with it) to sync to Notion.")
+	}
+	if !dryRun && readOnly.Load() {
+		return errReadOnly("not syncing to Notion")
+	}
+	r, err := parseDateRange(rangeName)
+	if err != nil {
+		return err
+	}
+	timers, err := loadRange(timer, r)
+	if err != nil {
+		return err
+	}
+	rows := notionRows(exportDays(timers, ""))
+	if len(rows) == 0 {
+		fmt.Printf("No days to sync for %s.\n", r.Name)
+		return nil
+	}
+
+	if dryRun {
+		for _, row := range rows {
+			line := fmt.Sprintf("%s | Work: %s | Break: %s", row.Date, minutesToHourMinuteStr(row.Work), minutesToHourMinuteStr(row.Break))
+			if len(row.Tags) > 0 {
+				line += " | Tags: " + strings.Join(row.Tags, ", ")
+			}
+			if len(row.Notes) > 0 {
+				line += " | Notes: " + strings.Join(row.Notes, "; ")
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	folder, err := outputFolderPath()
+	if err != nil {
+		return err
+	}
+	statePath := filepath.Join(folder, NotionStateFile)
+	pages, err := notionPages(statePath)
+	if err != nil {
+		return err
+	}
+	if pages[database] == nil {
+		pages[database] = map[string]string{}
+	}
+	api := strings.TrimRight(cmp.Or(setting("WT_NOTION_API"), DefaultNotionAPI), "/")
+
+	added, updated := 0, 0
+	for _, row := range rows {
+		var page struct {
+			ID string `json:"id"`
+		}
+		if id := pages[database][row.Date]; id != "" {
+			if err := notionRequest(api, token, http.MethodPatch, "/v1/pages/"+id, map[string]any{"properties": row.properties()}, &page); err != nil {
+				return fmt.Errorf("%v (syncing %s)", err, row.Date)
+			}
+			updated++
+			continue
+		}
+		body := map[string]any{"parent": map[string]string{"database_id": database}, "properties": row.properties()}
+		if err := notionRequest(api, token, http.MethodPost, "/v1/pages", body, &page); err != nil {
+			return fmt.Errorf("%v (syncing %s)", err, row.Date)
+		}
+		added++
+		// Saved after every new row, so a failure later doesn't lead to duplicates
+		pages[database][row.Date] = page.ID
+		data, err := json.MarshalIndent(pages, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(statePath, data, 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Synced %d days to Notion (%d added, %d updated).\n", len(rows), added, updated)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// `wt open [folder|report|today]` opens the .out folder, the daily report
+// file, or a page of the current day (rendered like `wt export site`, into
+// .out/today) in the system's default application. WT_OPENER replaces the
+// opener (xdg-open, open, or start), e.g. with "code" to use an editor.
+
+const TodayFolder = "today"
+
+// openTargets are the things `wt open` opens, the first one by default
+var openTargets = []string{"folder", "report", "today"}
+
+// opener returns the command that opens a file or folder in its default application
+func opener() []string {
+	if custom := strings.Fields(setting("WT_OPENER")); len(custom) > 0 {
+		return custom
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler"}
+	}
+	return []string{"xdg-open"}
+}
+
+// openPath returns the file or folder to open for target, writing it first
+// if needed
+func openPath(timer *Timer, target string) (string, error) {
+	switch target {
+	case "folder":
+		return outputFolderPath()
+	case "report":
+		path, err := dailyReportFilePath()
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return "", fmt.Errorf("No daily report yet at %s. Days are added by wt new, wt reset, and wt close.", path)
+		}
+		return path, nil
+	case "today":
+		if timer.DayStart == "" {
+			return "", fmt.Errorf("No work recorded today.")
+		}
+		date := timer.DayStart[:len(DATE_FORMAT)]
+		r, err := parseDateRange(date)
+		if err != nil {
+			return "", err
+		}
+		timers, err := loadRange(timer, r)
+		if err != nil {
+			return "", err
+		}
+		folder, err := outputFolderPath()
+		if err != nil {
+			return "", err
+		}
+		dir := filepath.Join(folder, TodayFolder)
+		if err := writeExportSite(dir, exportDays(timers, "")); err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "days", date+".html"), nil
+	}
+	return "", fmt.Errorf("Invalid target: %s. Use %s.", target, strings.Join(openTargets, ", "))
+}
+
+func openCmd(timer *Timer, target string) error {
+	if target == "" {
+		target = openTargets[0]
+	}
+	path, err := openPath(timer, target)
+	if err != nil {
+		return err
+	}
+	command := opener()
+	cmd := exec.Command(command[0], append(command[1:], path)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Couldn't open %s with %s: %v. Set WT_OPENER to the command to use.", path, command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// openAPIDocument builds an OpenAPI 3 document from apiRoutes. Response
+// schemas are derived from the routes' Response types and their json tags,
+// so the document can't drift from what the handlers return.
+
+const APIVersion = "1.0.0"
+
+// openAPISchemas collects named struct schemas for components/schemas
+type openAPISchemas map[string]any
+
+// schema returns the JSON schema of t, registering structs as components
+func (s openAPISchemas) schema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := s[t.Name()]; !ok {
+			s[t.Name()] = nil // Placeholder against recursive types
+			properties := map[string]any{}
+			var required []string
+			s.addFields(t, properties, &required)
+			object := map[string]any{"type": "object", "properties": properties}
+			if len(required) > 0 {
+				object["required"] = required
+			}
+			s[t.Name()] = object
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// addFields adds t's json fields to properties, inlining embedded structs like encoding/json does
+func (s openAPISchemas) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			s.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// operationID names a route for generated clients, e.g. "postStart" for POST /api/start
+func operationID(route APIRoute) string {
+	id := strings.ToLower(route.Method)
+	for _, part := range strings.Split(strings.TrimPrefix(route.Path, "/api/"), "/") {
+		if part != "" {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return id
+}
+
+func openAPIDocument() map[string]any {
+	schemas := openAPISchemas{}
+	errorResponse := map[string]any{
+		"description": "The error",
+		"content": map[string]any{"application/json": map[string]any{"schema": map[string]any{
+			"type": "object", "properties": map[string]any{"error": map[string]any{"type": "string"}},
+		}}},
+	}
+
+	paths := map[string]any{}
+	for _, route := range apiRoutes {
+		operation := map[string]any{
+			"summary":     route.Summary,
+			"operationId": operationID(route),
+			"description": fmt.Sprintf("Requires a %s token (when WT_API_TOKENS is set).", route.Scope),
+			"security":    []any{map[string]any{"bearer": []string{}}},
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content":     map[string]any{"application/json": map[string]any{"schema": schemas.schema(reflect.TypeOf(route.Response))}},
+				},
+				"400": errorResponse,
+				"401": errorResponse,
+				"403": errorResponse,
+			},
+		}
+		if len(route.Body) > 0 {
+			properties := map[string]any{}
+			for _, field := range route.Body {
+				properties[field] = map[string]any{"type": "string"}
+			}
+			operation["requestBody"] = map[string]any{
+				"required": false,
+				"content":  map[string]any{"application/json": map[string]any{"schema": map[string]any{"type": "object", "properties": properties}}},
+			}
+		}
+
+		item, _ := paths[route.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "wt API",
+			"version": APIVersion,
+		},
+		"servers": []any{map[string]any{"url": "http://" + DefaultServeListen}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas":         map[string]any(schemas),
+			"securitySchemes": map[string]any{"bearer": map[string]any{"type": "http", "scheme": "bearer"}},
+		},
+	}
+}
+
+func openAPICmd() error {
+	data, err := json.MarshalIndent(openAPIDocument(), "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// openAPIHandler serves the document at /api/openapi.json, without authentication
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, openAPIDocument())
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Each pause of a work cycle is kept as an interval in minutes from the start
+// of the cycle, so it stays in place when `wt mod` moves the cycle. Ended
+// pauses of the active cycle are in Timer.Pauses until stop moves them to the
+// timeline entry. PausedMinutes stays the total; paused time without an
+// interval (from `wt mod N pause add`, or saved before pauses were tracked)
+// becomes one at the end of the cycle. `wt pause -m "phone call"` gives the
+// pause a reason, and `wt log` and `wt report` break paused time down by
+// reason; pauses without one are "other".
+
+const OtherPauseReason = "other"
+
+// Pause is one pause of a work cycle
+type Pause struct {
+	Start  int    `json:"start"`            // Minutes from the start of the cycle
+	End    int    `json:"end"`              // Minutes from the start of the cycle
+	Reason string `json:"reason,omitempty"` // Given with wt pause -m
+}
+
+// Minutes returns the length of the pause
+func (p Pause) Minutes() int {
+	return p.End - p.Start
+}
+
+// PauseRecord is the machine-readable form of a Pause, with clock times
+type PauseRecord struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// UnmarshalJSON gives entries saved with only a paused total their pause,
+// and those saved with minutes by reason (pause_reasons) one pause per reason
+func (e *TimelineEntry) UnmarshalJSON(data []byte) error {
+	type Alias TimelineEntry
+	aux := &struct {
+		PauseReasons map[string]int `json:"pause_reasons,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(e.Pauses) == 0 {
+		e.Pauses = legacyPauses(aux.PauseReasons)
+	}
+	if e.Type == "work" {
+		e.Pauses = syncPauses(e.Pauses, e.PausedMinutes, e.Duration())
+	}
+	return nil
+}
+
+// legacyPauses turns paused minutes by reason, as saved before pauses were
+// intervals, into one pause per reason. Their times weren't kept, so they
+// follow each other from the start of the cycle, by reason.
+func legacyPauses(reasons map[string]int) []Pause {
+	var pauses []Pause
+	start := 0
+	for _, reason := range slices.Sorted(maps.Keys(reasons)) {
+		if minutes := reasons[reason]; minutes > 0 {
+			pauses = append(pauses, Pause{Start: start, End: start + minutes, Reason: reason})
+			start += minutes
+		}
+	}
+	return pauses
+}
+
+// pausedTotal adds up the pauses
+func pausedTotal(pauses []Pause) int {
+	total := 0
+	for _, p := range pauses {
+		total += p.Minutes()
+	}
+	return total
+}
+
+// shiftPauses moves pauses by the given minutes, for cycles merged after
+// another one
+func shiftPauses(pauses []Pause, minutes int) []Pause {
+	shifted := make([]Pause, 0, len(pauses))
+	for _, p := range pauses {
+		shifted = append(shifted, Pause{Start: p.Start + minutes, End: p.End + minutes, Reason: p.Reason})
+	}
+	return shifted
+}
+
+// syncPauses fits the pauses of a cycle lasting end minutes to its paused
+// total: pauses past the end are moved back, the last ones are shortened if
+// there's too much paused time, and the missing time is added as a pause
+// ending the cycle.
+func syncPauses(pauses []Pause, paused, end int) []Pause {
+	if len(pauses) == 0 && paused <= 0 {
+		return nil
+	}
+	synced := slices.Clone(pauses)
+	slices.SortStableFunc(synced, func(a, b Pause) int { return a.Start - b.Start })
+	for i := range synced {
+		if over := synced[i].End - end; over > 0 {
+			synced[i].Start, synced[i].End = max(synced[i].Start-over, 0), end
+		}
+	}
+	for extra := pausedTotal(synced) - paused; extra > 0; {
+		last := &synced[len(synced)-1]
+		if cut := min(extra, last.Minutes()); cut < last.Minutes() {
+			last.End -= cut
+			extra = 0
+		} else {
+			extra -= cut
+			synced = synced[:len(synced)-1]
+		}
+	}
+	if missing := paused - pausedTotal(synced); missing > 0 {
+		synced = append(synced, Pause{Start: max(end-missing, 0), End: max(end, missing)})
+	}
+	if len(synced) == 0 {
+		return nil
+	}
+	return synced
+}
+
+// syncTimelinePauses fits the pauses of all work entries to their totals,
+// after commands changed PausedMinutes or merged cycles
+func syncTimelinePauses(timer *Timer) {
+	for i := range timer.Timeline {
+		if entry := &timer.Timeline[i]; entry.Type == "work" {
+			entry.Pauses = syncPauses(entry.Pauses, entry.PausedMinutes, entry.Duration())
+		}
+	}
+}
+
+// endPause returns the running pause of the active cycle, ending now
+func endPause(timer *Timer, now time.Time) Pause {
+	cycleStart := timer.CurrentCycleStart()
+	pauseStart, _ := parseTime(timer.PauseStartStr)
+	return Pause{Start: deltaMinutes(cycleStart, pauseStart), End: deltaMinutes(cycleStart, now), Reason: timer.PauseReason}
+}
+
+// currentPauses returns the active cycle's pauses, with the running one
+func currentPauses(timer *Timer) []Pause {
+	now := getCurrentTime()
+	pauses, paused := timer.Pauses, timer.PausedMinutes
+	if timer.Status == StatusPaused {
+		pause := endPause(timer, now)
+		pauses = append(slices.Clone(pauses), pause)
+		paused += pause.Minutes()
+	}
+	return syncPauses(pauses, paused, deltaMinutes(timer.CurrentCycleStart(), now))
+}
+
+// pauseRecords converts pauses of a cycle starting at start to clock times
+func pauseRecords(start time.Time, pauses []Pause) []PauseRecord {
+	var records []PauseRecord
+	for _, p := range pauses {
+		records = append(records, PauseRecord{
+			Start:  start.Add(time.Duration(p.Start) * time.Minute).Format(DT_FORMAT),
+			End:    start.Add(time.Duration(p.End) * time.Minute).Format(DT_FORMAT),
+			Reason: p.Reason,
+		})
+	}
+	return records
+}
+
+// recordPauses converts pause records back, relative to start
+func recordPauses(start time.Time, records []PauseRecord) ([]Pause, error) {
+	var pauses []Pause
+	for _, r := range records {
+		from, err := parseTime(r.Start)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseTime(r.End)
+		if err != nil {
+			return nil, err
+		}
+		pauses = append(pauses, Pause{Start: deltaMinutes(start, from), End: deltaMinutes(start, to), Reason: r.Reason})
+	}
+	return pauses, nil
+}
+
+// pauseReasons adds up the pauses with a reason, by reason
+func pauseReasons(pauses []Pause) map[string]int {
+	reasons := map[string]int{}
+	for _, p := range pauses {
+		if p.Reason != "" {
+			reasons[p.Reason] += p.Minutes()
+		}
+	}
+	return reasons
+}
+
+// formatPauseReasons renders paused minutes by reason, largest first, with
+// the rest as "other": `phone call 0h:20m, other 0h:05m`
+func formatPauseReasons(paused int, reasons map[string]int, format func(int) string) string {
+	names := slices.Collect(maps.Keys(reasons))
+	slices.SortFunc(names, func(a, b string) int {
+		if reasons[a] != reasons[b] {
+			return reasons[b] - reasons[a]
+		}
+		return strings.Compare(a, b)
+	})
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, name+" "+format(reasons[name]))
+		paused -= reasons[name]
+	}
+	if paused > 0 {
+		parts = append(parts, OtherPauseReason+" "+format(paused))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatPauses renders a cycle's pauses as indented lines of `wt log --pauses`
+func formatPauses(entry LogEntry) string {
+	var b strings.Builder
+	for _, p := range entry.Pauses {
+		start := entry.Start.Add(time.Duration(p.Start) * time.Minute)
+		end := entry.Start.Add(time.Duration(p.End) * time.Minute)
+		fmt.Fprintf(&b, "    [%s => %s] Paused: %s", start.Format(TIME_ONLY_FORMAT), end.Format(TIME_ONLY_FORMAT), minutesToHourMinuteStr(p.Minutes()))
+		if p.Reason != "" {
+			b.WriteString(" " + p.Reason)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// pauseSummary renders the day's paused time by reason for `wt report`, or
+// "" if no pause had a reason
+func pauseSummary(timer *Timer) string {
+	reasons := map[string]int{}
+	for _, entry := range buildLogEntries(timer) {
+		for reason, minutes := range pauseReasons(entry.Pauses) {
+			reasons[reason] += minutes
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Paused: %s", formatPauseReasons(timer.Totals().Paused, reasons, minutesToHourMinuteStr))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Pay periods are set with WT_PAY_PERIOD: "weekly" (Monday to Sunday),
+// "biweekly YYYY-MM-DD" (two weeks from the first day of any period),
+// "semimonthly" (1st-15th and 16th-end of month), or "monthly". "weekly" takes
+// an optional first day too. The date ranges thisperiod and lastperiod, and
+// `wt report --period current|previous`, follow them.
+
+const PayPeriodHelp = "weekly, weekly YYYY-MM-DD, biweekly YYYY-MM-DD, semimonthly, or monthly"
+
+// payPeriod returns the pay period containing day
+func payPeriod(day time.Time) (DateRange, error) {
+	spec := strings.Fields(setting("WT_PAY_PERIOD"))
+	if len(spec) == 0 {
+		return DateRange{}, fmt.Errorf("No pay period set. Set WT_PAY_PERIOD to %s.", PayPeriodHelp)
+	}
+	invalid := fmt.Errorf("Invalid WT_PAY_PERIOD: %s. Use %s.", strings.Join(spec, " "), PayPeriodHelp)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+
+	switch {
+	case spec[0] == "weekly" || spec[0] == "biweekly":
+		length, anchor := 7, weekStart(day)
+		if spec[0] == "biweekly" {
+			length = 14
+		}
+		switch {
+		case len(spec) == 2:
+			var err error
+			if anchor, err = time.ParseInLocation(DATE_FORMAT, spec[1], day.Location()); err != nil {
+				return DateRange{}, invalid
+			}
+		case len(spec) > 2 || length == 14:
+			return DateRange{}, invalid
+		}
+		// Whole periods from the anchor, also before it
+		days := int(math.Round(day.Sub(anchor).Hours() / 24))
+		offset := days % length
+		if offset < 0 {
+			offset += length
+		}
+		from := day.AddDate(0, 0, -offset)
+		return DateRange{From: from, To: from.AddDate(0, 0, length)}, nil
+	case len(spec) > 1:
+		return DateRange{}, invalid
+	case spec[0] == "semimonthly":
+		first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		if day.Day() <= 15 {
+			return DateRange{From: first, To: first.AddDate(0, 0, 15)}, nil
+		}
+		return DateRange{From: first.AddDate(0, 0, 15), To: first.AddDate(0, 1, 0)}, nil
+	case spec[0] == "monthly":
+		first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return DateRange{From: first, To: first.AddDate(0, 1, 0)}, nil
+	default:
+		return DateRange{}, invalid
+	}
+}
+
+// payPeriodRange returns the current pay period, or the one before it if
+// previous is set
+func payPeriodRange(previous bool) (DateRange, error) {
+	r, err := payPeriod(getCurrentTime())
+	if err != nil || !previous {
+		return r, err
+	}
+	return payPeriod(r.From.AddDate(0, 0, -1))
+}
+
+// formatPeriod renders a range as `2026-01-05 to 2026-01-18`
+func formatPeriod(r DateRange) string {
+	return r.From.Format(DATE_FORMAT) + " to " + r.To.AddDate(0, 0, -1).Format(DATE_FORMAT)
+}
+
+// payPeriodReportCmd is `wt report --period current|previous`: a range report
+// over the pay period, by day unless grouped otherwise
+func payPeriodReportCmd(timer *Timer, period, rangeStr, groupBy string) error {
+	if rangeStr != "" {
+		return fmt.Errorf("Use either --range or --period.")
+	}
+	rangeStr = "thisperiod"
+	switch period {
+	case "current":
+	case "previous":
+		rangeStr = "lastperiod"
+	default:
+		return fmt.Errorf("Invalid period: %s. Use current or previous.", period)
+	}
+	r, err := payPeriodRange(period == "previous")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pay period: %s\n", formatPeriod(r))
+	return rangeReportCmd(timer, rangeStr, groupBy)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// `wt export payroll` writes what payroll usually asks for: a row per date
+// with the regular and overtime hours, the breaks, and empty approval
+// columns to fill in. Work up to WT_OVERTIME_AFTER (HHMM, default
+// WT_DAILY_GOAL, else 8 hours) on a workday is regular, the rest overtime;
+// all work on other days is overtime. Workdays are the days of WT_SCHEDULE,
+// or Monday to Friday, holidays excepted. Work and breaks are rounded to the
+// nearest WT_PAYROLL_ROUND minutes (default 15, 1 to not round) first.
+// Numbers and dates follow the WT_CSV_* settings.
+
+const (
+	DefaultOvertimeAfter = 8 * 60
+	DefaultPayrollRound  = 15
+)
+
+// payrollWorkday reports whether regular hours are due on day
+func payrollWorkday(day time.Time) (bool, error) {
+	if setting("WT_SCHEDULE") != "" {
+		_, scheduled, err := scheduledStart(day)
+		return scheduled, err
+	}
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false, nil
+	}
+	holiday, err := holidayOn(day)
+	return holiday == "", err
+}
+
+// roundMinutes rounds minutes to the nearest multiple of step
+func roundMinutes(minutes, step int) int {
+	if step <= 1 {
+		return minutes
+	}
+	return (minutes + step/2) / step * step
+}
+
+// FormatDate formats the date part of the dialect's layout
+func (d CSVDialect) FormatDate(t time.Time) string {
+	return t.Format(strings.TrimSpace(strings.TrimSuffix(d.DateLayout, "15:04")))
+}
+
+func writeExportPayroll(w io.Writer, days []ExportDay) error {
+	dialect, err := csvDialect()
+	if err != nil {
+		return err
+	}
+	after := envMinutes("WT_OVERTIME_AFTER", envMinutes("WT_DAILY_GOAL", DefaultOvertimeAfter))
+	step := envInt("WT_PAYROLL_ROUND", DefaultPayrollRound)
+
+	// Profiles of the same date are paid together
+	var dates []string
+	work, breaks := map[string]int{}, map[string]int{}
+	for _, day := range days {
+		if _, ok := work[day.Date]; !ok {
+			dates = append(dates, day.Date)
+		}
+		work[day.Date] += day.Work
+		breaks[day.Date] += day.Break + day.Lunch
+	}
+
+	hours := func(minutes int) string { return dialect.FormatAmount(float64(minutes) / 60) }
+	writer := dialect.NewWriter(w)
+	writer.Write([]string{"date", "regular_hours", "overtime_hours", "break_hours", "approved_by", "approved_on"})
+	for _, date := range dates {
+		day, _ := time.ParseInLocation(DATE_FORMAT, date, time.Local)
+		workday, err := payrollWorkday(day)
+		if err != nil {
+			return err
+		}
+		total := roundMinutes(work[date], step)
+		regular := 0
+		if workday {
+			regular = min(total, after)
+		}
+		writer.Write([]string{dialect.FormatDate(day), hours(regular), hours(total - regular), hours(roundMinutes(breaks[date], step)), "", ""})
+	}
+	writer.Flush()
+	return writer.Error()
+}
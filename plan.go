@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// `wt plan` records the blocks of work intended for the day, by category,
+// e.g. `wt plan 4x50 +deepwork 2:00 +meeting 1:00`: four 50 minute blocks of
+// deep work, two hours of meetings, and an hour of any work. A category is a
+// tag, so the actual time comes from the cycles tagged by presets (see
+// preset.go) or the calendar (see calendar.go). `wt report` and `wt plan`
+// then show planned vs. actual per category. The plan is kept with the day
+// and archived with it; `wt new` starts without one.
+
+const PlanClear = "clear"
+
+// PlanBlock is planned work: Count blocks of Minutes each, tagged Tag ("" for
+// any work)
+type PlanBlock struct {
+	Tag     string `json:"tag,omitempty"`
+	Count   int    `json:"count"`
+	Minutes int    `json:"minutes"`
+}
+
+// String renders the block as e.g. `4x 0h:50m +deepwork`
+func (b PlanBlock) String() string {
+	s := minutesToHourMinuteStr(b.Minutes)
+	if b.Count > 1 {
+		s = fmt.Sprintf("%dx %s", b.Count, s)
+	}
+	if b.Tag != "" {
+		s += " +" + b.Tag
+	}
+	return s
+}
+
+// parsePlan parses blocks given as [Nx]HHMM, each optionally followed by a
+// +tag
+func parsePlan(args []string) ([]PlanBlock, error) {
+	var blocks []PlanBlock
+	for _, arg := range args {
+		if tag, ok := strings.CutPrefix(arg, "+"); ok {
+			if len(blocks) == 0 || blocks[len(blocks)-1].Tag != "" || tag == "" {
+				return nil, fmt.Errorf("Misplaced %s. Put a tag after the time it applies to, e.g. 2:00 +meeting.", arg)
+			}
+			blocks[len(blocks)-1].Tag = tag
+			continue
+		}
+
+		block := PlanBlock{Count: 1}
+		timeStr := arg
+		if countStr, rest, ok := strings.Cut(strings.ToLower(arg), "x"); ok {
+			count, err := strconv.Atoi(countStr)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("Invalid block count in %s. Use e.g. 4x50 for four 50 minute blocks.", arg)
+			}
+			block.Count, timeStr = count, rest
+		}
+		if err := validateTimeString(timeStr); err != nil {
+			return nil, fmt.Errorf("Invalid time in %s. %v", arg, err)
+		}
+		block.Minutes, _ = stringTimeToMinutes(timeStr)
+		if block.Minutes == 0 {
+			return nil, fmt.Errorf("Invalid time in %s. Plan at least a minute.", arg)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// planCategory sums up the blocks planned for one tag
+type planCategory struct {
+	Tag     string
+	Blocks  int
+	Minutes int
+}
+
+// planCategories merges the blocks of the plan by tag, in plan order
+func planCategories(plan []PlanBlock) []planCategory {
+	var categories []planCategory
+	for _, block := range plan {
+		i := slices.IndexFunc(categories, func(c planCategory) bool { return c.Tag == block.Tag })
+		if i < 0 {
+			categories = append(categories, planCategory{Tag: block.Tag})
+			i = len(categories) - 1
+		}
+		categories[i].Blocks += block.Count
+		categories[i].Minutes += block.Count * block.Minutes
+	}
+	return categories
+}
+
+// actualWork returns the work minutes and completed cycles tagged tag (any
+// work if tag is ""), counting the running cycle's minutes but not the cycle
+func actualWork(timer *Timer, tag string) (minutes, cycles int) {
+	for _, entry := range timer.Timeline {
+		if entry.Type == "work" && (tag == "" || slices.Contains(entry.Tags, tag)) {
+			minutes += entry.Minutes
+			cycles++
+		}
+	}
+	if tag == "" || slices.Contains(timer.Tags, tag) {
+		minutes += calculateCurrentMinutes(timer)
+	}
+	return minutes, cycles
+}
+
+// planSummary renders one line per planned category, like
+// `Plan +deepwork: 2h:30m of 3h:20m (75%), 3 of 4 blocks`
+func planSummary(timer *Timer) []string {
+	var lines []string
+	for _, category := range planCategories(timer.Plan) {
+		minutes, cycles := actualWork(timer, category.Tag)
+		line := "Plan"
+		if category.Tag != "" {
+			line += " +" + category.Tag
+		}
+		line += fmt.Sprintf(": %s of %s (%d%%)", minutesToHourMinuteStr(minutes),
+			minutesToHourMinuteStr(category.Minutes), minutes*100/category.Minutes)
+		if category.Blocks > 1 {
+			line += fmt.Sprintf(", %d of %d blocks", cycles, category.Blocks)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// planCmd shows the plan against the actual work without args, replaces it
+// with the given blocks, or removes it with "clear"
+func planCmd(timer *Timer, args []string) error {
+	if len(args) == 0 {
+		if len(timer.Plan) == 0 {
+			fmt.Println("No plan for today. Example: wt plan 4x50 +deepwork 2:00 +meeting")
+			return nil
+		}
+		for _, line := range planSummary(timer) {
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	var plan []PlanBlock
+	if !(len(args) == 1 && args[0] == PlanClear) {
+		var err error
+		if plan, err = parsePlan(args); err != nil {
+			return err
+		}
+	}
+
+	timer.Plan = plan
+	logCommand(timer, "plan", args, nil)
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	if len(plan) == 0 {
+		fmt.Println("Plan cleared.")
+		return nil
+	}
+	total := 0
+	blocks := make([]string, len(plan))
+	for i, block := range plan {
+		total += block.Count * block.Minutes
+		blocks[i] = block.String()
+	}
+	fmt.Printf("Planned %s: %s\n", minutesToHourMinuteStr(total), strings.Join(blocks, ", "))
+	return nil
+}
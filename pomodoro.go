@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pomodoro mode (WT_POMODORO=1) gives every work cycle a target length and
+// every break after it a short or long one: WT_POMODORO_WORK (default 25m),
+// WT_POMODORO_BREAK (5m), and WT_POMODORO_LONG_BREAK (15m) after every
+// WT_POMODORO_LONG_EVERY (4th) pomodoro, all in HHMM like WT_LONG_SESSION.
+// `wt check` then counts down the cycle and the break, `wt next` ends the
+// pomodoro and starts its break (or, during the break, starts the next
+// pomodoro), and `wt remind` reminds when either is over. An active preset's
+// targets take precedence.
+
+const (
+	DefaultPomodoroWorkMinutes      = 25
+	DefaultPomodoroBreakMinutes     = 5
+	DefaultPomodoroLongBreakMinutes = 15
+	DefaultPomodoroLongEvery        = 4
+)
+
+func pomodoroEnabled() bool {
+	return setting("WT_POMODORO") != ""
+}
+
+// pomodoros returns the number of work cycles stopped today
+func pomodoros(timer *Timer) int {
+	count := 0
+	for _, entry := range timer.Timeline {
+		if entry.Type == "work" {
+			count++
+		}
+	}
+	return count
+}
+
+// pomodoroLongBreak tells whether the break after the pomodoros stopped so
+// far is a long one, as it is after every WT_POMODORO_LONG_EVERY
+func pomodoroLongBreak(timer *Timer) bool {
+	every := envInt("WT_POMODORO_LONG_EVERY", DefaultPomodoroLongEvery)
+	count := pomodoros(timer)
+	return every > 0 && count > 0 && count%every == 0
+}
+
+// pomodoroBreakMinutes returns the length of the break after the pomodoros
+// stopped so far
+func pomodoroBreakMinutes(timer *Timer) int {
+	if pomodoroLongBreak(timer) {
+		return envMinutes("WT_POMODORO_LONG_BREAK", DefaultPomodoroLongBreakMinutes)
+	}
+	return envMinutes("WT_POMODORO_BREAK", DefaultPomodoroBreakMinutes)
+}
+
+// pomodoroBreakLeft returns the minutes left of the break since the stop, and
+// false outside pomodoro mode or breaks
+func pomodoroBreakLeft(timer *Timer) (int, bool) {
+	if !pomodoroEnabled() || timer.Status != StatusStopped || timer.StopDatetimeStr == "" {
+		return 0, false
+	}
+	stop, err := parseTime(timer.StopDatetimeStr)
+	if err != nil {
+		return 0, false
+	}
+	return breakTargetMinutes(timer, 0) - deltaMinutes(stop, getCurrentTime()), true
+}
+
+// pomodoroNextCmd moves on to the next phase: a running or paused pomodoro
+// is stopped and its break begins, a break ends with the next pomodoro
+func pomodoroNextCmd(timer *Timer) error {
+	if timer.Status == StatusStopped {
+		return startCmd(timer, StartOptions{})
+	}
+	commandVia = "next"
+	err := stopCmd(timer)
+	commandVia = ""
+	if err != nil {
+		return err
+	}
+	timer, err = load()
+	if err != nil {
+		return err
+	}
+	breakMinutes := breakTargetMinutes(timer, 0)
+	kind := "short"
+	if pomodoroLongBreak(timer) {
+		kind = "long"
+	}
+	until := getCurrentTime().Add(time.Duration(breakMinutes) * time.Minute)
+	printMessageIfNotSilent(timer, fmt.Sprintf("Pomodoro %d done. Take a %s break (%s), until %s; 'wt next' starts the next one.",
+		pomodoros(timer), kind, minutesToHourMinuteStr(breakMinutes), until.Format(TIME_ONLY_FORMAT)))
+	return nil
+}
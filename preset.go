@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Presets bundle cycle targets, tags, and output mode under a name, defined as
+// WT_PRESET_<NAME> settings (environment or profile), e.g.
+//
+//	WT_PRESET_DEEPWORK="50/10"             - 50 min work, 10 min break targets
+//	WT_PRESET_MEETINGS="untimed +meeting"  - no targets, tag cycles +meeting
+//
+// Tokens: W/B targets in minutes (either may be empty, e.g. "90/"), "untimed",
+// "+tag", and an output mode (silent, normal, verbose). The preset chosen with
+// `wt start --preset` stays active for the following cycles until another
+// preset (or "none") is chosen or the timer is reset.
+
+const PresetSettingPrefix = "WT_PRESET_"
+
+// Preset is a parsed WT_PRESET_* setting
+type Preset struct {
+	Name    string
+	Untimed bool // Disables break warnings and reminders
+	Work    int  // Work target in minutes, 0 if unset
+	Break   int  // Break target in minutes, 0 if unset
+	Tags    []string
+	Mode    string
+}
+
+func parsePreset(name, spec string) (Preset, error) {
+	preset := Preset{Name: name}
+	for _, token := range strings.Fields(spec) {
+		switch {
+		case token == "untimed":
+			preset.Untimed = true
+		case strings.HasPrefix(token, "+") && len(token) > 1:
+			preset.Tags = append(preset.Tags, token[1:])
+		case token == ModeSilent || token == ModeNormal || token == ModeVerbose:
+			preset.Mode = token
+		case strings.Contains(token, "/"):
+			workStr, breakStr, _ := strings.Cut(token, "/")
+			var err error
+			if workStr != "" {
+				if preset.Work, err = strconv.Atoi(workStr); err != nil || preset.Work <= 0 {
+					return Preset{}, fmt.Errorf("Preset %s: invalid work target %q", name, workStr)
+				}
+			}
+			if breakStr != "" {
+				if preset.Break, err = strconv.Atoi(breakStr); err != nil || preset.Break <= 0 {
+					return Preset{}, fmt.Errorf("Preset %s: invalid break target %q", name, breakStr)
+				}
+			}
+		default:
+			return Preset{}, fmt.Errorf("Preset %s: unknown token %q. Use W/B, untimed, +tag, or a mode", name, token)
+		}
+	}
+	return preset, nil
+}
+
+// presetNames lists the presets defined in the environment and the active profile
+func presetNames() []string {
+	seen := map[string]bool{}
+	add := func(key string) {
+		if strings.HasPrefix(key, PresetSettingPrefix) && len(key) > len(PresetSettingPrefix) {
+			seen[strings.ToLower(strings.TrimPrefix(key, PresetSettingPrefix))] = true
+		}
+	}
+	for _, env := range os.Environ() {
+		key, _, _ := strings.Cut(env, "=")
+		add(key)
+	}
+	if profile := activeProfile(); profile != "" {
+		values, _ := readProfile(profile)
+		for key := range values {
+			add(key)
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func loadPreset(name string) (Preset, error) {
+	spec := setting(PresetSettingPrefix + strings.ToUpper(name))
+	if spec == "" {
+		available := strings.Join(presetNames(), ", ")
+		if available == "" {
+			available = "none defined, set WT_PRESET_<NAME>"
+		}
+		return Preset{}, fmt.Errorf("Unknown preset: %s (available: %s)", name, available)
+	}
+	return parsePreset(name, spec)
+}
+
+// activePreset returns the timer's preset, if one is active and still defined
+func activePreset(timer *Timer) (Preset, bool) {
+	if timer.Preset == "" {
+		return Preset{}, false
+	}
+	preset, err := loadPreset(timer.Preset)
+	return preset, err == nil
+}
+
+// applyPreset makes the preset active for the current and following cycles.
+// "none" clears the active preset and its tags.
+func applyPreset(timer *Timer, name string) error {
+	if name == "none" {
+		timer.Preset = ""
+		timer.Tags = nil
+		return nil
+	}
+	preset, err := loadPreset(name)
+	if err != nil {
+		return err
+	}
+	timer.Preset = preset.Name
+	timer.Tags = preset.Tags
+	if preset.Mode != "" {
+		timer.Mode = preset.Mode
+	}
+	return nil
+}
+
+// workTargetMinutes returns the running cycle length after which check warns
+// and remind reminds: the preset's work target, or 0 for untimed presets,
+// else the pomodoro length in pomodoro mode
+func workTargetMinutes(timer *Timer, fallback int) int {
+	if preset, ok := activePreset(timer); ok {
+		if preset.Untimed {
+			return 0
+		}
+		if preset.Work > 0 {
+			return preset.Work
+		}
+	}
+	if pomodoroEnabled() {
+		return envMinutes("WT_POMODORO_WORK", DefaultPomodoroWorkMinutes)
+	}
+	return fallback
+}
+
+// breakTargetMinutes returns the break length after which remind reminds to
+// start again, the pomodoro break in pomodoro mode unless a preset sets one
+func breakTargetMinutes(timer *Timer, fallback int) int {
+	if preset, ok := activePreset(timer); ok {
+		if preset.Untimed {
+			return 0
+		}
+		if preset.Break > 0 {
+			return preset.Break
+		}
+	}
+	if pomodoroEnabled() {
+		return pomodoroBreakMinutes(timer)
+	}
+	return fallback
+}
+
+// mergeTags returns the union of two tag lists, keeping order
+func mergeTags(a, b []string) []string {
+	result := append([]string{}, a...)
+	for _, tag := range b {
+		found := false
+		for _, existing := range result {
+			if existing == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, tag)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// formatTags renders tags as " +a +b", or "" if there are none
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " +" + strings.Join(tags, " +")
+}
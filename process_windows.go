@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd without a console, so it outlives the terminal that started it
+func detach(cmd *exec.Cmd) {
+	const detachedProcess = 0x00000008
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess}
+}
+
+// processAlive reports whether a process with this pid exists
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid) // Opens the process on Windows, failing if it's gone
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profiles bundle WT_* settings (goals, reminders, report file, ...) in
+// $WT_ROOT/.out/profiles/<name>.env, one KEY=VALUE per line. The active
+// profile is chosen with --profile, $WT_PROFILE, or `wt profile use <name>`
+// (stored per root in .out/profile). Environment variables always win over
+// profile values, so one-off overrides keep working.
+
+const (
+	ProfilesFolder  = "profiles"
+	ProfileFileName = "profile"
+	ProfileFileExt  = ".env"
+)
+
+// setting returns the value of a WT_* setting from the environment, falling
+// back to the active profile, then the config file (config.go).
+func setting(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	if profile := activeProfile(); profile != "" {
+		if values, err := readProfile(profile); err == nil && values[name] != "" {
+			return values[name]
+		}
+	}
+	return readConfig()[name]
+}
+
+// activeProfile returns the selected profile name, or "" if none
+func activeProfile() string {
+	if name := os.Getenv("WT_PROFILE"); name != "" {
+		return name
+	}
+	folder, err := outputFolderPath()
+	if err != nil {
+		return ""
+	}
+	data, err := readFile(filepath.Join(folder, ProfileFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func profilesFolderPath() (string, error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, ProfilesFolder), nil
+}
+
+func profileFilePath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\.`) {
+		return "", fmt.Errorf("Invalid profile name: %q", name)
+	}
+	folder, err := profilesFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, name+ProfileFileExt), nil
+}
+
+// readProfile parses a profile's KEY=VALUE lines. Blank lines and # comments are ignored.
+func readProfile(name string) (map[string]string, error) {
+	filePath, err := profileFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("Profile %s does not exist.", name)
+		}
+		return nil, err
+	}
+	return parseSettings(data)
+}
+
+// parseSettings parses KEY=VALUE lines, as in profiles and the config file
+func parseSettings(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, scanner.Err()
+}
+
+// writeProfile saves a profile's values, sorted by key
+func writeProfile(name string, values map[string]string) error {
+	filePath, err := profileFilePath(name)
+	if err != nil {
+		return err
+	}
+	if readOnly.Load() {
+		return errReadOnly("not writing " + filepath.Base(filePath))
+	}
+	return writeSettings(filePath, values)
+}
+
+// writeSettings saves KEY=VALUE lines, sorted by key
+func writeSettings(filePath string, values map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", key, values[key])
+	}
+	return writeFile(filePath, []byte(b.String()), 0644)
+}
+
+func profileNames() ([]string, error) {
+	folder, err := profilesFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(folder)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ProfileFileExt) {
+			names = append(names, strings.TrimSuffix(file.Name(), ProfileFileExt))
+		}
+	}
+	return names, nil
+}
+
+func profileListCmd() error {
+	names, err := profileNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("No profiles. Create one with: wt profile set <name> <KEY> <value>")
+		return nil
+	}
+
+	active := activeProfile()
+	for _, name := range names {
+		marker := "  "
+		if name == active {
+			marker = "* "
+		}
+		fmt.Println(marker + name)
+	}
+	return nil
+}
+
+func profileShowCmd(name string) error {
+	if name == "" {
+		name = activeProfile()
+		if name == "" {
+			fmt.Println("No active profile.")
+			return nil
+		}
+	}
+
+	values, err := readProfile(name)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("Profile: %s\n", name)
+	for _, key := range keys {
+		fmt.Printf("  %s=%s\n", key, values[key])
+	}
+	return nil
+}
+
+func profileUseCmd(name string) error {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(folder, ProfileFileName)
+
+	if name == "none" {
+		if err := removeFile(filePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Println("Profile cleared.")
+		return nil
+	}
+
+	if _, err := readProfile(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return err
+	}
+	if err := writeFile(filePath, []byte(name+"\n"), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Using profile %s.\n", name)
+	return nil
+}
+
+func profileSetCmd(name, key, value string) error {
+	if !strings.HasPrefix(key, "WT_") {
+		return fmt.Errorf("Invalid setting: %s. Settings are WT_* variables, e.g. WT_DAILY_GOAL", key)
+	}
+	if key == "WT_ROOT" || key == "WT_PROFILE" {
+		return fmt.Errorf("%s cannot be set in a profile.", key)
+	}
+
+	values, err := readProfile(name)
+	if err != nil {
+		values = map[string]string{}
+	}
+	if value == "" {
+		delete(values, key)
+	} else {
+		values[key] = value
+	}
+	if err := writeProfile(name, values); err != nil {
+		return err
+	}
+	fmt.Printf("Profile %s: %s=%s\n", name, key, value)
+	return nil
+}
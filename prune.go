@@ -0,0 +1,202 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRetention parses an age such as 90d, 8w, 6m, or 2y and returns the
+// cutoff: midnight of the first day that is kept
+func parseRetention(value string, now time.Time) (time.Time, error) {
+	invalid := fmt.Errorf("Invalid age: %s. Use a number followed by d, w, m, or y (e.g. 2y)", value)
+	if len(value) < 2 {
+		return time.Time{}, invalid
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return time.Time{}, invalid
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch value[len(value)-1] {
+	case 'd':
+		return today.AddDate(0, 0, -n), nil
+	case 'w':
+		return today.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return today.AddDate(0, -n, 0), nil
+	case 'y':
+		return today.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, invalid
+}
+
+// PruneResult lists the files pruning touched (or would touch)
+type PruneResult struct {
+	Deleted    []string
+	Compressed []string
+}
+
+// pruneData removes archived days and rotated debug logs older than cutoff.
+// With compress, archived days are gzipped instead of deleted. Nothing is
+// changed on a dry run.
+func pruneData(cutoff time.Time, compress, dryRun bool) (PruneResult, error) {
+	var result PruneResult
+
+	folder, err := archiveFolderPath()
+	if err != nil {
+		return result, err
+	}
+	files, err := os.ReadDir(folder)
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+	for _, file := range files {
+		day, ok := archiveFileDate(file.Name())
+		if file.IsDir() || !ok || !day.Before(cutoff) {
+			continue
+		}
+		filePath := filepath.Join(folder, file.Name())
+
+		if !compress {
+			result.Deleted = append(result.Deleted, filePath)
+			if !dryRun {
+				if err := os.Remove(filePath); err != nil {
+					return result, err
+				}
+			}
+			continue
+		}
+		if strings.HasSuffix(filePath, ".gz") {
+			continue
+		}
+		result.Compressed = append(result.Compressed, filePath)
+		if !dryRun {
+			if err := gzipFile(filePath); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	// Rotated debug logs go once their newest entry is past the cutoff
+	debugPath, err := debugLogFilePath()
+	if err != nil {
+		return result, err
+	}
+	for i := 1; i <= DebugLogRotations; i++ {
+		filePath := fmt.Sprintf("%s.%d", debugPath, i)
+		if newest, ok := lastDebugLogTime(filePath); ok && newest.Before(cutoff) {
+			result.Deleted = append(result.Deleted, filePath)
+			if !dryRun {
+				if err := os.Remove(filePath); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// gzipFile replaces a file with its gzipped version (name.gz)
+func gzipFile(filePath string) error {
+	data, err := readFile(filePath)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(filePath + ".gz")
+	if err != nil {
+		return err
+	}
+	traceFile("write", filePath+".gz")
+	gz := gzip.NewWriter(out)
+	if _, err := gz.Write(data); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(filePath)
+}
+
+// lastDebugLogTime returns the time of the last entry in a debug log
+func lastDebugLogTime(filePath string) (time.Time, bool) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if t, ok := debugLineTime(lines[i]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// applyRetention prunes data older than $WT_RETENTION, if set. Run on reset
+// and by the daemon once a day.
+func applyRetention() error {
+	retention := setting("WT_RETENTION")
+	if retention == "" {
+		return nil
+	}
+	cutoff, err := parseRetention(retention, getCurrentTime())
+	if err != nil {
+		return fmt.Errorf("WT_RETENTION: %v", err)
+	}
+	_, err = pruneData(cutoff, setting("WT_RETENTION_COMPRESS") != "", false)
+	return err
+}
+
+func pruneCmd(olderThan string, compress, dryRun bool) error {
+	if olderThan == "" {
+		olderThan = setting("WT_RETENTION")
+	}
+	if olderThan == "" {
+		return fmt.Errorf("Usage: wt prune --older-than <age> (e.g. 2y), or set WT_RETENTION")
+	}
+	cutoff, err := parseRetention(olderThan, getCurrentTime())
+	if err != nil {
+		return err
+	}
+
+	// Look before deleting anything
+	result, err := pruneData(cutoff, compress, true)
+	if err != nil {
+		return err
+	}
+	if len(result.Deleted)+len(result.Compressed) == 0 {
+		fmt.Printf("Nothing older than %s.\n", cutoff.Format(DATE_FORMAT))
+		return nil
+	}
+
+	for _, filePath := range result.Deleted {
+		fmt.Printf("delete   %s\n", filepath.Base(filePath))
+	}
+	for _, filePath := range result.Compressed {
+		fmt.Printf("compress %s\n", filepath.Base(filePath))
+	}
+	if dryRun {
+		return nil
+	}
+	if len(result.Deleted) > 0 && !yesOrNoPrompt(fmt.Sprintf("Delete %d files older than %s?", len(result.Deleted), cutoff.Format(DATE_FORMAT))) {
+		return nil
+	}
+
+	if _, err := pruneData(cutoff, compress, false); err != nil {
+		return err
+	}
+	fmt.Println("Pruned.")
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// reportGroupKey returns the group a day belongs to for `wt report --group-by`
+func reportGroupKey(day DaySummary, groupBy string) (string, error) {
+	start, err := parseTime(day.DayStart)
+	if err != nil {
+		return "", err
+	}
+	switch groupBy {
+	case "", "day":
+		return start.Format(DATE_FORMAT), nil
+	case "week":
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), nil
+	case "month":
+		return start.Format("2006-01"), nil
+	case "project":
+		if day.Profile == "" {
+			return "(no profile)", nil
+		}
+		return day.Profile, nil
+	case "location", "task", "tag":
+		return "", nil // Per cycle, see reportGroups
+	default:
+		return "", fmt.Errorf("Invalid group: %s. Use day, week, month, tag, project, location, or task", groupBy)
+	}
+}
+
+// perCycleGroup reports whether a --group-by splits days by their cycles,
+// so the report needs the archived cycles rather than the archive index
+func perCycleGroup(groupBy string) bool {
+	return groupBy == "location" || groupBy == "task" || groupBy == "tag"
+}
+
+// reportGroups returns the day's totals split by the location, task, or
+// tags of each cycle
+func reportGroups(t *Timer, groupBy string) map[string]DayTotals {
+	switch groupBy {
+	case "location":
+		return t.locationTotals()
+	case "tag":
+		return t.tagTotals()
+	}
+	return t.taskTotals()
+}
+
+// rangeReportCmd prints one report line per group over the range, then the grand total.
+// Projects are the profiles active when each day was archived; locations,
+// tasks, and tags are per cycle, and a cycle counts for each of its tags.
+// Tasks are followed by actual vs. estimated work.
+func rangeReportCmd(timer *Timer, rangeStr, groupBy string) error {
+	r, err := parseDateRange(rangeStr)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	groups := map[string]DayTotals{}
+	days := map[string]map[string]bool{}
+	estimates := map[string]int{} // Latest estimate per task
+	var tagged DayTotals          // Each cycle once, for the total of tags
+	add := func(date string, dayGroups map[string]DayTotals) {
+		for key, dayTotals := range dayGroups {
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+				days[key] = map[string]bool{}
+			}
+			totals := groups[key]
+			totals.Add(dayTotals)
+			groups[key] = totals
+			days[key][date] = true
+		}
+	}
+	if perCycleGroup(groupBy) {
+		timers, err := loadRange(timer, r)
+		if err != nil {
+			return err
+		}
+		for _, t := range timers {
+			maps.Copy(estimates, t.Estimates)
+			tagged.Add(t.Totals())
+			add(t.DayStart[:len(DATE_FORMAT)], reportGroups(t, groupBy))
+		}
+	} else {
+		summaries, err := loadRangeSummaries(timer, r)
+		if err != nil {
+			return err
+		}
+		for _, day := range summaries {
+			key, err := reportGroupKey(day, groupBy)
+			if err != nil {
+				return err
+			}
+			add(day.DayStart[:len(DATE_FORMAT)], map[string]DayTotals{key: day.Totals})
+		}
+	}
+
+	if len(keys) == 0 {
+		fmt.Printf("No work recorded in %s.\n", r.Name)
+		return nil
+	}
+	sort.Strings(keys)
+
+	width := len("Total")
+	for _, key := range keys {
+		width = max(width, len(key))
+	}
+	line := func(label string, totals DayTotals, dayCount int) {
+		fmt.Printf("%-*s | Work: %s | %s | Paused: %s | Total: %s | Days: %d\n",
+			width, label, minutesToHourMinuteStr(totals.Work), totals.breakSummary(),
+			minutesToHourMinuteStr(totals.Paused), minutesToHourMinuteStr(totals.Total()), dayCount)
+	}
+
+	var grand DayTotals
+	allDays := map[string]bool{}
+	for _, key := range keys {
+		line(key, groups[key], len(days[key]))
+		grand.Add(groups[key])
+		for day := range days[key] {
+			allDays[day] = true
+		}
+	}
+	if groupBy == "tag" {
+		grand = tagged // Not the sum, as cycles count for each of their tags
+	}
+	if len(keys) > 1 {
+		line("Total", grand, len(allDays))
+	}
+	if groupBy == "task" {
+		for _, line := range estimateSummary(estimates, groups) {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
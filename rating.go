@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// `wt rate 1-5` scores the energy or mood of the running cycle (kept in
+// Timer.Rating until the stop), or else of the last work cycle.
+// `wt stats --ratings` averages the scores by time of day and by cycle
+// length, to show when work goes well and how long a cycle should be.
+
+const (
+	MinRating       = 1
+	MaxRating       = 5
+	RatingStatsDays = 30 // Days covered by `wt stats --ratings` without --range
+)
+
+// ratingBucket is a group of `wt stats --ratings`, [From, To) in minutes
+// of the cycle start (time of day) or of its work (length)
+type ratingBucket struct {
+	Label    string
+	From, To int
+}
+
+var (
+	ratingTimesOfDay = []ratingBucket{
+		{"Before 10:00", 0, 10 * 60}, {"10:00-12:00", 10 * 60, 12 * 60}, {"12:00-14:00", 12 * 60, 14 * 60},
+		{"14:00-16:00", 14 * 60, 16 * 60}, {"16:00-18:00", 16 * 60, 18 * 60}, {"From 18:00", 18 * 60, 24 * 60},
+	}
+	ratingLengths = []ratingBucket{
+		{"Under 0h:30m", 0, 30}, {"0h:30m-1h:00m", 30, 60}, {"1h:00m-1h:30m", 60, 90},
+		{"1h:30m-2h:00m", 90, 120}, {"2h:00m or more", 120, 24 * 60},
+	}
+)
+
+// formatRating renders a rating as ` ★4`, or ""
+func formatRating(rating int) string {
+	if rating == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %s%d", glyphs("★", "*"), rating)
+}
+
+func rateCmd(timer *Timer, value string) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	rating, err := strconv.Atoi(value)
+	if err != nil || rating < MinRating || rating > MaxRating {
+		return fmt.Errorf("Invalid rating: %s. Use %d (drained) to %d (energized).", value, MinRating, MaxRating)
+	}
+
+	message := ""
+	if timer.Status == StatusRunning || timer.Status == StatusPaused {
+		timer.Rating = rating
+		message = fmt.Sprintf("Rated the running cycle: %d/%d.", rating, MaxRating)
+	} else {
+		last := -1
+		for i, entry := range timer.Timeline {
+			if entry.Type == "work" {
+				last = i
+			}
+		}
+		if last < 0 {
+			fmt.Println("No cycle to rate yet.")
+			logWarning(timer, "rate", []string{value}, "No cycle to rate yet.")
+			return nil
+		}
+		timer.Timeline[last].Rating = rating
+		message = fmt.Sprintf("Rated cycle %d: %d/%d.", last+1, rating, MaxRating)
+	}
+
+	logCommand(timer, "rate", []string{value}, nil)
+	if err := save(timer); err != nil {
+		return err
+	}
+	printMessageIfNotSilent(timer, message)
+	return nil
+}
+
+// ratingAverages averages the ratings of the entries per bucket of key
+func ratingAverages(entries []LogEntry, buckets []ratingBucket, key func(LogEntry) int) []string {
+	var lines []string
+	for _, bucket := range buckets {
+		sum, count := 0, 0
+		for _, entry := range entries {
+			if k := key(entry); k >= bucket.From && k < bucket.To {
+				sum += entry.Rating
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		average := float64(sum) / float64(count)
+		bar := strings.Repeat(glyphs("█", "#"), int(average+0.5))
+		lines = append(lines, fmt.Sprintf("  %-14s %.1f %-5s (cycles: %d)", bucket.Label, average, bar, count))
+	}
+	return lines
+}
+
+// statsRatingsCmd prints the average ratings of the rated work cycles in the
+// range (the last RatingStatsDays days if empty) by time of day and length
+func statsRatingsCmd(timer *Timer, rangeStr string) error {
+	var r DateRange
+	if rangeStr == "" {
+		now := getCurrentTime()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		r = DateRange{Name: fmt.Sprintf("the last %d days", RatingStatsDays), From: today.AddDate(0, 0, 1-RatingStatsDays), To: today.AddDate(0, 0, 1)}
+	} else {
+		var err error
+		if r, err = parseDateRange(rangeStr); err != nil {
+			return err
+		}
+	}
+	timers, err := loadRange(timer, r)
+	if err != nil {
+		return err
+	}
+
+	var rated []LogEntry
+	sum := 0
+	for _, t := range timers {
+		for _, entry := range buildLogEntries(t) {
+			if entry.Type == "work" && entry.Rating > 0 {
+				rated = append(rated, entry)
+				sum += entry.Rating
+			}
+		}
+	}
+	if len(rated) == 0 {
+		fmt.Printf("No rated cycles in %s. Rate cycles with wt rate %d-%d.\n", r.Name, MinRating, MaxRating)
+		return nil
+	}
+
+	fmt.Printf("Ratings: %d cycles in %s, average %.1f\n", len(rated), r.Name, float64(sum)/float64(len(rated)))
+	fmt.Println("By time of day:")
+	for _, line := range ratingAverages(rated, ratingTimesOfDay, func(e LogEntry) int { return e.Start.Hour()*60 + e.Start.Minute() }) {
+		fmt.Println(line)
+	}
+	fmt.Println("By cycle length:")
+	for _, line := range ratingAverages(rated, ratingLengths, func(e LogEntry) int { return e.Minutes }) {
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/urfave/cli/v3"
+)
+
+// In read-only mode wt doesn't open any file for writing: commands that
+// change the timer are refused, and nothing goes to the debug log. `wt check`
+// (and plain `wt`) and `wt status` always run this way, since status bars and
+// shell prompts call them every few seconds, possibly on a slow or read-only
+// filesystem. Other commands opt in with --read-only or WT_READ_ONLY=1.
+//
+// check and status also keep what they read for the rest of the command:
+// every setting() looks at the active profile, so without the cache a single
+// `wt check` would read the same few files dozens of times. They never open
+// the archive or the daily report unless asked to (--trend, --budget).
+// `make bench` times them; a check should take a few milliseconds.
+
+// readOnlyCommands are the top-level commands that always run read-only
+var readOnlyCommands = map[string]bool{"check": true, "status": true}
+
+var readOnly atomic.Bool
+
+// readCache holds the files read by a check or status, missing ones included.
+// Nil while no such command runs.
+var readCache struct {
+	mu    sync.Mutex
+	files map[string]cachedFile
+}
+
+type cachedFile struct {
+	data []byte
+	err  error
+}
+
+// cachedRead returns the file from the read cache, reading it on first use
+func cachedRead(path string, read func(string) ([]byte, error)) ([]byte, error) {
+	readCache.mu.Lock()
+	files := readCache.files
+	file, ok := files[path]
+	readCache.mu.Unlock()
+	if files == nil {
+		return read(path)
+	}
+	if !ok {
+		file.data, file.err = read(path)
+		readCache.mu.Lock()
+		files[path] = file
+		readCache.mu.Unlock()
+	}
+	return file.data, file.err
+}
+
+// startReadCache turns the read cache on until the returned function is
+// called. Nested calls keep the outer cache.
+func startReadCache() func() {
+	readCache.mu.Lock()
+	defer readCache.mu.Unlock()
+	if readCache.files != nil {
+		return func() {}
+	}
+	readCache.files = map[string]cachedFile{}
+	return func() {
+		readCache.mu.Lock()
+		readCache.files = nil
+		readCache.mu.Unlock()
+	}
+}
+
+// errReadOnly is returned instead of writing in read-only mode
+func errReadOnly(what string) error {
+	return fmt.Errorf("Read-only mode: %s.", what)
+}
+
+// readOnlyActions makes the actions of readOnlyCommands (and the default
+// check) run read-only. The previous mode is restored afterwards, as the
+// daemon and 'wt serve' run commands in-process.
+func readOnlyActions(app *cli.Command) {
+	wrap := func(action cli.ActionFunc) cli.ActionFunc {
+		return func(ctx context.Context, cmd *cli.Command) error {
+			previous := readOnly.Swap(true)
+			defer readOnly.Store(previous)
+			defer startReadCache()()
+			defer guardClock(cmd.Name, false)()
+			return action(ctx, cmd)
+		}
+	}
+	app.Action = wrap(app.Action)
+	for _, command := range app.Commands {
+		if readOnlyCommands[command.Name] && command.Action != nil {
+			command.Action = wrap(command.Action)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// With --remote (or WT_REMOTE) set to the URL of a `wt serve`, the timer
+// commands talk to its HTTP API instead of the local files, authenticating
+// with WT_REMOTE_TOKEN. Only what the API offers is available remotely.
+// `--remote daemon` sends them to the running `wt daemon` of this timer
+// instead, which serves the same API on its unix socket.
+
+// RemoteDaemon is the WT_REMOTE value that means the local daemon
+const RemoteDaemon = "daemon"
+
+// remoteCommands are the top-level commands that work against a remote timer
+var remoteCommands = map[string]func(cmd *cli.Command) error{
+	"check":  remoteCheckCmd,
+	"status": remoteStatusCmd,
+	"log":    remoteLogCmd,
+	"start": func(cmd *cli.Command) error {
+		body := map[string]string{"time": cmd.Args().Get(0), "preset": cmd.String("preset"), "break": cmd.String("break"),
+			"task": cmd.String("message"), "estimate": cmd.String("estimate")}
+		if cmd.Bool("no-break") {
+			body["no_break"] = "true"
+		}
+		return remoteTimerCmd("start", body)
+	},
+	"pause": func(cmd *cli.Command) error {
+		return remoteTimerCmd("pause", map[string]string{"time": cmd.Args().Get(0), "reason": cmd.String("message")})
+	},
+	"stop":   func(cmd *cli.Command) error { return remoteTimerCmd("stop", nil) },
+	"next":   func(cmd *cli.Command) error { return remoteTimerCmd("next", nil) },
+	"toggle": func(cmd *cli.Command) error { return remoteTimerCmd("toggle", nil) },
+}
+
+// remoteActions makes the commands in remoteCommands use the remote timer
+// when one is configured, and the other commands refuse to run
+func remoteActions(app *cli.Command) {
+	wrap := func(name string, action cli.ActionFunc) cli.ActionFunc {
+		return func(ctx context.Context, cmd *cli.Command) error {
+			if setting("WT_REMOTE") == "" || name == "help" {
+				return action(ctx, cmd)
+			}
+			remote, ok := remoteCommands[name]
+			if !ok {
+				return fmt.Errorf("'wt %s' isn't available with --remote. Remote commands: check, status, log, start, pause, stop, next, toggle", name)
+			}
+			return remote(cmd)
+		}
+	}
+	app.Action = wrap("check", app.Action)
+	for _, command := range app.Commands {
+		if command.Action != nil {
+			command.Action = wrap(command.Name, command.Action)
+		}
+	}
+}
+
+// remoteRequest calls the remote API, decoding the JSON response into out
+func remoteRequest(method, path string, body, out any) error {
+	if setting("WT_REMOTE") == RemoteDaemon {
+		return daemonAPIRequest(method, path, body, out)
+	}
+	base := strings.TrimSuffix(strings.TrimRight(setting("WT_REMOTE"), "/"), "/api")
+	return jsonRequestVia(pinnedClient(), "Remote wt", method, base+"/api"+path, setting("WT_REMOTE_TOKEN"), body, out)
+}
+
+func remoteCheckCmd(cmd *cli.Command) error {
+	if cmd.Bool("budget") || cmd.Bool("trend") {
+		return fmt.Errorf("--budget and --trend aren't available with --remote")
+	}
+	var status APIStatus
+	if err := remoteRequest(http.MethodGet, "/status", nil, &status); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(status)
+	}
+	fmt.Println(status.Check)
+	return nil
+}
+
+func remoteStatusCmd(cmd *cli.Command) error {
+	var status APIStatus
+	if err := remoteRequest(http.MethodGet, "/status", nil, &status); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(status)
+	}
+	fmt.Println(status.Status)
+	return nil
+}
+
+func remoteLogCmd(cmd *cli.Command) error {
+	for _, name := range []string{"since", "until", "type", "last", "condensed", "min-break", "gantt", "tail", "today"} {
+		if cmd.IsSet(name) {
+			return fmt.Errorf("--%s isn't available with --remote", name)
+		}
+	}
+	format := cmd.String("format")
+	if jsonOutput && format == "" {
+		format = "json"
+	}
+	if cmd.Args().Len() > 0 || (format != "" && format != "text" && format != "json") {
+		return fmt.Errorf("Only the info log, as text or json, is available with --remote")
+	}
+
+	var records []LogRecord
+	if err := remoteRequest(http.MethodGet, "/log", nil, &records); err != nil {
+		return err
+	}
+	if format == "json" {
+		return printJSON(records)
+	}
+	if len(records) == 0 {
+		fmt.Println("No work cycles recorded.")
+		return nil
+	}
+	for _, record := range records {
+		entry, err := record.Entry()
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatLogEntry(entry))
+	}
+	return nil
+}
+
+// remoteTimerCmd runs a timer command remotely and prints what it printed there
+func remoteTimerCmd(command string, body map[string]string) error {
+	for key, value := range body {
+		if value == "" {
+			delete(body, key)
+		}
+	}
+	var result APIResult
+	if err := remoteRequest(http.MethodPost, "/"+command, body, &result); err != nil {
+		return err
+	}
+	if result.Output != "" {
+		fmt.Println(result.Output)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReplayStep is a command to re-execute at a given time
+type ReplayStep struct {
+	Time time.Time
+	Args []string // Command name followed by its arguments
+}
+
+// journalSteps reads the debug log and returns the user-issued commands since
+// the last reset, in order. Nested entries (stop via next), warnings, and
+// read-only commands are skipped.
+func journalSteps() ([]ReplayStep, error) {
+	filePath, err := debugLogFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("No debug log to replay.")
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []DebugEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if entry, ok := parseDebugLine(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var steps []ReplayStep
+	for i, entry := range entries {
+		if entry.Level != LevelInfo || entry.Via != "" {
+			continue
+		}
+		// Changes to archived days don't touch the current timer, and the mods
+		// of a mod file follow its entry one by one
+		if entry.Command == "mod" && len(entry.Args) > 0 && (entry.Args[0] == "--date" || entry.Args[0] == "--file") {
+			continue
+		}
+
+		args := append([]string{entry.Command}, entry.Args...)
+		if entry.Command == "" {
+			// Legacy "[time] wt <command> <args>" line
+			fields := strings.Fields(entry.Message)
+			if len(fields) < 2 || fields[0] != "wt" {
+				continue
+			}
+			args = fields[1:]
+
+			// Legacy next logged its inner stop first
+			if args[0] == "stop" && i+1 < len(entries) && entries[i+1].Time == entry.Time &&
+				strings.TrimSpace(entries[i+1].Message) == "wt next" {
+				continue
+			}
+		}
+
+		t, err := parseTime(entry.Time)
+		if err != nil {
+			continue
+		}
+
+		// Everything before a reset belongs to a previous day
+		if args[0] == "reset" {
+			steps = nil
+			continue
+		}
+
+		steps = append(steps, ReplayStep{Time: t, Args: args})
+	}
+
+	return steps, nil
+}
+
+// runSteps executes steps against a copy of base (or a fresh timer) in a scratch root and returns
+// the resulting timer. Command output is suppressed. The caller's environment
+// ($WT_ROOT) and clock are restored afterwards.
+func runSteps(base *Timer, steps []ReplayStep) (*Timer, error) {
+	scratch, err := os.MkdirTemp("", "wt-replay-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	config, err := configFilePath() // The scratch root has none
+	if err != nil {
+		return nil, err
+	}
+	restore := overrideEnv(map[string]string{
+		"WT_ROOT":               scratch,
+		"WT_CONFIG":             config,
+		"WT_REPORT_FILE":        filepath.Join(scratch, "daily-reports"),
+		"WT_WEEKLY_REPORT_FILE": filepath.Join(scratch, "weekly-reports"), // Not "", which falls back to the profile or config
+		"WT_SKIP_PROMPTS":       "1",
+	})
+	defer restore()
+	muted := linearMuted
+	linearMuted = true
+	defer func() { linearMuted = muted }()
+	frozen := NewFrozenClock(getCurrentTime()) // Set per step below
+	defer useClock(frozen)()
+
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	if base == nil {
+		base = &Timer{Status: StatusStopped, Mode: ModeSilent, Timeline: []TimelineEntry{}}
+	}
+	if err := save(base); err != nil {
+		return nil, err
+	}
+
+	for _, step := range steps {
+		frozen.Set(step.Time)
+		args := append([]string{"wt"}, step.Args...)
+		if err := newApp().Run(context.Background(), args); err != nil {
+			return nil, fmt.Errorf("[%s] wt %s: %v", step.Time.Format(DT_FORMAT), strings.Join(step.Args, " "), err)
+		}
+	}
+
+	return load()
+}
+
+// overrideEnv sets environment variables and returns a function restoring the previous values
+func overrideEnv(values map[string]string) func() {
+	previous := map[string]*string{}
+	for key, value := range values {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, old := range previous {
+			if old == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *old)
+			}
+		}
+	}
+}
+
+// parseUntil accepts "YYYY-MM-DD HH:MM" or a clock time (HHMM or HH:MM) on the given day
+func parseUntil(value string, day time.Time) (time.Time, error) {
+	if t, err := parseTime(value); err == nil {
+		return t, nil
+	}
+	digits := strings.ReplaceAll(value, ":", "")
+	if validateTimeString(digits) != nil || len(digits) < 3 {
+		return time.Time{}, fmt.Errorf("Invalid time: %s. Use HHMM, HH:MM, or \"YYYY-MM-DD HH:MM\"", value)
+	}
+	minutes, _ := stringTimeToMinutes(digits)
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return midnight.Add(time.Duration(minutes) * time.Minute), nil
+}
+
+func replayCmd(until string, apply bool) error {
+	steps, err := journalSteps()
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Println("Nothing to replay.")
+		return nil
+	}
+
+	if until != "" {
+		untilTime, err := parseUntil(until, steps[0].Time)
+		if err != nil {
+			return err
+		}
+		var kept []ReplayStep
+		for _, step := range steps {
+			if !step.Time.After(untilTime) {
+				kept = append(kept, step)
+			}
+		}
+		steps = kept
+
+		// Show the state as it was at that point in time
+		defer useClock(NewFrozenClock(untilTime))()
+	}
+
+	timer, err := runSteps(nil, steps)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Replayed %d commands.\n", len(steps))
+	if err := historyCmd(timer, "", LogOptions{}); err != nil {
+		return err
+	}
+	if err := reportCmd(timer); err != nil {
+		return err
+	}
+
+	if !apply {
+		return nil
+	}
+
+	if !yesOrNoPrompt("Overwrite current timer with replayed state?") {
+		return nil
+	}
+
+	// Keep the current output mode, it isn't always in the journal
+	if current, err := load(); err == nil {
+		timer.Mode = current.Mode
+	}
+	if err := save(timer); err != nil {
+		return err
+	}
+	printMessageIfNotSilent(timer, "Timer state restored from replay.")
+	return nil
+}
+
+// parseSimulationSteps parses "HH:MM command [args]" steps (the time may also
+// be HHMM or "now") into ReplaySteps on the current day, in chronological order.
+func parseSimulationSteps(specs []string) ([]ReplayStep, error) {
+	now := getCurrentTime()
+	last := now
+
+	var steps []ReplayStep
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("Invalid step: %q. Use \"HH:MM command [args]\"", spec)
+		}
+
+		stepTime := now
+		if fields[0] != "now" {
+			var err error
+			if stepTime, err = parseUntil(fields[0], now); err != nil {
+				return nil, err
+			}
+		}
+		if stepTime.Before(last) {
+			return nil, fmt.Errorf("Step %q is earlier than %s. Steps must be in order and not in the past.", spec, last.Format(TIME_ONLY_FORMAT))
+		}
+		last = stepTime
+
+		steps = append(steps, ReplayStep{Time: stepTime, Args: fields[1:]})
+	}
+
+	return steps, nil
+}
+
+func simulateCmd(timer *Timer, specs []string, showLog bool) error {
+	if len(specs) == 0 {
+		return fmt.Errorf("No steps given. Example: wt simulate \"12:00 stop\" \"13:00 start\" \"18:00 stop\"")
+	}
+
+	steps, err := parseSimulationSteps(specs)
+	if err != nil {
+		return err
+	}
+
+	result, err := runSteps(timer, steps)
+	if err != nil {
+		return err
+	}
+
+	// Show the outcome as of the last simulated step
+	defer useClock(NewFrozenClock(steps[len(steps)-1].Time))()
+
+	if showLog {
+		if err := historyCmd(result, "", LogOptions{}); err != nil {
+			return err
+		}
+	}
+	return reportCmd(result)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// A day can end with a short retrospective note (what went well, blockers),
+// kept in Timer.Note and so in the archive, and shown by `wt week` and
+// `wt export`. It's given with --note on close, new, and reset, or asked for
+// when WT_RETRO is set and prompts aren't skipped.
+
+// noteFlag returns the --note flag of the commands ending a day
+func noteFlag() cli.Flag {
+	return &cli.StringFlag{Name: "note", Usage: "Retrospective note for the day being ended (what went well, blockers)"}
+}
+
+// retroPrompt asks for the note, returning "" if it's skipped
+func retroPrompt() string {
+	if setting("WT_SKIP_PROMPTS") != "" || setting("WT_RETRO") == "" {
+		return ""
+	}
+	fmt.Print("Retrospective (what went well, blockers; Enter to skip): ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// addRetroNote sets the note of the day being ended: the given one, or else
+// the answer to the prompt. A note the day already has is kept unless
+// replaced.
+func addRetroNote(timer *Timer, note string) {
+	if timer.DayStart == "" {
+		return
+	}
+	if note == "" {
+		note = retroPrompt()
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		timer.Note = note
+	}
+}
+
+// formatNote renders a note as an indented line of `wt week`, or ""
+func formatNote(note string) string {
+	if note == "" {
+		return ""
+	}
+	return "    Note: " + note + "\n"
+}
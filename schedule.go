@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The work schedule is set with WT_SCHEDULE: start times (HHMM) with optional
+// weekdays, entries separated by ";", e.g.
+//
+//	WT_SCHEDULE="0900 mon-fri"
+//	WT_SCHEDULE="0830 mon-thu; 1000 fri,sat"
+//
+// `wt remind` reminds to start once a scheduled time has passed without a
+// start that day (holidays in WT_HOLIDAYS excepted). With WT_SCHEDULE_ACTION=start it starts the timer instead,
+// backdated to the scheduled time, as long as it runs within
+// ScheduleStartWindowMinutes of it.
+
+const (
+	ScheduleActionRemind       = "remind"
+	ScheduleActionStart        = "start"
+	ScheduleStartWindowMinutes = 60 // Later than this, a missed start is only reminded
+)
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ScheduleEntry is a start time on a set of weekdays
+type ScheduleEntry struct {
+	Minutes int // Minutes after midnight
+	Days    [7]bool
+}
+
+// parseWeekdays parses "mon-fri", "sat,sun", or "tue" into a set of weekdays
+func parseWeekdays(spec string) ([7]bool, error) {
+	var days [7]bool
+	index := func(name string) (int, error) {
+		for i, day := range weekdayNames {
+			if day == strings.ToLower(name) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("Unknown weekday: %s. Use mon, tue, wed, thu, fri, sat, or sun", name)
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := index(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = index(to); err != nil {
+				return days, err
+			}
+		}
+		// Ranges may wrap around the week, e.g. fri-mon
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseSchedule(spec string) ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	for _, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("Invalid schedule entry %q. Use \"HHMM [weekdays]\", e.g. \"0900 mon-fri\"", strings.TrimSpace(part))
+		}
+		if err := validateTimeString(fields[0]); err != nil {
+			return nil, fmt.Errorf("Invalid schedule time %q: %v", fields[0], err)
+		}
+		minutes, _ := stringTimeToMinutes(fields[0])
+		if minutes >= 24*60 {
+			return nil, fmt.Errorf("Invalid schedule time %q", fields[0])
+		}
+
+		entry := ScheduleEntry{Minutes: minutes, Days: [7]bool{true, true, true, true, true, true, true}}
+		if len(fields) == 2 {
+			days, err := parseWeekdays(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			entry.Days = days
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// scheduledStart returns the earliest scheduled start on now's day, if any and
+// it isn't a holiday
+func scheduledStart(now time.Time) (time.Time, bool, error) {
+	spec := setting("WT_SCHEDULE")
+	if spec == "" {
+		return time.Time{}, false, nil
+	}
+	entries, err := parseSchedule(spec)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	found := false
+	earliest := 24 * 60
+	for _, entry := range entries {
+		if entry.Days[now.Weekday()] && entry.Minutes < earliest {
+			earliest = entry.Minutes
+			found = true
+		}
+	}
+	if !found {
+		return time.Time{}, false, nil
+	}
+	if holiday, err := holidayOn(now); err != nil || holiday != "" {
+		return time.Time{}, false, err
+	}
+	return midnight.Add(time.Duration(earliest) * time.Minute), true, nil
+}
+
+// startedToday reports whether the timer has a day start on now's date
+func startedToday(timer *Timer, now time.Time) bool {
+	dayStart, err := parseTime(timer.DayStart)
+	return err == nil && dayStart.Format(DATE_FORMAT) == now.Format(DATE_FORMAT)
+}
+
+// scheduleCmd applies the schedule for `wt remind`. It returns the message to
+// print, or "" when the schedule doesn't apply: no schedule today, its time
+// hasn't come yet, or the timer was already started today.
+func scheduleCmd(timer *Timer) (string, error) {
+	now := getCurrentTime()
+	start, ok, err := scheduledStart(now)
+	if err != nil || !ok || now.Before(start) {
+		return "", err
+	}
+	if startedToday(timer, now) || timer.Status != StatusStopped {
+		return "", nil
+	}
+
+	late := deltaMinutes(start, now)
+	action := setting("WT_SCHEDULE_ACTION")
+	switch action {
+	case "", ScheduleActionRemind:
+	case ScheduleActionStart:
+		if late <= ScheduleStartWindowMinutes {
+			backdate := ""
+			if late > 0 {
+				backdate = fmt.Sprintf("%02d%02d", late/60, late%60)
+			}
+			if timer.DayStart != "" {
+				// Yesterday's timer is still around, start a new day without asking
+				restore := overrideEnv(map[string]string{"WT_SKIP_PROMPTS": "1"})
+				err = restartCmd(backdate)
+				restore()
+			} else {
+				err = startCmd(timer, StartOptions{Time: backdate})
+			}
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Started timer at %s as scheduled.", start.Format(TIME_ONLY_FORMAT)), nil
+		}
+	default:
+		return "", fmt.Errorf("Invalid WT_SCHEDULE_ACTION: %s. Use '%s' or '%s'", action, ScheduleActionRemind, ScheduleActionStart)
+	}
+	return fmt.Sprintf("Scheduled start was %s - forgot to start? wt start", start.Format(TIME_ONLY_FORMAT)), nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// `wt selftest` runs a scripted day through the real commands in a scratch
+// root with the mock clock and compares the totals after every step with
+// values worked out by hand. The user's WT_* settings are cleared for the
+// run, so it checks the build, not the configuration.
+
+const SelftestDay = "2026-03-02"
+
+// selftestStep is a command of the scripted day and what it must lead to
+type selftestStep struct {
+	Time   string // HH:MM on SelftestDay
+	Args   []string
+	Status string
+	Totals DayTotals
+	Output string // Expected in the command's output, if set
+}
+
+var selftestSteps = []selftestStep{
+	{Time: "09:00", Args: []string{"new"}, Status: StatusStopped},
+	{Time: "09:00", Args: []string{"start"}, Status: StatusRunning},
+	{Time: "10:00", Args: []string{"pause"}, Status: StatusPaused, Totals: DayTotals{Work: 60}},
+	{Time: "10:15", Args: []string{"start"}, Status: StatusRunning, Totals: DayTotals{Work: 60, Paused: 15}},
+	{Time: "11:00", Args: []string{"next"}, Status: StatusRunning, Totals: DayTotals{Work: 105, Paused: 15}},
+	{Time: "12:00", Args: []string{"stop"}, Status: StatusStopped, Totals: DayTotals{Work: 165, Paused: 15}},
+	{Time: "12:30", Args: []string{"start"}, Status: StatusRunning, Totals: DayTotals{Work: 165, Break: 30, Paused: 15}},
+	{Time: "13:30", Args: []string{"stop"}, Status: StatusStopped, Totals: DayTotals{Work: 225, Break: 30, Paused: 15}},
+	{Time: "13:30", Args: []string{"mod", "1", "sub", "15"}, Status: StatusStopped, Totals: DayTotals{Work: 210, Break: 30, Paused: 15}},
+	{Time: "13:30", Args: []string{"report"}, Status: StatusStopped, Totals: DayTotals{Work: 210, Break: 30, Paused: 15},
+		Output: SelftestDay + " | 09:00 -> 13:15 | Work: 3h:30m | Break: 0h:30m | Paused: 0h:15m | Total: 4h:15m | Earned: 350.00 EUR"},
+}
+
+// selftestEnv clears the user's WT_* settings and points wt at the scratch root
+func selftestEnv(scratch string) map[string]string {
+	env := map[string]string{}
+	for _, pair := range os.Environ() {
+		if name, _, _ := strings.Cut(pair, "="); strings.HasPrefix(name, "WT_") {
+			env[name] = ""
+		}
+	}
+	env["WT_ROOT"] = scratch
+	env["WT_REPORT_FILE"] = filepath.Join(scratch, "daily-reports")
+	env["WT_SKIP_PROMPTS"] = "1"
+	env["WT_RATE"] = "100 EUR"
+	return env
+}
+
+// selftestTime returns the time of day hhmm ("HH:MM") on SelftestDay
+func selftestTime(hhmm string) time.Time {
+	t, _ := parseTime(SelftestDay + " " + hhmm)
+	return t
+}
+
+// runSelftestStep runs a step at its time on clock and returns what doesn't match
+func runSelftestStep(clock *FrozenClock, step selftestStep) []string {
+	clock.Set(selftestTime(step.Time))
+	output, err := captureOutput(func() error {
+		return newApp().Run(context.Background(), append([]string{"wt"}, step.Args...))
+	})
+	if err != nil {
+		return []string{err.Error()}
+	}
+	timer, err := load()
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	if timer.Status != step.Status {
+		problems = append(problems, fmt.Sprintf("status is %s, expected %s", timer.Status, step.Status))
+	}
+	if totals := timer.Totals(); totals != step.Totals {
+		problems = append(problems, fmt.Sprintf("totals are %s, expected %s", selftestTotals(totals), selftestTotals(step.Totals)))
+	}
+	if step.Output != "" && !strings.Contains(output, step.Output) {
+		problems = append(problems, fmt.Sprintf("output is %q, expected %q", strings.TrimSpace(output), step.Output))
+	}
+	return problems
+}
+
+func selftestTotals(t DayTotals) string {
+	return fmt.Sprintf("work %s, break %s, paused %s",
+		minutesToHourMinuteStr(t.Work), minutesToHourMinuteStr(t.Break), minutesToHourMinuteStr(t.Paused))
+}
+
+func selftestCmd() error {
+	scratch, err := os.MkdirTemp("", "wt-selftest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	restore := overrideEnv(selftestEnv(scratch))
+	defer restore()
+	clock := NewFrozenClock(selftestTime(selftestSteps[0].Time))
+	defer useClock(clock)()
+
+	failed := 0
+	for _, step := range selftestSteps {
+		problems := runSelftestStep(clock, step)
+		command := "wt " + strings.Join(step.Args, " ")
+		if len(problems) == 0 {
+			fmt.Printf("ok   %s %-16s %s\n", step.Time, command, selftestTotals(step.Totals))
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s %s\n", step.Time, command)
+		for _, problem := range problems {
+			fmt.Printf("       %s\n", problem)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("Selftest failed: %d of %d steps didn't match.", failed, len(selftestSteps))
+	}
+	fmt.Printf("Selftest passed: %d steps.\n", len(selftestSteps))
+	return nil
+}
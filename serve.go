@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// `wt serve` exposes the timer over HTTP for dashboards, phones, and other
+// machines. Every endpoint is declared in apiRoutes, which also generates the
+// OpenAPI document (openapi.go). Clients authenticate
+// with a bearer token from WT_API_TOKENS ("token:scope" pairs, scope read or
+// write); without tokens the API only listens on loopback addresses.
+
+const DefaultServeListen = "127.0.0.1:8788"
+
+// Token scopes. Write includes read.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// APIRoute is an endpoint of `wt serve`. Handle returns the value to send as
+// JSON; an error is sent as {"error": ...} with status 400.
+type APIRoute struct {
+	Method   string
+	Path     string
+	Summary  string
+	Scope    string
+	Body     []string // JSON body fields the route accepts, all optional strings
+	Response any      // Zero value of what Handle returns, for the OpenAPI document
+	Handle   func(body map[string]string) (any, error)
+}
+
+// APIStatus is the timer state returned by GET /api/status
+type APIStatus struct {
+	Status       string    `json:"status"`
+	DayStart     string    `json:"day_start,omitempty"`
+	CycleStart   string    `json:"cycle_start,omitempty"` // Of the running or paused cycle
+	PauseStart   string    `json:"pause_start,omitempty"`
+	CycleMinutes int       `json:"cycle_minutes"`
+	CycleTarget  int       `json:"cycle_target,omitempty"` // Minutes, from a preset or pomodoro mode
+	Task         string    `json:"task,omitempty"`
+	Totals       DayTotals `json:"totals"`
+	Check        string    `json:"check"` // The line `wt check` prints
+}
+
+// APIResult is the response of the timer commands
+type APIResult struct {
+	Output string `json:"output"` // What the command printed, if the mode isn't silent
+	Status string `json:"status"`
+}
+
+var apiRoutes = []APIRoute{
+	{Method: http.MethodGet, Path: "/api/status", Summary: "Current timer state and today's totals", Scope: ScopeRead, Response: APIStatus{}, Handle: apiStatus},
+	{Method: http.MethodGet, Path: "/api/log", Summary: "Today's cycles, as `wt log --format json`", Scope: ScopeRead, Response: []LogRecord{}, Handle: apiLog},
+	{Method: http.MethodGet, Path: "/api/week", Summary: "Work per day of the current week, Monday to Sunday", Scope: ScopeRead, Response: []APIDay{}, Handle: apiWeek},
+	{Method: http.MethodPost, Path: "/api/start", Summary: "Start or resume the timer", Scope: ScopeWrite, Body: []string{"time", "preset", "location", "no_break", "break", "task", "estimate"}, Response: APIResult{},
+		Handle: apiCommand("start", func(timer *Timer, body map[string]string) error {
+			return startCmd(timer, StartOptions{Time: body["time"], Preset: body["preset"], Location: body["location"], NoBreak: body["no_break"] == "true", Break: body["break"],
+				Task: body["task"], Estimate: body["estimate"]})
+		})},
+	{Method: http.MethodPost, Path: "/api/pause", Summary: "Pause the running timer", Scope: ScopeWrite, Body: []string{"time", "reason"}, Response: APIResult{},
+		Handle: apiCommand("pause", func(timer *Timer, body map[string]string) error { return pauseCmd(timer, body["time"], body["reason"]) })},
+	{Method: http.MethodPost, Path: "/api/stop", Summary: "Stop the timer", Scope: ScopeWrite, Response: APIResult{},
+		Handle: apiCommand("stop", func(timer *Timer, body map[string]string) error { return stopCmd(timer) })},
+	{Method: http.MethodPost, Path: "/api/next", Summary: "Stop the current cycle and start the next", Scope: ScopeWrite, Response: APIResult{},
+		Handle: apiCommand("next", func(timer *Timer, body map[string]string) error { return nextCmd(timer) })},
+	{Method: http.MethodPost, Path: "/api/toggle", Summary: "Start, pause, or resume, whichever comes next", Scope: ScopeWrite, Response: APIResult{},
+		Handle: apiCommand("toggle", func(timer *Timer, body map[string]string) error { return toggleCmd(timer) })},
+}
+
+// apiMu serializes requests: commands print to (and the API captures) os.Stdout
+var apiMu sync.Mutex
+
+// captureOutput runs fn with os.Stdout redirected and returns what it printed
+func captureOutput(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+
+	runErr := fn()
+	os.Stdout = stdout
+	w.Close()
+	<-done
+	r.Close()
+	return buf.String(), runErr
+}
+
+func apiStatus(body map[string]string) (any, error) {
+	timer, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return timerStatus(timer), nil
+}
+
+func apiLog(body map[string]string) (any, error) {
+	timer, err := load()
+	if err != nil {
+		return nil, err
+	}
+	records := []LogRecord{}
+	for _, entry := range buildLogEntries(timer) {
+		records = append(records, entry.Record())
+	}
+	return records, nil
+}
+
+// apiCommand wraps a timer command as a route handler
+func apiCommand(command string, run func(timer *Timer, body map[string]string) error) func(map[string]string) (any, error) {
+	return func(body map[string]string) (any, error) {
+		var output string
+		err := withStateLock("api "+command, func() error {
+			timer, err := load()
+			if err != nil {
+				return err
+			}
+			output, err = captureOutput(func() error { return run(timer, body) })
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Commands save as they go; reload for the resulting state
+		timer, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return APIResult{Output: strings.TrimSpace(output), Status: timer.Status}, nil
+	}
+}
+
+// apiTokens parses WT_API_TOKENS into token -> scope
+func apiTokens() (map[string]string, error) {
+	tokens := map[string]string{}
+	for _, pair := range strings.Split(setting("WT_API_TOKENS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		token, scope, ok := strings.Cut(pair, ":")
+		if !ok || token == "" || (scope != ScopeRead && scope != ScopeWrite) {
+			return nil, fmt.Errorf("Invalid WT_API_TOKENS entry %q. Use token:read or token:write pairs separated by commas", pair)
+		}
+		tokens[token] = scope
+	}
+	return tokens, nil
+}
+
+// allowed reports whether a token scope grants the route's scope
+func allowed(tokenScope, routeScope string) bool {
+	return tokenScope == ScopeWrite || tokenScope == routeScope
+}
+
+// isLoopback reports whether the listen address only accepts local connections
+func isLoopback(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func apiHandler(tokens map[string]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/openapi.json", openAPIHandler)
+	mux.HandleFunc("GET "+FeedPath, feedHandler(tokens))
+	for _, route := range apiRoutes {
+		mux.HandleFunc(route.Method+" "+route.Path, func(w http.ResponseWriter, r *http.Request) {
+			if len(tokens) > 0 {
+				scope, ok := lookupToken(tokens, bearerToken(r))
+				if !ok {
+					writeJSONError(w, http.StatusUnauthorized, "Invalid or missing bearer token.")
+					return
+				}
+				if !allowed(scope, route.Scope) {
+					writeJSONError(w, http.StatusForbidden, fmt.Sprintf("This token can't %s.", route.Scope))
+					return
+				}
+			}
+
+			body := map[string]string{}
+			if r.ContentLength != 0 && r.Method == http.MethodPost {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+					writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+					return
+				}
+			}
+
+			apiMu.Lock()
+			result, err := route.Handle(body)
+			apiMu.Unlock()
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, result)
+		})
+	}
+	return mux
+}
+
+func serveCmd(listen string, tlsOpts TLSOptions, openAPI, ui bool) error {
+	if openAPI {
+		return openAPICmd()
+	}
+	if err := tlsOpts.validate(); err != nil {
+		return err
+	}
+	tokens, err := apiTokens()
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 && !isLoopback(listen) {
+		return fmt.Errorf("Refusing to serve on %s without authentication. Set WT_API_TOKENS or listen on 127.0.0.1.", listen)
+	}
+
+	auth := "no authentication, local only"
+	if len(tokens) > 0 {
+		auth = fmt.Sprintf("%d tokens", len(tokens))
+	}
+	handler := apiHandler(tokens)
+	if ui {
+		handler = dashboardHandler(handler)
+		auth += ", dashboard at /"
+	}
+	fmt.Printf("Serving the wt API on %s://%s (%s).\n", tlsOpts.scheme(), listen, auth)
+	return listenAndServe(listen, handler, tlsOpts)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// `wt export site <dir>` writes the exported days as a static site: an
+// index.html with a calendar per month and a chart of the monthly work,
+// and a page per day (days/YYYY-MM-DD.html) with its timeline, cycles,
+// pauses, markers, and note. It needs no server, so it can be opened from
+// disk or put behind any private static host.
+
+// siteCell is a day of the calendar; Day is 0 before the 1st and after the last
+type siteCell struct {
+	Day   int
+	Date  string
+	Work  int
+	Level int // 0-4, how much work, for the cell's shade
+}
+
+// siteMonth is a month of the index, newest first
+type siteMonth struct {
+	Name  string
+	Weeks [][]siteCell
+	Work  int
+	Days  int // Days with work
+	Bar   int // Work in percent of the busiest month
+}
+
+// siteSegment is a part of a day page's timeline
+type siteSegment struct {
+	Class   string // work or break
+	Minutes int
+	Title   string
+}
+
+// sitePage is one date of the site, with all the days (profiles) exported for it
+type sitePage struct {
+	Date       string
+	Title      string // e.g. Thursday, 22 January 2026
+	Days       []ExportDay
+	Work       int
+	Break      int
+	Paused     int
+	Timeline   []siteSegment
+	Prev, Next string
+}
+
+// siteLevel buckets a day's work for the calendar shade
+func siteLevel(minutes int) int {
+	switch {
+	case minutes == 0:
+		return 0
+	case minutes < 2*60:
+		return 1
+	case minutes < 4*60:
+		return 2
+	case minutes < 6*60:
+		return 3
+	}
+	return 4
+}
+
+// sitePages groups the days by date, oldest first
+func sitePages(days []ExportDay) []*sitePage {
+	var pages []*sitePage
+	byDate := map[string]*sitePage{}
+	for _, day := range days {
+		page, ok := byDate[day.Date]
+		if !ok {
+			date, _ := time.ParseInLocation(DATE_FORMAT, day.Date, time.Local)
+			page = &sitePage{Date: day.Date, Title: date.Format("Monday, 2 January 2006")}
+			byDate[day.Date] = page
+			pages = append(pages, page)
+		}
+		page.Days = append(page.Days, day)
+		page.Work += day.Work
+		page.Break += day.Break + day.Lunch
+		page.Paused += day.Paused
+		for _, entry := range day.logEntries {
+			page.Timeline = append(page.Timeline, siteSegment{Class: entry.Type, Minutes: max(entry.Minutes+entry.PausedMinutes, 1),
+				Title: entry.Start.Format(TIME_ONLY_FORMAT) + " " + entry.Label + " " + minutesToHourMinuteStr(entry.Minutes)})
+		}
+	}
+	for i, page := range pages {
+		if i > 0 {
+			page.Prev = pages[i-1].Date
+		}
+		if i < len(pages)-1 {
+			page.Next = pages[i+1].Date
+		}
+	}
+	return pages
+}
+
+// siteMonths lays out the calendar of the months with pages, newest first
+func siteMonths(pages []*sitePage) []siteMonth {
+	work := map[string]int{}
+	for _, page := range pages {
+		work[page.Date] = page.Work
+	}
+	var months []siteMonth
+	busiest := 1
+	for i := len(pages) - 1; i >= 0; i-- {
+		date, _ := time.ParseInLocation(DATE_FORMAT, pages[i].Date, time.Local)
+		name := date.Format("January 2006")
+		if len(months) > 0 && months[len(months)-1].Name == name {
+			continue
+		}
+		month := siteMonth{Name: name}
+		first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		week := make([]siteCell, (int(first.Weekday())+6)%7) // Blank days before the 1st, from Monday
+		for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+			key := day.Format(DATE_FORMAT)
+			minutes, tracked := work[key]
+			cell := siteCell{Day: day.Day(), Work: minutes, Level: siteLevel(minutes)}
+			if tracked {
+				cell.Date = key
+			}
+			if minutes > 0 {
+				month.Days++
+			}
+			month.Work += minutes
+			if week = append(week, cell); len(week) == 7 {
+				month.Weeks = append(month.Weeks, week)
+				week = nil
+			}
+		}
+		if len(week) > 0 {
+			month.Weeks = append(month.Weeks, append(week, make([]siteCell, 7-len(week))...))
+		}
+		busiest = max(busiest, month.Work)
+		months = append(months, month)
+	}
+	for i := range months {
+		months[i].Bar = months[i].Work * 100 / busiest
+	}
+	return months
+}
+
+var siteFuncs = template.FuncMap{
+	"hm": minutesToHourMinuteStr,
+	"clock": func(t time.Time) string {
+		return t.Format(TIME_ONLY_FORMAT)
+	},
+	"details": func(entry LogEntry) string {
+		return strings.TrimSpace(formatTask(entry.Task) + formatTags(entry.Tags) + formatLocation(entry.Location) + formatMeetings(entry.Meetings))
+	},
+	"entries": func(day ExportDay) []LogEntry { return day.logEntries },
+	"pauses":  func(entry LogEntry) []PauseRecord { return pauseRecords(entry.Start, entry.Pauses) },
+	"marks":   dayMarks,
+}
+
+const siteStyle = `body { font-family: system-ui, sans-serif; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+a { color: #275; }
+h1 { font-size: 1.4rem; } h2 { font-size: 1.1rem; margin-top: 2rem; }
+table { border-collapse: collapse; font-variant-numeric: tabular-nums; }
+td, th { padding: .2rem .5rem; border-bottom: 1px solid #eee; text-align: left; }
+.calendar td { width: 3.2rem; height: 2.6rem; vertical-align: top; font-size: .8rem; border: 1px solid #fff; }
+.calendar small { display: block; color: #333; }
+.l0 { background: #f4f4f4; } .l1 { background: #d6efdf; } .l2 { background: #a8dcbb; } .l3 { background: #6cc093; } .l4 { background: #3a9f6c; }
+.chart td { border: 0; } .bar { background: #3a9f6c; height: .9rem; }
+.timeline { display: flex; height: 1.6rem; border-radius: 4px; overflow: hidden; margin: 1rem 0; }
+.timeline .work { background: #3a9f6c; } .timeline .break { background: #ccc; }
+nav { display: flex; justify-content: space-between; }
+`
+
+var siteIndex = template.Must(template.New("index").Funcs(siteFuncs).Parse(`<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><title>wt history</title><link rel="stylesheet" href="style.css"></head>
+<body>
+<h1>wt history</h1>
+<p>{{len .Pages}} days, {{hm .Work}} of work.</p>
+<h2>Work per month</h2>
+<table class="chart">{{range .Months}}
+<tr><td>{{.Name}}</td><td style="width: 20rem"><div class="bar" style="width: {{.Bar}}%"></div></td><td>{{hm .Work}}</td></tr>{{end}}
+</table>
+{{range .Months}}
+<h2>{{.Name}}</h2>
+<p>{{hm .Work}} in {{.Days}} days</p>
+<table class="calendar">
+<tr><th>Mon</th><th>Tue</th><th>Wed</th><th>Thu</th><th>Fri</th><th>Sat</th><th>Sun</th></tr>{{range .Weeks}}
+<tr>{{range .}}{{if .Day}}<td class="l{{.Level}}">{{if .Date}}<a href="days/{{.Date}}.html">{{.Day}}</a><small>{{hm .Work}}</small>{{else}}{{.Day}}{{end}}</td>{{else}}<td></td>{{end}}{{end}}</tr>{{end}}
+</table>
+{{end}}
+</body></html>
+`))
+
+var siteDay = template.Must(template.New("day").Funcs(siteFuncs).Parse(`<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><title>{{.Date}}</title><link rel="stylesheet" href="../style.css"></head>
+<body>
+<nav><span>{{if .Prev}}<a href="{{.Prev}}.html">&larr; {{.Prev}}</a>{{end}}</span><a href="../index.html">Calendar</a><span>{{if .Next}}<a href="{{.Next}}.html">{{.Next}} &rarr;</a>{{end}}</span></nav>
+<h1>{{.Title}}</h1>
+<p>Work: {{hm .Work}} | Break: {{hm .Break}} | Paused: {{hm .Paused}}</p>
+<div class="timeline">{{range .Timeline}}<div class="{{.Class}}" style="flex: {{.Minutes}}" title="{{.Title}}"></div>{{end}}</div>
+{{range .Days}}{{if .Profile}}
+<h2>{{.Profile}}</h2>{{end}}
+<table>
+<tr><th>#</th><th>Time</th><th></th><th>Minutes</th><th>Paused</th><th></th></tr>{{range entries .}}
+<tr><td>{{.Num}}</td><td>{{clock .Start}} &ndash; {{clock .End}}</td><td>{{.Label}}</td><td>{{hm .Minutes}}</td><td>{{if .PausedMinutes}}{{hm .PausedMinutes}}{{end}}</td><td>{{details .}}</td></tr>{{range pauses .}}
+<tr><td></td><td>{{slice .Start 11}} &ndash; {{slice .End 11}}</td><td>Paused</td><td></td><td></td><td>{{.Reason}}</td></tr>{{end}}{{end}}
+</table>
+{{with marks .}}<h2>Notes</h2>
+<ul>{{range .}}<li>{{slice .At 11}} {{.Text}}</li>{{end}}</ul>{{end}}
+{{if .Note}}<p><em>{{.Note}}</em></p>{{end}}
+{{end}}
+</body></html>
+`))
+
+// writeExportSite writes the site into dir, creating it if needed
+func writeExportSite(dir string, days []ExportDay) error {
+	pages := sitePages(days)
+	if err := os.MkdirAll(filepath.Join(dir, "days"), 0755); err != nil {
+		return err
+	}
+	write := func(name string, render func(f *os.File) error) error {
+		f, err := createFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if err := render(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	if err := write("style.css", func(f *os.File) error {
+		_, err := f.WriteString(siteStyle)
+		return err
+	}); err != nil {
+		return err
+	}
+	work := 0
+	for _, page := range pages {
+		work += page.Work
+		if err := write(filepath.Join("days", page.Date+".html"), func(f *os.File) error { return siteDay.Execute(f, page) }); err != nil {
+			return err
+		}
+	}
+	return write("index.html", func(f *os.File) error {
+		return siteIndex.Execute(f, map[string]any{"Pages": pages, "Months": siteMonths(pages), "Work": work})
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Commands that change the timer take an exclusive lock on .out/wt.lock, so
+// the CLI, `wt serve`, and the daemon never interleave a load and a save.
+// When the timer changed, the writer records it in .out/wt.changed, which the
+// daemon watches to react right away instead of at its next tick, and keeps
+// the state it replaced for `wt undo` (undo.go).
+
+const (
+	StateLockFile          = "wt.lock"
+	StateChangeFile        = "wt.changed"
+	DefaultLockTimeoutSecs = 5
+)
+
+// mutatingCommands are the top-level commands that run under the state lock
+var mutatingCommands = map[string]bool{
+	"start": true, "stop": true, "pause": true, "next": true, "mod": true,
+	"reset": true, "restart": true, "new": true, "remove": true, "mode": true, "close": true,
+	"remind": true, "replay": true, "import": true, "prune": true, "plan": true, "toggle": true, "mark": true, "rate": true, "tidy": true, "undo": true, "redo": true, "tag": true, "task": true,
+}
+
+// StateChange is the content of .out/wt.changed
+type StateChange struct {
+	Seq     int    `json:"seq"` // Incremented on every change
+	PID     int    `json:"pid"`
+	Command string `json:"command"`
+	Time    string `json:"time"`
+}
+
+// heldLocks counts in-process holders per lock file, so nested commands
+// (replay dispatching through newApp) don't wait for themselves. Callers in
+// one process are serialized by apiMu or run on the main goroutine.
+var (
+	heldLocks   = map[string]int{}
+	heldLocksMu sync.Mutex
+)
+
+// lockStateActions puts the actions of mutatingCommands under the state lock
+func lockStateActions(app *cli.Command) {
+	for _, command := range app.Commands {
+		if !mutatingCommands[command.Name] || command.Action == nil {
+			continue
+		}
+		action, name := command.Action, command.Name
+		command.Action = func(ctx context.Context, cmd *cli.Command) error {
+			return withStateLock("wt "+name, func() error { return action(ctx, cmd) })
+		}
+	}
+}
+
+// withStateLock runs fn holding the state lock and records a StateChange if
+// fn changed the timer
+func withStateLock(command string, fn func() error) error {
+	if readOnly.Load() {
+		return errReadOnly("'" + command + "' would change the timer")
+	}
+	folder, err := outputFolderPath()
+	if err != nil {
+		return err
+	}
+	lockPath := filepath.Join(folder, StateLockFile)
+
+	heldLocksMu.Lock()
+	nested := heldLocks[lockPath] > 0
+	heldLocks[lockPath]++
+	heldLocksMu.Unlock()
+	defer func() {
+		heldLocksMu.Lock()
+		heldLocks[lockPath]--
+		heldLocksMu.Unlock()
+	}()
+	if nested {
+		return fn()
+	}
+
+	if err := acquireLock(lockPath); err != nil {
+		return err
+	}
+	defer os.Remove(lockPath)
+	auditCommand = command
+	defer guardClock(command, true)()
+
+	statePath, _ := outputFilePath()
+	before, _ := os.ReadFile(statePath)
+	runErr := fn()
+	after, _ := os.ReadFile(statePath)
+	if !bytes.Equal(before, after) {
+		recordStateChange(folder, command)
+		journalChange(folder, command, before)
+	}
+	return runErr
+}
+
+// acquireLock creates the lock file, waiting up to WT_LOCK_TIMEOUT seconds
+// for another holder. Locks of processes that died (e.g. exited at a
+// prompt) are taken over.
+func acquireLock(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(time.Duration(envInt("WT_LOCK_TIMEOUT", DefaultLockTimeoutSecs)) * time.Second)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			return file.Close()
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		holder := lockHolder(path)
+		if holder > 0 && !processAlive(holder) && takeOverLock(path, holder) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			if _, err := os.Stat(path + ".takeover"); err == nil {
+				path += ".takeover" // Left by a process that died taking over
+			}
+			return fmt.Errorf("Another wt process (pid %d) is changing the timer. Try again, or remove %s if it's stale.", holder, path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// takeOverLock removes the lock left by holder, a process that died. Two
+// processes finding it at once mustn't both remove it, as the second would
+// remove the lock the first just took, so removing happens under a second
+// lock file, created with O_EXCL like the lock itself, and only while the dead
+// holder still has the lock. It returns false while another process takes
+// the lock over.
+func takeOverLock(path string, holder int) bool {
+	takeover := path + ".takeover"
+	file, err := os.OpenFile(takeover, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return false
+	}
+	file.Close()
+	defer os.Remove(takeover)
+	if lockHolder(path) == holder {
+		os.Remove(path)
+	}
+	return true
+}
+
+// lockHolder returns the pid in the lock file, or 0 while it's being written
+func lockHolder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+func recordStateChange(folder, command string) {
+	change := readStateChange(folder)
+	change = StateChange{Seq: change.Seq + 1, PID: os.Getpid(), Command: command, Time: getCurrentTime().Format(DT_FORMAT)}
+	data, err := json.Marshal(change)
+	if err != nil {
+		return
+	}
+	// Write and rename, so the daemon never reads a partial file
+	tmp := filepath.Join(folder, StateChangeFile+".tmp")
+	if os.WriteFile(tmp, data, 0644) == nil {
+		os.Rename(tmp, filepath.Join(folder, StateChangeFile))
+	}
+}
+
+// readStateChange returns the last recorded change (zero if none)
+func readStateChange(folder string) StateChange {
+	var change StateChange
+	if data, err := os.ReadFile(filepath.Join(folder, StateChangeFile)); err == nil {
+		json.Unmarshal(data, &change)
+	}
+	return change
+}
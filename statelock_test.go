@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// A process that found the lock of a dead one after another process took it
+// over leaves the new lock alone
+func TestStaleLockTakenOverOnce(t *testing.T) {
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("no process to outlive: %v", err)
+	}
+	dead := exited.Process.Pid
+
+	path := filepath.Join(t.TempDir(), StateLockFile)
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n", dead)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := acquireLock(path); err != nil {
+		t.Fatalf("stale lock not taken over: %v", err)
+	}
+	if holder := lockHolder(path); holder != os.Getpid() {
+		t.Fatalf("lock held by %d after the takeover", holder)
+	}
+
+	// Too late: the lock is no longer the dead process's
+	takeOverLock(path, dead)
+	if holder := lockHolder(path); holder != os.Getpid() {
+		t.Fatalf("a late takeover removed the new lock (holder %d)", holder)
+	}
+
+	// While another process takes the lock over, nothing is removed
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n", dead)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".takeover", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if takeOverLock(path, dead) || lockHolder(path) != dead {
+		t.Fatalf("took over during another takeover")
+	}
+}
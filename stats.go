@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// `wt stats --chart N` draws the work of the last N days (today included)
+// as a horizontal bar chart in the terminal, with a marker where each day's
+// goal is. Days without work keep their line, so gaps show.
+// `wt stats --trend` shows rolling averages over the weeks, so slow drift
+// in work per day, start time, and breaks becomes visible.
+
+const (
+	DefaultChartDays = 14
+	MaxChartDays     = 366
+	chartWidth       = 40 // Columns of the longest bar (or goal)
+
+	TrendWindowWeeks = 4 // Weeks averaged by each line of --trend
+	TrendLineWeeks   = 8 // Lines (weeks) shown by --trend
+	trendMinutesStep = 5 // Changes below this many minutes (or percent points for breaks) show as flat
+)
+
+// dayGoalMinutes returns $WT_DAILY_GOAL for weekdays that aren't holidays,
+// 0 for other days, like weeklyGoalMinutes counts them
+func dayGoalMinutes(day time.Time) int {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return 0
+	}
+	if holiday, _ := holidayOn(day); holiday != "" {
+		return 0
+	}
+	return envMinutes("WT_DAILY_GOAL", 0)
+}
+
+// chartBar renders work as a bar of chartWidth columns on a scale where
+// chartWidth is most, with the goal marked if the bar doesn't reach it
+func chartBar(work, goal, most int) string {
+	filled := work * chartWidth / most
+	marker := -1
+	if goal > 0 {
+		marker = min(goal*chartWidth/most, chartWidth-1)
+	}
+	block, mark := glyphs("█", "#"), glyphs("┊", ":")
+	var b strings.Builder
+	for i := range chartWidth {
+		switch {
+		case i < filled:
+			b.WriteString(block)
+		case i == marker:
+			b.WriteString(mark)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// breakPercent returns the share of breaks in the tracked time
+func (s RangeStats) breakPercent() int {
+	if s.Work+s.Break == 0 {
+		return 0
+	}
+	return s.Break * 100 / (s.Work + s.Break)
+}
+
+// trendArrow shows whether value went up or down from previous by at least step
+func trendArrow(value, previous, step int) string {
+	switch {
+	case value-previous >= step:
+		return glyphs("↑", "^")
+	case previous-value >= step:
+		return glyphs("↓", "v")
+	}
+	return glyphs("→", "=")
+}
+
+// statsTrendCmd prints a line per week with the averages of the
+// TrendWindowWeeks weeks up to it, and how they changed from the week before
+func statsTrendCmd(timer *Timer) error {
+	monday := weekStart(getCurrentTime())
+	first := monday.AddDate(0, 0, -7*(TrendLineWeeks+TrendWindowWeeks-1))
+	days, err := loadRangeSummaries(timer, DateRange{From: first, To: monday.AddDate(0, 0, 7)})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d-week rolling averages\n", TrendWindowWeeks)
+	fmt.Printf("%-8s | %-10s | %-7s | %s\n", "Week", "Work/day", "Start", "Breaks")
+	var previous, oldest RangeStats
+	for i := TrendLineWeeks - 1; i >= 0; i-- {
+		end := monday.AddDate(0, 0, 7*(1-i))
+		from := end.AddDate(0, 0, -7*TrendWindowWeeks)
+		var window []DaySummary
+		for _, day := range days {
+			if start, err := parseTime(day.DayStart); err == nil && !start.Before(from) && start.Before(end) {
+				window = append(window, day)
+			}
+		}
+		stats := rangeStats(window)
+		year, week := end.AddDate(0, 0, -7).ISOWeek()
+		label := fmt.Sprintf("%d-W%02d", year, week)
+		if stats.Days == 0 {
+			fmt.Printf("%-8s | %-10s | %-7s | %s\n", label, "-", "-", "-")
+			previous = stats
+			continue
+		}
+		work, start, breaks := minutesToHourMinuteStr(stats.WorkPerDay()), clockStr(stats.StartMinute), fmt.Sprintf("%d%%", stats.breakPercent())
+		if previous.Days > 0 {
+			work += " " + trendArrow(stats.WorkPerDay(), previous.WorkPerDay(), trendMinutesStep)
+			start += " " + trendArrow(stats.StartMinute, previous.StartMinute, trendMinutesStep)
+			breaks += " " + trendArrow(stats.breakPercent(), previous.breakPercent(), 1)
+		}
+		fmt.Printf("%-8s | %-10s | %-7s | %s\n", label, work, start, breaks)
+		if oldest.Days == 0 {
+			oldest = stats
+		}
+		previous = stats
+	}
+
+	if oldest.Days == 0 {
+		fmt.Println("No work tracked in the last weeks.")
+		return nil
+	}
+	if previous.Days > 0 {
+		fmt.Printf("Drift: Work/day %s | Start %s | Breaks %+d points\n", signedDuration(previous.WorkPerDay()-oldest.WorkPerDay()),
+			signedDuration(previous.StartMinute-oldest.StartMinute), previous.breakPercent()-oldest.breakPercent())
+	}
+	return nil
+}
+
+func statsChartCmd(timer *Timer, n int) error {
+	if n < 1 || n > MaxChartDays {
+		return fmt.Errorf("Invalid chart length: %d. Use 1 to %d days.", n, MaxChartDays)
+	}
+	now := getCurrentTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	r := DateRange{From: today.AddDate(0, 0, 1-n), To: today.AddDate(0, 0, 1)}
+	days, err := loadRangeSummaries(timer, r)
+	if err != nil {
+		return err
+	}
+
+	work := map[string]int{}
+	for _, day := range days {
+		work[day.DayStart[:len(DATE_FORMAT)]] += day.Totals.Work
+	}
+	most := 60
+	for day := r.From; day.Before(r.To); day = day.AddDate(0, 0, 1) {
+		most = max(most, work[day.Format(DATE_FORMAT)], dayGoalMinutes(day))
+	}
+
+	total, worked, goalDays, met := 0, 0, 0, 0
+	for day := r.From; day.Before(r.To); day = day.AddDate(0, 0, 1) {
+		minutes, goal := work[day.Format(DATE_FORMAT)], dayGoalMinutes(day)
+		suffix := ""
+		if minutes > 0 {
+			suffix = " " + minutesToHourMinuteStr(minutes)
+			total += minutes
+			worked++
+		}
+		if goal > 0 {
+			goalDays++
+			if minutes >= goal {
+				met++
+				suffix += " " + glyphs("✓", "+")
+			}
+		}
+		fmt.Printf("%s | %s |%s\n", day.Format("Mon "+DATE_FORMAT), chartBar(minutes, goal, most), suffix)
+	}
+
+	summary := fmt.Sprintf("Work: %s in %d days", minutesToHourMinuteStr(total), worked)
+	if worked > 0 {
+		summary += " | Avg: " + minutesToHourMinuteStr(total/worked)
+	}
+	if goalDays > 0 {
+		summary += fmt.Sprintf(" | Goal %s (%s) met on %d of %d days", minutesToHourMinuteStr(envMinutes("WT_DAILY_GOAL", 0)), glyphs("┊", ":"), met, goalDays)
+	}
+	fmt.Println(summary)
+	return nil
+}
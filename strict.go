@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// With WT_STRICT set, save() re-validates the whole timer before writing it
+// and refuses inconsistent states, whatever command produced them. Without
+// it, commands only check what they change.
+
+func strictMode() bool {
+	return setting("WT_STRICT") != ""
+}
+
+// validateTimer returns every inconsistency in the timer: impossible
+// durations, unknown states, and times in the future
+func validateTimer(timer *Timer) []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch timer.Status {
+	case StatusStopped, StatusRunning, StatusPaused:
+	default:
+		add("unknown status %q", timer.Status)
+	}
+	if timer.PausedMinutes < 0 {
+		add("current cycle has %d paused minutes", timer.PausedMinutes)
+	}
+	for i, entry := range timer.Timeline {
+		num := i + 1
+		switch entry.Type {
+		case "work", "break":
+		default:
+			add("cycle %d has unknown type %q", num, entry.Type)
+		}
+		if entry.Minutes < 0 {
+			add("cycle %d (%s) has %d minutes", num, entry.Type, entry.Minutes)
+		}
+		if entry.PausedMinutes < 0 || (entry.Type == "break" && entry.PausedMinutes != 0) {
+			add("cycle %d (%s) has %d paused minutes", num, entry.Type, entry.PausedMinutes)
+		}
+	}
+
+	if timer.DayStart == "" {
+		if len(timer.Timeline) > 0 || timer.Status != StatusStopped {
+			add("cycles recorded without a day start")
+		}
+		return problems
+	}
+	dayStart, err := parseTime(timer.DayStart)
+	if err != nil {
+		add("invalid day start %q", timer.DayStart)
+		return problems
+	}
+
+	now := getCurrentTime()
+	if dayStart.After(now) {
+		add("day starts in the future (%s)", dayStart.Format(TIME_ONLY_FORMAT))
+	}
+	cycleStart := timer.CurrentCycleStart()
+	if len(timer.Timeline) > 0 && cycleStart.After(now) {
+		add("the timeline ends in the future (%s)", cycleStart.Format(TIME_ONLY_FORMAT))
+	}
+
+	switch timer.Status {
+	case StatusStopped:
+		if timer.PauseStartStr != "" {
+			add("stopped timer has a pause start")
+		}
+	case StatusPaused:
+		pauseStart, err := parseTime(timer.PauseStartStr)
+		if err != nil {
+			add("invalid pause start %q", timer.PauseStartStr)
+		} else if pauseStart.Before(cycleStart) || pauseStart.After(now) {
+			add("pause start %s is outside the current cycle", pauseStart.Format(TIME_ONLY_FORMAT))
+		}
+	}
+	if timer.StopDatetimeStr != "" {
+		if stop, err := parseTime(timer.StopDatetimeStr); err != nil {
+			add("invalid stop time %q", timer.StopDatetimeStr)
+		} else if stop.After(now) {
+			add("stop time %s is in the future", stop.Format(TIME_ONLY_FORMAT))
+		}
+	}
+	return problems
+}
+
+// checkStrict returns an error listing the problems if strict mode is on and
+// the timer is inconsistent
+func checkStrict(timer *Timer) error {
+	if !strictMode() {
+		return nil
+	}
+	problems := validateTimer(timer)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Strict mode: not saving an inconsistent timer:\n  - %s", strings.Join(problems, "\n  - "))
+}
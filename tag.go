@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// `wt tag billing` labels the current work cycle, on top of the tags of the
+// active preset. Unlike those, cycle tags end with the cycle: they're kept in
+// Timer.CycleTags while it runs, then with its timeline entry. `wt log` shows
+// them and `wt report --group-by tag` sums up the work per tag.
+
+const NoTag = "(no tag)"
+
+// parseTags returns the tags given as arguments, with or without the + of
+// presets
+func parseTags(args []string) ([]string, error) {
+	var tags []string
+	for _, arg := range args {
+		for _, tag := range strings.Fields(arg) {
+			tag = strings.TrimPrefix(tag, "+")
+			if tag == "" {
+				return nil, fmt.Errorf("Invalid tag: %q", arg)
+			}
+			tags = mergeTags(tags, []string{tag})
+		}
+	}
+	return tags, nil
+}
+
+// cycleTags returns the tags of the active cycle: the preset's and its own
+func cycleTags(timer *Timer) []string {
+	return mergeTags(timer.Tags, timer.CycleTags)
+}
+
+// tagTotals splits the day's totals by tag, like Totals. Cycles with
+// several tags count for each.
+func (t *Timer) tagTotals() map[string]DayTotals {
+	return t.cycleTotals(func(e TimelineEntry) []string {
+		if len(e.Tags) == 0 {
+			return []string{NoTag}
+		}
+		return e.Tags
+	})
+}
+
+func tagCmd(timer *Timer, args []string, remove bool) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	tags, err := parseTags(args)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("Nothing to tag. Usage: wt tag billing [more tags]")
+	}
+	if timer.Status == StatusStopped {
+		fmt.Println("No running cycle to tag. Start the timer first.")
+		logWarning(timer, "tag", args, "No running cycle to tag.")
+		return nil
+	}
+
+	if remove {
+		for _, tag := range tags {
+			if slices.Contains(timer.Tags, tag) && !slices.Contains(timer.CycleTags, tag) {
+				return fmt.Errorf("+%s is a tag of preset %s. Use 'wt start --preset none' to drop it.", tag, timer.Preset)
+			}
+		}
+		timer.CycleTags = slices.DeleteFunc(timer.CycleTags, func(tag string) bool { return slices.Contains(tags, tag) })
+		if len(timer.CycleTags) == 0 {
+			timer.CycleTags = nil
+		}
+	} else {
+		timer.CycleTags = mergeTags(timer.CycleTags, tags)
+	}
+
+	logArgs := args
+	if remove {
+		logArgs = append([]string{"--remove"}, args...)
+	}
+	logCommand(timer, "tag", logArgs, nil)
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	message := "Cycle tags:" + formatTags(cycleTags(timer))
+	if len(cycleTags(timer)) == 0 {
+		message = "Cycle has no tags."
+	}
+	printMessageIfNotSilent(timer, message)
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// `wt start -m "write RFC"` names the task of this and the following cycles
+// of the day (until another -m, or -m none), and `--estimate 2h` attaches an
+// estimate to it. `wt report` shows actual vs. estimated work per task, and
+// `wt report --range ... --group-by task` does so over several days, using
+// the latest estimate of each task.
+
+const (
+	TaskNone = "none" // Clears the task with -m
+	NoTask   = "(no task)"
+)
+
+// parseEstimate parses an estimate given as a duration (2h, 90m, 1h30m) or
+// in HHMM format
+func parseEstimate(s string) (int, error) {
+	minutes := 0
+	if err := validateTimeString(s); err == nil {
+		minutes, _ = stringTimeToMinutes(s)
+	} else if d, err := time.ParseDuration(s); err == nil {
+		minutes = int(d.Minutes())
+	}
+	if minutes <= 0 {
+		return 0, fmt.Errorf("Invalid estimate: %s. Use e.g. 2h, 90m, 1h30m, or 130.", s)
+	}
+	return minutes, nil
+}
+
+// formatTask renders a task as ` "write RFC"`, or ""
+func formatTask(task string) string {
+	if task == "" {
+		return ""
+	}
+	return fmt.Sprintf(" %q", task)
+}
+
+// setTask applies `wt start -m` and `--estimate`, returning the debug log args
+func setTask(timer *Timer, task, estimate string) ([]string, error) {
+	var logArgs []string
+	if task != "" {
+		logArgs = append(logArgs, "-m", task)
+	}
+	if task == TaskNone {
+		task = ""
+	} else {
+		task = cmp.Or(strings.TrimSpace(task), timer.Task)
+	}
+	if estimate != "" {
+		if task == "" {
+			return nil, fmt.Errorf("--estimate needs a task, e.g. wt start -m \"write RFC\" --estimate 2h")
+		}
+		minutes, err := parseEstimate(estimate)
+		if err != nil {
+			return nil, err
+		}
+		if timer.Estimates == nil {
+			timer.Estimates = map[string]int{}
+		}
+		timer.Estimates[task] = minutes
+		logArgs = append(logArgs, "--estimate", estimate)
+	}
+	timer.Task = task
+	return logArgs, nil
+}
+
+// taskTotals splits the day's totals by task, like Totals
+func (t *Timer) taskTotals() map[string]DayTotals {
+	return t.cycleTotals(func(e TimelineEntry) []string { return []string{cmp.Or(e.Task, NoTask)} })
+}
+
+// estimateLine renders actual vs. estimated work of a task:
+// `Estimate "write RFC": 2h:20m of 2h:00m (116%), 0h:20m over`
+func estimateLine(task string, actual, estimate int) string {
+	line := fmt.Sprintf("Estimate %q: %s of %s (%d%%)", task, minutesToHourMinuteStr(actual),
+		minutesToHourMinuteStr(estimate), actual*100/estimate)
+	switch {
+	case actual > estimate:
+		line += ", " + minutesToHourMinuteStr(actual-estimate) + " over"
+	case actual < estimate:
+		line += ", " + minutesToHourMinuteStr(estimate-actual) + " left"
+	}
+	return line
+}
+
+// estimateSummary renders the estimated tasks of the given estimates and
+// work per task, with a total line when there are several
+func estimateSummary(estimates map[string]int, totals map[string]DayTotals) []string {
+	tasks := slices.Sorted(maps.Keys(estimates))
+	var lines []string
+	actualSum, estimateSum := 0, 0
+	for _, task := range tasks {
+		lines = append(lines, estimateLine(task, totals[task].Work, estimates[task]))
+		actualSum += totals[task].Work
+		estimateSum += estimates[task]
+	}
+	if len(tasks) > 1 {
+		lines = append(lines, fmt.Sprintf("Estimated tasks: %s of %s (%d%%)", minutesToHourMinuteStr(actualSum),
+			minutesToHourMinuteStr(estimateSum), actualSum*100/estimateSum))
+	}
+	return lines
+}
+
+// taskStartCmd switches to the task: a stopped timer starts on it, an active
+// cycle without a task becomes the task's, and one on another task ends
+// there so that the next cycle, starting right away, is the task's
+func taskStartCmd(timer *Timer, task, estimate string) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	task = strings.TrimSpace(task)
+	if task == "" || task == TaskNone {
+		return fmt.Errorf("Name the task, e.g. wt task start \"fix login bug\". 'wt start -m none' clears it.")
+	}
+	if timer.Status == StatusStopped {
+		commandVia = "task"
+		defer func() { commandVia = "" }()
+		return startCmd(timer, StartOptions{Task: task, Estimate: estimate})
+	}
+	if task == timer.Task && estimate == "" {
+		fmt.Printf("Already on %q.\n", task)
+		logWarning(timer, "task", []string{"start", task}, "Already on the task.")
+		return nil
+	}
+
+	message := fmt.Sprintf("Cycle %d is on %q now.", len(timer.Timeline)+1, task)
+	if timer.Task != "" && task != timer.Task {
+		previous := timer.Task
+		commandVia = "task"
+		err := stopCmd(timer)
+		commandVia = ""
+		if err != nil {
+			return err
+		}
+		if timer, err = load(); err != nil {
+			return err
+		}
+		// Like next: the new cycle starts right away
+		timer.Timeline = append(timer.Timeline, TimelineEntry{Type: "break", Minutes: 0})
+		timer.StopDatetimeStr = ""
+		timer.PauseStartStr = getCurrentTime().Format(DT_FORMAT)
+		timer.PausedMinutes = 0
+		timer.Status = StatusRunning
+		message = fmt.Sprintf("Switched from %q to %q.", previous, task)
+	}
+
+	logArgs, err := setTask(timer, task, estimate)
+	if err != nil {
+		return err
+	}
+	logCommand(timer, "task", append([]string{"start"}, logArgs...), nil)
+	if err := save(timer); err != nil {
+		return err
+	}
+	printMessageIfNotSilent(timer, message)
+	printCheckIfVerbose(timer)
+	return nil
+}
+
+// taskListCmd shows the day's work per task, in the order the tasks were
+// first worked on, with the estimate where there is one
+func taskListCmd(timer *Timer) error {
+	var tasks []string
+	work, cycles := map[string]int{}, map[string]int{}
+	for _, entry := range buildLogEntries(timer) {
+		if entry.Type != "work" {
+			continue
+		}
+		task := cmp.Or(entry.Task, NoTask)
+		if _, ok := work[task]; !ok {
+			tasks = append(tasks, task)
+		}
+		work[task] += entry.Minutes
+		cycles[task]++
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No work recorded today. Start on a task with: wt task start \"fix login bug\"")
+		return nil
+	}
+
+	width := 0
+	for _, task := range tasks {
+		width = max(width, len(task))
+	}
+	active := timer.Status == StatusRunning || timer.Status == StatusPaused
+	for _, task := range tasks {
+		current := " "
+		if active && task == cmp.Or(timer.Task, NoTask) {
+			current = "*"
+		}
+		line := fmt.Sprintf("%s %-*s | Work: %s | Cycles: %d", current, width, task, minutesToHourMinuteStr(work[task]), cycles[task])
+		if estimate := timer.Estimates[task]; estimate > 0 {
+			line += fmt.Sprintf(" | Estimate: %s (%d%%)", minutesToHourMinuteStr(estimate), work[task]*100/estimate)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
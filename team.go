@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Team mode: `wt server` collects days submitted by each member's `wt team
+// submit` and serves them back for `wt team report`. Members authenticate with
+// a bearer token; the server maps tokens to user names with WT_TEAM_TOKENS
+// ("alice:token1,bob:token2"). Clients set WT_TEAM_SERVER and WT_TEAM_TOKEN.
+// Every member sees everyone's days, so only the day totals are shared: no
+// cycles, tags, tasks, notes, or earnings.
+
+const (
+	TeamFolder        = "team"
+	DefaultTeamListen = ":8787"
+	teamDaysPath      = "/team/days"
+	teamMaxBodyBytes  = 8 << 20 // Years of days
+)
+
+// TeamDay is a submitted day with the member it belongs to
+type TeamDay struct {
+	User string `json:"user"`
+	ExportDay
+}
+
+var teamUserPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// teamShared keeps what the team sees of a day: its times and totals
+func teamShared(day ExportDay) ExportDay {
+	return ExportDay{Date: day.Date, Start: day.Start, End: day.End, Work: day.Work, Break: day.Break, Lunch: day.Lunch, Paused: day.Paused, Entries: []LogRecord{}}
+}
+
+// teamTokens parses WT_TEAM_TOKENS into token -> user
+func teamTokens() (map[string]string, error) {
+	tokens := map[string]string{}
+	for _, pair := range strings.Split(setting("WT_TEAM_TOKENS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		user, token, ok := strings.Cut(pair, ":")
+		if !ok || token == "" || !teamUserPattern.MatchString(user) {
+			return nil, fmt.Errorf("Invalid WT_TEAM_TOKENS entry %q. Use user:token pairs separated by commas", pair)
+		}
+		tokens[token] = user
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("No team members configured. Set WT_TEAM_TOKENS, e.g. \"alice:token1,bob:token2\"")
+	}
+	return tokens, nil
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// lookupToken finds the token's user, comparing in constant time
+func lookupToken(tokens map[string]string, token string) (string, bool) {
+	user, found := "", false
+	for known, name := range tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			user, found = name, true
+		}
+	}
+	return user, found
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// teamStore keeps each member's days in .out/team/<user>.json, keyed by date
+type teamStore struct {
+	mu     sync.Mutex
+	folder string
+}
+
+func (s *teamStore) path(user string) string {
+	return filepath.Join(s.folder, user+".json")
+}
+
+func (s *teamStore) read(user string) (map[string][]ExportDay, error) {
+	days := map[string][]ExportDay{}
+	data, err := os.ReadFile(s.path(user))
+	if os.IsNotExist(err) {
+		return days, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("%s: %v", s.path(user), err)
+	}
+	return days, nil
+}
+
+// submit replaces the member's days on the submitted dates, so resubmitting is safe
+func (s *teamStore) submit(user string, submitted []ExportDay) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days, err := s.read(user)
+	if err != nil {
+		return err
+	}
+	replaced := map[string]bool{}
+	for _, day := range submitted {
+		if !replaced[day.Date] {
+			days[day.Date] = nil
+			replaced[day.Date] = true
+		}
+		days[day.Date] = append(days[day.Date], day)
+	}
+
+	data, err := json.MarshalIndent(days, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.folder, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(user), data, 0644)
+}
+
+// days returns every member's days with from <= date <= to, by user and date
+func (s *teamStore) days(from, to string) ([]TeamDay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.folder, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	result := []TeamDay{}
+	for _, file := range files {
+		user := strings.TrimSuffix(filepath.Base(file), ".json")
+		days, err := s.read(user)
+		if err != nil {
+			return nil, err
+		}
+		for date, entries := range days {
+			if date < from || date > to {
+				continue
+			}
+			for _, day := range entries {
+				result = append(result, TeamDay{User: user, ExportDay: teamShared(day)}) // Also for days submitted with details
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].User != result[j].User {
+			return result[i].User < result[j].User
+		}
+		return result[i].Date < result[j].Date
+	})
+	return result, nil
+}
+
+// teamHandler serves POST (submit own days) and GET (everyone's days) on teamDaysPath
+func teamHandler(store *teamStore, tokens map[string]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(teamDaysPath, func(w http.ResponseWriter, r *http.Request) {
+		user, ok := lookupToken(tokens, bearerToken(r))
+		if !ok {
+			writeJSONError(w, http.StatusUnauthorized, "Invalid or missing bearer token.")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			var days []ExportDay
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, teamMaxBodyBytes)).Decode(&days); err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Submit at most %d MB at once.", teamMaxBodyBytes>>20))
+					return
+				}
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+				return
+			}
+			for i, day := range days {
+				if _, err := time.Parse(DATE_FORMAT, day.Date); err != nil {
+					writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date: %q", day.Date))
+					return
+				}
+				days[i] = teamShared(day)
+			}
+			if err := store.submit(user, days); err != nil {
+				writeJSONError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"user": user, "days": len(days)})
+		case http.MethodGet:
+			from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+			if to == "" {
+				to = "9999-12-31"
+			}
+			days, err := store.days(from, to)
+			if err != nil {
+				writeJSONError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, days)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJSONError(w, http.StatusMethodNotAllowed, "Use GET or POST.")
+		}
+	})
+	return mux
+}
+
+func serverCmd(listen string, tlsOpts TLSOptions) error {
+	if err := tlsOpts.validate(); err != nil {
+		return err
+	}
+	tokens, err := teamTokens()
+	if err != nil {
+		return err
+	}
+	folder, err := outputFolderPath()
+	if err != nil {
+		return err
+	}
+	store := &teamStore{folder: filepath.Join(folder, TeamFolder)}
+
+	fmt.Printf("Team server listening on %s://%s (%d members).\n", tlsOpts.scheme(), listen, len(tokens))
+	return listenAndServe(listen, teamHandler(store, tokens), tlsOpts)
+}
+
+// teamRequest calls the WT_TEAM_SERVER with WT_TEAM_TOKEN, decoding the JSON response into out
+func teamRequest(method, path string, body, out any) error {
+	server := strings.TrimRight(setting("WT_TEAM_SERVER"), "/")
+	token := setting("WT_TEAM_TOKEN")
+	if server == "" || token == "" {
+		return fmt.Errorf("Set WT_TEAM_SERVER (e.g. http://host:8787) and WT_TEAM_TOKEN to use the team server.")
+	}
+	return jsonRequestVia(pinnedClient(), "Team server", method, server+path, token, body, out)
+}
+
+// jsonRequest sends body as JSON with a bearer token (if any) and decodes the
+// JSON response into out. Error responses ({"error": ...}) and connection
+// failures are reported with the service's name.
+func jsonRequest(service, method, url, token string, body, out any) error {
+	return jsonRequestVia(httpClient(), service, method, url, token, body, out)
+}
+
+// jsonRequestVia is jsonRequest with another client, e.g. the daemon's
+func jsonRequestVia(client *http.Client, service, method, url, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s unreachable: %v", service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return fmt.Errorf("%s: %s", service, apiErr.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// teamSubmitCmd sends the range's days to the team server. Earnings stay
+// private: only the time is shared.
+func teamSubmitCmd(timer *Timer, rangeName string) error {
+	r, err := parseDateRange(rangeName)
+	if err != nil {
+		return err
+	}
+	timers, err := loadRange(timer, r)
+	if err != nil {
+		return err
+	}
+	days := exportDays(timers, "")
+	for i := range days {
+		days[i] = teamShared(days[i])
+	}
+
+	var result struct {
+		User string `json:"user"`
+		Days int    `json:"days"`
+	}
+	if err := teamRequest(http.MethodPost, teamDaysPath, days, &result); err != nil {
+		return err
+	}
+	fmt.Printf("Submitted %d days as %s.\n", result.Days, result.User)
+	return nil
+}
+
+func teamReportCmd(rangeName string) error {
+	r, err := parseDateRange(rangeName)
+	if err != nil {
+		return err
+	}
+	last := r.To.AddDate(0, 0, -1)
+	path := fmt.Sprintf("%s?from=%s&to=%s", teamDaysPath, r.From.Format(DATE_FORMAT), last.Format(DATE_FORMAT))
+
+	var days []TeamDay
+	if err := teamRequest(http.MethodGet, path, nil, &days); err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		fmt.Printf("No team work submitted for %s.\n", r.Name)
+		return nil
+	}
+
+	var users []string
+	totals := map[string]DayTotals{}
+	dates := map[string]map[string]bool{}
+	for _, day := range days {
+		if _, ok := totals[day.User]; !ok {
+			users = append(users, day.User)
+			dates[day.User] = map[string]bool{}
+		}
+		t := totals[day.User]
+		t.Add(DayTotals{Work: day.Work, Break: day.Break, Lunch: day.Lunch, Paused: day.Paused})
+		totals[day.User] = t
+		dates[day.User][day.Date] = true
+	}
+
+	width := len("Total")
+	for _, user := range users {
+		width = max(width, len(user))
+	}
+	line := func(label string, t DayTotals, dayCount int) {
+		avg := 0
+		if dayCount > 0 {
+			avg = t.Work / dayCount
+		}
+		fmt.Printf("%-*s | Work: %s | %s | Days: %d | Avg: %s\n",
+			width, label, minutesToHourMinuteStr(t.Work), t.breakSummary(), dayCount, minutesToHourMinuteStr(avg))
+	}
+
+	fmt.Printf("Team %s (%s..%s)\n", r.Name, r.From.Format(DATE_FORMAT), last.Format(DATE_FORMAT))
+	var grand DayTotals
+	memberDays := 0
+	for _, user := range users {
+		line(user, totals[user], len(dates[user]))
+		grand.Add(totals[user])
+		memberDays += len(dates[user])
+	}
+	if len(users) > 1 {
+		line("Total", grand, memberDays)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// With WT_TELEGRAM_TOKEN (from @BotFather) and WT_TELEGRAM_USER_ID set, the
+// daemon runs a Telegram bot: it answers /status and runs /start, /pause,
+// /stop, and /next for that user only, and reminders are sent to them.
+// WT_TELEGRAM_API points at a self-hosted Bot API server instead of Telegram's.
+
+const (
+	DefaultTelegramAPI  = "https://api.telegram.org"
+	TelegramPollSeconds = 25 // Long-poll timeout, below httpClient's
+	telegramMaxBackoff  = time.Minute
+)
+
+type telegramBot struct {
+	api    string
+	token  string
+	userID int64
+	offset int64
+}
+
+type telegramMessage struct {
+	From struct {
+		ID int64 `json:"id"`
+	} `json:"from"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text string `json:"text"`
+}
+
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+// telegramConfig returns the configured bot, or nil if Telegram isn't set up
+func telegramConfig() (*telegramBot, error) {
+	token, user := setting("WT_TELEGRAM_TOKEN"), setting("WT_TELEGRAM_USER_ID")
+	if token == "" && user == "" {
+		return nil, nil
+	}
+	userID, err := strconv.ParseInt(user, 10, 64)
+	if token == "" || err != nil {
+		return nil, fmt.Errorf("Set both WT_TELEGRAM_TOKEN and WT_TELEGRAM_USER_ID (your numeric Telegram user id) to use the Telegram bot")
+	}
+	api := strings.TrimRight(setting("WT_TELEGRAM_API"), "/")
+	if api == "" {
+		api = DefaultTelegramAPI
+	}
+	return &telegramBot{api: api, token: token, userID: userID}, nil
+}
+
+// call invokes a Bot API method, decoding its result into out (if not nil)
+func (b *telegramBot) call(method string, params, out any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient().Post(b.api+"/bot"+b.token+"/"+method, "application/json", bytes.NewReader(data))
+	if err != nil {
+		// The URL contains the token; keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("Telegram %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("Telegram %s: %s", method, resp.Status)
+	}
+	if !result.OK {
+		return fmt.Errorf("Telegram %s: %s", method, result.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}
+
+func (b *telegramBot) send(chatID int64, text string) error {
+	return b.call("sendMessage", map[string]any{"chat_id": chatID, "text": text}, nil)
+}
+
+// updates long-polls for new messages
+func (b *telegramBot) updates() ([]telegramUpdate, error) {
+	var updates []telegramUpdate
+	err := b.call("getUpdates", map[string]any{"offset": b.offset, "timeout": TelegramPollSeconds, "allowed_updates": []string{"message"}}, &updates)
+	for _, update := range updates {
+		b.offset = max(b.offset, update.UpdateID+1)
+	}
+	return updates, err
+}
+
+// run answers messages until done is closed
+func (b *telegramBot) run(d *daemon) {
+	backoff := time.Second
+	for {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+		updates, err := b.updates()
+		if err != nil {
+			d.logf("Error: %v", err)
+			select {
+			case <-d.done:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, telegramMaxBackoff)
+			continue
+		}
+		backoff = time.Second
+
+		for _, update := range updates {
+			message := update.Message
+			if message == nil || message.Text == "" {
+				continue
+			}
+			if message.From.ID != b.userID {
+				d.logf("Telegram: ignored a message from user %d.", message.From.ID)
+				continue
+			}
+			if err := b.send(message.Chat.ID, telegramReply(message.Text)); err != nil {
+				d.logf("Error: %v", err)
+			}
+		}
+	}
+}
+
+// telegramHelp answers anything that isn't a bot command
+const telegramHelp = "Commands: /status, /start [HHMM], /pause [HHMM], /stop, /next"
+
+// telegramReply runs a bot command through the API routes and describes the result
+func telegramReply(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return telegramHelp
+	}
+	command, _, _ := strings.Cut(fields[0], "@") // "/stop@my_wt_bot" in groups
+	body := map[string]string{}
+	if len(fields) > 1 {
+		body["time"] = fields[1]
+	}
+
+	var route *APIRoute
+	switch command {
+	case "/status", "/start", "/pause", "/stop", "/next":
+		for i := range apiRoutes {
+			if apiRoutes[i].Path == "/api/"+strings.TrimPrefix(command, "/") {
+				route = &apiRoutes[i]
+			}
+		}
+	}
+	if route == nil {
+		return telegramHelp
+	}
+
+	apiMu.Lock()
+	result, err := route.Handle(body)
+	apiMu.Unlock()
+	if err != nil {
+		return err.Error()
+	}
+	switch result := result.(type) {
+	case APIStatus:
+		return result.Check
+	case APIResult:
+		if result.Output != "" {
+			return result.Output
+		}
+		return "Timer is " + result.Status + "."
+	}
+	return "Done."
+}
+
+// notifyTelegram sends a reminder to WT_TELEGRAM_USER_ID
+func notifyTelegram(title, body string) error {
+	bot, err := telegramConfig()
+	if err != nil {
+		return err
+	}
+	return bot.send(bot.userID, title+": "+body)
+}
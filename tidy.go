@@ -0,0 +1,54 @@
+package main
+
+import "fmt"
+
+// `wt tidy` merges all breaks shorter than --min-break into the work
+// around them in one pass, for days fragmented by quick next/stop toggles.
+// Each break is dropped like `wt mod <n> drop` drops it, so its time
+// becomes work, and pauses, markers, and ratings move along.
+
+// tidyBreaks returns the indexes of the short breaks that have work on
+// both sides (or the running cycle after them), last first
+func tidyBreaks(timer *Timer, minBreak int) []int {
+	active := timer.Status == StatusRunning || timer.Status == StatusPaused
+	var breaks []int
+	for i := len(timer.Timeline) - 1; i > 0; i-- {
+		entry := timer.Timeline[i]
+		if entry.Type != "break" || entry.Minutes >= minBreak || timer.Timeline[i-1].Type != "work" {
+			continue
+		}
+		last := i == len(timer.Timeline)-1
+		if (last && active) || (!last && timer.Timeline[i+1].Type == "work") {
+			breaks = append(breaks, i)
+		}
+	}
+	return breaks
+}
+
+func tidyCmd(timer *Timer, minBreak int) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	args := []string{"--min-break", fmt.Sprint(minBreak)}
+	breaks := tidyBreaks(timer, minBreak)
+	if len(breaks) == 0 {
+		message := fmt.Sprintf("No breaks shorter than %s between work cycles.", minutesToHourMinuteStr(minBreak))
+		logCommand(timer, "tidy", args, map[string]int{"merged": 0})
+		printMessageIfNotSilent(timer, message)
+		return nil
+	}
+
+	// Last first, so merging doesn't move the breaks still to go
+	minutes := 0
+	for _, i := range breaks {
+		minutes += timer.Timeline[i].Minutes
+		dropEntry(timer, i)
+	}
+
+	logCommand(timer, "tidy", args, map[string]int{"merged": len(breaks), "minutes": minutes})
+	if err := save(timer); err != nil {
+		return err
+	}
+	printMessageIfNotSilent(timer, fmt.Sprintf("Merged %d breaks (%s) into work.", len(breaks), minutesToHourMinuteStr(minutes)))
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Named timers track several projects (clients) side by side in one root.
+// `wt --timer clientA <command>` (or $WT_TIMER) runs the command on the timer
+// in .out/timers/clientA, which has everything a root's .out holds: its own
+// wt.json, debug log, daily report, archive, profiles, and lock, so timers
+// don't wait for each other. Without a name, the root's own timer is used.
+// `wt list` shows all timers of the root with their status and work today.
+
+const (
+	TimersFolder     = "timers"
+	DefaultTimerName = "default"
+)
+
+var timerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// timerName returns the name of the selected timer, or "" for the root's own.
+// Read from the environment only, as profiles live in the timer's folder.
+func timerName() string {
+	return os.Getenv("WT_TIMER")
+}
+
+// validateTimerName refuses names that aren't a plain folder name
+func validateTimerName(name string) error {
+	if !timerNamePattern.MatchString(name) || name == DefaultTimerName {
+		return fmt.Errorf("Invalid timer name: %s. Use letters, digits, - and _ (and not %q).", name, DefaultTimerName)
+	}
+	return nil
+}
+
+// timerFolderPath returns the output folder of the named timer, or of the
+// root's own timer for ""
+func timerFolderPath(name string) (string, error) {
+	root, err := projectRootPath()
+	if err != nil {
+		return "", err
+	}
+	folder := filepath.Join(root, OutputFolder)
+	if name == "" {
+		return folder, nil
+	}
+	return filepath.Join(folder, TimersFolder, name), nil
+}
+
+// timerNames returns the names of the root's timers, "" (its own) first if
+// it has one
+func timerNames() ([]string, error) {
+	folder, err := timerFolderPath("")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if _, err := os.Stat(filepath.Join(folder, OutputFileName)); err == nil {
+		names = append(names, "")
+	}
+	entries, err := os.ReadDir(filepath.Join(folder, TimersFolder))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries { // Sorted by name
+		if _, err := os.Stat(filepath.Join(folder, TimersFolder, entry.Name(), OutputFileName)); entry.IsDir() && err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func listCmd() error {
+	names, err := timerNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("No timers yet. Run 'wt new' for the root's timer, or 'wt --timer <name> new' for a named one.")
+		return nil
+	}
+
+	selected := timerName()
+	restore := overrideEnv(map[string]string{"WT_TIMER": ""})
+	defer restore()
+	width := len(DefaultTimerName)
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	for _, name := range names {
+		os.Setenv("WT_TIMER", name)
+		timer, err := load()
+		if err != nil {
+			return fmt.Errorf("Timer %s: %v", name, err)
+		}
+		current := " "
+		if name == selected {
+			current = "*"
+		}
+		status := timer.Status
+		if timer.isClosed() {
+			status = "closed"
+		}
+		line := fmt.Sprintf("%s %-*s | %-7s | Work: %s", current, width, cmp.Or(name, DefaultTimerName), status, minutesToHourMinuteStr(timer.Totals().Work))
+		if timer.Task != "" && (timer.Status == StatusRunning || timer.Status == StatusPaused) {
+			line += " | " + timer.Task
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
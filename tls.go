@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// TLS for `wt serve` and `wt server`: either a provided certificate and key,
+// or a self-signed certificate generated once into .out/tls. Clients of a
+// self-signed server pin its fingerprint with WT_TLS_FINGERPRINT.
+
+const (
+	TLSFolder          = "tls"
+	SelfSignedValidity = 365 * 24 * time.Hour
+)
+
+// TLSOptions selects how a server is secured. The zero value serves plain HTTP.
+type TLSOptions struct {
+	SelfSigned bool
+	CertFile   string
+	KeyFile    string
+}
+
+// tlsFlags are the TLS flags of the server commands, read back with tlsOptions
+func tlsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{Name: "tls", Usage: "Serve HTTPS with a self-signed certificate (kept in .out/tls)"},
+		&cli.StringFlag{Name: "cert", Usage: "Serve HTTPS with this PEM certificate (needs --key)"},
+		&cli.StringFlag{Name: "key", Usage: "PEM private key for --cert"},
+	}
+}
+
+func tlsOptions(cmd *cli.Command) TLSOptions {
+	return TLSOptions{SelfSigned: cmd.Bool("tls"), CertFile: cmd.String("cert"), KeyFile: cmd.String("key")}
+}
+
+func (o TLSOptions) enabled() bool {
+	return o.SelfSigned || o.CertFile != "" || o.KeyFile != ""
+}
+
+func (o TLSOptions) scheme() string {
+	if o.enabled() {
+		return "https"
+	}
+	return "http"
+}
+
+// validate checks the flag combination, before the server announces itself
+func (o TLSOptions) validate() error {
+	if o.SelfSigned && (o.CertFile != "" || o.KeyFile != "") {
+		return fmt.Errorf("Use either --tls (self-signed) or --cert and --key, not both")
+	}
+	if !o.SelfSigned && (o.CertFile == "") != (o.KeyFile == "") {
+		return fmt.Errorf("--cert and --key must be given together")
+	}
+	return nil
+}
+
+// certFingerprint is the SHA-256 of the DER certificate, as colon-separated hex
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	hexSum := strings.ToUpper(hex.EncodeToString(sum[:]))
+	var pairs []string
+	for i := 0; i < len(hexSum); i += 2 {
+		pairs = append(pairs, hexSum[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+// selfSignedCert returns the certificate in .out/tls, creating (or renewing)
+// it when missing or about to expire
+func selfSignedCert(listen string) (certFile, keyFile string, err error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", "", err
+	}
+	folder = filepath.Join(folder, TLSFolder)
+	certFile, keyFile = filepath.Join(folder, "cert.pem"), filepath.Join(folder, "key.pem")
+
+	if pair, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		if cert, err := x509.ParseCertificate(pair.Certificate[0]); err == nil && time.Now().Add(7*24*time.Hour).Before(cert.NotAfter) {
+			return certFile, keyFile, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "wt"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(SelfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if host, _, err := net.SplitHostPort(listen); err == nil && host != "" {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "localhost" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(folder, 0700); err != nil {
+		return "", "", err
+	}
+	if err := writeFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return "", "", err
+	}
+	if err := writeFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// listenAndServe serves handler on listen, over TLS if opts ask for it
+func listenAndServe(listen string, handler http.Handler, opts TLSOptions) error {
+	if !opts.enabled() {
+		return http.ListenAndServe(listen, handler)
+	}
+
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	certFile, keyFile := opts.CertFile, opts.KeyFile
+	if opts.SelfSigned {
+		var err error
+		if certFile, keyFile, err = selfSignedCert(listen); err != nil {
+			return err
+		}
+	}
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("Cannot load TLS certificate: %v", err)
+	}
+	if opts.SelfSigned {
+		fmt.Printf("Self-signed certificate fingerprint (set as WT_TLS_FINGERPRINT on clients):\n%s\n", certFingerprint(pair.Certificate[0]))
+	}
+
+	server := &http.Server{
+		Addr:      listen,
+		Handler:   handler,
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{pair}},
+	}
+	return server.ListenAndServeTLS("", "")
+}
+
+// httpClient returns the client for talking to other services, which are
+// verified as usual
+func httpClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// pinnedClient returns the client for talking to wt servers (wt server and
+// wt serve). With WT_TLS_FINGERPRINT set, the server certificate is accepted
+// only if its fingerprint matches, which is how self-signed servers are
+// trusted.
+func pinnedClient() *http.Client {
+	client := httpClient()
+	pin := strings.ToUpper(strings.ReplaceAll(setting("WT_TLS_FINGERPRINT"), " ", ""))
+	if pin == "" {
+		return client
+	}
+	client.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, // Replaced by the pin check below
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) > 0 && certFingerprint(rawCerts[0]) == pin {
+					return nil
+				}
+				return fmt.Errorf("server certificate doesn't match WT_TLS_FINGERPRINT")
+			},
+		},
+	}
+	return client
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Every command run from the CLI is traced: how long it took and which files
+// it read and wrote. The trace is written to the debug log as a debug-level
+// entry, so `WT_LOG_LEVEL=debug` shows why a command is slow (a network
+// filesystem, a big archive scan, a lock wait). Commands running read-only,
+// like `wt check`, aren't logged (see readonly.go) unless --trace (or
+// $WT_TRACE) asks for their trace, which is then their only write.
+
+// commandTrace collects the file accesses of the running command
+var commandTrace struct {
+	mu     sync.Mutex
+	active int // Nesting depth; commands dispatched in-process are part of the outer trace
+	read   []string
+	wrote  []string
+}
+
+// traceActions times every command action and logs its trace when it returns
+func traceActions(app *cli.Command) {
+	var wrap func(command *cli.Command, name string)
+	wrap = func(command *cli.Command, name string) {
+		if command.Action != nil {
+			command.Action = tracedAction(cmp.Or(name, "check"), command.Action)
+		}
+		for _, sub := range command.Commands {
+			wrap(sub, strings.TrimSpace(name+" "+sub.Name))
+		}
+	}
+	wrap(app, "")
+}
+
+func tracedAction(name string, action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		commandTrace.mu.Lock()
+		nested := commandTrace.active > 0
+		if !nested {
+			commandTrace.read, commandTrace.wrote = nil, nil
+		}
+		commandTrace.active++
+		commandTrace.mu.Unlock()
+
+		start := time.Now()
+		err := action(ctx, cmd)
+		elapsed := time.Since(start)
+
+		commandTrace.mu.Lock()
+		commandTrace.active--
+		read, wrote := commandTrace.read, commandTrace.wrote
+		commandTrace.mu.Unlock()
+		if nested {
+			return err
+		}
+
+		entry := DebugEntry{
+			Level:      LevelDebug,
+			Command:    name,
+			Args:       cmd.Args().Slice(),
+			DurationMs: float64(elapsed.Microseconds()) / 1000,
+			Read:       read,
+			Wrote:      wrote,
+		}
+		if err != nil {
+			entry.Message = err.Error()
+		}
+		if setting("WT_TRACE") != "" {
+			appendDebugEntry(entry)
+		} else {
+			writeDebugEntry(entry)
+		}
+		return err
+	}
+}
+
+// traceFile records a file access ("read" or "write") for the running
+// command, relative to the project root when it's inside it
+func traceFile(op, path string) {
+	if root, err := projectRootPath(); err == nil {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+
+	commandTrace.mu.Lock()
+	defer commandTrace.mu.Unlock()
+	if commandTrace.active == 0 {
+		return
+	}
+	files := &commandTrace.read
+	if op == "write" {
+		files = &commandTrace.wrote
+	}
+	if !slices.Contains(*files, path) {
+		*files = append(*files, path)
+	}
+}
+
+// readFile is os.ReadFile, traced, and answered from the read cache during
+// check and status (see readonly.go)
+func readFile(path string) ([]byte, error) {
+	data, err := cachedRead(path, os.ReadFile)
+	if err == nil {
+		traceFile("read", path)
+	}
+	return data, err
+}
+
+// writeFile is os.WriteFile, traced, and refused in read-only mode
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if readOnly.Load() {
+		return errReadOnly("not writing " + filepath.Base(path))
+	}
+	err := os.WriteFile(path, data, perm)
+	if err == nil {
+		traceFile("write", path)
+	}
+	return err
+}
+
+// appendFile appends data to the file at path, creating it if needed; traced,
+// and refused in read-only mode. A single small append doesn't interleave
+// with other writers.
+func appendFile(path string, data []byte, perm os.FileMode) error {
+	if readOnly.Load() {
+		return errReadOnly("not writing " + filepath.Base(path))
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		traceFile("write", path)
+	}
+	return err
+}
+
+// createFile is os.Create, traced, and refused in read-only mode
+func createFile(path string) (*os.File, error) {
+	if readOnly.Load() {
+		return nil, errReadOnly("not writing " + filepath.Base(path))
+	}
+	f, err := os.Create(path)
+	if err == nil {
+		traceFile("write", path)
+	}
+	return f, err
+}
+
+// removeFile is os.Remove, traced as a write, and refused in read-only mode
+func removeFile(path string) error {
+	if readOnly.Load() {
+		return errReadOnly("not removing " + filepath.Base(path))
+	}
+	err := os.Remove(path)
+	if err == nil {
+		traceFile("write", path)
+	}
+	return err
+}
+
+// formatTrace renders a trace for `wt log debug`, e.g.
+// "(3.2ms, read .out/wt.json, wrote .out/wt.json)"
+func formatTrace(entry DebugEntry) string {
+	parts := []string{strconv.FormatFloat(entry.DurationMs, 'f', -1, 64) + "ms"}
+	if len(entry.Read) > 0 {
+		parts = append(parts, "read "+strings.Join(entry.Read, " "))
+	}
+	if len(entry.Wrote) > 0 {
+		parts = append(parts, "wrote "+strings.Join(entry.Wrote, " "))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
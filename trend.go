@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const TrendDays = 7 // Archived days shown by `wt check --trend`
+
+// sparkline renders values as block characters scaled to the largest one
+func sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	sparkChars := []rune(glyphs("▁▂▃▄▅▆▇█", "_.-:=+*#"))
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = v * (len(sparkChars) - 1) / peak
+		}
+		b.WriteRune(sparkChars[level])
+	}
+	return b.String()
+}
+
+// recentDailyWork returns work minutes of the last n archived days before
+// today, oldest first. Several archives of the same day are added up.
+func recentDailyWork(n int) ([]int, error) {
+	now := getCurrentTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	timers, err := loadArchivedDays(time.Time{}, today)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []string
+	work := map[string]int{}
+	for _, t := range timers {
+		date := t.DayStart[:len(DATE_FORMAT)]
+		if _, ok := work[date]; !ok {
+			days = append(days, date)
+		}
+		work[date] += t.Totals().Work
+	}
+	if len(days) > n {
+		days = days[len(days)-n:]
+	}
+
+	values := make([]int, len(days))
+	for i, date := range days {
+		values[i] = work[date]
+	}
+	return values, nil
+}
+
+// trendSummary renders the recent days' work as a sparkline with their average,
+// followed by today's work on the same scale
+func trendSummary(timer *Timer, n int) (string, error) {
+	values, err := recentDailyWork(n)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return "Trend: no archived days yet", nil
+	}
+
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	line := []rune(sparkline(append(values, timer.Totals().Work)))
+
+	return fmt.Sprintf("Trend: %s %s today | Avg: %s (%d days)",
+		string(line[:len(values)]), string(line[len(values):]),
+		minutesToHourMinuteStr(total/len(values)), len(values)), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// `wt tutorial` walks through a made-up day: creating a timer, working,
+// taking a break, and fixing a mistake with mod. Like `wt selftest` it runs
+// the real commands in a scratch root with the mock clock, so nothing
+// touches the user's timer.
+
+// tutorialLesson explains a command, then runs it at Time on SelftestDay
+type tutorialLesson struct {
+	Time string
+	Text string
+	Args []string
+}
+
+var tutorialLessons = []tutorialLesson{
+	{"09:00", "A timer keeps one day of work in its root folder ($WT_ROOT). Create the\nday's timer:", []string{"new"}},
+	{"09:00", "It's 09:00 and you sit down to work. Start a work cycle:", []string{"start"}},
+	{"10:30", "An hour and a half later, see where you are. 'wt' alone does the same:", []string{"check"}},
+	{"10:30", "Time for a break. Stopping ends the work cycle; the time until you start\nagain counts as break:", []string{"stop"}},
+	{"10:45", "Back after 15 minutes. Start the next work cycle:", []string{"start"}},
+	{"12:00", "Lunch. Stop again:", []string{"stop"}},
+	{"12:00", "The log lists the day's work and break cycles:", []string{"log"}},
+	{"12:00", "Oops: the first cycle really ended at 10:15, you just forgot to stop the\ntimer. Take 15 minutes off cycle 1 (see 'wt mod' for all corrections):", []string{"mod", "1", "sub", "15"}},
+	{"12:00", "The cycles after it moved up:", []string{"log"}},
+	{"12:00", "At the end of the day, the report sums it up. Each 'wt new' saves it to the\ndaily report file before starting over:", []string{"report"}},
+}
+
+// tutorialPrompt waits for Enter and reports whether to go on. With
+// WT_SKIP_PROMPTS, or once the input ends, it goes on by itself.
+func tutorialPrompt(input *bufio.Reader, skip bool) bool {
+	if skip {
+		fmt.Println()
+		return true
+	}
+	answer, err := input.ReadString('\n')
+	if err != nil || !isTerminal(os.Stdin) {
+		fmt.Println() // The terminal echoes the Enter
+	}
+	return strings.TrimSpace(strings.ToLower(answer)) != "q"
+}
+
+func tutorialCmd() error {
+	skip := setting("WT_SKIP_PROMPTS") != ""
+	scratch, err := os.MkdirTemp("", "wt-tutorial-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	env := selftestEnv(scratch)
+	env["WT_RATE"] = ""
+	restore := overrideEnv(env)
+	defer restore()
+	clock := NewFrozenClock(selftestTime(tutorialLessons[0].Time))
+	defer useClock(clock)()
+
+	fmt.Printf("This tutorial runs a made-up day (%s) in a temporary root with a\n", SelftestDay)
+	fmt.Println("made-up clock. Your timer and settings aren't touched.")
+	fmt.Println("Press Enter to run each command, or q and Enter to quit.")
+
+	input := bufio.NewReader(os.Stdin)
+	for i, lesson := range tutorialLessons {
+		fmt.Println()
+		fmt.Printf("%d/%d %s\n", i+1, len(tutorialLessons), strings.ReplaceAll(lesson.Text, "\n", "\n    "))
+		fmt.Printf("  %s $ wt %s ", lesson.Time, strings.Join(lesson.Args, " "))
+		if !tutorialPrompt(input, skip) {
+			return nil
+		}
+
+		clock.Set(selftestTime(lesson.Time))
+		output, err := captureOutput(func() error {
+			return newApp().Run(context.Background(), append([]string{"wt"}, lesson.Args...))
+		})
+		for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+			if line != "" {
+				fmt.Println("  " + line)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Tutorial step 'wt %s' failed: %v", strings.Join(lesson.Args, " "), err)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("That's the basics. To track your own work, set WT_ROOT in your shell")
+	fmt.Println("profile (see 'wt env') and run 'wt new'. 'wt help' lists every command.")
+	return nil
+}
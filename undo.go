@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// `wt undo` reverts the last change to the timer (start, stop, tidy, mark,
+// rate, plan, a scheduled start, ...) by restoring wt.json as it was before,
+// and can be repeated to go further back; `wt redo` re-applies what was
+// undone. withStateLock keeps the states such changes replaced in
+// .out/wt.undo, the last WT_UNDO_LIMIT (default 20) of them. The commands
+// that start over (new, reset, import, close, ...) leave a state undo can't go
+// back across, so they clear it, and a new change clears what could be
+// redone. Only wt.json is restored: reports written and comments posted to
+// Linear stay.
+
+const (
+	UndoFile         = "wt.undo"
+	DefaultUndoLimit = 20
+)
+
+// startOverCommands are the commands whose changes `wt undo` can't revert,
+// by name without the "wt "/"api " of withStateLock's command: they replace
+// the day, or archive it
+var startOverCommands = map[string]bool{"new": true, "reset": true, "restart": true, "remove": true, "import": true, "replay": true, "prune": true, "close": true}
+
+// UndoEntry is a change in the journal
+type UndoEntry struct {
+	Command string          `json:"command"` // As given to withStateLock, e.g. "wt stop"
+	Time    string          `json:"time"`
+	State   json.RawMessage `json:"state"` // wt.json before the command (undo) or after it (redo)
+}
+
+// UndoJournal is the content of .out/wt.undo, oldest entries first
+type UndoJournal struct {
+	Undo []UndoEntry `json:"undo"`
+	Redo []UndoEntry `json:"redo,omitempty"`
+}
+
+func undoFilePath() (string, error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, UndoFile), nil
+}
+
+// readUndoJournal returns the journal, empty if there is none. Like
+// .out/wt.changed, the journal is read and written untraced.
+func readUndoJournal(path string) (UndoJournal, error) {
+	var journal UndoJournal
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return journal, nil
+	}
+	if err != nil {
+		return journal, err
+	}
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return journal, fmt.Errorf("Invalid undo journal %s: %v", path, err)
+	}
+	return journal, nil
+}
+
+// writeUndoJournal writes the journal, or removes it when it's empty
+func writeUndoJournal(path string, journal UndoJournal) error {
+	if len(journal.Undo) == 0 && len(journal.Redo) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// journalChange records the state command replaced, or clears the journal
+// if command can't be undone
+func journalChange(folder, command string, before []byte) {
+	fields := strings.Fields(command)
+	name := fields[len(fields)-1]
+	if name == "undo" || name == "redo" {
+		return
+	}
+	path := filepath.Join(folder, UndoFile)
+	limit := envInt("WT_UNDO_LIMIT", DefaultUndoLimit)
+	if startOverCommands[name] || !json.Valid(before) || limit <= 0 {
+		os.Remove(path)
+		return
+	}
+	journal, err := readUndoJournal(path)
+	if err != nil {
+		journal = UndoJournal{} // Start over rather than fail the command
+	}
+	journal.Undo = append(journal.Undo, UndoEntry{Command: command, Time: getCurrentTime().Format(DT_FORMAT), State: before})
+	journal.Undo = journal.Undo[max(0, len(journal.Undo)-limit):]
+	journal.Redo = nil
+	writeUndoJournal(path, journal)
+}
+
+func undoCmd() error {
+	return stepJournal("undo")
+}
+
+func redoCmd() error {
+	return stepJournal("redo")
+}
+
+// stepJournal undoes the last change, or redoes the last undone one, and
+// moves it to the other side of the journal with the state it replaced
+func stepJournal(command string) error {
+	path, err := undoFilePath()
+	if err != nil {
+		return err
+	}
+	journal, err := readUndoJournal(path)
+	if err != nil {
+		return err
+	}
+	from, to, verb := &journal.Undo, &journal.Redo, "Undid"
+	if command == "redo" {
+		from, to, verb = &journal.Redo, &journal.Undo, "Redid"
+	}
+	if len(*from) == 0 {
+		fmt.Printf("Nothing to %s.\n", command)
+		return nil
+	}
+	// A closed day's archive and report line wouldn't follow wt.json back
+	timer, err := load()
+	if err != nil {
+		return err
+	}
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	entry := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+
+	statePath, err := outputFilePath()
+	if err != nil {
+		return err
+	}
+	current, err := readFile(statePath)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(statePath, entry.State, 0644); err != nil {
+		return err
+	}
+	*to = append(*to, UndoEntry{Command: entry.Command, Time: entry.Time, State: current})
+	if err := writeUndoJournal(path, journal); err != nil {
+		return err
+	}
+
+	if timer, err = load(); err != nil {
+		return err
+	}
+	writeDebugEntry(DebugEntry{
+		Level:   LevelInfo,
+		Command: command,
+		Status:  timer.Status,
+		Message: fmt.Sprintf("%s '%s' of %s", verb, entry.Command, entry.Time),
+	})
+	fmt.Printf("%s '%s' (%s); the timer is %s.\n", verb, entry.Command, entry.Time[len(DATE_FORMAT)+1:], timer.Status)
+	return nil
+}
+
+// undoListCmd shows what `wt undo` and `wt redo` would revert and re-apply,
+// next first
+func undoListCmd() error {
+	path, err := undoFilePath()
+	if err != nil {
+		return err
+	}
+	journal, err := readUndoJournal(path)
+	if err != nil {
+		return err
+	}
+	if len(journal.Undo) == 0 && len(journal.Redo) == 0 {
+		fmt.Println("Nothing to undo or redo.")
+		return nil
+	}
+	for _, list := range []struct {
+		title   string
+		entries []UndoEntry
+	}{{"Undo", journal.Undo}, {"Redo", journal.Redo}} {
+		if len(list.entries) == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", list.title)
+		for i := len(list.entries) - 1; i >= 0; i-- {
+			fmt.Printf("  %d. %s (%s)\n", len(list.entries)-i, list.entries[i].Command, list.entries[i].Time)
+		}
+	}
+	return nil
+}
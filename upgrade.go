@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Releases are published on GitHub with one binary per platform, named
+// wt_<os>_<arch> (.exe on Windows), and a checksums.txt with their SHA-256
+// sums in sha256sum format (see `make release`). `wt upgrade` downloads the
+// binary for this platform, checks it against checksums.txt, and swaps it in
+// for the running executable.
+
+// Version is set at build time: go build -ldflags "-X main.Version=v1.2.3"
+var Version = "dev"
+
+const (
+	DefaultReleaseURL = "https://api.github.com/repos/simwahl/wt/releases/latest"
+	ChecksumsAsset    = "checksums.txt"
+)
+
+type release struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of the release's asset name, or ""
+func (r *release) assetURL(name string) string {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL
+		}
+	}
+	return ""
+}
+
+// latestRelease fetches the latest release ($WT_RELEASE_URL overrides where from)
+func latestRelease() (*release, error) {
+	var r release
+	if err := jsonRequest("GitHub", "GET", cmp.Or(setting("WT_RELEASE_URL"), DefaultReleaseURL), "", nil, &r); err != nil {
+		return nil, err
+	}
+	if r.Tag == "" {
+		return nil, fmt.Errorf("GitHub: no release found")
+	}
+	return &r, nil
+}
+
+// releaseAssetName is the name of this platform's binary in a release
+func releaseAssetName() string {
+	name := "wt_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// compareVersions compares versions like v1.2.3 numerically, part by part
+func compareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x, _ = strconv.Atoi(strings.SplitN(partsA[i], "-", 2)[0])
+		}
+		if i < len(partsB) {
+			y, _ = strconv.Atoi(strings.SplitN(partsB[i], "-", 2)[0])
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func versionCmd(check bool) error {
+	fmt.Printf("wt %s (%s/%s)\n", Version, runtime.GOOS, runtime.GOARCH)
+	if !check {
+		return nil
+	}
+	latest, err := latestRelease()
+	if err != nil {
+		return err
+	}
+	switch {
+	case Version == "dev":
+		fmt.Printf("Latest release: %s. This is a development build; 'wt upgrade --force' replaces it.\n", latest.Tag)
+	case compareVersions(latest.Tag, Version) > 0:
+		fmt.Printf("wt %s is available. Run 'wt upgrade' to install it.\n", latest.Tag)
+	default:
+		fmt.Println("wt is up to date.")
+	}
+	return nil
+}
+
+// download fetches url's body
+func download(url string) ([]byte, error) {
+	resp, err := httpClient().Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Download failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Download of %s failed: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// releaseChecksum finds name's SHA-256 sum in a checksums.txt
+func releaseChecksum(checksums []byte, name string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0])
+		}
+	}
+	return ""
+}
+
+// replaceExecutable swaps the binary at path for data. The old binary is
+// moved aside first, as a running executable can't be overwritten on Windows.
+func replaceExecutable(path string, data []byte) error {
+	newPath, oldPath := path+".new", path+".old"
+	os.Remove(oldPath) // Left behind by a previous upgrade on Windows
+	if err := writeFile(newPath, data, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, oldPath); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+	if err := os.Rename(newPath, path); err != nil {
+		os.Rename(oldPath, path)
+		os.Remove(newPath)
+		return err
+	}
+	os.Remove(oldPath) // Fails on Windows while running; removed next time
+	return nil
+}
+
+func upgradeCmd(force bool) error {
+	latest, err := latestRelease()
+	if err != nil {
+		return err
+	}
+	if !force {
+		if Version == "dev" {
+			return fmt.Errorf("This is a development build. Use 'wt upgrade --force' to replace it with %s.", latest.Tag)
+		}
+		if compareVersions(latest.Tag, Version) <= 0 {
+			fmt.Printf("wt %s is up to date.\n", Version)
+			return nil
+		}
+	}
+
+	name := releaseAssetName()
+	binaryURL, checksumsURL := latest.assetURL(name), latest.assetURL(ChecksumsAsset)
+	if binaryURL == "" {
+		return fmt.Errorf("Release %s has no binary for %s/%s.", latest.Tag, runtime.GOOS, runtime.GOARCH)
+	}
+	if checksumsURL == "" {
+		return fmt.Errorf("Release %s has no %s. Not upgrading.", latest.Tag, ChecksumsAsset)
+	}
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		return err
+	}
+	expected := releaseChecksum(checksums, name)
+	if expected == "" {
+		return fmt.Errorf("%s of %s has no entry for %s. Not upgrading.", ChecksumsAsset, latest.Tag, name)
+	}
+	binary, err := download(binaryURL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("Checksum mismatch for %s: expected %s, got %s. Not upgrading.", name, expected, actual)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, binary); err != nil {
+		return fmt.Errorf("Can't replace %s: %v", exe, err)
+	}
+	fmt.Printf("Upgraded wt %s -> %s.\n", Version, latest.Tag)
+	return nil
+}
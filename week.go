@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	WeekGridSlotMinutes = 30 // Time of day covered by one grid row
+	weekGridCellWidth   = 6
+)
+
+// weekDay collects the timers (archived and current) of one calendar day
+type weekDay struct {
+	Date   time.Time
+	Timers []*Timer
+}
+
+// weekStart returns midnight of the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// loadWeek returns Monday through Sunday of the current week, combining the
+// archive with the current timer
+func loadWeek(timer *Timer) ([]weekDay, error) {
+	week, _ := parseDateRange("thisweek")
+	monday := week.From
+
+	timers, err := loadRange(timer, week)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]weekDay, 7)
+	for i := range days {
+		days[i].Date = monday.AddDate(0, 0, i)
+	}
+	for _, t := range timers {
+		start, _ := parseTime(t.DayStart)
+		index := int(start.Sub(monday).Hours() / 24)
+		days[index].Timers = append(days[index].Timers, t)
+	}
+	return days, nil
+}
+
+// workMinutes sums work time over the day's timers
+func (d weekDay) workMinutes() int {
+	total := 0
+	for _, t := range d.Timers {
+		total += t.Totals().Work
+	}
+	return total
+}
+
+// workSpans returns the day's work, between pauses, as minute offsets from midnight, capped at 24:00.
+func (d weekDay) workSpans() [][2]int {
+	var spans [][2]int
+	for _, t := range d.Timers {
+		for _, entry := range buildLogEntries(t) {
+			if entry.Type != "work" {
+				continue
+			}
+			// Work between the pauses
+			start := int(entry.Start.Sub(d.Date).Minutes())
+			cursor := 0
+			for _, p := range append(slices.Clone(entry.Pauses), Pause{Start: entry.Minutes + entry.PausedMinutes}) {
+				from, to := min(start+cursor, 24*60), min(start+p.Start, 24*60)
+				if to > from {
+					spans = append(spans, [2]int{from, to})
+				}
+				cursor = max(cursor, p.End)
+			}
+		}
+	}
+	return spans
+}
+
+func weekCmd(timer *Timer, grid bool, imagePath string) error {
+	days, err := loadWeek(timer)
+	if err != nil {
+		return err
+	}
+	if imagePath != "" {
+		if grid {
+			return fmt.Errorf("Use either --grid or --image.")
+		}
+		return writeWeekImage(days, imagePath)
+	}
+	if grid {
+		fmt.Print(renderWeekGrid(days))
+		return nil
+	}
+
+	total := 0
+	for _, day := range days {
+		holiday, err := holidayOn(day.Date)
+		if err != nil {
+			return err
+		}
+		if holiday != "" {
+			holiday = " | Holiday: " + holiday
+		}
+		if len(day.Timers) == 0 {
+			if holiday != "" {
+				fmt.Printf("%s%s\n", day.Date.Format("Mon "+DATE_FORMAT), holiday)
+			}
+			continue
+		}
+		first, _ := parseTime(day.Timers[0].DayStart)
+		last := buildLogEntries(day.Timers[len(day.Timers)-1])
+		end := first
+		if len(last) > 0 {
+			end = last[len(last)-1].End
+		}
+
+		work := day.workMinutes()
+		total += work
+		fmt.Printf("%s | %s -> %s | Work: %s%s\n",
+			day.Date.Format("Mon "+DATE_FORMAT), first.Format(TIME_ONLY_FORMAT), end.Format(TIME_ONLY_FORMAT), minutesToHourMinuteStr(work), holiday)
+		for _, t := range day.Timers {
+			fmt.Print(formatNote(t.Note))
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("No work tracked this week.")
+		return nil
+	}
+	fmt.Printf("Week: %s\n", minutesToHourMinuteStr(total))
+	return nil
+}
+
+// renderWeekGrid draws one column per weekday and one row per time slot. A
+// slot is filled when at least half of it was spent working. Rows cover the
+// hours worked during the week (09:00-17:00 if nothing was tracked).
+func renderWeekGrid(days []weekDay) string {
+	first, last := 24*60, 0
+	daySpans := make([][][2]int, len(days))
+	for i, day := range days {
+		daySpans[i] = day.workSpans()
+		for _, span := range daySpans[i] {
+			first = min(first, span[0])
+			last = max(last, span[1])
+		}
+	}
+	if first >= last {
+		first, last = 9*60, 17*60
+	}
+	first = first / 60 * 60
+	last = (last + 59) / 60 * 60
+
+	var b strings.Builder
+	row := func(label string, cells []string) {
+		line := fmt.Sprintf("%-*s", weekGridCellWidth, label)
+		for _, cell := range cells {
+			line += fmt.Sprintf(" %-*s", weekGridCellWidth, cell)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	header := make([]string, len(days))
+	for i, day := range days {
+		header[i] = day.Date.Format("Mon 02")
+	}
+	row("", header)
+
+	block := strings.Repeat(glyphs("█", "#"), weekGridCellWidth)
+	for slot := first; slot < last; slot += WeekGridSlotMinutes {
+		cells := make([]string, len(days))
+		for i := range days {
+			worked := 0
+			for _, span := range daySpans[i] {
+				worked += max(0, min(span[1], slot+WeekGridSlotMinutes)-max(span[0], slot))
+			}
+			if worked*2 >= WeekGridSlotMinutes {
+				cells[i] = block
+			}
+		}
+		row(fmt.Sprintf("%02d:%02d", slot/60, slot%60), cells)
+	}
+
+	totals := make([]string, len(days))
+	for i, day := range days {
+		if len(day.Timers) > 0 {
+			totals[i] = minutesToHourMinuteStr(day.workMinutes())
+		}
+	}
+	row("Work", totals)
+
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+// `wt week --image out.png` draws the week summary as a PNG for a team
+// channel or a visual journal: the week's total (and goal), and a bar per
+// day with its work, against the daily goal if one is set. Text uses a small
+// built-in 5x7 font, so only the standard library is needed.
+
+const (
+	weekImageWidth  = 720
+	weekImageHeight = 400
+	weekImageMargin = 32
+)
+
+var (
+	weekImageBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	weekImageText       = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	weekImageMuted      = color.RGBA{0x88, 0x88, 0x88, 0xff}
+	weekImageBar        = color.RGBA{0x3a, 0x9f, 0x6c, 0xff}
+	weekImageGoal       = color.RGBA{0xd0, 0x60, 0x40, 0xff}
+)
+
+// weekImageFont has the glyphs of the image text, 7 rows of 5 pixels.
+// Text is drawn in upper case; other characters are left blank.
+var weekImageFont = map[rune]string{
+	'A': "01110 10001 10001 11111 10001 10001 10001",
+	'B': "11110 10001 10001 11110 10001 10001 11110",
+	'C': "01110 10001 10000 10000 10000 10001 01110",
+	'D': "11110 10001 10001 10001 10001 10001 11110",
+	'E': "11111 10000 10000 11110 10000 10000 11111",
+	'F': "11111 10000 10000 11110 10000 10000 10000",
+	'G': "01110 10001 10000 10111 10001 10001 01111",
+	'H': "10001 10001 10001 11111 10001 10001 10001",
+	'I': "01110 00100 00100 00100 00100 00100 01110",
+	'J': "00111 00010 00010 00010 00010 10010 01100",
+	'K': "10001 10010 10100 11000 10100 10010 10001",
+	'L': "10000 10000 10000 10000 10000 10000 11111",
+	'M': "10001 11011 10101 10101 10001 10001 10001",
+	'N': "10001 10001 11001 10101 10011 10001 10001",
+	'O': "01110 10001 10001 10001 10001 10001 01110",
+	'P': "11110 10001 10001 11110 10000 10000 10000",
+	'Q': "01110 10001 10001 10001 10101 10010 01101",
+	'R': "11110 10001 10001 11110 10100 10010 10001",
+	'S': "01111 10000 10000 01110 00001 00001 11110",
+	'T': "11111 00100 00100 00100 00100 00100 00100",
+	'U': "10001 10001 10001 10001 10001 10001 01110",
+	'V': "10001 10001 10001 10001 10001 01010 00100",
+	'W': "10001 10001 10001 10101 10101 10101 01010",
+	'X': "10001 10001 01010 00100 01010 10001 10001",
+	'Y': "10001 10001 10001 01010 00100 00100 00100",
+	'Z': "11111 00001 00010 00100 01000 10000 11111",
+	'0': "01110 10001 10011 10101 11001 10001 01110",
+	'1': "00100 01100 00100 00100 00100 00100 01110",
+	'2': "01110 10001 00001 00010 00100 01000 11111",
+	'3': "11111 00010 00100 00010 00001 10001 01110",
+	'4': "00010 00110 01010 10010 11111 00010 00010",
+	'5': "11111 10000 11110 00001 00001 10001 01110",
+	'6': "00110 01000 10000 11110 10001 10001 01110",
+	'7': "11111 00001 00010 00100 01000 01000 01000",
+	'8': "01110 10001 10001 01110 10001 10001 01110",
+	'9': "01110 10001 10001 01111 00001 00010 01100",
+	':': "00000 01100 01100 00000 01100 01100 00000",
+	'-': "00000 00000 00000 11111 00000 00000 00000",
+	'.': "00000 00000 00000 00000 00000 01100 01100",
+	'/': "00001 00001 00010 00100 01000 10000 10000",
+	'(': "00010 00100 01000 01000 01000 00100 00010",
+	')': "01000 00100 00010 00010 00010 00100 01000",
+	'%': "11000 11001 00010 00100 01000 10011 00011",
+	'+': "00000 00100 00100 11111 00100 00100 00000",
+}
+
+// textWidth returns the width of s drawn at the given scale
+func textWidth(s string, scale int) int {
+	return len(toASCII(s)) * 6 * scale
+}
+
+// drawText draws s with its top left corner at x, y, each font pixel scale
+// pixels wide
+func drawText(img draw.Image, x, y int, s string, scale int, c color.Color) {
+	for i, r := range strings.ToUpper(toASCII(s)) {
+		for row, bits := range strings.Fields(weekImageFont[r]) {
+			for col, bit := range bits {
+				if bit == '1' {
+					px := x + (i*6+col)*scale
+					py := y + row*scale
+					draw.Draw(img, image.Rect(px, py, px+scale, py+scale), image.NewUniform(c), image.Point{}, draw.Src)
+				}
+			}
+		}
+	}
+}
+
+// renderWeekImage draws the week: a header with the total, then the bars
+func renderWeekImage(days []weekDay) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, weekImageWidth, weekImageHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(weekImageBackground), image.Point{}, draw.Src)
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	monday := days[0].Date
+	year, week := monday.ISOWeek()
+	total, most := 0, 60
+	for _, day := range days {
+		total += day.workMinutes()
+		most = max(most, day.workMinutes())
+	}
+	title := fmt.Sprintf("Week %d-W%02d", year, week)
+	drawText(img, weekImageMargin, weekImageMargin, title, 3, weekImageText)
+	dates := monday.Format("2 Jan") + " - " + days[len(days)-1].Date.Format("2 Jan 2006")
+	drawText(img, weekImageWidth-weekImageMargin-textWidth(dates, 2), weekImageMargin+7, dates, 2, weekImageMuted)
+	summary := "Work " + minutesToHourMinuteStr(total)
+	if goal := weeklyGoalMinutes(monday); goal > 0 {
+		summary += fmt.Sprintf(" of %s (%d%%)", minutesToHourMinuteStr(goal), total*100/goal)
+	}
+	drawText(img, weekImageMargin, weekImageMargin+40, summary, 2, weekImageText)
+
+	// Bars, scaled to the busiest day or the highest daily goal
+	goals := make([]int, len(days))
+	for i, day := range days {
+		goals[i] = dailyGoalOn(day.Date)
+		most = max(most, goals[i])
+	}
+	top, bottom := weekImageMargin+100, weekImageHeight-weekImageMargin-24
+	slot := (weekImageWidth - 2*weekImageMargin) / len(days)
+	fill(image.Rect(weekImageMargin, bottom, weekImageWidth-weekImageMargin, bottom+2), weekImageMuted)
+	for i, day := range days {
+		work := day.workMinutes()
+		x := weekImageMargin + i*slot
+		height := work * (bottom - top) / most
+		fill(image.Rect(x+slot/5, bottom-height, x+slot-slot/5, bottom), weekImageBar)
+		label := day.Date.Format("Mon")
+		drawText(img, x+(slot-textWidth(label, 2))/2, bottom+10, label, 2, weekImageText)
+		if work > 0 {
+			value := minutesToHourMinuteStr(work)
+			drawText(img, x+(slot-textWidth(value, 2))/2, bottom-height-20, value, 2, weekImageText)
+		}
+	}
+
+	// Each day's goal over its bar; holidays have none
+	for i, goal := range goals {
+		if goal <= 0 {
+			continue
+		}
+		y := bottom - goal*(bottom-top)/most
+		for x := weekImageMargin + i*slot; x < weekImageMargin+(i+1)*slot; x += 12 {
+			fill(image.Rect(x, y, min(x+6, weekImageMargin+(i+1)*slot), y+2), weekImageGoal)
+		}
+	}
+	return img
+}
+
+// writeWeekImage writes the week as a PNG to path
+func writeWeekImage(days []weekDay, path string) error {
+	f, err := createFile(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, renderWeekImage(days)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the week to %s.\n", path)
+	return nil
+}
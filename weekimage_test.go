@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The dashed goal line is drawn over each day with that day's goal, so a
+// holiday in the week has none whether or not it's today
+func TestWeekImageGoalPerDay(t *testing.T) {
+	calendar := filepath.Join(t.TempDir(), "holidays.ics")
+	ics := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20260121\nSUMMARY:Company day\nEND:VEVENT\nEND:VCALENDAR\n"
+	if err := os.WriteFile(calendar, []byte(ics), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WT_ROOT", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WT_HOLIDAYS", calendar)
+	t.Setenv("WT_DAILY_GOAL", "0700")
+
+	monday := time.Date(2026, 1, 19, 0, 0, 0, 0, time.Local)
+	var days []weekDay
+	for i := range 7 {
+		days = append(days, weekDay{Date: monday.AddDate(0, 0, i)})
+	}
+
+	for _, today := range []time.Time{monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 2)} {
+		restore := useClock(NewFrozenClock(today.Add(10 * time.Hour)))
+		img := renderWeekImage(days)
+		restore()
+
+		slot := (weekImageWidth - 2*weekImageMargin) / len(days)
+		hasGoal := func(i int) bool {
+			r := image.Rect(weekImageMargin+i*slot, weekImageMargin+100, weekImageMargin+(i+1)*slot, weekImageHeight-weekImageMargin-24)
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					if img.RGBAAt(x, y) == weekImageGoal {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		for i, day := range days {
+			if want := i != 2; hasGoal(i) != want {
+				t.Errorf("on %s: goal drawn over %s: %v, want %v", today.Format("Mon"), day.Date.Format("Mon"), !want, want)
+			}
+		}
+	}
+}
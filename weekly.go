@@ -0,0 +1,118 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Next to the daily report file, wt keeps a weekly report file with one line
+// per ISO week, newest first, for skimming months at a glance. A week's line
+// is recomputed from its archived days whenever one of them is archived or
+// changed, so it always matches the archive.
+
+const WeeklyReportName = "weekly-reports"
+
+func weeklyReportFilePath() (string, error) {
+	if reportFile := setting("WT_WEEKLY_REPORT_FILE"); reportFile != "" {
+		return reportFile, nil
+	}
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, WeeklyReportName), nil
+}
+
+// weeklyGoalMinutes returns $WT_WEEKLY_GOAL (HHMM), or else $WT_DAILY_GOAL for
+// each weekday of the week that isn't a holiday. 0 means no goal.
+func weeklyGoalMinutes(monday time.Time) int {
+	if goal := envMinutes("WT_WEEKLY_GOAL", 0); goal > 0 {
+		return goal
+	}
+	goal := 0
+	for i := 0; i < 5; i++ {
+		goal += dailyGoalOn(monday.AddDate(0, 0, i))
+	}
+	return goal
+}
+
+// weeklyReportLine summarizes the archived days of the week starting at monday,
+// or returns "" if there are none
+func weeklyReportLine(monday time.Time) (string, error) {
+	timers, err := loadArchivedDays(monday, monday.AddDate(0, 0, 7))
+	if err != nil || len(timers) == 0 {
+		return "", err
+	}
+	var totals DayTotals
+	days := map[string]bool{}
+	for _, t := range timers {
+		totals.Add(t.Totals())
+		days[t.DayStart[:len(DATE_FORMAT)]] = true
+	}
+
+	year, week := monday.ISOWeek()
+	line := fmt.Sprintf("%d-W%02d | %s -> %s | Days: %d | Work: %s | %s | Paused: %s | Total: %s",
+		year, week, monday.Format(DATE_FORMAT), monday.AddDate(0, 0, 6).Format(DATE_FORMAT), len(days),
+		minutesToHourMinuteStr(totals.Work), totals.breakSummary(), minutesToHourMinuteStr(totals.Paused), minutesToHourMinuteStr(totals.Total()))
+	if goal := weeklyGoalMinutes(monday); goal > 0 {
+		line += fmt.Sprintf(" | Goal: %d%% of %s", totals.Work*100/goal, minutesToHourMinuteStr(goal))
+	}
+	return line, nil
+}
+
+// updateWeeklyReport rewrites the lines of the weeks of days in the weekly
+// report file, writing it once however many days are given
+func updateWeeklyReport(days ...time.Time) error {
+	if len(days) == 0 {
+		return nil
+	}
+	updated := map[string]string{} // Key -> line, "" if the week has no days left
+	for _, day := range days {
+		monday := weekStart(day)
+		year, week := monday.ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		if _, ok := updated[key]; ok {
+			continue
+		}
+		line, err := weeklyReportLine(monday)
+		if err != nil {
+			return err
+		}
+		updated[key] = line
+	}
+
+	filePath, err := weeklyReportFilePath()
+	if err != nil {
+		return err
+	}
+	var existing []string
+	if data, err := readFile(filePath); err == nil && strings.TrimSpace(string(data)) != "" {
+		existing = strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	weekKey := func(line string) string {
+		key, _, _ := strings.Cut(line, " |")
+		return key
+	}
+	var lines []string
+	for _, old := range existing {
+		if _, ok := updated[weekKey(old)]; !ok {
+			lines = append(lines, old)
+		}
+	}
+	for _, line := range updated {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 && len(existing) == 0 {
+		return nil
+	}
+	// Newest week first; keys sort like the weeks they name
+	slices.SortStableFunc(lines, func(a, b string) int { return cmp.Compare(weekKey(b), weekKey(a)) })
+	return writeFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
@@ -34,6 +34,15 @@ run_wt() {
     $WT_CMD "$@" > /dev/null 2>&1
 }
 
+# Helper function to wait (up to 5s) until a background server accepts connections
+wait_for_port() {
+    for _ in $(seq 50); do
+        (echo > "/dev/tcp/127.0.0.1/$1") 2> /dev/null && return 0
+        sleep 0.1
+    done
+    return 1
+}
+
 print_test() {
     echo -e "${YELLOW}TEST $1: $2${NC}"
 }
@@ -1035,6 +1044,3903 @@ expected_log="01. [09:15 => 09:45] Work: 0h:30m (0h:30m)"
 actual_log=$($WT_CMD log)
 check_output "mod start add adjusts first cycle later" "$expected_log" "$actual_log"
 
+###############################################################################
+# Test 28: Long-session warning in check
+###############################################################################
+print_test "28" "Long-session warning in check"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+
+mock_time "2026-01-20 10:29"
+expected_check="1h 29m RUNNING (1h 29m)"
+actual_check=$($WT_CMD check)
+check_output "no warning below threshold" "$expected_check" "$actual_check"
+
+mock_time "2026-01-20 10:30"
+expected_check="1h 30m RUNNING (1h 30m) [!] take a break: wt next"
+actual_check=$($WT_CMD check)
+check_output "warning at default threshold" "$expected_check" "$actual_check"
+
+expected_check="1h 30m RUNNING (1h 30m)"
+actual_check=$(WT_LONG_SESSION=0 $WT_CMD check)
+check_output "warning disabled with WT_LONG_SESSION=0" "$expected_check" "$actual_check"
+
+run_wt next
+mock_time "2026-01-20 10:45"
+expected_check="0h 15m RUNNING (1h 45m)"
+actual_check=$($WT_CMD check)
+check_output "warning cleared after next" "$expected_check" "$actual_check"
+
+###############################################################################
+# Test 29: Remind command
+###############################################################################
+print_test "29" "Remind command"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+
+expected_msg=""
+actual_msg=$($WT_CMD remind)
+check_output "no reminder before first start" "$expected_msg" "$actual_msg"
+
+run_wt start
+mock_time "2026-01-20 09:59"
+actual_msg=$($WT_CMD remind)
+check_output "no reminder below running interval" "$expected_msg" "$actual_msg"
+
+mock_time "2026-01-20 11:10"
+expected_msg="2h 10m on current cycle - consider a break."
+actual_msg=$($WT_CMD remind)
+check_output "reminder while running" "$expected_msg" "$actual_msg"
+
+run_wt pause
+mock_time "2026-01-20 11:45"
+expected_msg="Timer has been paused for 0h 35m - still on break?"
+actual_msg=$($WT_CMD remind)
+check_output "reminder while paused" "$expected_msg" "$actual_msg"
+
+run_wt stop
+mock_time "2026-01-20 12:30"
+expected_msg="Timer has been stopped for 0h 45m - still on break?"
+actual_msg=$($WT_CMD remind)
+check_output "reminder while stopped mid-day" "$expected_msg" "$actual_msg"
+
+expected_msg=""
+actual_msg=$(WT_REMIND_IDLE=100 $WT_CMD remind)
+check_output "idle interval is configurable" "$expected_msg" "$actual_msg"
+
+###############################################################################
+# Test 30: Daily goal ETA in check and report
+###############################################################################
+print_test "30" "Daily goal ETA in check and report"
+setup_test
+export WT_DAILY_GOAL=800
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+
+mock_time "2026-01-20 10:00"
+run_wt stop
+
+mock_time "2026-01-20 10:30"
+expected_check="--:-- STOPPED (1h 00m) ETA 17:30"
+actual_check=$($WT_CMD check)
+check_output "ETA moves with breaks while stopped" "$expected_check" "$actual_check"
+
+run_wt start
+mock_time "2026-01-20 11:00"
+expected_check="0h 30m RUNNING (1h 30m) ETA 17:30"
+actual_check=$($WT_CMD check)
+check_output "ETA while running" "$expected_check" "$actual_check"
+
+expected_report="2026-01-20 | 09:00 -> 11:00 | Work: 1h:30m | Break: 0h:30m | Paused: 0h:00m | Total: 2h:00m | ETA 17:30"
+actual_report=$($WT_CMD report)
+check_output "ETA in report" "$expected_report" "$actual_report"
+
+mock_time "2026-01-20 17:30"
+expected_check="7h 00m RUNNING (8h 00m) Goal reached [!] take a break: wt next"
+actual_check=$($WT_CMD check)
+check_output "goal reached" "$expected_check" "$actual_check"
+unset WT_DAILY_GOAL
+
+###############################################################################
+# Test 31: Automatic lunch-break classification
+###############################################################################
+print_test "31" "Automatic lunch-break classification"
+setup_test
+export WT_LUNCH_WINDOW=1130-1400
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:45"
+run_wt start  # 45 min break outside the window
+mock_time "2026-01-20 12:00"
+run_wt stop
+mock_time "2026-01-20 12:40"
+run_wt start  # 40 min break inside the window
+mock_time "2026-01-20 14:00"
+run_wt stop
+mock_time "2026-01-20 14:10"
+run_wt start  # 10 min break, too short for lunch
+mock_time "2026-01-20 15:00"
+run_wt stop
+
+expected_log="01. [09:00 => 10:00] Work: 1h:00m (1h:00m)
+02. [10:00 => 10:45] Break: 0h:45m
+03. [10:45 => 12:00] Work: 1h:15m (2h:15m)
+04. [12:00 => 12:40] Lunch: 0h:40m
+05. [12:40 => 14:00] Work: 1h:20m (3h:35m)
+06. [14:00 => 14:10] Break: 0h:10m
+07. [14:10 => 15:00] Work: 0h:50m (4h:25m)"
+actual_log=$($WT_CMD log)
+check_output "log labels qualifying break as lunch" "$expected_log" "$actual_log"
+
+expected_report="2026-01-20 | 09:00 -> 15:00 | Work: 4h:25m | Break: 0h:55m | Lunch: 0h:40m | Paused: 0h:00m | Total: 6h:00m"
+actual_report=$($WT_CMD report)
+check_output "report shows lunch separately" "$expected_report" "$actual_report"
+
+run_wt reset
+actual_daily=$(cat "$WT_ROOT/.out/daily-reports")
+check_output "daily report shows lunch separately" "$expected_report" "$actual_daily"
+
+unset WT_LUNCH_WINDOW
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+mock_time "2026-01-20 13:00"
+run_wt start
+mock_time "2026-01-20 14:00"
+run_wt stop
+expected_report="2026-01-20 | 09:00 -> 14:00 | Work: 4h:00m | Break: 1h:00m | Paused: 0h:00m | Total: 5h:00m"
+actual_report=$($WT_CMD report)
+check_output "no lunch classification without window" "$expected_report" "$actual_report"
+
+###############################################################################
+# Test 32: Log filtering flags
+###############################################################################
+print_test "32" "Log filtering flags"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+mock_time "2026-01-20 13:00"
+run_wt start
+mock_time "2026-01-20 14:30"
+
+expected_log="03. [10:15 => 12:00] Work: 1h:45m (2h:45m)
+04. [12:00 => 13:00] Break: 1h:00m"
+actual_log=$($WT_CMD log --since 11:00 --until 1300)
+check_output "since/until keeps overlapping entries" "$expected_log" "$actual_log"
+
+expected_log="02. [10:00 => 10:15] Break: 0h:15m
+04. [12:00 => 13:00] Break: 1h:00m"
+actual_log=$($WT_CMD log --type break)
+check_output "type filter keeps original numbering" "$expected_log" "$actual_log"
+
+expected_log="03. [10:15 => 12:00] Work: 1h:45m (2h:45m)
+05. [13:00 => .....] Work: 1h:30m (4h:15m)"
+actual_log=$($WT_CMD log --type work --last 2)
+check_output "last includes active cycle" "$expected_log" "$actual_log"
+
+expected_error="Invalid clock time: 25:00. Use HHMM or HH:MM"
+actual_error=$($WT_CMD log --since 25:00 2>&1 || true)
+check_output "invalid clock time" "$expected_error" "$actual_error"
+
+###############################################################################
+# Test 33: Structured log output
+###############################################################################
+print_test "33" "Structured log output"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:50"
+run_wt stop
+mock_time "2026-01-20 10:00"
+run_wt start
+mock_time "2026-01-20 10:20"
+run_wt pause
+mock_time "2026-01-20 10:30"
+
+expected_csv="num,type,label,start,end,minutes,paused_minutes,running_total,active,status
+1,work,Work,2026-01-20 09:00,2026-01-20 09:50,50,0,50,false,
+2,break,Break,2026-01-20 09:50,2026-01-20 10:00,10,0,50,false,
+3,work,Work,2026-01-20 10:00,2026-01-20 10:30,20,10,70,true,paused"
+actual_csv=$($WT_CMD log --format csv)
+check_output "csv log includes active cycle" "$expected_csv" "$actual_csv"
+
+expected_json='[
+    {
+        "num": 3,
+        "type": "work",
+        "label": "Work",
+        "start": "2026-01-20 10:00",
+        "end": "2026-01-20 10:30",
+        "minutes": 20,
+        "paused_minutes": 10,
+        "running_total": 70,
+        "active": true,
+        "status": "paused",
+        "pauses": [
+            {
+                "start": "2026-01-20 10:20",
+                "end": "2026-01-20 10:30"
+            }
+        ]
+    }
+]'
+actual_json=$($WT_CMD log --format json --last 1)
+check_output "json log honors filters" "$expected_json" "$actual_json"
+
+setup_test
+run_wt new
+expected_json="[]"
+actual_json=$($WT_CMD log --format json)
+check_output "json log is empty array without cycles" "$expected_json" "$actual_json"
+
+###############################################################################
+# Test 34: Condensed log view
+###############################################################################
+print_test "34" "Condensed log view"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:30"
+run_wt next
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:03"
+run_wt start  # 3 min break gets folded
+mock_time "2026-01-20 10:30"
+run_wt stop
+mock_time "2026-01-20 11:00"
+run_wt start  # 30 min break stays
+mock_time "2026-01-20 11:20"
+run_wt next
+mock_time "2026-01-20 11:45"
+
+expected_log="01-05. [09:00 => 10:30] Work: 1h:27m (1h:27m) [3 cycles, 0h:03m in breaks]
+06. [10:30 => 11:00] Break: 0h:30m
+07-09. [11:00 => .....] Work: 0h:45m (2h:12m) [2 cycles, 0h:00m in breaks]"
+actual_log=$($WT_CMD log --condensed)
+check_output "condensed log folds short breaks" "$expected_log" "$actual_log"
+
+expected_log="01-03. [09:00 => 10:00] Work: 1h:00m (1h:00m) [2 cycles, 0h:00m in breaks]
+04. [10:00 => 10:03] Break: 0h:03m
+05. [10:03 => 10:30] Work: 0h:27m (1h:27m)
+06. [10:30 => 11:00] Break: 0h:30m
+07-09. [11:00 => .....] Work: 0h:45m (2h:12m) [2 cycles, 0h:00m in breaks]"
+actual_log=$($WT_CMD log --condensed --min-break 1)
+check_output "condensed log with custom threshold" "$expected_log" "$actual_log"
+
+###############################################################################
+# Test 35: Debug log rotation and tailing
+###############################################################################
+print_test "35" "Debug log rotation and tailing"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt pause
+mock_time "2026-01-20 10:10"
+run_wt start
+mock_time "2026-01-21 08:00"
+run_wt stop
+
+expected_log="[2026-01-20 10:10] wt start
+[2026-01-21 08:00] wt stop"
+actual_log=$($WT_CMD log debug --tail 2)
+check_output "debug log tail" "$expected_log" "$actual_log"
+
+expected_log="[2026-01-21 08:00] wt stop"
+actual_log=$($WT_CMD log debug --today)
+check_output "debug log today" "$expected_log" "$actual_log"
+
+# Oldest entry is older than the max age, so the next write rotates the log
+mock_time "2026-01-22 09:00"
+WT_DEBUG_LOG_MAX_DAYS=1 $WT_CMD start > /dev/null
+expected_log="[2026-01-22 09:00] wt start"
+actual_log=$($WT_CMD log debug)
+check_output "debug log rotated by age" "$expected_log" "$actual_log"
+
+expected_log='{"time":"2026-01-21 08:00","level":"info","command":"stop","status":"stopped","minutes":{"paused":10,"work":1370}}'
+actual_log=$(tail -n 1 "$WT_ROOT/.out/debug-log.1")
+check_output "rotated debug log kept" "$expected_log" "$actual_log"
+
+###############################################################################
+# Test 36: Structured debug logging with levels
+###############################################################################
+print_test "36" "Structured debug logging with levels"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+run_wt start  # Refused: already running
+mock_time "2026-01-20 09:30"
+run_wt next
+mock_time "2026-01-20 09:45"
+run_wt mod 1 sub 5
+
+expected_log="[2026-01-20 09:00] wt reset
+[2026-01-20 09:00] wt start
+[2026-01-20 09:00] WARN wt start: Already running.
+[2026-01-20 09:30] wt stop (via next)
+[2026-01-20 09:30] wt next
+[2026-01-20 09:45] wt mod 1 sub 5"
+actual_log=$($WT_CMD log debug)
+check_output "debug log renders structured entries" "$expected_log" "$actual_log"
+
+expected_log='{"time":"2026-01-20 09:45","level":"info","command":"mod","args":["1","sub","5"],"status":"running","minutes":{"cycle":1,"duration":-5}}'
+actual_log=$($WT_CMD log debug --format json --tail 1)
+check_output "debug log raw json lines" "$expected_log" "$actual_log"
+
+WT_LOG_LEVEL=error $WT_CMD start > /dev/null
+actual_log=$($WT_CMD log debug --tail 1)
+expected_log="[2026-01-20 09:45] wt mod 1 sub 5"
+check_output "log level filters warnings" "$expected_log" "$actual_log"
+
+###############################################################################
+# Test 37: Replay reconstructs state from the journal
+###############################################################################
+print_test "37" "Replay reconstructs state from the journal"
+setup_test
+
+mock_time "2026-01-20 08:45"
+run_wt new
+run_wt start 45
+mock_time "2026-01-20 09:30"
+run_wt stop
+mock_time "2026-01-20 09:45"
+run_wt start
+mock_time "2026-01-20 10:15"
+run_wt pause
+mock_time "2026-01-20 10:25"
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt next
+mock_time "2026-01-20 11:30"
+run_wt stop
+run_wt mod 1 add 10
+run_wt mod start sub 5
+
+mock_time "2026-01-20 12:00"
+expected_log=$($WT_CMD log)
+rm "$WT_ROOT/.out/wt.json"
+
+expected_replay="Replayed 9 commands.
+$expected_log
+2026-01-20 | 07:55 -> 11:35 | Work: 3h:15m | Break: 0h:15m | Paused: 0h:10m | Total: 3h:40m"
+actual_replay=$($WT_CMD replay)
+check_output "replay matches lost state" "$expected_replay" "$actual_replay"
+
+expected_replay="Replayed 4 commands.
+01. [08:00 => 09:30] Work: 1h:30m (1h:30m)
+02. [09:30 => 09:45] Break: 0h:15m
+03. [09:45 => .....] Work (paused): 0h:30m |05m| (2h:00m)
+2026-01-20 | 08:00 -> 10:15 | Work: 2h:00m | Break: 0h:15m | Paused: 0h:05m | Total: 2h:20m"
+actual_replay=$($WT_CMD replay --until 10:20)
+check_output "replay until a point in time" "$expected_replay" "$actual_replay"
+
+run_wt replay --apply
+actual_log=$($WT_CMD log)
+check_output "replay --apply restores wt.json" "$expected_log" "$actual_log"
+
+###############################################################################
+# Test 38: Simulate what-if mode
+###############################################################################
+print_test "38" "Simulate what-if mode"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 11:00"
+
+expected_report="2026-01-20 | 09:00 -> 18:00 | Work: 8h:00m | Break: 1h:00m | Paused: 0h:00m | Total: 9h:00m"
+actual_report=$($WT_CMD simulate "12:00 stop" "1300 start" "18:00 stop")
+check_output "simulate prints resulting report" "$expected_report" "$actual_report"
+
+expected_log="01. [09:00 => 11:00] Work: 2h:00m (2h:00m)
+02. [11:00 => 11:00] Break: 0h:00m
+03. [11:00 => .....] Work: 1h:30m (3h:30m)
+2026-01-20 | 09:00 -> 12:30 | Work: 3h:30m | Break: 0h:00m | Paused: 0h:00m | Total: 3h:30m"
+actual_log=$($WT_CMD simulate --log "now next" "12:30 mod 3 pause add 0")
+check_output "simulate with log" "$expected_log" "$actual_log"
+
+expected_log="01. [09:00 => .....] Work: 2h:00m (2h:00m)"
+actual_log=$($WT_CMD log)
+check_output "simulate does not persist" "$expected_log" "$actual_log"
+
+expected_error="Step \"10:00 stop\" is earlier than 11:00. Steps must be in order and not in the past."
+actual_error=$($WT_CMD simulate "10:00 stop" 2>&1 || true)
+check_output "simulate rejects past steps" "$expected_error" "$actual_error"
+
+###############################################################################
+# Test 39: Named profiles
+###############################################################################
+print_test "39" "Named profiles"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt profile set freelance WT_DAILY_GOAL 400
+run_wt profile set freelance WT_LONG_SESSION 45
+run_wt profile set work WT_DAILY_GOAL 800
+run_wt start
+mock_time "2026-01-20 10:00"
+
+expected_check="1h 00m RUNNING (1h 00m) ETA 13:00 [!] take a break: wt next"
+actual_check=$($WT_CMD --profile freelance check)
+check_output "profile flag applies settings" "$expected_check" "$actual_check"
+
+run_wt profile use work
+expected_check="1h 00m RUNNING (1h 00m) ETA 17:00"
+actual_check=$($WT_CMD check)
+check_output "profile selected for root" "$expected_check" "$actual_check"
+
+expected_check="1h 00m RUNNING (1h 00m) ETA 16:00"
+actual_check=$(WT_DAILY_GOAL=700 $WT_CMD check)
+check_output "environment overrides profile" "$expected_check" "$actual_check"
+
+expected_list="  freelance
+* work"
+actual_list=$($WT_CMD profile list)
+check_output "profile list marks active" "$expected_list" "$actual_list"
+
+run_wt reset
+expected_profile="Profile: work
+  WT_DAILY_GOAL=800"
+actual_profile=$($WT_CMD profile)
+check_output "profiles survive reset" "$expected_profile" "$actual_profile"
+
+expected_error="Profile nope does not exist."
+actual_error=$($WT_CMD --profile nope check 2>&1 || true)
+check_output "unknown profile" "$expected_error" "$actual_error"
+
+###############################################################################
+# Test 40: Per-client billing rates
+###############################################################################
+print_test "40" "Per-client billing rates"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt profile set clientA WT_RATE "90 EUR"
+run_wt profile set clientB WT_RATE "120 USD"
+run_wt start
+mock_time "2026-01-20 10:30"
+run_wt stop
+mock_time "2026-01-20 10:45"
+run_wt start
+mock_time "2026-01-20 11:05"
+
+expected_report="2026-01-20 | 09:00 -> 11:05 | Work: 1h:50m | Break: 0h:15m | Paused: 0h:00m | Total: 2h:05m | Earned: 165.00 EUR"
+actual_report=$($WT_CMD --profile clientA report)
+check_output "report earnings at client A rate" "$expected_report" "$actual_report"
+
+expected_report="2026-01-20 | 09:00 -> 11:05 | Work: 1h:50m | Break: 0h:15m | Paused: 0h:00m | Total: 2h:05m | Earned: 220.00 USD"
+actual_report=$($WT_CMD --profile clientB report)
+check_output "report earnings at client B rate" "$expected_report" "$actual_report"
+
+expected_csv="num,type,label,start,end,minutes,paused_minutes,running_total,active,status,earned
+1,work,Work,2026-01-20 09:00,2026-01-20 10:30,90,0,90,false,,135.00
+2,break,Break,2026-01-20 10:30,2026-01-20 10:45,15,0,90,false,,0.00
+3,work,Work,2026-01-20 10:45,2026-01-20 11:05,20,0,110,true,running,30.00"
+actual_csv=$($WT_CMD --profile clientA log --format csv)
+check_output "per-cycle earnings in csv" "$expected_csv" "$actual_csv"
+
+# Tagged cycles bill at the tag's rate, in the currency of WT_RATE
+run_wt tag support
+run_wt profile set clientA WT_RATE_SUPPORT 150
+expected_report="2026-01-20 | 09:00 -> 11:05 | Work: 1h:50m | Break: 0h:15m | Paused: 0h:00m | Total: 2h:05m | Earned: 185.00 EUR"
+check_output "report earnings at the tag rate" "$expected_report" "$($WT_CMD --profile clientA report)"
+check_output "tags without a rate at WT_RATE" "Earned: 220.00 USD" "$(WT_RATE_OTHER=150 $WT_CMD --profile clientB report | grep -o 'Earned: .*')"
+check_output "rate in another currency refused" "WT_RATE_SUPPORT is in USD, WT_RATE in EUR. Use one currency for all rates." "$(WT_RATE_SUPPORT="150 USD" $WT_CMD --profile clientA report 2>&1)"
+check_output "profile fixable with rates in two currencies" "Profile clientA: WT_RATE_SUPPORT=150" "$(WT_RATE_SUPPORT="150 USD" $WT_CMD profile set clientA WT_RATE_SUPPORT 150 2>&1)"
+check_output "tag rate without WT_RATE" "Earned: 50.00 USD" "$(WT_RATE_SUPPORT="150 USD" $WT_CMD report | grep -o 'Earned: .*')"
+check_output "export earnings at the tag rate" "135 50" "$($WT_CMD --profile clientA export --range today | python3 -c 'import json, sys; print(*[e["earned"] for e in json.load(sys.stdin)[0]["entries"] if e["type"] == "work"])')"
+check_output "json report at the tag rate" "185" "$($WT_CMD --profile clientA --json report | python3 -c 'import json, sys; print(json.load(sys.stdin)["earned"])')"
+
+###############################################################################
+# Test 41: Project budget alerts
+###############################################################################
+print_test "41" "Project budget alerts"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt profile set projX WT_RATE "100 EUR"
+run_wt profile set projX WT_BUDGET 1000
+run_wt profile use projX
+run_wt start
+mock_time "2026-01-19 17:00"
+run_wt stop
+run_wt reset
+
+mock_time "2026-01-20 09:00"
+run_wt start
+mock_time "2026-01-20 10:00"
+
+expected_check="1h 00m RUNNING (1h 00m)
+Budget: 900.00 EUR / 1000.00 EUR (90%) [!] approaching budget"
+actual_check=$($WT_CMD check --budget)
+check_output "check --budget warns near the cap" "$expected_check" "$actual_check"
+
+mock_time "2026-01-20 11:30"
+expected_report="2026-01-20 | 09:00 -> 11:30 | Work: 2h:30m | Break: 0h:00m | Paused: 0h:00m | Total: 2h:30m | Earned: 250.00 EUR | Budget: 1050.00 EUR / 1000.00 EUR (105%) [!] budget exceeded"
+actual_report=$($WT_CMD report)
+check_output "report shows budget exceeded" "$expected_report" "$actual_report"
+
+# With WT_BUDGET_TAG only that tag's cycles count, from the archive and today
+run_wt tag projx
+run_wt stop
+mock_time "2026-01-21 09:00"
+run_wt new
+run_wt start
+run_wt tag projx
+mock_time "2026-01-21 10:00"
+run_wt next
+mock_time "2026-01-21 11:00"
+check_output "budget of a tag" "Budget: 350.00 EUR / 1000.00 EUR (35%)" "$(WT_BUDGET_TAG=projx $WT_CMD check --budget | tail -1)"
+
+###############################################################################
+# Test 42: Week summary and calendar grid
+###############################################################################
+print_test "42" "Week summary and calendar grid"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 10:30"
+run_wt stop
+mock_time "2026-01-19 11:00"
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-20 10:00"
+run_wt restart
+mock_time "2026-01-20 11:00"
+run_wt pause
+mock_time "2026-01-20 11:30"
+run_wt stop
+mock_time "2026-01-21 08:30"
+run_wt restart
+mock_time "2026-01-21 09:30"
+
+expected_week="Mon 2026-01-19 | 09:00 -> 12:00 | Work: 2h:30m
+Tue 2026-01-20 | 10:00 -> 11:30 | Work: 1h:00m
+Wed 2026-01-21 | 08:30 -> 09:30 | Work: 1h:00m
+Week: 4h:30m"
+actual_week=$($WT_CMD week)
+check_output "week summary from archive" "$expected_week" "$actual_week"
+
+expected_grid="       Mon 19 Tue 20 Wed 21 Thu 22 Fri 23 Sat 24 Sun 25
+08:00
+08:30                ██████
+09:00  ██████        ██████
+09:30  ██████
+10:00  ██████ ██████
+10:30         ██████
+11:00  ██████
+11:30  ██████
+Work   2h:30m 1h:00m 1h:00m"
+actual_grid=$($WT_CMD week --grid)
+check_output "week grid" "$expected_grid" "$actual_grid"
+
+actual_archive=$(ls "$WT_ROOT/.out/archive" | tr '\n' ' ')
+check_output "days archived on reset" "2026-01-19.json 2026-01-20.json " "$actual_archive"
+
+###############################################################################
+# Test 43: Gantt timeline
+###############################################################################
+print_test "43" "Gantt timeline"
+setup_test
+
+mock_time "2026-01-20 09:10"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt pause
+mock_time "2026-01-20 10:10"
+run_wt start
+mock_time "2026-01-20 10:30"
+run_wt stop
+mock_time "2026-01-20 10:45"
+run_wt start
+mock_time "2026-01-20 11:20"
+
+expected_gantt="09:00 |     █████████████████████████|
+10:00 |░░░░░██████████········███████|
+11:00 |██████████                    |
+█ work  ░ paused  · break (1 char = 2 min)"
+actual_gantt=$($WT_CMD log --gantt)
+check_output "gantt bars per hour" "$expected_gantt" "$actual_gantt"
+
+actual_error=$($WT_CMD log --gantt --format json 2>&1 || true)
+check_output "gantt rejects structured format" "--gantt can't be combined with --condensed or --format" "$actual_error"
+
+###############################################################################
+# Test 44: Recent-days trend in check
+###############################################################################
+print_test "44" "Recent-days trend in check"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt check --trend
+expected_trend="--:-- STOPPED (0h 00m)
+Trend: no archived days yet"
+actual_trend=$($WT_CMD check --trend)
+check_output "trend without archive" "$expected_trend" "$actual_trend"
+
+run_wt start
+mock_time "2026-01-19 15:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt restart
+mock_time "2026-01-20 17:00"
+run_wt stop
+mock_time "2026-01-21 09:00"
+run_wt restart
+mock_time "2026-01-21 11:00"
+run_wt stop
+mock_time "2026-01-22 09:00"
+run_wt restart
+mock_time "2026-01-22 13:00"
+run_wt pause
+
+expected_trend="4h 00m PAUSED (4h 00m)
+Trend: ▆█▂ ▄ today | Avg: 5h:20m (3 days)"
+actual_trend=$($WT_CMD check --trend)
+check_output "sparkline of archived days" "$expected_trend" "$actual_trend"
+
+###############################################################################
+# Test 45: Compare date ranges
+###############################################################################
+print_test "45" "Compare date ranges"
+setup_test
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-15 12:00"
+run_wt stop
+mock_time "2026-01-15 13:00"
+run_wt start
+mock_time "2026-01-15 17:00"
+run_wt stop
+mock_time "2026-01-16 09:30"
+run_wt restart
+mock_time "2026-01-16 15:30"
+run_wt stop
+mock_time "2026-01-19 08:30"
+run_wt restart
+mock_time "2026-01-19 10:00"
+run_wt stop
+mock_time "2026-01-19 10:15"
+run_wt start
+mock_time "2026-01-19 14:00"
+
+expected_compare="           lastweek     thisweek     Delta
+Days       2            1            -1
+Work       13h:00m      5h:15m       -7h:45m
+Work/day   6h:30m       5h:15m       -1h:15m
+Break      1h:00m       0h:15m       -0h:45m
+Paused     0h:00m       0h:00m       +0h:00m
+Cycles     3            2            -1
+Avg start  09:15        08:30        -0h:45m"
+actual_compare=$($WT_CMD compare)
+check_output "last week vs this week" "$expected_compare" "$actual_compare"
+
+expected_compare="           2026-01-15..2026-01-15  2026-01-16              Delta
+Days       1                       1                       +0"
+actual_compare=$($WT_CMD compare --a 2026-01-15..2026-01-15 --b 2026-01-16 | head -2)
+check_output "explicit date ranges" "$expected_compare" "$actual_compare"
+
+###############################################################################
+# Test 46: CSV dialect
+###############################################################################
+print_test "46" "CSV dialect"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:25"
+run_wt stop
+
+expected_csv="num;type;label;start;end;minutes;paused_minutes;running_total;active;status;earned
+1;work;Work;20.01.2026 09:00;20.01.2026 10:25;85;0;85;false;;127,50"
+actual_csv=$(WT_RATE="90 EUR" WT_CSV_DELIMITER=";" WT_CSV_DECIMAL="," WT_CSV_DATE_FORMAT=eu $WT_CMD log --format csv)
+check_output "european csv" "$expected_csv" "$actual_csv"
+
+expected_error="WT_CSV_DECIMAL ',' needs a different WT_CSV_DELIMITER, e.g. ';'"
+actual_error=$(WT_CSV_DECIMAL="," $WT_CMD log --format csv 2>&1 || true)
+check_output "decimal comma needs another delimiter" "$expected_error" "$actual_error"
+
+###############################################################################
+# Test 47: Range reports grouped by day, week, and project
+###############################################################################
+print_test "47" "Range reports grouped by day, week, and project"
+setup_test
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt profile set acme WT_DAILY_GOAL 800
+run_wt profile use acme
+run_wt start
+mock_time "2026-01-15 12:00"
+run_wt stop
+mock_time "2026-01-15 13:00"
+run_wt start
+mock_time "2026-01-15 17:00"
+run_wt stop
+mock_time "2026-01-16 09:30"
+run_wt restart
+mock_time "2026-01-16 15:30"
+run_wt stop
+mock_time "2026-01-19 08:30"
+run_wt restart
+run_wt profile use none
+mock_time "2026-01-19 10:00"
+run_wt stop
+mock_time "2026-01-19 10:15"
+run_wt start
+mock_time "2026-01-19 14:00"
+
+expected_report="2026-01-15 | Work: 7h:00m | Break: 1h:00m | Paused: 0h:00m | Total: 8h:00m | Days: 1
+2026-01-16 | Work: 6h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 6h:00m | Days: 1
+2026-01-19 | Work: 5h:15m | Break: 0h:15m | Paused: 0h:00m | Total: 5h:30m | Days: 1
+Total      | Work: 18h:15m | Break: 1h:15m | Paused: 0h:00m | Total: 19h:30m | Days: 3"
+actual_report=$($WT_CMD report --range 2026-01-01..2026-01-31)
+check_output "range report per day" "$expected_report" "$actual_report"
+
+expected_report="2026-W03 | Work: 13h:00m | Break: 1h:00m | Paused: 0h:00m | Total: 14h:00m | Days: 2
+2026-W04 | Work: 5h:15m | Break: 0h:15m | Paused: 0h:00m | Total: 5h:30m | Days: 1
+Total    | Work: 18h:15m | Break: 1h:15m | Paused: 0h:00m | Total: 19h:30m | Days: 3"
+actual_report=$($WT_CMD report --group-by week)
+check_output "group by week defaults to this month" "$expected_report" "$actual_report"
+
+expected_report="(no profile) | Work: 5h:15m | Break: 0h:15m | Paused: 0h:00m | Total: 5h:30m | Days: 1
+acme         | Work: 13h:00m | Break: 1h:00m | Paused: 0h:00m | Total: 14h:00m | Days: 2
+Total        | Work: 18h:15m | Break: 1h:15m | Paused: 0h:00m | Total: 19h:30m | Days: 3"
+actual_report=$($WT_CMD report --group-by project)
+check_output "group by project" "$expected_report" "$actual_report"
+
+###############################################################################
+# Test 48: Pruning old data
+###############################################################################
+print_test "48" "Pruning old data"
+setup_test
+
+mock_time "2025-01-10 09:00"
+run_wt new
+run_wt start
+mock_time "2025-01-10 12:00"
+run_wt stop
+mock_time "2025-06-10 09:00"
+run_wt restart
+mock_time "2025-06-10 11:00"
+run_wt stop
+mock_time "2026-01-12 09:00"
+run_wt restart
+mock_time "2026-01-12 10:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt restart
+
+expected_prune="delete   2025-01-10.json
+delete   2025-06-10.json"
+actual_prune=$($WT_CMD prune --older-than 6m --dry-run)
+check_output "dry run lists old days" "$expected_prune" "$actual_prune"
+
+expected_prune="compress 2025-01-10.json
+compress 2025-06-10.json
+Pruned."
+actual_prune=$($WT_CMD prune --older-than 6m --compress)
+check_output "compress old days" "$expected_prune" "$actual_prune"
+
+expected_report="2025-01-10 | Work: 3h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 3h:00m | Days: 1"
+actual_report=$($WT_CMD report --range 2025-01-01..2025-01-31)
+check_output "compressed days stay readable" "$expected_report" "$actual_report"
+
+mock_time "2026-01-21 09:00"
+WT_RETENTION=1y run_wt reset
+actual_archive=$(ls "$WT_ROOT/.out/archive" | tr '\n' ' ')
+check_output "retention applied on reset" "2025-06-10.json.gz 2026-01-12.json 2026-01-20.json " "$actual_archive"
+check_output "retention error quiet in silent mode" "" "$(WT_RETENTION=soon $WT_CMD reset)"
+check_output "retention error logged" "WT_RETENTION: Invalid age: soon. Use a number followed by d, w, m, or y (e.g. 2y)" "$(grep '"level":"warn"' "$WT_ROOT/.out/debug-log" | python3 -c 'import json, sys; print(json.loads(sys.stdin.readline())["message"])')"
+check_output "retention error shown in normal mode" "WT_RETENTION: Invalid age: soon. Use a number followed by d, w, m, or y (e.g. 2y)" "$($WT_CMD mode normal > /dev/null; WT_RETENTION=soon $WT_CMD reset | tail -1)"
+
+# A day that can't be archived stays in wt.json
+run_wt start
+mock_time "2026-01-21 10:00"
+mv "$WT_ROOT/.out/archive" "$WT_ROOT/.out/archive.bak"
+touch "$WT_ROOT/.out/archive"
+check_output "reset fails without the archive" "1" "$($WT_CMD reset > /dev/null 2>&1; echo $?)"
+check_output "day kept when not archived" "2026-01-21" "$($WT_CMD report | cut -d' ' -f1)"
+rm "$WT_ROOT/.out/archive"
+mv "$WT_ROOT/.out/archive.bak" "$WT_ROOT/.out/archive"
+
+###############################################################################
+# Test 49: Anonymized export
+###############################################################################
+print_test "49" "Anonymized export"
+setup_test
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt profile set acme WT_RATE "100 EUR"
+run_wt profile use acme
+run_wt start
+mock_time "2026-01-15 12:00"
+run_wt stop
+mock_time "2026-01-15 12:30"
+run_wt start
+mock_time "2026-01-15 13:00"
+run_wt stop
+mock_time "2026-01-16 09:30"
+run_wt restart
+mock_time "2026-01-16 10:30"
+
+actual_export=$($WT_CMD export | grep -E '"(date|profile|work_minutes|earned)"' | tr -d ' ')
+expected_export='"date":"2026-01-15",
+"profile":"acme",
+"work_minutes":210,
+"earned":300
+"earned":50
+"date":"2026-01-16",
+"profile":"acme",
+"work_minutes":60,
+"earned":100'
+check_output "export keeps profiles and earnings" "$expected_export" "$actual_export"
+
+actual_export=$($WT_CMD export --anonymize | grep -E '"(date|profile|work_minutes|earned)"' | tr -d ' ')
+expected_export='"date":"2026-01-15",
+"profile":"profile-1",
+"work_minutes":210,
+"date":"2026-01-16",
+"profile":"profile-1",
+"work_minutes":60,'
+check_output "anonymized export hides client data" "$expected_export" "$actual_export"
+
+###############################################################################
+# Test 50: Export formats
+###############################################################################
+print_test "50" "Export formats"
+setup_test
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-15 12:00"
+run_wt stop
+mock_time "2026-01-15 12:30"
+run_wt start
+mock_time "2026-01-15 13:00"
+run_wt stop
+mock_time "2026-01-16 09:30"
+run_wt restart
+mock_time "2026-01-16 10:30"
+
+expected_md="| Date | Start | End | Work | Break | Paused | Total |
+|------|-------|-----|------|-------|--------|-------|
+| 2026-01-15 | 09:00 | 13:00 | 3h:30m | 0h:30m | 0h:00m | 4h:00m |
+| 2026-01-16 | 09:30 | 10:30 | 1h:00m | 0h:00m | 0h:00m | 1h:00m |
+| **Total** | | | **4h:30m** | 0h:30m | 0h:00m | 5h:00m |"
+actual_md=$($WT_CMD export md)
+check_output "markdown export" "$expected_md" "$actual_md"
+
+expected_timeclock="i 2026-01-15 09:00:00 work
+o 2026-01-15 12:00:00
+i 2026-01-15 12:30:00 work
+o 2026-01-15 13:00:00"
+actual_timeclock=$($WT_CMD export timeclock --range 2026-01-15)
+check_output "timeclock export with range" "$expected_timeclock" "$actual_timeclock"
+
+expected_csv="date,profile,num,type,label,start,end,minutes,paused_minutes,active
+2026-01-15,,2,break,Break,2026-01-15 12:00,2026-01-15 12:30,30,0,false"
+actual_csv=$($WT_CMD export csv --type break)
+check_output "csv export filtered by type" "$expected_csv" "$actual_csv"
+
+actual_msg=$($WT_CMD export xlsx --output "$WT_ROOT/out.xlsx")
+check_output "xlsx written to file" "Exported 2 days to $WT_ROOT/out.xlsx." "$actual_msg"
+actual_magic=$(head -c 2 "$WT_ROOT/out.xlsx")
+check_output "xlsx is a zip file" "PK" "$actual_magic"
+
+expected_error="Unknown export format: pdf. Use one of: json, csv, payroll, md, ics, timeclock, org, influx, xlsx, site"
+actual_error=$($WT_CMD export pdf 2>&1 || true)
+check_output "unknown export format" "$expected_error" "$actual_error"
+
+###############################################################################
+# Test 51: Import
+###############################################################################
+print_test "51" "Import"
+setup_test
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-15 12:00"
+run_wt pause
+mock_time "2026-01-15 12:10"
+run_wt stop
+mock_time "2026-01-15 12:30"
+run_wt start
+mock_time "2026-01-15 13:00"
+run_wt stop
+mock_time "2026-01-16 09:30"
+run_wt restart
+mock_time "2026-01-16 10:30"
+$WT_CMD export --range 2026-01-15 > "$WT_ROOT/backup.json"
+
+cat > "$WT_ROOT/time.timeclock" <<'TIMECLOCK'
+; Exported from another tool
+i 2026-01-14 08:00:00 acme
+o 2026-01-14 10:00:00
+i 2026-01-14 10:15:00 acme
+o 2026-01-14 11:45:00
+TIMECLOCK
+
+expected_import="add  2026-01-14 | Work: 3h:30m | Break: 0h:15m | Paused: 0h:00m
+Dry run: 1 days would be imported."
+actual_import=$($WT_CMD import timeclock "$WT_ROOT/time.timeclock" --dry-run)
+check_output "dry run preview" "$expected_import" "$actual_import"
+
+expected_import="add  2026-01-14 | Work: 3h:30m | Break: 0h:15m | Paused: 0h:00m
+Imported 1 days."
+actual_import=$($WT_CMD import timeclock "$WT_ROOT/time.timeclock")
+check_output "timeclock import" "$expected_import" "$actual_import"
+
+cat > "$WT_ROOT/older.timeclock" <<'TIMECLOCK'
+i 2026-01-06 09:00:00 acme
+o 2026-01-06 10:00:00
+i 2025-12-22 09:00:00 acme
+o 2025-12-22 11:00:00
+i 2026-01-08 09:00:00 acme
+o 2026-01-08 10:30:00
+TIMECLOCK
+run_wt import timeclock "$WT_ROOT/older.timeclock"
+expected_weeks="2026-W03 | Days: 2 | Work: 7h:00m
+2026-W02 | Days: 2 | Work: 2h:30m
+2025-W52 | Days: 1 | Work: 2h:00m"
+check_output "weeks of an import" "$expected_weeks" "$(cut -d'|' -f1,3,4 "$WT_ROOT/.out/weekly-reports" | sed 's/ *$//')"
+
+expected_import="skip 2026-01-15 (already tracked)
+Imported 0 days."
+actual_import=$($WT_CMD import json "$WT_ROOT/backup.json")
+check_output "duplicate days are skipped" "$expected_import" "$actual_import"
+
+expected_report="2026-01-14 | Work: 3h:30m | Break: 0h:15m | Paused: 0h:00m | Total: 3h:45m | Days: 1
+2026-01-15 | Work: 3h:30m | Break: 0h:20m | Paused: 0h:10m | Total: 4h:00m | Days: 1
+2026-01-16 | Work: 1h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 1h:00m | Days: 1
+Total      | Work: 8h:00m | Break: 0h:35m | Paused: 0h:10m | Total: 8h:45m | Days: 3"
+actual_report=$($WT_CMD report --range 2026-01-12..2026-01-31)
+check_output "imported day in range report" "$expected_report" "$actual_report"
+
+rm "$WT_ROOT"/.out/archive/2026-01-15.json
+expected_import="add  2026-01-15 | Work: 3h:30m | Break: 0h:20m | Paused: 0h:10m
+Imported 1 days."
+actual_import=$($WT_CMD import json "$WT_ROOT/backup.json")
+check_output "restore from json backup" "$expected_import" "$actual_import"
+
+# Archived days are looked up by wt's clock (mocked here), not the system's
+mock_time "2130-01-05 09:00"
+run_wt new
+run_wt start
+mock_time "2130-01-05 10:00"
+run_wt stop
+mock_time "2130-01-06 09:00"
+run_wt new
+printf 'i 2130-01-05 09:00:00 acme\no 2130-01-05 10:00:00\n' > "$WT_ROOT/future.timeclock"
+check_output "archived days at the mocked time" "skip 2130-01-05 (already tracked)" "$($WT_CMD import timeclock "$WT_ROOT/future.timeclock" | head -1)"
+
+###############################################################################
+# Test 52: Timer presets
+###############################################################################
+print_test "52" "Timer presets"
+setup_test
+export WT_PRESET_DEEPWORK="50/10 +focus"
+export WT_PRESET_MEETINGS="untimed +meeting"
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt start --preset deepwork
+mock_time "2026-01-15 09:55"
+actual_check=$($WT_CMD check)
+check_output "work target warns" "0h 55m RUNNING (0h 55m) [!] take a break: wt next" "$actual_check"
+run_wt stop
+mock_time "2026-01-15 10:00"
+run_wt start --preset meetings
+mock_time "2026-01-15 11:30"
+actual_check=$($WT_CMD check)
+check_output "untimed preset has no warning" "1h 30m RUNNING (2h 25m)" "$actual_check"
+run_wt stop
+
+expected_log="01. [09:00 => 09:55] Work: 0h:55m (0h:55m) +focus
+02. [09:55 => 10:00] Break: 0h:05m
+03. [10:00 => 11:30] Work: 1h:30m (2h:25m) +meeting"
+actual_log=$($WT_CMD log)
+check_output "cycles tagged by preset" "$expected_log" "$actual_log"
+
+actual_error=$($WT_CMD start --preset nope 2>&1 || true)
+check_output "unknown preset lists available" "Unknown preset: nope (available: deepwork, meetings)" "$actual_error"
+unset WT_PRESET_DEEPWORK WT_PRESET_MEETINGS
+
+###############################################################################
+# Test 53: Scheduled starts
+###############################################################################
+print_test "53" "Scheduled starts"
+setup_test
+export WT_SCHEDULE="0900 mon-fri"
+
+mock_time "2026-01-14 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-14 17:00"
+run_wt stop
+
+mock_time "2026-01-15 09:20"
+actual_remind=$($WT_CMD remind)
+check_output "reminds after scheduled time" "Scheduled start was 09:00 - forgot to start? wt start" "$actual_remind"
+
+mock_time "2026-01-17 09:20"
+actual_remind=$($WT_CMD remind)
+check_output "no schedule on saturday" "Timer has been stopped for 64h 20m - still on break?" "$actual_remind"
+
+mock_time "2026-01-15 09:20"
+actual_remind=$(WT_SCHEDULE_ACTION=start $WT_CMD remind)
+check_output "auto-start backdated to schedule" "Started timer at 09:00 as scheduled." "$actual_remind"
+mock_time "2026-01-15 09:30"
+check_output "new day started at 09:00" "01. [09:00 => .....] Work: 0h:30m (0h:30m)" "$($WT_CMD log)"
+check_output "no reminder once started" "" "$($WT_CMD remind)"
+unset WT_SCHEDULE
+
+###############################################################################
+# Test 54: Holidays
+###############################################################################
+print_test "54" "Holidays"
+setup_test
+
+expected_holidays="Fri 2027-12-24 | Christmas Day (observed)"
+actual_holidays=$(WT_HOLIDAYS=US $WT_CMD holidays 2027 | grep Christmas)
+check_output "observed us holiday" "$expected_holidays" "$actual_holidays"
+
+cat > "$WT_ROOT/company.ics" <<'ICS'
+BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20260114
+DTEND;VALUE=DATE:20260116
+SUMMARY:Company retreat
+END:VEVENT
+END:VCALENDAR
+ICS
+export WT_HOLIDAYS="$WT_ROOT/company.ics"
+export WT_SCHEDULE="0900 mon-fri"
+
+expected_holidays="Wed 2026-01-14 | Company retreat
+Thu 2026-01-15 | Company retreat"
+check_output "ics multi-day event" "$expected_holidays" "$($WT_CMD holidays 2026)"
+
+cat > "$WT_ROOT/founders.ics" <<'ICS'
+BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;TZID=Europe/Berlin:20200501T000000
+DURATION:P2D
+RRULE:FREQ=YEARLY
+SUMMARY:Founders'
+  days
+END:VEVENT
+END:VCALENDAR
+ICS
+expected_holidays="Fri 2026-05-01 | Founders' days
+Sat 2026-05-02 | Founders' days"
+check_output "ics parsed like the calendar" "$expected_holidays" "$(WT_HOLIDAYS="$WT_ROOT/founders.ics" $WT_CMD holidays 2026)"
+
+mock_time "2026-01-13 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-13 12:00"
+run_wt stop
+mock_time "2026-01-14 09:20"
+actual_remind=$(WT_SCHEDULE_ACTION=start $WT_CMD remind)
+check_output "no scheduled start on holiday" "Timer has been stopped for 21h 20m - still on break?" "$actual_remind"
+
+expected_week="Tue 2026-01-13 | 09:00 -> 12:00 | Work: 3h:00m
+Wed 2026-01-14 | Holiday: Company retreat
+Thu 2026-01-15 | Holiday: Company retreat
+Week: 3h:00m"
+check_output "week marks holidays" "$expected_week" "$($WT_CMD week)"
+unset WT_HOLIDAYS WT_SCHEDULE
+
+###############################################################################
+# Test 55: Team server
+###############################################################################
+print_test "55" "Team server"
+setup_test
+
+TEAM_ROOT=$(mktemp -d)
+TEAM_PORT=$((20000 + RANDOM % 10000))
+WT_ROOT="$TEAM_ROOT" WT_TEAM_TOKENS="alice:a1,bob:b2" $WT_CMD server --listen "127.0.0.1:$TEAM_PORT" > /dev/null 2>&1 &
+TEAM_PID=$!
+wait_for_port "$TEAM_PORT"
+export WT_TEAM_SERVER="http://127.0.0.1:$TEAM_PORT"
+
+mock_time "2026-01-13 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-13 17:00"
+run_wt stop
+mock_time "2026-01-14 09:00"
+run_wt restart
+mock_time "2026-01-14 12:00"
+run_wt stop
+
+check_output "submit as alice" "Submitted 2 days as alice." "$(WT_TEAM_TOKEN=a1 $WT_CMD team submit)"
+check_output "resubmit replaces days" "Submitted 2 days as alice." "$(WT_TEAM_TOKEN=a1 $WT_CMD team submit)"
+check_output "submit as bob" "Submitted 1 days as bob." "$(WT_TEAM_TOKEN=b2 $WT_CMD team submit --range today)"
+
+actual_team=$(WT_TEAM_TOKEN=nope $WT_CMD team report --week 2>&1 || true)
+check_output "unknown token rejected" "Team server: Invalid or missing bearer token." "$actual_team"
+
+expected_team="Team thisweek (2026-01-12..2026-01-18)
+alice | Work: 11h:00m | Break: 0h:00m | Days: 2 | Avg: 5h:30m
+bob   | Work: 3h:00m | Break: 0h:00m | Days: 1 | Avg: 3h:00m
+Total | Work: 14h:00m | Break: 0h:00m | Days: 3 | Avg: 4h:40m"
+check_output "team report" "$expected_team" "$(WT_TEAM_TOKEN=b2 $WT_CMD team report --week)"
+
+TEAM_DAYS="$WT_TEAM_SERVER/team/days"
+curl -s -H "Authorization: Bearer b2" -X POST "$TEAM_DAYS" -d '[{"date":"2026-01-20","start":"2026-01-20 09:00","end":"2026-01-20 10:00","work_minutes":60,"break_minutes":0,"lunch_minutes":0,"paused_minutes":0,"entries":[{"type":"work","task":"LIN-42","tags":["secret"]}],"note":"private"}]' > /dev/null
+expected_team='[{"user":"bob","date":"2026-01-20","start":"2026-01-20 09:00","end":"2026-01-20 10:00","work_minutes":60,"break_minutes":0,"lunch_minutes":0,"paused_minutes":0,"entries":[]}]'
+check_output "only totals shared" "$expected_team" "$(curl -s -H "Authorization: Bearer a1" "$TEAM_DAYS?from=2026-01-20")"
+actual_team=$( (printf '['; head -c 9000000 /dev/zero | tr '\0' ' ') | curl -s -H "Authorization: Bearer b2" -X POST "$TEAM_DAYS" --data-binary @-)
+check_output "submission size limited" '{"error":"Submit at most 8 MB at once."}' "$actual_team"
+
+kill $TEAM_PID
+wait $TEAM_PID 2> /dev/null || true
+rm -rf "$TEAM_ROOT"
+unset WT_TEAM_SERVER
+
+###############################################################################
+# Test 56: HTTP API with token scopes
+###############################################################################
+print_test "56" "HTTP API with token scopes"
+setup_test
+
+actual_serve=$($WT_CMD serve --listen 0.0.0.0:8788 2>&1 || true)
+check_output "no tokens, no public listen" "Refusing to serve on 0.0.0.0:8788 without authentication. Set WT_API_TOKENS or listen on 127.0.0.1." "$actual_serve"
+
+mock_time "2026-01-13 09:00"
+run_wt new
+API_PORT=$((20000 + RANDOM % 10000))
+WT_API_TOKENS="r1:read,w1:write" $WT_CMD serve --listen "127.0.0.1:$API_PORT" > /dev/null 2>&1 &
+API_PID=$!
+wait_for_port "$API_PORT"
+API="http://127.0.0.1:$API_PORT/api"
+
+check_output "missing token" '{"error":"Invalid or missing bearer token."}' "$(curl -s -X POST "$API/start")"
+check_output "read token can't write" '{"error":"This token can'"'"'t write."}' "$(curl -s -H "Authorization: Bearer r1" -X POST "$API/start")"
+check_output "write token starts" '{"output":"","status":"running"}' "$(curl -s -H "Authorization: Bearer w1" -X POST "$API/start" -d '{"time":"0010"}')"
+
+expected_status='{"status":"running","day_start":"2026-01-13 08:50","cycle_start":"2026-01-13 08:50","cycle_minutes":10,"totals":{"work_minutes":10,"break_minutes":0,"lunch_minutes":0,"paused_minutes":0},"check":"0h 10m RUNNING (0h 10m)"}'
+check_output "read token gets status" "$expected_status" "$(curl -s -H "Authorization: Bearer r1" "$API/status")"
+check_output "state saved to disk" "0h 10m RUNNING (0h 10m)" "$($WT_CMD check)"
+
+kill $API_PID
+wait $API_PID 2> /dev/null || true
+
+###############################################################################
+# Test 57: TLS
+###############################################################################
+print_test "57" "TLS"
+setup_test
+
+TEAM_ROOT=$(mktemp -d)
+TEAM_PORT=$((20000 + RANDOM % 10000))
+WT_ROOT="$TEAM_ROOT" WT_TEAM_TOKENS="alice:a1" $WT_CMD server --listen "127.0.0.1:$TEAM_PORT" --tls > "$TEAM_ROOT/server.out" 2>&1 &
+TEAM_PID=$!
+wait_for_port "$TEAM_PORT"
+export WT_TEAM_SERVER="https://127.0.0.1:$TEAM_PORT" WT_TEAM_TOKEN=a1
+FINGERPRINT=$(sed -n 3p "$TEAM_ROOT/server.out")
+
+check_output "self-signed certificate created" "yes" "$([ -f "$TEAM_ROOT/.out/tls/cert.pem" ] && echo yes)"
+actual_tls=$($WT_CMD team report 2>&1 | grep -c "certificate" || true)
+check_output "unpinned certificate rejected" "1" "$actual_tls"
+actual_tls=$(WT_TLS_FINGERPRINT="00:11" $WT_CMD team report 2>&1 | grep -c "doesn't match WT_TLS_FINGERPRINT" || true)
+check_output "wrong fingerprint rejected" "1" "$actual_tls"
+check_output "pinned fingerprint accepted" "No team work submitted for thisweek." "$(WT_TLS_FINGERPRINT="$FINGERPRINT" $WT_CMD team report)"
+actual_tls=$(WT_TLS_FINGERPRINT="$FINGERPRINT" WT_RELEASE_URL="https://127.0.0.1:$TEAM_PORT/latest" $WT_CMD version --check 2>&1 | grep -c "certificate signed by unknown authority" || true)
+check_output "pin only trusts wt servers" "1" "$actual_tls"
+
+actual_tls=$($WT_CMD serve --cert "$TEAM_ROOT/.out/tls/cert.pem" 2>&1 || true)
+check_output "cert needs key" "--cert and --key must be given together" "$actual_tls"
+
+kill $TEAM_PID
+wait $TEAM_PID 2> /dev/null || true
+rm -rf "$TEAM_ROOT"
+unset WT_TEAM_SERVER WT_TEAM_TOKEN
+
+###############################################################################
+# Test 58: OpenAPI document
+###############################################################################
+print_test "58" "OpenAPI document"
+setup_test
+
+expected_openapi="3.0.3
+get /api/log getLog
+post /api/next postNext
+post /api/pause postPause
+post /api/start postStart
+get /api/status getStatus
+post /api/stop postStop
+post /api/toggle postToggle
+get /api/week getWeek
+status: check,cycle_minutes,cycle_start,cycle_target,day_start,pause_start,status,task,totals"
+actual_openapi=$($WT_CMD serve --openapi | python3 -c '
+import json, sys
+doc = json.load(sys.stdin)
+print(doc["openapi"])
+for path, item in sorted(doc["paths"].items()):
+    for method, op in sorted(item.items()):
+        print(method, path, op["operationId"])
+print("status:", ",".join(sorted(doc["components"]["schemas"]["APIStatus"]["properties"])))
+')
+check_output "document lists every route" "$expected_openapi" "$actual_openapi"
+
+###############################################################################
+# Test 59: Daemon
+###############################################################################
+print_test "59" "Daemon"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+
+check_output "status when not running" "Daemon not running." "$($WT_CMD daemon status)"
+check_output "start in the background" "Daemon started." "$($WT_CMD daemon start --interval 100ms)"
+check_output "start is idempotent" "Daemon already running." "$($WT_CMD daemon start)"
+
+actual_status=$($WT_CMD daemon status | head -1 | grep -c "^Daemon running (pid [0-9]*), up 0h 00m since ") || true
+check_output "status reports pid and uptime" "1" "$actual_status"
+check_output "status reports features" "Features: reminders" "$($WT_CMD daemon status | tail -1)"
+
+sleep 0.3
+actual_logs=$($WT_CMD daemon logs | grep -o "Reminder: .*")
+check_output "reminder logged once" "Reminder: 2h 00m on current cycle - consider a break." "$actual_logs"
+check_output "logs tail" "1" "$($WT_CMD daemon logs -n 1 | wc -l | tr -d ' ')"
+
+check_output "restart" "Daemon stopped.
+Daemon started." "$($WT_CMD daemon restart)"
+check_output "restart keeps options" "Features: reminders" "$($WT_CMD daemon status | tail -1)"
+check_output "stop" "Daemon stopped." "$($WT_CMD daemon stop)"
+check_output "stop when not running" "Daemon not running. Start it with 'wt daemon start'." "$($WT_CMD daemon stop 2>&1 || true)"
+actual_logs=$($WT_CMD daemon logs | grep -c "Daemon stopped.") || true
+check_output "logs readable after stop" "2" "$actual_logs"
+
+###############################################################################
+# Test 60: Write coordination between CLI and daemon
+###############################################################################
+print_test "60" "Write coordination between CLI and daemon"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt daemon start --interval 1h
+sleep 0.3
+
+run_wt stop
+check_output "change recorded" '"command":"wt stop"' "$(grep -o '"command":"[^"]*"' "$WT_ROOT/.out/wt.changed")"
+check_output "lock released" "no" "$([ -e "$WT_ROOT/.out/wt.lock" ] && echo yes || echo no)"
+sleep 1
+actual_logs=$($WT_CMD daemon logs | grep -c "Timer changed by 'wt stop'") || true
+check_output "daemon notices cli change" "1" "$actual_logs"
+
+seq_before=$(grep -o '"seq":[0-9]*' "$WT_ROOT/.out/wt.changed")
+run_wt check
+run_wt mode
+check_output "read-only commands record nothing" "$seq_before" "$(grep -o '"seq":[0-9]*' "$WT_ROOT/.out/wt.changed")"
+run_wt daemon stop
+
+sleep 30 &
+holder=$!
+echo "$holder" > "$WT_ROOT/.out/wt.lock"
+actual_error=$(WT_LOCK_TIMEOUT=0 $WT_CMD start 2>&1 || true)
+check_output "waits for a live lock holder" "Another wt process (pid $holder) is changing the timer. Try again, or remove $WT_ROOT/.out/wt.lock if it's stale." "$actual_error"
+kill "$holder"
+wait "$holder" 2> /dev/null || true
+check_output "takes over a dead holder's lock" "running" "$($WT_CMD start > /dev/null; $WT_CMD status)"
+
+###############################################################################
+# Test 61: Notify command
+###############################################################################
+print_test "61" "Notify command"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+
+notified="$WT_ROOT/.out/notified"
+export WT_NOTIFY_COMMAND="sh -c 'echo \"\$1 | \$2\" >> $notified' notify {title} \"{body}\""
+check_output "remind still prints" "2h 00m on current cycle - consider a break." "$($WT_CMD remind --notify)"
+check_output "command gets title and body" "wt | 2h 00m on current cycle - consider a break." "$(cat "$notified")"
+
+rm -f "$notified"
+run_wt remind
+check_output "no notification without --notify" "no" "$([ -e "$notified" ] && echo yes || echo no)"
+
+run_wt daemon start --interval 1h
+sleep 0.3
+run_wt daemon stop
+check_output "daemon notifies through the command" "wt | 2h 00m on current cycle - consider a break." "$(cat "$notified")"
+
+actual_error=$(WT_NOTIFY_COMMAND="false" $WT_CMD remind --notify 2>&1 || true)
+check_output "failing command reported" "2h 00m on current cycle - consider a break.
+command notifier: exit status 1" "$actual_error"
+unset WT_NOTIFY_COMMAND
+
+###############################################################################
+# Test 62: ntfy notifications
+###############################################################################
+print_test "62" "ntfy notifications"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+
+port=$((20000 + RANDOM % 10000))
+received="$WT_ROOT/.out/ntfy"
+python3 - "$port" "$received" <<'PY' &
+import sys
+from http.server import BaseHTTPRequestHandler, HTTPServer
+
+class Handler(BaseHTTPRequestHandler):
+    def do_POST(self):
+        body = self.rfile.read(int(self.headers["Content-Length"])).decode()
+        with open(sys.argv[2], "a") as f:
+            f.write(f"{self.path} | {self.headers['Title']} | {self.headers['Priority']} | {self.headers['Authorization']} | {body}\n")
+        self.send_response(403 if self.path == "/denied" else 200)
+        self.end_headers()
+
+    def log_message(self, *args):
+        pass
+
+HTTPServer(("127.0.0.1", int(sys.argv[1])), Handler).serve_forever()
+PY
+ntfy_pid=$!
+wait_for_port "$port"
+
+WT_NTFY_URL="http://127.0.0.1:$port/wt-test" WT_NTFY_PRIORITY=high WT_NTFY_TOKEN=tk_123 run_wt remind --notify
+check_output "published to topic" "/wt-test | wt | high | Bearer tk_123 | 2h 00m on current cycle - consider a break." "$(cat "$received")"
+
+actual_error=$(WT_NTFY_URL="http://127.0.0.1:$port/denied" $WT_CMD remind --notify 2>&1 || true)
+check_output "rejected publish reported" "2h 00m on current cycle - consider a break.
+ntfy notifier: 127.0.0.1:$port returned 403 Forbidden" "$actual_error"
+
+kill "$ntfy_pid"
+wait "$ntfy_pid" 2> /dev/null || true
+
+###############################################################################
+# Test 63: Telegram bot
+###############################################################################
+print_test "63" "Telegram bot"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+
+port=$((20000 + RANDOM % 10000))
+sent="$WT_ROOT/.out/telegram"
+python3 - "$port" "$sent" <<'PY' &
+import json, sys, time
+from http.server import BaseHTTPRequestHandler, HTTPServer
+
+updates = [
+    {"update_id": 1, "message": {"from": {"id": 42}, "chat": {"id": 42}, "text": "/status"}},
+    {"update_id": 2, "message": {"from": {"id": 99}, "chat": {"id": 99}, "text": "/stop"}},
+    {"update_id": 3, "message": {"from": {"id": 42}, "chat": {"id": 42}, "text": "/stop@wt_bot"}},
+    {"update_id": 4, "message": {"from": {"id": 42}, "chat": {"id": 42}, "text": "/hello"}},
+    {"update_id": 5, "message": {"from": {"id": 42}, "chat": {"id": 42}, "text": "   "}},
+]
+
+class Handler(BaseHTTPRequestHandler):
+    def do_POST(self):
+        params = json.loads(self.rfile.read(int(self.headers["Content-Length"])))
+        if not self.path.startswith("/botT0KEN/"):
+            result = {"ok": False, "description": "Unauthorized"}
+        elif self.path.endswith("/getUpdates"):
+            pending = [u for u in updates if u["update_id"] >= params["offset"]]
+            if not pending:
+                time.sleep(0.2)
+            result = {"ok": True, "result": pending}
+        else:
+            with open(sys.argv[2], "a") as f:
+                f.write(f"{params['chat_id']} | {params['text']}\n")
+            result = {"ok": True, "result": {}}
+        data = json.dumps(result).encode()
+        try:
+            self.send_response(200)
+            self.send_header("Content-Length", str(len(data)))
+            self.end_headers()
+            self.wfile.write(data)
+        except BrokenPipeError:
+            pass  # The daemon stopped mid long-poll
+
+    def log_message(self, *args):
+        pass
+
+HTTPServer(("127.0.0.1", int(sys.argv[1])), Handler).serve_forever()
+PY
+telegram_pid=$!
+wait_for_port "$port"
+export WT_TELEGRAM_API="http://127.0.0.1:$port" WT_TELEGRAM_TOKEN=T0KEN WT_TELEGRAM_USER_ID=42
+
+actual_error=$(WT_TELEGRAM_USER_ID=me $WT_CMD daemon run 2>&1 || true)
+check_output "user id must be numeric" "Set both WT_TELEGRAM_TOKEN and WT_TELEGRAM_USER_ID (your numeric Telegram user id) to use the Telegram bot" "$actual_error"
+
+run_wt daemon start --interval 1h
+sleep 1
+check_output "telegram feature" "Features: reminders, telegram" "$($WT_CMD daemon status | tail -1)"
+run_wt daemon stop
+
+expected_sent="42 | 2h 00m RUNNING (2h 00m) [!] take a break: wt next
+42 | Commands: /status, /start [HHMM], /pause [HHMM], /stop, /next
+42 | Commands: /status, /start [HHMM], /pause [HHMM], /stop, /next
+42 | Timer is stopped.
+42 | wt: 2h 00m on current cycle - consider a break."
+check_output "bot answers its user only" "$expected_sent" "$(sort "$sent")"
+actual_logs=$($WT_CMD daemon logs | grep -c "Telegram: ignored a message from user 99.") || true
+check_output "other users ignored" "1" "$actual_logs"
+check_output "timer stopped from telegram" "stopped" "$($WT_CMD status)"
+
+mock_time "2026-01-20 10:45"
+actual_error=$(WT_TELEGRAM_TOKEN=wrong $WT_CMD remind --notify 2>&1 || true)
+check_output "remind errors don't leak the token" "Timer has been stopped for 0h 45m - still on break?
+telegram notifier: Telegram sendMessage: Unauthorized" "$actual_error"
+
+unset WT_TELEGRAM_API WT_TELEGRAM_TOKEN WT_TELEGRAM_USER_ID
+kill "$telegram_pid"
+wait "$telegram_pid" 2> /dev/null || true
+
+###############################################################################
+# Test 64: Remote control client
+###############################################################################
+print_test "64" "Remote control client"
+setup_test
+
+REMOTE_ROOT=$(mktemp -d)
+mock_time "2026-01-13 09:00"
+WT_ROOT="$REMOTE_ROOT" $WT_CMD new > /dev/null
+REMOTE_PORT=$((20000 + RANDOM % 10000))
+WT_ROOT="$REMOTE_ROOT" WT_API_TOKENS="r1:read,w1:write" $WT_CMD serve --listen "127.0.0.1:$REMOTE_PORT" > /dev/null 2>&1 &
+REMOTE_PID=$!
+wait_for_port "$REMOTE_PORT"
+REMOTE="http://127.0.0.1:$REMOTE_PORT"
+
+actual_error=$($WT_CMD --remote "$REMOTE" stop 2>&1 || true)
+check_output "token required" "Remote wt: Invalid or missing bearer token." "$actual_error"
+
+export WT_REMOTE_TOKEN=w1
+# The server's clock (mocked when it started) decides the times
+run_wt --remote "$REMOTE" start 0010
+check_output "remote check" "0h 10m RUNNING (0h 10m)" "$($WT_CMD --remote "$REMOTE" check)"
+check_output "remote default command" "0h 10m RUNNING (0h 10m)" "$($WT_CMD --remote "$REMOTE")"
+run_wt --remote "$REMOTE" next
+run_wt --remote "$REMOTE" pause
+
+expected_log="01. [08:50 => 09:00] Work: 0h:10m (0h:10m)
+02. [09:00 => 09:00] Break: 0h:00m
+03. [09:00 => .....] Work (paused): 0h:00m (0h:10m)"
+check_output "remote log" "$expected_log" "$(WT_REMOTE="$REMOTE" $WT_CMD log)"
+check_output "remote state on the server" "paused" "$(WT_ROOT="$REMOTE_ROOT" $WT_CMD status)"
+check_output "no local timer created" "no" "$([ -e "$WT_ROOT/.out/wt.json" ] && echo yes || echo no)"
+
+actual_error=$(WT_REMOTE_TOKEN=r1 $WT_CMD --remote "$REMOTE" stop 2>&1 || true)
+check_output "read token can't stop" "Remote wt: This token can't write." "$actual_error"
+actual_error=$($WT_CMD --remote "$REMOTE" mod 1 add 10 2>&1 || true)
+check_output "local-only command refused" "'wt mod' isn't available with --remote. Remote commands: check, status, log, start, pause, stop, next, toggle" "$actual_error"
+unset WT_REMOTE_TOKEN
+
+kill $REMOTE_PID
+wait $REMOTE_PID 2> /dev/null || true
+rm -rf "$REMOTE_ROOT"
+
+###############################################################################
+# Test 65: Closing a day
+###############################################################################
+print_test "65" "Closing a day"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+mock_time "2026-01-20 12:30"
+run_wt start
+mock_time "2026-01-20 16:00"
+
+check_output "close stops and archives" "Timer stopped.
+Day 2026-01-20 closed. Work: 7h:30m, archived as archive/2026-01-20.json." "$($WT_CMD close)"
+check_output "archive read-only" "-r--r--r--" "$(ls -l "$WT_ROOT/.out/archive/2026-01-20.json" | cut -c1-10)"
+check_output "daily report written" "1" "$(grep -c "^2026-01-20" "$WT_ROOT/.out/daily-reports")"
+
+actual_error=$($WT_CMD start 2>&1 || true)
+check_output "no new cycles on closed day" "Day 2026-01-20 is closed. Run 'wt new' to start the next day." "$actual_error"
+actual_error=$($WT_CMD close 2>&1 || true)
+check_output "close only once" "Day 2026-01-20 is already closed." "$actual_error"
+actual_error=$($WT_CMD mod 1 add 15 2>&1 || true)
+check_output "mod needs force" "Day 2026-01-20 is closed. Use 'wt mod --force 1 add 15' to change it anyway; the change is recorded." "$actual_error"
+
+mock_time "2026-01-20 18:00"
+run_wt mod --force 1 add 15
+check_output "forced mod recorded" "2026-01-20 18:00 wt mod 1 add 15" "$(grep -o '"2026-01-20 18:00 wt mod [^"]*"' "$WT_ROOT/.out/archive/2026-01-20.json" | tr -d '"')"
+check_output "archive updated" '"minutes": 255' "$(grep -m1 -o '"minutes": [0-9]*' "$WT_ROOT/.out/archive/2026-01-20.json")"
+check_output "archive still read-only" "-r--r--r--" "$(ls -l "$WT_ROOT/.out/archive/2026-01-20.json" | cut -c1-10)"
+check_output "audit in debug log" "1" "$($WT_CMD log debug | grep -c "Changed closed day 2026-01-20")"
+check_output "report line updated" "2026-01-20 | 08:00 -> 16:15 | Work: 7h:45m" "$(grep "^2026-01-20" "$WT_ROOT/.out/daily-reports" | cut -d'|' -f1-3 | sed 's/ $//')"
+
+for command in stop toggle undo; do
+    actual_error=$($WT_CMD $command 2>&1 || true)
+    check_output "no $command on closed day" "Day 2026-01-20 is closed. Run 'wt new' to start the next day." "$actual_error"
+done
+
+mock_time "2026-01-21 08:00"
+run_wt new
+check_output "new day doesn't archive again" "2026-01-20.json" "$(ls "$WT_ROOT/.out/archive")"
+check_output "daily report not duplicated" "1" "$(grep -c "^2026-01-20" "$WT_ROOT/.out/daily-reports")"
+
+###############################################################################
+# Test 66: Strict mode
+###############################################################################
+print_test "66" "Strict mode"
+setup_test
+export WT_STRICT=1
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:00"
+run_wt stop
+mock_time "2026-01-20 09:15"
+run_wt start
+
+actual_error=$($WT_CMD mod start add 90 2>&1 || true)
+check_output "day start in the future refused" "Strict mode: not saving an inconsistent timer:
+  - day starts in the future (09:30)
+  - the timeline ends in the future (10:45)" "$actual_error"
+
+mock_time "2026-01-20 08:45"
+actual_error=$($WT_CMD pause 2>&1 > /dev/null || true)
+check_output "clock going backwards held" "Warning: The clock went back 0h:30m since the last command (2026-01-20 09:15). Times are held at 09:15 until it catches up." "$actual_error"
+check_output "paused at the held time" "paused" "$($WT_CMD status)"
+
+mock_time "2026-01-20 10:00"
+run_wt next
+check_output "consistent changes saved" "running" "$($WT_CMD status)"
+unset WT_STRICT
+
+###############################################################################
+# Test 67: ASCII-only output
+###############################################################################
+print_test "67" "ASCII-only output"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:00"
+run_wt stop
+mock_time "2026-01-20 09:10"
+run_wt start
+mock_time "2026-01-20 09:30"
+
+check_output "unicode gantt by default" "3" "$($WT_CMD log --gantt | grep -c "█")"
+expected_gantt="08:00 |##############################|
+09:00 |.....##########               |
+# work  = paused  . break (1 char = 2 min)"
+check_output "ascii gantt" "$expected_gantt" "$(WT_ASCII=1 $WT_CMD log --gantt)"
+
+check_output "ascii report currency" "yes" "$(WT_ASCII=1 WT_RATE="90 €" $WT_CMD report | grep -q "Earned: 120.00 EUR" && echo yes)"
+
+cat > "$WT_ROOT/.out/company.ics" <<'ICS'
+BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20260212
+SUMMARY:Betriebsausflug Köln – Café
+END:VEVENT
+END:VCALENDAR
+ICS
+check_output "ascii transliterates data" "Thu 2026-02-12 | Betriebsausflug Koeln - Cafe" "$(WT_ASCII=1 WT_HOLIDAYS="$WT_ROOT/.out/company.ics" $WT_CMD holidays 2026)"
+check_output "no bytes outside ascii" "0" "$(WT_ASCII=1 WT_RATE="90 ¤" $WT_CMD report | LC_ALL=C grep -c '[^ -~]' || true)"
+
+###############################################################################
+# Test 68: Command traces in the debug log
+###############################################################################
+print_test "68" "Command traces in the debug log"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+$WT_CMD check > /dev/null
+check_output "no traces at info level" "0" "$(grep -c '"level":"debug"' "$WT_ROOT/.out/debug-log" || true)"
+
+check_output "no traces of check" "0" "$(WT_LOG_LEVEL=debug $WT_CMD check > /dev/null; grep -c '"level":"debug"' "$WT_ROOT/.out/debug-log" || true)"
+
+# check runs read-only, so its trace takes --trace (or WT_TRACE)
+$WT_CMD --trace check > /dev/null
+export WT_LOG_LEVEL=debug
+$WT_CMD log > /dev/null
+run_wt stop
+$WT_CMD close > /dev/null
+unset WT_LOG_LEVEL
+
+check_output "check trace" '"read":[".out/wt.json"]}' "$(grep '"command":"check"' "$WT_ROOT/.out/debug-log" | grep -o '"read":.*')"
+check_output "check has a duration" "1" "$(grep '"command":"check"' "$WT_ROOT/.out/debug-log" | grep -c '"duration_ms":[0-9.]*,')"
+check_output "log trace" '"read":[".out/wt.json"]}' "$(grep '"command":"log"' "$WT_ROOT/.out/debug-log" | grep -o '"read":.*')"
+check_output "stop trace" '"read":[".out/wt.json"],"wrote":[".out/wt.json"]}' "$(grep '"level":"debug","command":"stop"' "$WT_ROOT/.out/debug-log" | grep -o '"read":.*')"
+check_output "close trace" '"wrote":[".out/daily-reports",".out/archive/2026-01-20.json",".out/weekly-reports",".out/wt.json"]}' "$(grep '"level":"debug","command":"close"' "$WT_ROOT/.out/debug-log" | grep -o '"wrote":.*')"
+check_output "readable trace" "[2026-01-20 08:00] DEBUG wt check (Nms, read .out/wt.json)" "$($WT_CMD log debug | grep 'DEBUG wt check' | sed -E 's/[0-9.]+ms/Nms/')"
+check_output "replay ignores traces" "Replayed 3 commands." "$($WT_CMD replay | head -1)"
+
+###############################################################################
+# Test 69: Selftest
+###############################################################################
+print_test "69" "Selftest"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+before=$(cat "$WT_ROOT/.out/wt.json")
+
+selftest_output=$(WT_RATE="50 USD" WT_LUNCH="12:00-13:00" WT_STRICT=1 $WT_CMD selftest)
+check_output "selftest passes" "Selftest passed: 10 steps." "$(echo "$selftest_output" | tail -1)"
+check_output "selftest checks every step" "10" "$(echo "$selftest_output" | grep -c '^ok ')"
+check_output "report step" "ok   13:30 wt report        work 3h:30m, break 0h:30m, paused 0h:15m" "$(echo "$selftest_output" | grep 'wt report')"
+check_output "own timer untouched" "$before" "$(cat "$WT_ROOT/.out/wt.json")"
+check_output "settings restored" "0h 00m RUNNING (0h 00m)" "$($WT_CMD check)"
+
+###############################################################################
+# Test 70: Colon-separated time arguments
+###############################################################################
+print_test "70" "Colon-separated time arguments"
+setup_test
+
+mock_time "2026-01-20 10:00"
+run_wt new
+run_wt mode normal
+check_output "start with colon" "Starting timer." "$($WT_CMD start 0:30)"
+mock_time "2026-01-20 10:30"
+check_output "pause with colon" "Paused timer (added 5m pause time)" "$($WT_CMD pause 00:05)"
+mock_time "2026-01-20 10:40"
+run_wt stop
+check_output "mod with colon" "Modified cycle 1 duration by +1h:30m" "$($WT_CMD mod 1 add 1:30)"
+check_output "mod start with colon" "Day start adjusted by -0h:15m" "$($WT_CMD mod start sub 0:15)"
+check_output "mod pause with colon" "Modified cycle 1 paused time by -0h:05m" "$($WT_CMD mod 1 pause sub 0:05)"
+check_output "same as digits" "01. [09:15 => 11:50] Work: 2h:25m |10m| (2h:25m)" "$($WT_CMD log)"
+
+check_output "minutes need two digits" "Incorrect time format. Should be 1-4 digit HHMM or HH:MM." "$($WT_CMD start 9:3 2>&1 || true)"
+check_output "one colon only" "Invalid time format. Use HHMM or HH:MM." "$($WT_CMD mod 1 add 1:2:3 2>&1 || true)"
+check_output "minutes over 59" "Incorrect time format. Minutes cannot exceed 59." "$($WT_CMD start 0:75 2>&1 || true)"
+
+###############################################################################
+# Test 71: Move the day to another date
+###############################################################################
+print_test "71" "Move the day to another date"
+setup_test
+
+mock_time "2026-01-20 00:10"
+run_wt new
+run_wt mode normal
+run_wt start
+check_output "refused while running" "Stop the timer before moving the day to another date." "$($WT_CMD mod start date 2026-01-19 2>&1 || true)"
+mock_time "2026-01-20 00:40"
+run_wt stop
+
+check_output "moved back" "Day moved to 2026-01-19 (-1 day)" "$($WT_CMD mod start date 2026-01-19)"
+check_output "report on new date" "2026-01-19 | 00:10 -> 00:40 | Work: 0h:30m" "$($WT_CMD report | cut -d'|' -f1-3 | sed 's/ *$//')"
+check_output "stop time moved" "2026-01-19 00:40" "$(grep -o '"stop_datetime_str": "[^"]*"' "$WT_ROOT/.out/wt.json" | cut -d'"' -f4)"
+check_output "same date" "Day is already on 2026-01-19." "$($WT_CMD mod start date 2026-01-19)"
+check_output "not into the future" "Can't move the day to 2026-01-21: it would end in the future." "$($WT_CMD mod start date 2026-01-21 2>&1 || true)"
+check_output "invalid date" "Invalid date: 19.01.2026. Use YYYY-MM-DD" "$($WT_CMD mod start date 19.01.2026 2>&1 || true)"
+check_output "logged" "[2026-01-20 00:40] wt mod start date 2026-01-19" "$($WT_CMD log debug | grep 'start date')"
+check_output "moved forward" "Day moved to 2026-01-20 (+1 day)" "$($WT_CMD mod start date 2026-01-20)"
+
+mock_time "2026-01-25 12:00"
+check_output "several days" "Day moved to 2026-01-23 (+3 days)" "$($WT_CMD mod start date 2026-01-23)"
+run_wt close
+check_output "closed days stay" "Day 2026-01-23 is closed and archived as 2026-01-23.json. It can't be moved to another date." "$($WT_CMD mod --force start date 2026-01-22 2>&1 || true)"
+
+###############################################################################
+# Test 72: Editing archived days
+###############################################################################
+print_test "72" "Editing archived days"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+run_wt close
+mock_time "2026-01-21 09:00"
+run_wt new
+run_wt start
+
+check_output "edit archived day" "Modified cycle 1 duration by +0h:15m
+Updated archive/2026-01-19.json and the daily report. Work: 3h:00m -> 3h:15m" "$($WT_CMD mod --date 2026-01-19 1 add 15)"
+check_output "archive updated" "195" "$(grep -o '"minutes": [0-9]*' "$WT_ROOT/.out/archive/2026-01-19.json" | head -1 | grep -o '[0-9]*$')"
+expected_reports="2026-01-20 | 09:00 -> 10:00 | Work: 1h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 1h:00m
+2026-01-19 | 09:00 -> 12:15 | Work: 3h:15m | Break: 0h:00m | Paused: 0h:00m | Total: 3h:15m"
+check_output "report line regenerated in place" "$expected_reports" "$($WT_CMD report history)"
+check_output "current timer untouched" "0h 00m RUNNING (0h 00m)" "$($WT_CMD check)"
+check_output "logged" "[2026-01-21 09:00] wt mod --date 2026-01-19 1 add 15: Changed archived day 2026-01-19" "$($WT_CMD log debug | grep -- '--date')"
+check_output "replay skips it" "Replayed 1 commands." "$($WT_CMD replay | head -1)"
+
+check_output "closed day needs force" "Day 2026-01-20 is closed. Use 'wt mod --force --date 2026-01-20 1 drop' to change it anyway; the change is recorded." "$($WT_CMD mod --date 2026-01-20 1 drop 2>&1 || true)"
+run_wt mod --force --date 2026-01-20 1 sub 10
+check_output "closed archive amended" "2026-01-21 09:00 wt mod 1 sub 10" "$(grep -A1 '"amended"' "$WT_ROOT/.out/archive/2026-01-20.json" | tail -1 | cut -d'"' -f2)"
+check_output "closed archive stays read-only" "-r--r--r--" "$(ls -l "$WT_ROOT/.out/archive/2026-01-20.json" | cut -c1-10)"
+check_output "closed day report line" "2026-01-20 | 09:00 -> 09:50 | Work: 0h:50m" "$($WT_CMD report history -n 1 | cut -d'|' -f1-3 | sed 's/ *$//')"
+
+check_output "unknown day" "No archived day 2026-01-18." "$($WT_CMD mod --date 2026-01-18 1 add 5 2>&1 || true)"
+check_output "refused change" "Cycle 3 does not exist. Valid range: 1-1" "$($WT_CMD mod --date 2026-01-19 3 add 5 2>&1 || true)"
+
+mock_time "2026-01-19 14:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 15:00"
+run_wt stop
+mock_time "2026-01-21 10:00"
+run_wt new
+check_output "ambiguous day" "2026-01-19 has 2 archived timers: 2026-01-19.2, 2026-01-19. Pick one with --date." "$($WT_CMD mod --date 2026-01-19 1 add 5 2>&1 || true)"
+run_wt prune --compress --older-than 1d
+run_wt mod --date 2026-01-19.2 1 add 30
+check_output "compressed archive edited" "90" "$(gzip -dc "$WT_ROOT/.out/archive/2026-01-19.2.json.gz" | grep -o '"minutes": [0-9]*' | head -1 | grep -o '[0-9]*$')"
+check_output "both lines of the day kept" "2" "$(grep -c '^2026-01-19' "$WT_ROOT/.out/daily-reports")"
+check_output "newest line first" "2026-01-19 | 14:00 -> 15:30 | Work: 1h:30m" "$($WT_CMD report history | grep '^2026-01-19' | head -1 | cut -d'|' -f1-3 | sed 's/ *$//')"
+
+###############################################################################
+# Test 73: Weekly report file
+###############################################################################
+print_test "73" "Weekly report file"
+setup_test
+export WT_DAILY_GOAL=800
+
+for day in 12 13 14 19 20; do
+    mock_time "2026-01-$day 09:00"
+    run_wt new
+    run_wt start
+    mock_time "2026-01-$day 17:00"
+    run_wt stop
+done
+check_output "current day not counted" "2026-W04 | 2026-01-19 -> 2026-01-25 | Days: 1" "$(head -1 "$WT_ROOT/.out/weekly-reports" | cut -d'|' -f1-3 | sed 's/ *$//')"
+
+mock_time "2026-01-21 09:00"
+run_wt new
+expected_weekly="2026-W04 | 2026-01-19 -> 2026-01-25 | Days: 2 | Work: 16h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 16h:00m | Goal: 40% of 40h:00m
+2026-W03 | 2026-01-12 -> 2026-01-18 | Days: 3 | Work: 24h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 24h:00m | Goal: 60% of 40h:00m"
+check_output "one line per week, newest first" "$expected_weekly" "$(cat "$WT_ROOT/.out/weekly-reports")"
+
+run_wt mod --date 2026-01-13 1 sub 100
+check_output "recomputed after mod --date" "2026-W03 | 2026-01-12 -> 2026-01-18 | Days: 3 | Work: 23h:00m" "$(tail -1 "$WT_ROOT/.out/weekly-reports" | cut -d'|' -f1-4 | sed 's/ *$//')"
+
+run_wt start
+mock_time "2026-01-21 12:00"
+run_wt stop
+run_wt close
+check_output "closed day counted" "Days: 3 | Work: 19h:00m" "$(head -1 "$WT_ROOT/.out/weekly-reports" | cut -d'|' -f3-4 | sed 's/^ *//; s/ *$//')"
+run_wt mod --force 1 add 30
+check_output "recomputed after forced mod" "Days: 3 | Work: 19h:30m" "$(head -1 "$WT_ROOT/.out/weekly-reports" | cut -d'|' -f3-4 | sed 's/^ *//; s/ *$//')"
+
+unset WT_DAILY_GOAL
+WT_WEEKLY_GOAL=2000 $WT_CMD mod --date 2026-01-20 1 add 15 > /dev/null
+check_output "weekly goal" "Goal: 98% of 20h:00m" "$(head -1 "$WT_ROOT/.out/weekly-reports" | grep -o 'Goal: .*')"
+
+mock_time "2026-02-02 09:00"
+run_wt new
+run_wt start
+mock_time "2026-02-02 10:00"
+run_wt stop
+run_wt new
+check_output "no goal without a setting" "2026-W06 | 2026-02-02 -> 2026-02-08 | Days: 1 | Work: 1h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 1h:00m" "$(head -1 "$WT_ROOT/.out/weekly-reports")"
+
+WT_WEEKLY_REPORT_FILE="$WT_ROOT/weeks.txt" $WT_CMD mod --date 2026-02-02 1 add 15 > /dev/null
+check_output "custom file" "2026-W06" "$(cut -d' ' -f1 "$WT_ROOT/weeks.txt")"
+run_wt remove
+check_output "removed with the timer" "no" "$([ -f "$WT_ROOT/.out/weekly-reports" ] && echo yes || echo no)"
+
+###############################################################################
+# Test 74: Shell exports for a root
+###############################################################################
+print_test "74" "Shell exports for a root"
+setup_test
+run_wt new
+
+mkdir -p "$WT_ROOT/project/sub"
+wt_abs="$(cd "$(dirname "$WT_CMD")" && pwd)/$(basename "$WT_CMD")"
+check_output "given path" "export WT_ROOT='$WT_ROOT/project'" "$($WT_CMD env "$WT_ROOT/project/sub/.." | head -1)"
+check_output "report file in the root" "export WT_REPORT_FILE='$WT_ROOT/project/.out/daily-reports'" "$($WT_CMD env "$WT_ROOT/project" | sed -n 2p)"
+actual_exports=$(WT_REPORT_FILE="$WT_ROOT/.out/daily-reports" WT_WEEKLY_REPORT_FILE="$WT_ROOT/.out/weekly-reports" $WT_CMD env "$WT_ROOT/project" | sed -n 2,3p)
+check_output "current report files not carried over" "export WT_REPORT_FILE='$WT_ROOT/project/.out/daily-reports'
+export WT_WEEKLY_REPORT_FILE='$WT_ROOT/project/.out/weekly-reports'" "$actual_exports"
+check_output "discovered from a subfolder" "export WT_ROOT='$WT_ROOT'" "$(cd "$WT_ROOT/project/sub" && WT_ROOT= "$wt_abs" env | head -1)"
+check_output "evaluates" "$WT_ROOT/project" "$(eval "$($WT_CMD env "$WT_ROOT/project")"; echo "$WT_ROOT")"
+check_output "no root" "No timer found in / or above, and \$WT_ROOT isn't set. Pass a path: wt env <path>" "$(cd / && WT_ROOT= "$wt_abs" env 2>&1 || true)"
+check_output "missing folder" "$WT_ROOT/nowhere is not a folder." "$($WT_CMD env "$WT_ROOT/nowhere" 2>&1 || true)"
+
+###############################################################################
+# Test 75: Version and self-upgrade
+###############################################################################
+print_test "75" "Version and self-upgrade"
+setup_test
+
+platform=$($WT_CMD version | sed 's/.*(\(.*\))/\1/')
+asset="wt_${platform%/*}_${platform#*/}"
+check_output "version" "wt dev ($platform)" "$($WT_CMD version)"
+
+port=$((20000 + RANDOM % 10000))
+release="$WT_ROOT/.out/release"
+mkdir -p "$release" "$WT_ROOT/.out/bin"
+printf '#!/bin/sh\necho "upgraded wt"\n' > "$release/$asset"
+(cd "$release" && sha256sum "$asset" > checksums.txt)
+cat > "$release/latest" <<JSON
+{"tag_name": "v9.9.9", "assets": [
+  {"name": "$asset", "browser_download_url": "http://127.0.0.1:$port/$asset"},
+  {"name": "checksums.txt", "browser_download_url": "http://127.0.0.1:$port/checksums.txt"}]}
+JSON
+python3 -m http.server "$port" --bind 127.0.0.1 --directory "$release" > /dev/null 2>&1 &
+release_pid=$!
+wait_for_port "$port"
+export WT_RELEASE_URL="http://127.0.0.1:$port/latest"
+
+check_output "check from a dev build" "wt dev ($platform)
+Latest release: v9.9.9. This is a development build; 'wt upgrade --force' replaces it." "$($WT_CMD version --check)"
+
+cp "$WT_CMD" "$WT_ROOT/.out/bin/wt"
+check_output "dev build needs --force" "This is a development build. Use 'wt upgrade --force' to replace it with v9.9.9." "$("$WT_ROOT/.out/bin/wt" upgrade 2>&1 || true)"
+
+mv "$release/checksums.txt" "$release/checksums.good"
+echo "0000000000000000000000000000000000000000000000000000000000000000  $asset" > "$release/checksums.txt"
+check_output "checksum mismatch refused" "Checksum mismatch for $asset" "$("$WT_ROOT/.out/bin/wt" upgrade --force 2>&1 | cut -d: -f1 || true)"
+check_output "binary kept" "wt dev ($platform)" "$("$WT_ROOT/.out/bin/wt" version)"
+
+mv "$release/checksums.good" "$release/checksums.txt"
+check_output "upgraded" "Upgraded wt dev -> v9.9.9." "$("$WT_ROOT/.out/bin/wt" upgrade --force)"
+check_output "new binary runs" "upgraded wt" "$("$WT_ROOT/.out/bin/wt")"
+check_output "nothing left behind" "wt" "$(ls "$WT_ROOT/.out/bin")"
+
+kill "$release_pid"
+wait "$release_pid" 2> /dev/null || true
+unset WT_RELEASE_URL
+
+###############################################################################
+# Test 76: Tutorial
+###############################################################################
+print_test "76" "Tutorial"
+setup_test
+
+actual_tutorial=$($WT_CMD tutorial)
+check_output "lessons" "10" "$(echo "$actual_tutorial" | grep -c '^[0-9]*/10 ')"
+check_output "fixed with mod" "  01. [09:00 => 10:15] Work: 1h:15m (1h:15m)" "$(echo "$actual_tutorial" | grep '01\. ' | tail -1)"
+check_output "report" "  2026-03-02 | 09:00 -> 11:45 | Work: 2h:30m | Break: 0h:15m | Paused: 0h:00m | Total: 2h:45m" "$(echo "$actual_tutorial" | grep '^  2026-03-02')"
+check_output "real timer untouched" "no" "$([ -f "$WT_ROOT/.out/wt.json" ] && echo yes || echo no)"
+check_output "quit" "2/10" "$(printf '\nq\n' | WT_SKIP_PROMPTS= $WT_CMD tutorial | grep -o '^[0-9]*/10' | tail -1)"
+
+###############################################################################
+# Test 77: Demo data
+###############################################################################
+print_test "77" "Demo data"
+setup_test
+
+mock_time "2026-03-06 11:20"
+demo="$WT_ROOT/demo"
+check_output "created" "Created 10 demo days (2026-02-20 to 2026-03-05) and today's timer in $demo" "$($WT_CMD demo "$demo" | head -1)"
+check_output "archived days" "10" "$(ls "$demo/.out/archive" | wc -l | tr -d ' ')"
+check_output "report lines" "10" "$(wc -l < "$demo/.out/daily-reports" | tr -d ' ')"
+check_output "weekly lines" "2026-W10 2026-W09 2026-W08" "$(cut -d' ' -f1 "$demo/.out/weekly-reports" | tr '\n' ' ' | sed 's/ $//')"
+check_output "demo profile" "demo" "$(cat "$demo/.out/profile")"
+check_output "today's timer" "2026-03-06" "$(WT_ROOT="$demo" $WT_CMD report | cut -d' ' -f1)"
+check_output "same seed, same days" "" "$($WT_CMD demo "$WT_ROOT/demo2" > /dev/null; diff "$demo/.out/daily-reports" "$WT_ROOT/demo2/.out/daily-reports")"
+check_output "refuses a root with a timer" "$demo already has a timer. Pick another folder for the demo." "$($WT_CMD demo "$demo" 2>&1 || true)"
+check_output "current timer untouched" "no" "$([ -f "$WT_ROOT/.out/wt.json" ] && echo yes || echo no)"
+
+# Interruptions resume within the cycle, so the clock never goes back
+for seed in 1 2 5 10 11; do
+    output=$($WT_CMD demo --seed $seed "$WT_ROOT/seed$seed" 2>&1)
+    check_output "seed $seed: no clock warning" "" "$(echo "$output" | grep 'clock went back')"
+    check_output "seed $seed: no clock jumps" "" "$(grep -l clock_jumps "$WT_ROOT/seed$seed/.out/archive/"* || true)"
+done
+
+###############################################################################
+# Test 78: Read-only mode
+###############################################################################
+print_test "78" "Read-only mode"
+setup_test
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:00"
+export WT_LOG_LEVEL=debug
+before=$(cksum < "$WT_ROOT/.out/debug-log")
+$WT_CMD check > /dev/null
+$WT_CMD > /dev/null
+$WT_CMD status > /dev/null
+check_output "check and status don't log" "$before" "$(cksum < "$WT_ROOT/.out/debug-log")"
+unset WT_LOG_LEVEL
+
+check_output "changes refused" "Read-only mode: 'wt stop' would change the timer." "$($WT_CMD --read-only stop 2>&1 || true)"
+check_output "timer unchanged" "running" "$($WT_CMD status)"
+check_output "reads work" "01. [08:00 => .....] Work: 1h:00m (1h:00m)" "$(WT_READ_ONLY=1 $WT_CMD log)"
+check_output "exports refused" "Read-only mode: not writing days.csv." "$(WT_READ_ONLY=1 $WT_CMD export csv -o "$WT_ROOT/days.csv" 2>&1 || true)"
+check_output "no file written" "no" "$([ -f "$WT_ROOT/days.csv" ] && echo yes || echo no)"
+check_output "profile changes refused" "Read-only mode: not removing profile." "$($WT_CMD profile set work WT_DAILY_GOAL 800 > /dev/null; $WT_CMD profile use work > /dev/null; $WT_CMD --read-only profile use none 2>&1 || true)"
+check_output "profile kept" "work" "$(cat "$WT_ROOT/.out/profile")"
+
+###############################################################################
+# Test 79: Meeting tags from a calendar
+###############################################################################
+print_test "79" "Meeting tags from a calendar"
+setup_test
+
+cat > "$WT_ROOT/.out/calendar.ics" <<'ICS'
+BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Daily standup
+DTSTART:20260105T093000
+DTEND:20260105T094500
+RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+EXDATE:20260121T093000
+END:VEVENT
+BEGIN:VEVENT
+UID:planning
+SUMMARY:Sprint planning\, Q1
+DTSTART:20260120T130000
+DURATION:PT1H
+END:VEVENT
+BEGIN:VEVENT
+UID:focus
+SUMMARY:Focus time
+TRANSP:TRANSPARENT
+DTSTART:20260120T080000
+DTEND:20260120T120000
+END:VEVENT
+BEGIN:VEVENT
+UID:offsite
+SUMMARY:Offsite
+DTSTART;VALUE=DATE:20260120
+DTEND;VALUE=DATE:20260121
+END:VEVENT
+END:VCALENDAR
+ICS
+export WT_CALENDAR="$WT_ROOT/.out/calendar.ics"
+
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:00"
+run_wt stop
+mock_time "2026-01-20 09:15"
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 14:30"
+run_wt stop
+
+expected_log="01. [08:00 => 09:00] Work: 1h:00m (1h:00m)
+02. [09:00 => 09:15] Break: 0h:15m
+03. [09:15 => 10:00] Work: 0h:45m (1h:45m) +meeting (Daily standup)
+04. [10:00 => 10:15] Break: 0h:15m
+05. [10:15 => 14:30] Work: 4h:15m (6h:00m) +meeting (Sprint planning, Q1)"
+check_output "cycles tagged" "$expected_log" "$($WT_CMD log)"
+check_output "titles in json" '"meetings": [ "Sprint planning, Q1" ]' "$($WT_CMD log --format json --last 1 | tr -s ' \n' ' ' | grep -o '"meetings": \[[^]]*\]')"
+check_output "anonymized json drops titles" "" "$($WT_CMD export --anonymize | grep -e standup -e Sprint || true)"
+check_output "anonymized md drops titles" "" "$($WT_CMD export md --anonymize | grep -e standup -e Sprint || true)"
+
+mock_time "2026-01-21 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-21 10:00"
+run_wt stop
+check_output "excluded occurrence" "01. [09:00 => 10:00] Work: 1h:00m (1h:00m)" "$($WT_CMD log)"
+
+mock_time "2026-01-21 10:15"
+run_wt start
+mock_time "2026-01-21 10:30"
+check_output "unreadable calendar" "Calendar not read: open $WT_ROOT/nowhere.ics: no such file or directory" "$(WT_CALENDAR="$WT_ROOT/nowhere.ics" $WT_CMD stop)"
+check_output "stopped anyway" "stopped" "$($WT_CMD status)"
+unset WT_CALENDAR
+
+###############################################################################
+# Test 80: Planned vs. actual work
+###############################################################################
+print_test "80" "Planned vs. actual work"
+setup_test
+
+export WT_PRESET_DEEPWORK="50/10 +deepwork"
+export WT_PRESET_MEETINGS="untimed +meeting"
+
+mock_time "2026-01-20 08:00"
+run_wt new
+check_output "no plan" "No plan for today. Example: wt plan 4x50 +deepwork 2:00 +meeting" "$($WT_CMD plan)"
+check_output "plan set" "Planned 3h:30m: 3x 0h:50m +deepwork, 1h:00m +meeting" "$($WT_CMD plan 3x50 +deepwork 1:00 +meeting)"
+
+run_wt start --preset deepwork
+mock_time "2026-01-20 08:50"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt start
+mock_time "2026-01-20 09:50"
+run_wt stop
+mock_time "2026-01-20 10:00"
+run_wt start --preset meetings
+mock_time "2026-01-20 10:30"
+
+expected_plan="Plan +deepwork: 1h:40m of 2h:30m (66%), 2 of 3 blocks
+Plan +meeting: 0h:30m of 1h:00m (50%)"
+check_output "plan vs. actual" "$expected_plan" "$($WT_CMD plan)"
+check_output "in report" "$expected_plan" "$($WT_CMD report | tail -n 2)"
+
+check_output "untagged block" "Planned 6h:00m: 6h:00m" "$($WT_CMD plan 6:00)"
+check_output "any work" "Plan: 2h:10m of 6h:00m (36%)" "$($WT_CMD plan)"
+check_output "misplaced tag" "Misplaced +meeting. Put a tag after the time it applies to, e.g. 2:00 +meeting." "$($WT_CMD plan +meeting 2>&1 || true)"
+check_output "bad block" "Invalid time in 4x75. Incorrect time format. Minutes cannot exceed 59." "$($WT_CMD plan 4x75 2>&1 || true)"
+check_output "plan kept" "Plan: 2h:10m of 6h:00m (36%)" "$($WT_CMD plan)"
+
+check_output "cleared" "Plan cleared." "$($WT_CMD plan clear)"
+check_output "report without plan" "1" "$($WT_CMD report | wc -l | tr -d ' ')"
+unset WT_PRESET_DEEPWORK WT_PRESET_MEETINGS
+
+###############################################################################
+# Test 81: Retrospective notes
+###############################################################################
+print_test "81" "Retrospective notes"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt close --note "Parser done; blocked on review"
+check_output "archived with close" '"note": "Parser done; blocked on review"' "$(grep -o '"note": "[^"]*"' "$WT_ROOT/.out/archive/2026-01-19.json")"
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt stop
+mock_time "2026-01-21 08:00"
+printf 'y\nPairing went well\n' | WT_SKIP_PROMPTS= WT_RETRO=1 $WT_CMD new > /dev/null
+check_output "asked on new" '"note": "Pairing went well"' "$(grep -o '"note": "[^"]*"' "$WT_ROOT/.out/archive/2026-01-20.json")"
+
+run_wt start
+mock_time "2026-01-21 10:00"
+run_wt stop
+mock_time "2026-01-22 08:00"
+run_wt new
+check_output "no prompt without WT_RETRO" "" "$(grep -o '"note"' "$WT_ROOT/.out/archive/2026-01-21.json")"
+
+expected_week="Mon 2026-01-19 | 09:00 -> 12:00 | Work: 3h:00m
+    Note: Parser done; blocked on review
+Tue 2026-01-20 | 09:00 -> 11:00 | Work: 2h:00m
+    Note: Pairing went well
+Wed 2026-01-21 | 08:00 -> 10:00 | Work: 2h:00m
+Week: 7h:00m"
+check_output "week" "$expected_week" "$($WT_CMD week)"
+
+expected_notes="## Notes
+
+- **2026-01-19:** Parser done; blocked on review
+- **2026-01-20:** Pairing went well"
+check_output "markdown export" "$expected_notes" "$($WT_CMD export md --range thisweek | tail -n 4)"
+check_output "json export" "2" "$($WT_CMD export --range thisweek | grep -c '"note"')"
+check_output "anonymized" "0" "$($WT_CMD export --range thisweek --anonymize | grep -c '"note"')"
+
+###############################################################################
+# Test 82: Cycle locations
+###############################################################################
+print_test "82" "Cycle locations"
+setup_test
+
+export WT_LOCATION=office
+export WT_LOCATION_FRI=home
+
+mock_time "2026-01-15 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-15 12:00"
+run_wt stop
+mock_time "2026-01-15 13:00"
+run_wt start @travel
+mock_time "2026-01-15 15:00"
+run_wt stop
+
+expected_log="01. [09:00 => 12:00] Work: 3h:00m (3h:00m) @office
+02. [12:00 => 13:00] Break: 1h:00m
+03. [13:00 => 15:00] Work: 2h:00m (5h:00m) @travel"
+check_output "default and chosen location" "$expected_log" "$($WT_CMD log)"
+check_output "journaled" "[2026-01-15 13:00] wt start @travel" "$($WT_CMD log debug | grep '13:00')"
+
+mock_time "2026-01-16 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-16 11:00"
+run_wt stop
+mock_time "2026-01-16 11:30"
+run_wt start
+mock_time "2026-01-16 12:30"
+check_output "weekday default" "01. [09:00 => 11:00] Work: 2h:00m (2h:00m) @home" "$($WT_CMD log | head -n 1)"
+check_output "anonymized json drops locations" "" "$($WT_CMD export --range thisweek --anonymize | grep -e home -e office -e travel || true)"
+check_output "anonymized md drops locations" "" "$($WT_CMD export md --range thisweek --anonymize | grep -e home -e office -e travel || true)"
+
+expected_report="home   | Work: 3h:00m | Break: 0h:30m | Paused: 0h:00m | Total: 3h:30m | Days: 1
+office | Work: 3h:00m | Break: 1h:00m | Paused: 0h:00m | Total: 4h:00m | Days: 1
+travel | Work: 2h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 2h:00m | Days: 1
+Total  | Work: 8h:00m | Break: 1h:30m | Paused: 0h:00m | Total: 9h:30m | Days: 2"
+check_output "group by location" "$expected_report" "$($WT_CMD report --range 2026-01-15..2026-01-16 --group-by location)"
+unset WT_LOCATION WT_LOCATION_FRI
+
+expected_report="(no location) | Work: 1h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 1h:00m | Days: 1
+home          | Work: 2h:00m | Break: 0h:30m | Paused: 0h:00m | Total: 2h:30m | Days: 1
+Total         | Work: 3h:00m | Break: 0h:30m | Paused: 0h:00m | Total: 3h:30m | Days: 1"
+check_output "stopped cycles keep their location" "$expected_report" "$($WT_CMD report --range today --group-by location)"
+
+###############################################################################
+# Test 83: Pay periods
+###############################################################################
+print_test "83" "Pay periods"
+setup_test
+
+for day in 2026-01-07 2026-01-13 2026-01-20 2026-01-27; do
+    mock_time "$day 09:00"
+    run_wt new
+    run_wt start
+    mock_time "$day 16:00"
+    run_wt stop
+done
+mock_time "2026-01-28 10:00"
+
+check_output "not set" "No pay period set. Set WT_PAY_PERIOD to weekly, weekly YYYY-MM-DD, biweekly YYYY-MM-DD, semimonthly, or monthly." "$($WT_CMD report --period current 2>&1 || true)"
+
+export WT_PAY_PERIOD="biweekly 2026-01-05"
+expected_report="Pay period: 2026-01-19 to 2026-02-01
+2026-01-20 | Work: 7h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 7h:00m | Days: 1
+2026-01-27 | Work: 7h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 7h:00m | Days: 1
+Total      | Work: 14h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 14h:00m | Days: 2"
+check_output "biweekly current" "$expected_report" "$($WT_CMD report --period current)"
+check_output "biweekly previous" "Pay period: 2026-01-05 to 2026-01-18" "$($WT_CMD report --period previous | head -n 1)"
+
+export WT_PAY_PERIOD=semimonthly
+expected_report="Pay period: 2026-01-16 to 2026-01-31
+2026-W04 | Work: 7h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 7h:00m | Days: 1
+2026-W05 | Work: 7h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 7h:00m | Days: 1
+Total    | Work: 14h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 14h:00m | Days: 2"
+check_output "semimonthly by week" "$expected_report" "$($WT_CMD report --period current --group-by week)"
+check_output "semimonthly previous" "Pay period: 2026-01-01 to 2026-01-15" "$($WT_CMD report --period previous | head -n 1)"
+
+export WT_PAY_PERIOD="weekly 2026-01-07"
+check_output "weekly from wednesday" "Pay period: 2026-01-21 to 2026-01-27" "$($WT_CMD report --period previous | head -n 1)"
+check_output "range name" "| **Total** | | | **7h:00m** | 0h:00m | 0h:00m | 7h:00m |" "$($WT_CMD export md --range lastperiod | tail -n 1)"
+
+export WT_PAY_PERIOD=biweekly
+check_output "anchor needed" "Invalid WT_PAY_PERIOD: biweekly. Use weekly, weekly YYYY-MM-DD, biweekly YYYY-MM-DD, semimonthly, or monthly." "$($WT_CMD report --period current 2>&1 || true)"
+check_output "invalid period" "Invalid period: next. Use current or previous." "$(WT_PAY_PERIOD=monthly $WT_CMD report --period next 2>&1 || true)"
+unset WT_PAY_PERIOD
+
+###############################################################################
+# Test 84: Entry IDs and audit trail
+###############################################################################
+print_test "84" "Entry IDs and audit trail"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt next
+mock_time "2026-01-20 11:30"
+run_wt stop
+mock_time "2026-01-20 12:00"
+run_wt mod 1 add 10
+run_wt start
+mock_time "2026-01-20 12:30"
+
+expected_audit="01. e1   [09:00 => 10:10] Work | Created 2026-01-20 10:00 | Modified 2026-01-20 12:00 by wt mod 1 add 10
+02. e2   [10:10 => 10:25] Break | Created 2026-01-20 10:15 by wt start
+03. e3   [10:25 => 11:10] Work | Created 2026-01-20 11:00 by wt next
+04. e4   [11:10 => 11:10] Break | Created 2026-01-20 11:00 by wt next
+05. e5   [11:10 => 11:40] Work | Created 2026-01-20 11:30 by wt stop
+06. e6   [11:40 => 12:10] Break | Created 2026-01-20 12:00 by wt start
+07. -    [12:10 => .....] Work | Running"
+check_output "audit trail" "$expected_audit" "$($WT_CMD log --audit)"
+check_output "ids in json" '"id": "e5",' "$($WT_CMD log --format json --last 3 | grep -o '"id": "e5",')"
+
+# Dropping the empty break merges e5 into e3; the others keep their IDs
+mock_time "2026-01-20 12:40"
+run_wt mod 4 drop
+expected_audit="03. e3   [10:25 => 11:40] Work | Created 2026-01-20 11:00 | Modified 2026-01-20 12:40 by wt mod 4 drop
+04. e6   [11:40 => 12:10] Break | Created 2026-01-20 12:00 by wt start"
+check_output "stable ids" "$expected_audit" "$($WT_CMD log --audit | sed -n 3,4p)"
+check_output "no combining" "--audit can't be combined with --gantt, --condensed, or --format; --format json includes the audit fields" "$($WT_CMD log --audit --condensed 2>&1 || true)"
+
+###############################################################################
+# Test 85: Mod by entry ID
+###############################################################################
+print_test "85" "Mod by entry ID"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt stop
+mock_time "2026-01-20 11:10"
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+
+# Dropping the break e2 merges e3 into e1, so e5 is cycle 3 now
+run_wt mod e2 drop
+run_wt mod e5 add 10
+expected_log="01. [09:00 => 11:00] Work: 2h:00m (2h:00m)
+02. [11:00 => 11:10] Break: 0h:10m
+03. [11:10 => 12:10] Work: 1h:00m (3h:00m)"
+check_output "drop and add by id" "$expected_log" "$($WT_CMD log)"
+check_output "id kept" "03. e5   [11:10 => 12:10] Work | Created 2026-01-20 12:00 | Modified 2026-01-20 12:00 by wt mod 3 add 10" "$($WT_CMD log --audit | sed -n 3p)"
+check_output "unknown id" "No cycle with ID e9. 'wt log --audit' lists the IDs." "$($WT_CMD mod e9 add 10)"
+
+###############################################################################
+# Test 86: Pause reasons
+###############################################################################
+print_test "86" "Pause reasons"
+setup_test
+
+mock_time "2026-01-21 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-21 10:00"
+run_wt pause -m "phone call"
+mock_time "2026-01-21 10:20"
+run_wt start
+mock_time "2026-01-21 10:30"
+run_wt pause
+mock_time "2026-01-21 10:35"
+run_wt start
+mock_time "2026-01-21 11:00"
+run_wt pause -m errand
+mock_time "2026-01-21 11:10"
+
+# The running pause counts toward its reason; pauses without one are "other"
+check_output "running breakdown" "01. [09:00 => .....] Work (paused): 1h:35m |35m: phone call 20m, errand 10m, other 5m| (1h:35m)" "$($WT_CMD log)"
+run_wt stop
+check_output "stopped breakdown" "01. [09:00 => 11:10] Work: 1h:35m |35m: phone call 20m, errand 10m, other 5m| (1h:35m)" "$($WT_CMD log)"
+check_output "report breakdown" "Paused: phone call 0h:20m, errand 0h:10m, other 0h:05m" "$($WT_CMD report | grep '^Paused:')"
+check_output "json breakdown" '"phone call": 20' "$($WT_CMD log --format json | grep -o '"phone call": 20')"
+check_output "json pauses" '"reason": "phone call"' "$($WT_CMD log --format json | grep -o '"reason": "phone call"')"
+check_output "anonymized json drops reasons" "" "$($WT_CMD export --anonymize | grep -e 'phone call' -e errand || true)"
+check_output "anonymized md drops reasons" "" "$($WT_CMD export md --anonymize | grep -e 'phone call' -e errand || true)"
+check_output "intervals kept" "3" "$($WT_CMD export --anonymize | grep -c '"start": "2026-01-21 1[01]:')"
+check_output "reasons kept in the timer" "01. [09:00 => 11:10] Work: 1h:35m |35m: phone call 20m, errand 10m, other 5m| (1h:35m)" "$($WT_CMD log)"
+
+# Without reasons the log and report stay as before
+setup_test
+mock_time "2026-01-21 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-21 10:00"
+run_wt pause
+mock_time "2026-01-21 10:15"
+run_wt stop
+check_output "no breakdown" "01. [09:00 => 10:15] Work: 1h:00m |15m| (1h:00m)" "$($WT_CMD log)"
+check_output "no report line" "" "$($WT_CMD report | grep '^Paused:' || true)"
+
+# wt.json saved with paused minutes by reason (pause_reasons) gets one pause per reason
+setup_test
+mock_time "2026-01-21 11:30"
+cat > "$WT_ROOT/.out/wt.json" << 'EOF'
+{
+  "status": "paused",
+  "pause_start_str": "2026-01-21 11:20",
+  "day_start": "2026-01-21 10:00",
+  "paused_minutes": 15,
+  "pause_reason": "lunch",
+  "pause_reasons": {"coffee": 10},
+  "timeline": [
+    {"type": "work", "minutes": 30, "paused_minutes": 20, "pause_reasons": {"phone call": 5, "errand": 10}},
+    {"type": "break", "minutes": 10}
+  ]
+}
+EOF
+expected_pauses="01. [10:00 => 10:50] Work: 0h:30m |20m: errand 10m, phone call 5m, other 5m| (0h:30m)
+    [10:00 => 10:10] Paused: 0h:10m errand
+    [10:10 => 10:15] Paused: 0h:05m phone call
+    [10:45 => 10:50] Paused: 0h:05m
+02. [10:50 => 11:00] Break: 0h:10m
+03. [11:00 => .....] Work (paused): 0h:05m |25m: coffee 10m, lunch 10m, other 5m| (0h:35m)
+    [11:00 => 11:10] Paused: 0h:10m coffee
+    [11:15 => 11:20] Paused: 0h:05m
+    [11:20 => 11:30] Paused: 0h:10m lunch"
+check_output "legacy reasons" "$expected_pauses" "$($WT_CMD log --pauses)"
+
+###############################################################################
+# Test 87: Pause intervals
+###############################################################################
+print_test "87" "Pause intervals"
+setup_test
+
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-22 09:30"
+run_wt pause -m coffee
+mock_time "2026-01-22 09:40"
+run_wt start
+mock_time "2026-01-22 10:00"
+run_wt pause 5
+mock_time "2026-01-22 10:10"
+
+expected_pauses="01. [09:00 => .....] Work (paused): 0h:45m |25m: coffee 10m, other 15m| (0h:45m)
+    [09:30 => 09:40] Paused: 0h:10m coffee
+    [09:55 => 10:10] Paused: 0h:15m"
+check_output "running pauses" "$expected_pauses" "$($WT_CMD log --pauses)"
+
+# Pauses added with mod end the cycle; cycles merged by mod keep their pauses in place
+run_wt stop
+mock_time "2026-01-22 10:20"
+run_wt start
+mock_time "2026-01-22 10:40"
+run_wt pause -m call
+mock_time "2026-01-22 10:50"
+run_wt stop
+run_wt mod 1 pause add 5
+run_wt mod 2 drop
+expected_pauses="01. [09:00 => 10:55] Work: 1h:15m |40m: call 10m, coffee 10m, other 20m| (1h:15m)
+    [09:30 => 09:40] Paused: 0h:10m coffee
+    [09:55 => 10:10] Paused: 0h:15m
+    [10:10 => 10:15] Paused: 0h:05m
+    [10:45 => 10:55] Paused: 0h:10m call"
+check_output "merged pauses" "$expected_pauses" "$($WT_CMD log --pauses)"
+
+expected_gantt="09:00 |███████████████░░░░░████████░░|
+10:00 |░░░░░░░░███████████████░░░░░  |
+█ work  ░ paused  · break (1 char = 2 min)"
+check_output "pauses in gantt" "$expected_gantt" "$($WT_CMD log --gantt)"
+check_output "no combining" "--pauses only works with the plain log; --gantt draws the pauses and --format json includes them" "$($WT_CMD log --pauses --gantt 2>&1 || true)"
+
+# Timers saved with only a paused total get one pause ending the cycle
+setup_test
+mkdir -p "$WT_ROOT/.out"
+cat > "$WT_ROOT/.out/wt.json" <<'JSON'
+{"status": "stopped", "pause_start_str": "", "stop_datetime_str": "2026-01-22 10:00", "paused_minutes": 0, "mode": "normal",
+ "timeline": [{"type": "work", "minutes": 45, "paused_minutes": 15}], "day_start": "2026-01-22 09:00"}
+JSON
+mock_time "2026-01-22 10:30"
+expected_pauses="01. [09:00 => 10:00] Work: 0h:45m |15m| (0h:45m)
+    [09:45 => 10:00] Paused: 0h:15m"
+check_output "migrated pause" "$expected_pauses" "$($WT_CMD log --pauses)"
+
+###############################################################################
+# Test 88: Toggle
+###############################################################################
+print_test "88" "Toggle"
+setup_test
+
+mock_time "2026-01-23 09:00"
+run_wt new
+run_wt mode normal
+check_output "starts" "Starting timer." "$($WT_CMD toggle)"
+mock_time "2026-01-23 10:00"
+check_output "pauses" "Paused timer" "$($WT_CMD toggle)"
+mock_time "2026-01-23 10:15"
+check_output "resumes" "Resuming timer." "$($WT_CMD toggle)"
+mock_time "2026-01-23 11:00"
+run_wt stop
+mock_time "2026-01-23 11:10"
+run_wt toggle
+mock_time "2026-01-23 11:30"
+
+expected_log="01. [09:00 => 11:00] Work: 1h:45m |15m| (1h:45m)
+02. [11:00 => 11:10] Break: 0h:10m
+03. [11:10 => .....] Work: 0h:20m (2h:05m)"
+check_output "toggled day" "$expected_log" "$($WT_CMD log)"
+check_output "audit names toggle" "02. e2   [11:00 => 11:10] Break | Created 2026-01-23 11:10 by wt toggle" "$($WT_CMD log --audit | sed -n 2p)"
+
+###############################################################################
+# Test 89: Start without a break
+###############################################################################
+print_test "89" "Start without a break"
+setup_test
+
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-22 10:00"
+run_wt stop
+mock_time "2026-01-22 10:20"
+check_output "continues" "Continuing cycle 1 (0h:20m since the stop counted as work)." "$($WT_CMD start --no-break)"
+mock_time "2026-01-22 10:30"
+run_wt pause
+mock_time "2026-01-22 10:40"
+run_wt stop
+check_output "one cycle" "01. [09:00 => 10:40] Work: 1h:30m |10m| (1h:30m)" "$($WT_CMD log)"
+check_output "audit" "01. e1   [09:00 => 10:40] Work | Created 2026-01-22 10:00 | Modified 2026-01-22 10:40 by wt stop" "$($WT_CMD log --audit)"
+
+# A later start records the break as usual
+mock_time "2026-01-22 11:00"
+run_wt start
+check_output "break again" "02. [10:40 => 11:00] Break: 0h:20m" "$($WT_CMD log | sed -n 2p)"
+mock_time "2026-01-22 11:30"
+run_wt stop
+check_output "no backdating" "Use either a start time or --no-break." "$($WT_CMD start --no-break 10 2>&1 || true)"
+
+###############################################################################
+# Test 90: Break kinds
+###############################################################################
+print_test "90" "Break kinds"
+setup_test
+
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-22 10:00"
+run_wt stop
+mock_time "2026-01-22 10:15"
+run_wt start
+mock_time "2026-01-22 12:00"
+run_wt stop
+mock_time "2026-01-22 12:45"
+run_wt start --break lunch
+mock_time "2026-01-22 14:00"
+run_wt stop
+mock_time "2026-01-22 14:20"
+run_wt start
+check_output "mod kind" "Cycle 6 is now marked errand" "$($WT_CMD mod 6 kind errand)"
+mock_time "2026-01-22 15:00"
+
+expected_log="01. [09:00 => 10:00] Work: 1h:00m (1h:00m)
+02. [10:00 => 10:15] Break: 0h:15m
+03. [10:15 => 12:00] Work: 1h:45m (2h:45m)
+04. [12:00 => 12:45] Lunch: 0h:45m
+05. [12:45 => 14:00] Work: 1h:15m (4h:00m)
+06. [14:00 => 14:20] Errand: 0h:20m
+07. [14:20 => .....] Work: 0h:40m (4h:40m)"
+check_output "labels" "$expected_log" "$($WT_CMD log)"
+expected_report="2026-01-22 | 09:00 -> 15:00 | Work: 4h:40m | Break: 0h:35m | Lunch: 0h:45m | Paused: 0h:00m | Total: 6h:00m
+Breaks: short 0h:15m, lunch 0h:45m, errand 0h:20m"
+check_output "report by kind" "$expected_report" "$($WT_CMD report)"
+
+# A kind overrides the lunch window; auto goes back to it
+export WT_LUNCH_WINDOW=1000-1100
+run_wt mod 2 kind short
+check_output "kind over window" "02. [10:00 => 10:15] Break: 0h:15m" "$($WT_CMD log | sed -n 2p)"
+run_wt mod 4 kind auto
+check_output "auto" "04. [12:00 => 12:45] Break: 0h:45m" "$($WT_CMD log | sed -n 4p)"
+unset WT_LUNCH_WINDOW
+
+check_output "only after a stop" "No break to mark: --break sets the kind of the break a start after 'wt stop' records." "$($WT_CMD start --break lunch 2>&1 || true)"
+check_output "invalid kind" "Invalid break kind: nap. Use short, lunch, errand." "$($WT_CMD mod 2 kind nap 2>&1 || true)"
+check_output "work has no kind" "Cycle 1 is a work cycle. Only breaks have a kind." "$($WT_CMD mod 1 kind lunch)"
+
+###############################################################################
+# Test 91: Markers
+###############################################################################
+print_test "91" "Markers"
+setup_test
+
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-22 10:42"
+check_output "mark" "Marked 10:42: finished parser rewrite" "$($WT_CMD mark finished parser rewrite)"
+mock_time "2026-01-22 11:00"
+run_wt stop
+check_output "stopped" "No running cycle to mark. Start the timer first." "$($WT_CMD mark too late)"
+mock_time "2026-01-22 11:10"
+run_wt start
+mock_time "2026-01-22 11:20"
+run_wt mark "tests green, ship it"
+mock_time "2026-01-22 11:30"
+
+expected_notes="01. [09:00 => 11:00] Work: 2h:00m (2h:00m)
+    10:42 finished parser rewrite
+02. [11:00 => 11:10] Break: 0h:10m
+03. [11:10 => .....] Work: 0h:20m (2h:20m)
+    11:20 tests green, ship it"
+check_output "log notes" "$expected_notes" "$($WT_CMD log --notes)"
+
+# Markers stay at their time when cycles merge
+run_wt mod 2 drop
+expected_notes="01. [09:00 => .....] Work: 2h:30m (2h:30m)
+    10:42 finished parser rewrite
+    11:20 tests green, ship it"
+check_output "merged notes" "$expected_notes" "$($WT_CMD log --notes)"
+run_wt stop
+
+expected_org="  - [2026-01-22 Thu 10:42] finished parser rewrite
+  - [2026-01-22 Thu 11:20] tests green, ship it"
+check_output "org export" "$expected_org" "$($WT_CMD export org | grep '^  - ')"
+check_output "ics export" 'DESCRIPTION:10:42 finished parser rewrite\n11:20 tests green\, ship it' "$($WT_CMD export ics | grep DESCRIPTION | tr -d '\r')"
+check_output "json export" '"text": "tests green, ship it"' "$($WT_CMD export | grep -o '"text": "tests green, ship it"')"
+check_output "anonymized" "" "$($WT_CMD export md --anonymize | grep parser || true)"
+
+###############################################################################
+# Test 92: Task estimates
+###############################################################################
+print_test "92" "Task estimates"
+setup_test
+
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt mode normal
+run_wt start -m "write RFC" --estimate 2h
+mock_time "2026-01-22 10:30"
+run_wt stop
+mock_time "2026-01-22 10:40"
+run_wt start -m "code review" --estimate 30m
+mock_time "2026-01-22 11:00"
+run_wt stop
+mock_time "2026-01-22 11:10"
+run_wt start -m "write RFC"
+mock_time "2026-01-22 12:00"
+
+expected_log="01. [09:00 => 10:30] Work: 1h:30m (1h:30m) \"write RFC\"
+02. [10:30 => 10:40] Break: 0h:10m
+03. [10:40 => 11:00] Work: 0h:20m (1h:50m) \"code review\"
+04. [11:00 => 11:10] Break: 0h:10m
+05. [11:10 => .....] Work: 0h:50m (2h:40m) \"write RFC\""
+check_output "log" "$expected_log" "$($WT_CMD log)"
+
+expected_estimates='Estimate "code review": 0h:20m of 0h:30m (66%), 0h:10m left
+Estimate "write RFC": 2h:20m of 2h:00m (116%), 0h:20m over
+Estimated tasks: 2h:40m of 2h:30m (106%)'
+check_output "report" "$expected_estimates" "$($WT_CMD report | grep Estimate)"
+check_output "json" '"task": "code review"' "$($WT_CMD log --format json | grep -o '"task": "code review"')"
+run_wt stop
+
+check_output "estimate needs a task" "--estimate needs a task, e.g. wt start -m \"write RFC\" --estimate 2h" "$($WT_CMD start -m none --estimate 1h 2>&1)"
+check_output "invalid estimate" "Invalid estimate: soon. Use e.g. 2h, 90m, 1h30m, or 130." "$($WT_CMD start -m x --estimate soon 2>&1)"
+
+# -m none clears the task, the next day sums up with the range report
+mock_time "2026-01-22 12:30"
+run_wt start -m none
+mock_time "2026-01-22 13:00"
+run_wt stop
+mock_time "2026-01-23 09:00"
+run_wt new
+run_wt start -m "write RFC" --estimate 3h
+mock_time "2026-01-23 10:00"
+run_wt stop
+
+expected_range='(no task)   | Work: 0h:30m | Break: 0h:00m | Paused: 0h:00m | Total: 0h:30m | Days: 1
+code review | Work: 0h:20m | Break: 0h:10m | Paused: 0h:00m | Total: 0h:30m | Days: 1
+write RFC   | Work: 3h:20m | Break: 0h:40m | Paused: 0h:00m | Total: 4h:00m | Days: 2
+Total       | Work: 4h:10m | Break: 0h:50m | Paused: 0h:00m | Total: 5h:00m | Days: 2
+Estimate "code review": 0h:20m of 0h:30m (66%), 0h:10m left
+Estimate "write RFC": 3h:20m of 3h:00m (111%), 0h:20m over
+Estimated tasks: 3h:40m of 3h:30m (104%)'
+check_output "range by task" "$expected_range" "$($WT_CMD report --range 2026-01-01..2026-01-31 --group-by task)"
+
+###############################################################################
+# Test 93: InfluxDB export
+###############################################################################
+print_test "93" "InfluxDB export"
+setup_test
+
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt start "@home office" -m 'say "hi"'
+mock_time "2026-01-22 10:00"
+run_wt stop
+mock_time "2026-01-22 10:15"
+run_wt start
+mock_time "2026-01-22 11:00"
+
+expected_influx='wt_cycle,label=Work,location=home\ office,type=work minutes=60i,paused_minutes=0i,task="say \"hi\"" 1769072400000000000
+wt_cycle,label=Break,type=break minutes=15i,paused_minutes=0i 1769076000000000000
+wt_cycle,label=Work,location=home\ office,type=work minutes=45i,paused_minutes=0i,task="say \"hi\"" 1769076900000000000
+wt_day work_minutes=105i,break_minutes=15i,lunch_minutes=0i,paused_minutes=0i 1769072400000000000'
+check_output "line protocol" "$expected_influx" "$(TZ=UTC $WT_CMD export influx)"
+check_output "anonymized" "" "$(TZ=UTC $WT_CMD export influx --anonymize | grep task || true)"
+check_output "work only" "2" "$(TZ=UTC $WT_CMD export influx --type work | grep -c '^wt_cycle')"
+
+###############################################################################
+# Test 94: Web dashboard
+###############################################################################
+print_test "94" "Web dashboard"
+setup_test
+
+mock_time "2026-01-13 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-13 10:30"
+UI_PORT=$((20000 + RANDOM % 10000))
+WT_API_TOKENS="r1:read" $WT_CMD serve --listen "127.0.0.1:$UI_PORT" --ui > "$WT_ROOT/.out/serve.out" 2>&1 &
+UI_PID=$!
+wait_for_port "$UI_PORT"
+
+check_output "announced" "Serving the wt API on http://127.0.0.1:$UI_PORT (1 tokens, dashboard at /)." "$(cat "$WT_ROOT/.out/serve.out")"
+check_output "page without a token" "<title>wt</title>" "$(curl -s "http://127.0.0.1:$UI_PORT/" | grep -o '<title>wt</title>')"
+check_output "api still needs one" '{"error":"Invalid or missing bearer token."}' "$(curl -s "http://127.0.0.1:$UI_PORT/api/week")"
+expected_week='[{"date":"2026-01-12","weekday":"Mon","work_minutes":0},{"date":"2026-01-13","weekday":"Tue","work_minutes":90},{"date":"2026-01-14","weekday":"Wed","work_minutes":0},{"date":"2026-01-15","weekday":"Thu","work_minutes":0},{"date":"2026-01-16","weekday":"Fri","work_minutes":0},{"date":"2026-01-17","weekday":"Sat","work_minutes":0},{"date":"2026-01-18","weekday":"Sun","work_minutes":0}]'
+check_output "week" "$expected_week" "$(curl -s -H "Authorization: Bearer r1" "http://127.0.0.1:$UI_PORT/api/week")"
+check_output "other paths 404" "404" "$(curl -s -o /dev/null -w '%{http_code}' "http://127.0.0.1:$UI_PORT/nope")"
+
+kill $UI_PID
+wait $UI_PID 2> /dev/null || true
+
+###############################################################################
+# Test 95: Static site export
+###############################################################################
+print_test "95" "Static site export"
+setup_test
+
+mock_time "2026-01-21 09:00"
+run_wt new
+run_wt start -m "a <b>"
+mock_time "2026-01-21 10:00"
+run_wt pause -m "phone"
+mock_time "2026-01-21 10:10"
+run_wt start
+run_wt mark "shipped & done"
+mock_time "2026-01-21 11:00"
+run_wt stop
+run_wt close --note "good day"
+mock_time "2026-01-22 09:00"
+run_wt new
+run_wt start @travel
+run_wt tag billing
+run_wt rate 4
+mock_time "2026-01-22 12:00"
+
+SITE="$WT_ROOT/.out/site"
+check_output "needs a directory" "site writes a directory. Use wt export site <dir>" "$($WT_CMD export site 2>&1)"
+check_output "exported" "Exported 2 days to $SITE." "$($WT_CMD export site "$SITE")"
+check_output "files" "2026-01-21.html 2026-01-22.html index.html style.css" "$(cd "$SITE" && ls days/*.html index.html style.css | xargs -n1 basename | tr '\n' ' ' | sed 's/ $//')"
+check_output "calendar links days" '<td class="l1"><a href="days/2026-01-21.html">21</a><small>1h:50m</small></td>' "$(grep -o '<td class="l1">[^/]*/[^/]*/a><small>[^<]*</small></td>' "$SITE/index.html")"
+check_output "day cycle" '<tr><td>1</td><td>09:00 &ndash; 11:00</td><td>Work</td><td>1h:50m</td><td>0h:10m</td><td>&#34;a &lt;b&gt;&#34;</td></tr>' "$(grep '<td>1</td>' "$SITE/days/2026-01-21.html")"
+check_output "day pause" '<tr><td></td><td>10:00 &ndash; 10:10</td><td>Paused</td><td></td><td></td><td>phone</td></tr>' "$(grep 'Paused</td>' "$SITE/days/2026-01-21.html")"
+check_output "day note and marker" '<ul><li>10:10 shipped &amp; done</li></ul>
+<p><em>good day</em></p>' "$(grep -e '<li>' -e '<em>' "$SITE/days/2026-01-21.html")"
+check_output "next day link" '<a href="2026-01-22.html">2026-01-22 &rarr;</a>' "$(grep -o '<a href="2026-01-22.html">[^<]*</a>' "$SITE/days/2026-01-21.html")"
+check_output "day details" '<td>&#43;billing @travel</td>' "$(grep -o '<td>[^<]*@travel</td>' "$SITE/days/2026-01-22.html")"
+
+# --anonymize leaves tasks, tags, locations, pause reasons, notes, and ratings out
+run_wt export site --anonymize "$WT_ROOT/.out/anonymous"
+check_output "anonymized day pages" "" "$(cat "$WT_ROOT"/.out/anonymous/days/*.html | grep -e '&lt;b&gt;' -e phone -e shipped -e 'good day' -e travel -e billing || true)"
+check_output "anonymized cycle" '<tr><td>1</td><td>09:00 &ndash; 11:00</td><td>Work</td><td>1h:50m</td><td>0h:10m</td><td></td></tr>' "$(grep '<td>1</td>' "$WT_ROOT/.out/anonymous/days/2026-01-21.html")"
+check_output "anonymized rating" "" "$($WT_CMD export --anonymize | grep rating || true)"
+
+###############################################################################
+# Test 96: Week image
+###############################################################################
+print_test "96" "Week image"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 17:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 11:00"
+
+IMAGE="$WT_ROOT/.out/week.png"
+check_output "written" "Wrote the week to $IMAGE." "$(WT_DAILY_GOAL=0700 $WT_CMD week --image "$IMAGE")"
+actual_png=$(python3 -c '
+import struct, sys
+data = open(sys.argv[1], "rb").read()
+print(data[:8] == b"\x89PNG\r\n\x1a\n", *struct.unpack(">II", data[16:24]))
+' "$IMAGE")
+check_output "png size" "True 720 400" "$actual_png"
+check_output "not with grid" "Use either --grid or --image." "$($WT_CMD week --grid --image "$IMAGE" 2>&1)"
+
+###############################################################################
+# Test 97: Stats chart
+###############################################################################
+print_test "97" "Stats chart"
+setup_test
+
+mock_time "2026-01-16 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-16 17:00"
+run_wt stop
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 13:30"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 11:00"
+
+expected_chart="Fri 2026-01-16 | ######################################## | 8h:00m +
+Sat 2026-01-17 |                                          |
+Sun 2026-01-18 |                                          |
+Mon 2026-01-19 | ######################             :     | 4h:30m
+Tue 2026-01-20 | ##########                         :     | 2h:00m
+Work: 14h:30m in 3 days | Avg: 4h:50m | Goal 7h:00m (:) met on 1 of 3 days"
+check_output "chart" "$expected_chart" "$(WT_ASCII=1 WT_DAILY_GOAL=0700 $WT_CMD stats --chart 5)"
+
+expected_chart="Mon 2026-01-19 | ######################################## | 4h:30m
+Tue 2026-01-20 | #################                        | 2h:00m
+Work: 6h:30m in 2 days | Avg: 3h:15m"
+check_output "no goal" "$expected_chart" "$(WT_ASCII=1 $WT_CMD stats --chart 2)"
+check_output "invalid length" "Invalid chart length: 0. Use 1 to 366 days." "$($WT_CMD stats --chart 0 2>&1)"
+
+###############################################################################
+# Test 98: Stats trend
+###############################################################################
+print_test "98" "Stats trend"
+setup_test
+
+# Eight weeks of Monday to Wednesday, starting 5 minutes later and working 10 minutes longer each week
+for week in 0 1 2 3 4 5 6 7; do
+    for weekday in 0 1 2; do
+        day=$(date -d "2025-12-01 +$((week * 7 + weekday)) days" +%F)
+        start=$((9 * 60 + week * 5))
+        for step in "0 new" "0 start" "180 stop" "$((210 + week * 3)) start" "$((390 + week * 13)) stop"; do
+            offset=${step% *}
+            mock_time "$day $(printf %02d:%02d $(((start + offset) / 60)) $(((start + offset) % 60)))"
+            run_wt ${step#* }
+        done
+    done
+done
+mock_time "2026-01-21 10:00"
+
+expected_trend="4-week rolling averages
+Week     | Work/day   | Start   | Breaks
+2025-W49 | 6h:00m     | 09:00   | 7%
+2025-W50 | 6h:05m ^   | 09:02 = | 7% =
+2025-W51 | 6h:10m ^   | 09:05 = | 8% ^
+2025-W52 | 6h:15m ^   | 09:07 = | 8% =
+2026-W01 | 6h:25m ^   | 09:12 ^ | 8% =
+2026-W02 | 6h:35m ^   | 09:17 ^ | 9% ^
+2026-W03 | 6h:45m ^   | 09:22 ^ | 9% =
+2026-W04 | 6h:55m ^   | 09:27 ^ | 10% ^
+Drift: Work/day +0h:55m | Start +0h:27m | Breaks +3 points"
+check_output "trend" "$expected_trend" "$(WT_ASCII=1 $WT_CMD stats --trend)"
+check_output "not with chart" "Use only one of --chart, --trend, and --ratings." "$($WT_CMD stats --trend --chart 3 2>&1)"
+
+mock_time "2026-06-01 10:00"
+check_output "no recent work" "No work tracked in the last weeks." "$($WT_CMD stats --trend | tail -1)"
+
+###############################################################################
+# Test 99: Cycle ratings
+###############################################################################
+print_test "99" "Cycle ratings"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+check_output "nothing to rate" "No cycle to rate yet." "$($WT_CMD rate 3)"
+run_wt start
+mock_time "2026-01-20 09:50"
+check_output "running cycle" "Rated the running cycle: 5/5." "$($WT_CMD rate 5)"
+run_wt stop
+check_output "invalid" "Invalid rating: 6. Use 1 (drained) to 5 (energized)." "$($WT_CMD rate 6 2>&1)"
+mock_time "2026-01-20 10:00"
+run_wt start
+mock_time "2026-01-20 12:30"
+run_wt stop
+check_output "last cycle" "Rated cycle 3: 2/5." "$($WT_CMD rate 2)"
+mock_time "2026-01-20 13:00"
+run_wt start
+mock_time "2026-01-20 14:20"
+run_wt rate 4
+
+expected_log="01. [09:00 => 09:50] Work: 0h:50m (0h:50m) *5
+02. [09:50 => 10:00] Break: 0h:10m
+03. [10:00 => 12:30] Work: 2h:30m (3h:20m) *2
+04. [12:30 => 13:00] Break: 0h:30m
+05. [13:00 => .....] Work: 1h:20m (4h:40m) *4"
+check_output "log" "$expected_log" "$(WT_ASCII=1 $WT_CMD log)"
+
+expected_ratings="Ratings: 3 cycles in the last 30 days, average 3.7
+By time of day:
+  Before 10:00   5.0 ##### (cycles: 1)
+  10:00-12:00    2.0 ##    (cycles: 1)
+  12:00-14:00    4.0 ####  (cycles: 1)
+By cycle length:
+  0h:30m-1h:00m  5.0 ##### (cycles: 1)
+  1h:00m-1h:30m  4.0 ####  (cycles: 1)
+  2h:00m or more 2.0 ##    (cycles: 1)"
+check_output "stats" "$expected_ratings" "$(WT_ASCII=1 $WT_CMD stats --ratings)"
+check_output "empty range" "No rated cycles in 2025-01-01. Rate cycles with wt rate 1-5." "$($WT_CMD stats --ratings --range 2025-01-01)"
+check_output "one mode" "Use only one of --chart, --trend, and --ratings." "$($WT_CMD stats --trend --ratings 2>&1)"
+
+# The rating stays with the cycle through the stop
+mock_time "2026-01-20 14:30"
+run_wt stop
+check_output "stopped" '"rating": 4' "$($WT_CMD log --format json | grep -o '"rating": 4')"
+
+###############################################################################
+# Test 100: Notion sync
+###############################################################################
+print_test "100" "Notion sync"
+setup_test
+export WT_PRESET_DEEPWORK="+focus"
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start --preset deepwork
+mock_time "2026-01-20 10:30"
+run_wt mark "draft done"
+run_wt stop
+mock_time "2026-01-20 10:45"
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+unset WT_PRESET_DEEPWORK
+
+check_output "dry run" "2026-01-20 | Work: 2h:45m | Break: 0h:15m | Tags: focus | Notes: 10:30 draft done" "$($WT_CMD sync notion --dry-run)"
+actual_error=$($WT_CMD sync notion 2>&1 || true)
+check_output "needs settings" "Set WT_NOTION_TOKEN (an integration's secret) and WT_NOTION_DATABASE (the id of a database shared with it) to sync to Notion." "$actual_error"
+
+port=$((20000 + RANDOM % 10000))
+received="$WT_ROOT/.out/notion-requests"
+python3 - "$port" "$received" <<'PY' &
+import json, sys
+from http.server import BaseHTTPRequestHandler, HTTPServer
+
+class Handler(BaseHTTPRequestHandler):
+    def handle_request(self):
+        body = json.loads(self.rfile.read(int(self.headers["Content-Length"])))
+        with open(sys.argv[2], "a") as f:
+            f.write(f"{self.command} {self.path} | {self.headers['Notion-Version']} | {self.headers['Authorization']} | {json.dumps(body, sort_keys=True)}\n")
+        if body.get("parent", {}).get("database_id") == "missing":
+            status, reply = 404, {"object": "error", "status": 404, "message": "Could not find database with ID: missing."}
+        else:
+            status, reply = 200, {"object": "page", "id": "page-1"}
+        data = json.dumps(reply).encode()
+        self.send_response(status)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    do_POST = do_PATCH = handle_request
+
+    def log_message(self, *args):
+        pass
+
+HTTPServer(("127.0.0.1", int(sys.argv[1])), Handler).serve_forever()
+PY
+notion_pid=$!
+wait_for_port "$port"
+
+export WT_NOTION_API="http://127.0.0.1:$port" WT_NOTION_TOKEN=secret_123 WT_NOTION_DATABASE=db1
+check_output "added" "Synced 1 days to Notion (1 added, 0 updated)." "$($WT_CMD sync notion --range 2026-01-20)"
+expected_properties='{"Break": {"number": 0.25}, "Date": {"date": {"start": "2026-01-20"}}, "Name": {"title": [{"text": {"content": "2026-01-20"}}]}, "Notes": {"rich_text": [{"text": {"content": "10:30 draft done"}}]}, "Tags": {"multi_select": [{"name": "focus"}]}, "Work": {"number": 2.75}}'
+check_output "created page" "POST /v1/pages | 2022-06-28 | Bearer secret_123 | {\"parent\": {\"database_id\": \"db1\"}, \"properties\": $expected_properties}" "$(cat "$received")"
+
+rm "$received"
+check_output "repeated" "Synced 1 days to Notion (0 added, 1 updated)." "$($WT_CMD sync notion --range 2026-01-20)"
+check_output "updated page" "PATCH /v1/pages/page-1 | 2022-06-28 | Bearer secret_123 | {\"properties\": $expected_properties}" "$(cat "$received")"
+
+actual_error=$(WT_NOTION_DATABASE=missing $WT_CMD sync notion --range 2026-01-20 2>&1 || true)
+check_output "api error reported" "Notion: Could not find database with ID: missing. (syncing 2026-01-20)" "$actual_error"
+check_output "nothing in range" "No days to sync for 2026-01-19." "$($WT_CMD sync notion --range 2026-01-19)"
+unset WT_NOTION_API WT_NOTION_TOKEN WT_NOTION_DATABASE
+
+kill "$notion_pid"
+wait "$notion_pid" 2> /dev/null || true
+
+###############################################################################
+# Test 101: CalDAV push
+###############################################################################
+print_test "101" "CalDAV push"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt mark "standup notes"
+run_wt stop
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt stop
+
+expected_dry="2026-01-20 09:00-10:00 Work | wt-2026-01-20-e1.ics
+2026-01-20 10:15-11:00 Work | wt-2026-01-20-e3.ics"
+check_output "dry run" "$expected_dry" "$($WT_CMD sync caldav --dry-run)"
+actual_error=$($WT_CMD sync caldav 2>&1 || true)
+check_output "needs settings" "Set WT_CALDAV_URL (the calendar's URL), WT_CALDAV_USER, and WT_CALDAV_PASSWORD to push to a CalDAV calendar." "$actual_error"
+
+port=$((20000 + RANDOM % 10000))
+received="$WT_ROOT/.out/caldav-requests"
+python3 - "$port" "$received" <<'PY' &
+import sys
+from http.server import BaseHTTPRequestHandler, HTTPServer
+
+class Handler(BaseHTTPRequestHandler):
+    def handle_request(self):
+        length = int(self.headers.get("Content-Length") or 0)
+        body = self.rfile.read(length).decode().replace("\r\n", "\\n")
+        with open(sys.argv[2], "a") as f:
+            f.write(f"{self.command} {self.path} | {self.headers['Authorization']} | {self.headers['Content-Type']} | {body}\n")
+        self.send_response(403 if "denied" in self.path else 201 if self.command == "PUT" else 204)
+        self.end_headers()
+
+    do_PUT = do_DELETE = handle_request
+
+    def log_message(self, *args):
+        pass
+
+HTTPServer(("127.0.0.1", int(sys.argv[1])), Handler).serve_forever()
+PY
+caldav_pid=$!
+wait_for_port "$port"
+
+export WT_CALDAV_URL="http://127.0.0.1:$port/dav/calendars/me/work/" WT_CALDAV_USER=me WT_CALDAV_PASSWORD=app-pass
+check_output "pushed" "Pushed 2 work cycles to CalDAV." "$(TZ=UTC $WT_CMD sync caldav --range 2026-01-20)"
+expected_put='PUT /dav/calendars/me/work/wt-2026-01-20-e1.ics | Basic bWU6YXBwLXBhc3M= | text/calendar; charset=utf-8 | BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//wt//caldav//EN\nBEGIN:VEVENT\nUID:wt-2026-01-20-e1@wt\nDTSTAMP:20260120T090000Z\nDTSTART:20260120T090000Z\nDTEND:20260120T100000Z\nSUMMARY:Work\nDESCRIPTION:10:00 standup notes\nEND:VEVENT\nEND:VCALENDAR\n'
+check_output "event" "$expected_put" "$(head -1 "$received")"
+
+run_wt mod 2 drop
+rm "$received"
+check_output "merged cycle removed" "Pushed 1 work cycles to CalDAV, removed 1." "$($WT_CMD sync caldav --range 2026-01-20)"
+check_output "requests" "PUT /dav/calendars/me/work/wt-2026-01-20-e1.ics
+DELETE /dav/calendars/me/work/wt-2026-01-20-e3.ics" "$(cut -d' ' -f1,2 "$received")"
+
+actual_error=$(WT_CALDAV_URL="http://127.0.0.1:$port/denied" $WT_CMD sync caldav --range 2026-01-20 2>&1 || true)
+check_output "rejected push reported" "CalDAV: PUT wt-2026-01-20-e1.ics returned 403 Forbidden" "$actual_error"
+unset WT_CALDAV_URL WT_CALDAV_USER WT_CALDAV_PASSWORD
+
+kill "$caldav_pid"
+wait "$caldav_pid" 2> /dev/null || true
+
+###############################################################################
+# Test 102: iCalendar feed
+###############################################################################
+print_test "102" "iCalendar feed"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 11:00"
+
+FEED_PORT=$((20000 + RANDOM % 10000))
+TZ=UTC WT_API_TOKENS="r1:read,w1:write" $WT_CMD serve --listen "127.0.0.1:$FEED_PORT" > /dev/null 2>&1 &
+FEED_PID=$!
+wait_for_port "$FEED_PORT"
+
+expected_feed='BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//wt//feed//EN
+X-WR-CALNAME:wt
+REFRESH-INTERVAL;VALUE=DURATION:PT1H
+X-PUBLISHED-TTL:PT1H
+BEGIN:VEVENT
+UID:wt-2026-01-20-e1@wt
+DTSTAMP:20260120T090000Z
+DTSTART:20260120T090000Z
+DTEND:20260120T100000Z
+SUMMARY:Work
+END:VEVENT
+END:VCALENDAR'
+check_output "feed with token in the URL" "$expected_feed" "$(curl -s "http://127.0.0.1:$FEED_PORT/api/feed.ics?token=r1" | tr -d '\r')"
+check_output "calendar content type" "text/calendar; charset=utf-8" "$(curl -s -o /dev/null -w '%{content_type}' -H "Authorization: Bearer w1" "http://127.0.0.1:$FEED_PORT/api/feed.ics")"
+check_output "needs a token" '{"error":"Invalid or missing token. Subscribe to /api/feed.ics?token= with an API token."}' "$(curl -s "http://127.0.0.1:$FEED_PORT/api/feed.ics?token=nope")"
+
+kill $FEED_PID
+wait $FEED_PID 2> /dev/null || true
+
+###############################################################################
+# Test 103: Payroll export
+###############################################################################
+print_test "103" "Payroll export"
+setup_test
+
+mock_time "2026-01-23 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-23 12:00"
+run_wt stop
+mock_time "2026-01-23 12:40"
+run_wt start
+mock_time "2026-01-23 18:08"
+run_wt stop
+mock_time "2026-01-24 10:00"
+run_wt new
+run_wt start
+mock_time "2026-01-24 12:00"
+run_wt stop
+
+expected_payroll="date,regular_hours,overtime_hours,break_hours,approved_by,approved_on
+2026-01-23,8.00,0.50,0.75,,
+2026-01-24,0.00,2.00,0.00,,"
+check_output "regular and overtime" "$expected_payroll" "$($WT_CMD export payroll --range 2026-01-23..2026-01-24)"
+
+expected_payroll="date;regular_hours;overtime_hours;break_hours;approved_by;approved_on
+23.01.2026;7,50;0,97;0,67;;
+24.01.2026;0,00;2,00;0,00;;"
+check_output "csv dialect, threshold, no rounding" "$expected_payroll" "$(WT_CSV_DELIMITER=';' WT_CSV_DECIMAL=',' WT_CSV_DATE_FORMAT=eu WT_OVERTIME_AFTER=730 WT_PAYROLL_ROUND=1 $WT_CMD export payroll --range 2026-01-23..2026-01-24)"
+check_output "scheduled saturday" "2026-01-24,2.00,0.00,0.00,," "$(WT_SCHEDULE='0900 mon-sat' $WT_CMD export payroll --range 2026-01-24 | tail -1)"
+
+###############################################################################
+# Test 104: Clock jumps
+###############################################################################
+print_test "104" "Clock jumps"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+
+mock_time "2026-01-20 09:20"
+actual_error=$($WT_CMD start 2>&1 > /dev/null || true)
+check_output "jump back warned" "Warning: The clock went back 0h:40m since the last command (2026-01-20 10:00). Times are held at 10:00 until it catches up." "$actual_error"
+mock_time "2026-01-20 09:40"
+actual_error=$($WT_CMD stop 2>&1 > /dev/null || true)
+check_output "warned until it catches up" "Warning: The clock went back 0h:20m since the last command (2026-01-20 10:00). Times are held at 10:00 until it catches up." "$actual_error"
+mock_time "2026-01-20 10:30"
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt stop
+
+expected_log="01. [09:00 => 10:00] Work: 1h:00m (1h:00m)
+02. [10:00 => 10:00] Break: 0h:00m
+03. [10:00 => 10:00] Work: 0h:00m (1h:00m)
+04. [10:00 => 10:30] Break: 0h:30m
+05. [10:30 => 11:00] Work: 0h:30m (1h:30m)
+Clock went back 0h:40m at 2026-01-20 10:00 (it said 2026-01-20 09:20); times were held at 10:00."
+check_output "no negative cycles, jump recorded once" "$expected_log" "$($WT_CMD log)"
+check_output "in the debug log" "1" "$($WT_CMD log debug | grep -c 'The clock went back 0h:40m')"
+
+mock_time "2026-01-21 09:00"
+run_wt start
+mock_time "2026-01-22 12:00"
+actual_error=$($WT_CMD stop 2>&1 > /dev/null || true)
+check_output "long run warned" "Warning: The timer ran 27h:00m without a command since 2026-01-21 09:00. If the clock jumped ahead, correct the cycle with wt mod." "$actual_error"
+check_output "long run recorded" "Timer ran 27h:00m without a command from 2026-01-21 09:00 to 2026-01-22 12:00." "$($WT_CMD log | tail -1)"
+
+###############################################################################
+# Test 105: Tidy short breaks
+###############################################################################
+print_test "105" "Tidy short breaks"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:02"
+run_wt start
+mock_time "2026-01-20 11:00"
+run_wt stop
+mock_time "2026-01-20 11:30"
+run_wt start
+mock_time "2026-01-20 11:50"
+run_wt mark "tests green"
+mock_time "2026-01-20 12:00"
+run_wt next
+mock_time "2026-01-20 12:30"
+
+check_output "merged" "Merged 2 breaks (0h:02m) into work." "$($WT_CMD tidy --min-break 3)"
+expected_log="01. [09:00 => 11:00] Work: 2h:00m (2h:00m)
+02. [11:00 => 11:30] Break: 0h:30m
+03. [11:30 => .....] Work: 1h:00m (3h:00m)
+    11:50 tests green"
+check_output "one pass" "$expected_log" "$($WT_CMD log --notes)"
+check_output "nothing left" "No breaks shorter than 0h:05m between work cycles." "$($WT_CMD tidy)"
+check_output "bigger threshold" "Merged 1 breaks (0h:30m) into work." "$($WT_CMD tidy --min-break 100)"
+check_output "invalid threshold" "Incorrect time format. Should be 1-4 digit HHMM or HH:MM." "$($WT_CMD tidy --min-break abc 2>&1)"
+
+###############################################################################
+# Test 106: Batch mods from a file
+###############################################################################
+print_test "106" "Batch mods from a file"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-20 10:30"
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+cp "$WT_ROOT/.out/wt.json" "$WT_ROOT/.out/before.json"
+
+cat > "$WT_ROOT/.out/bad.wt" << 'MODS'
+# forgot to start the timer
+wt mod start sub 30
+9 add 15
+MODS
+check_output "bad line" "bad.wt:3 (9 add 15): Cycle 9 does not exist. Valid range: 1-3. Nothing was changed." "$($WT_CMD mod --file "$WT_ROOT/.out/bad.wt" 2>&1)"
+check_output "nothing saved" "same" "$(cmp -s "$WT_ROOT/.out/wt.json" "$WT_ROOT/.out/before.json" && echo same)"
+
+cat > "$WT_ROOT/.out/edits.wt" << 'MODS'
+# forgot to start the timer
+wt mod start sub 30
+
+1 add 30   # the first cycle started early
+3 sub 15
+MODS
+check_output "applied" "Applied 3 mods from edits.wt. Work: 2h:30m -> 2h:45m (the timer before is in .out/wt.json.bak)." "$($WT_CMD mod --file "$WT_ROOT/.out/edits.wt")"
+expected_log="01. [08:30 => 10:00] Work: 1h:30m (1h:30m)
+02. [10:00 => 10:30] Break: 0h:30m
+03. [10:30 => 11:45] Work: 1h:15m (2h:45m)"
+check_output "result" "$expected_log" "$($WT_CMD log)"
+check_output "backup" "same" "$(cmp -s "$WT_ROOT/.out/wt.json.bak" "$WT_ROOT/.out/before.json" && echo same)"
+check_output "stdin" "Applied 1 mods from -. Work: 2h:45m -> 2h:50m (the timer before is in .out/wt.json.bak)." "$(echo "3 add 5" | $WT_CMD mod --file -)"
+rm "$WT_ROOT/.out/edits.wt"
+check_output "replayed without the files" "Replayed 9 commands.
+03. [10:30 => 11:50] Work: 1h:20m (2h:50m)" "$($WT_CMD replay | sed -n '1p;4p')"
+check_output "empty" "No mods in empty.wt." "$(echo "# nothing" > "$WT_ROOT/.out/empty.wt"; $WT_CMD mod --file "$WT_ROOT/.out/empty.wt" 2>&1)"
+check_output "with args" "--file takes the mods from the file; it doesn't work with arguments or --date." "$($WT_CMD mod --file - 3 add 5 2>&1 < /dev/null)"
+
+###############################################################################
+# Test 107: Guard against dropping long work cycles
+###############################################################################
+print_test "107" "Guard against dropping long work cycles"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-20 12:00"
+run_wt stop
+mock_time "2026-01-20 12:30"
+run_wt start
+mock_time "2026-01-20 13:00"
+run_wt stop
+mock_time "2026-01-20 13:10"
+run_wt start
+mock_time "2026-01-20 14:00"
+run_wt stop
+
+expected="Cycle 1 is 3h:00m of work. Dropping it changes work 4h:20m -> 1h:20m, breaks 0h:40m -> 0h:40m.
+Drop it? y / n [n]: Nothing was dropped. Use 'wt mod --yes 1 drop' to drop it anyway."
+check_output "refused" "$expected" "$(echo n | WT_SKIP_PROMPTS= $WT_CMD mod 1 drop)"
+check_output "kept" "4h:20m" "$($WT_CMD log | tail -1 | grep -o '([0-9h:m]*)' | tr -d '()')"
+check_output "short cycle" "Removed cycle 3 (merged adjacent breaks: 1h:10m)" "$(WT_SKIP_PROMPTS= $WT_CMD mod 3 drop < /dev/null)"
+check_output "confirmed" "Drop it? y / n [n]: Removed cycle 1" "$(echo y | WT_SKIP_PROMPTS= $WT_CMD mod 1 drop | tail -1)"
+check_output "force is for closed days" "Drop it? y / n [n]: Nothing was dropped. Use 'wt mod --yes 2 drop' to drop it anyway." "$(WT_SKIP_PROMPTS= WT_DROP_GUARD=30 $WT_CMD mod --force 2 drop < /dev/null | tail -1)"
+check_output "without asking" "Removed cycle 2" "$(WT_SKIP_PROMPTS= WT_DROP_GUARD=30 $WT_CMD mod --yes 2 drop < /dev/null)"
+check_output "left" "01. [09:00 => 10:10] Break: 1h:10m" "$($WT_CMD log)"
+
+###############################################################################
+# Test 108: Archive days gzipped
+###############################################################################
+print_test "108" "Archive days gzipped"
+setup_test
+
+export WT_ARCHIVE_GZIP=1
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-19 13:00"
+run_wt reset
+run_wt start
+mock_time "2026-01-19 14:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt restart
+mock_time "2026-01-20 10:00"
+run_wt stop
+run_wt close
+unset WT_ARCHIVE_GZIP
+
+check_output "compressed" "2026-01-19.2.json.gz 2026-01-19.json.gz 2026-01-20.json.gz " "$(ls "$WT_ROOT/.out/archive" | tr '\n' ' ')"
+check_output "readable" "2026-01-19 | Work: 4h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 4h:00m | Days: 1" "$($WT_CMD report --range 2026-01-19..2026-01-19)"
+run_wt mod --force 1 add 15
+check_output "closed day stays compressed" "75" "$(zcat "$WT_ROOT/.out/archive/2026-01-20.json.gz" | grep -o '"minutes": [0-9]*' | head -1 | cut -d' ' -f2)"
+
+###############################################################################
+# Test 109: Archive index
+###############################################################################
+print_test "109" "Archive index"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt restart
+mock_time "2026-01-20 10:00"
+run_wt stop
+mock_time "2026-01-21 09:00"
+run_wt restart
+
+expected="2026-01-19 | Work: 3h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 3h:00m | Days: 1
+2026-01-20 | Work: 1h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 1h:00m | Days: 1
+Total      | Work: 4h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 4h:00m | Days: 2"
+check_output "report" "$expected" "$($WT_CMD report --range 2026-01-19..2026-01-20)"
+check_output "indexed" "2026-01-19.json 2026-01-20.json" "$(grep -o '"20[0-9-]*\.json"' "$WT_ROOT/.out/archive-index.json" | tr -d '"' | sort | tr '\n' ' ' | sed 's/ $//')"
+
+# Summaries come from the index while the archive file is unchanged
+sed -i 's/"work_minutes":180/"work_minutes":170/' "$WT_ROOT/.out/archive-index.json"
+check_output "read from index" "Work: 2h:50m" "$($WT_CMD report --range 2026-01-19 | grep -o 'Work: [0-9h:m]*')"
+run_wt mod --date 2026-01-19 1 add 15
+check_output "changed file summarized again" "Work: 3h:15m" "$($WT_CMD report --range 2026-01-19 | grep -o 'Work: [0-9h:m]*')"
+check_output "compare" "Work       3h:15m       1h:00m       -2h:15m" "$($WT_CMD compare --a 2026-01-19 --b 2026-01-20 | grep '^Work ')"
+rm "$WT_ROOT/.out/archive-index.json"
+check_output "rebuilt" "Work: 4h:15m" "$($WT_CMD report --range 2026-01-19..2026-01-20 | tail -1 | grep -o 'Work: [0-9h:m]*')"
+
+###############################################################################
+# Test 110: Daily report history
+###############################################################################
+print_test "110" "Daily report history"
+setup_test
+
+check_output "empty" "No days in the daily report yet. Days are added by wt new, wt reset, and wt close." "$($WT_CMD report history)"
+
+# A file from before appends, newest line at the top
+cat > "$WT_ROOT/.out/daily-reports" << 'LINES'
+2026-01-16 | 09:00 -> 11:00 | Work: 2h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 2h:00m
+2026-01-15 | 09:00 -> 10:00 | Work: 1h:00m | Break: 0h:00m | Paused: 0h:00m | Total: 1h:00m
+LINES
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt new
+
+check_output "appended" "2026-01-19 | 09:00 -> 12:00 | Work: 3h:00m" "$(tail -1 "$WT_ROOT/.out/daily-reports" | cut -d'|' -f1-3 | sed 's/ *$//')"
+expected="2026-01-15
+2026-01-16
+2026-01-19"
+check_output "old file left as is" "2026-01-16" "$(head -1 "$WT_ROOT/.out/daily-reports" | cut -c1-10)"
+check_output "fix" "Rewrote the daily report in date order, oldest first." "$($WT_CMD report history --fix)"
+check_output "old file rewritten oldest first" "$expected" "$(cut -c1-10 "$WT_ROOT/.out/daily-reports")"
+check_output "fix once" "The daily report is in date order already." "$($WT_CMD report history --fix)"
+expected="2026-01-19
+2026-01-16
+2026-01-15"
+check_output "newest first" "$expected" "$($WT_CMD report history | cut -c1-10)"
+check_output "limit" "2026-01-19" "$($WT_CMD report history -n 1 | cut -c1-10)"
+
+###############################################################################
+# Test 111: Check and status stay on the fast path
+###############################################################################
+print_test "111" "Check and status stay on the fast path"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt profile set acme WT_DAILY_GOAL 400
+run_wt profile use acme
+run_wt start
+mock_time "2026-01-20 10:00"
+
+# Neither the archive nor the daily report is read, and nothing is written
+echo "not json" > "$WT_ROOT/.out/archive/2026-01-19.json"
+echo "not a report" > "$WT_ROOT/.out/daily-reports"
+before=$(ls -l --time-style=+%s%N "$WT_ROOT/.out" "$WT_ROOT/.out/archive")
+check_output "check" "1h 00m RUNNING (1h 00m) ETA 13:00" "$($WT_CMD check)"
+check_output "status" "running" "$($WT_CMD status)"
+check_output "nothing written" "$before" "$(ls -l --time-style=+%s%N "$WT_ROOT/.out" "$WT_ROOT/.out/archive")"
+check_output "plain wt" "1h 00m RUNNING (1h 00m) ETA 13:00" "$($WT_CMD)"
+
+###############################################################################
+# Test 112: Search with wt grep
+###############################################################################
+print_test "112" "Search with wt grep"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start -m "Parser rewrite" @home
+mock_time "2026-01-19 10:00"
+run_wt pause -m "review call"
+mock_time "2026-01-19 10:15"
+run_wt start
+mock_time "2026-01-19 11:00"
+run_wt stop
+mock_time "2026-01-19 12:00"
+run_wt start -m "Emails"
+mock_time "2026-01-19 12:30"
+run_wt mark "parser tests green"
+mock_time "2026-01-19 13:00"
+run_wt stop
+mock_time "2026-01-20 09:00"
+run_wt new --note "Parser done, reviews pending"
+run_wt start -m "Code review"
+mock_time "2026-01-20 09:30"
+
+expected='2026-01-19 | 09:00 -> 11:00 | Work: 1h:45m | task "Parser rewrite"
+2026-01-19 | 12:00 -> 13:00 | Work: 1h:00m | mark 12:30 "parser tests green"
+2026-01-19 | note "Parser done, reviews pending"
+2 cycles, 2h:45m of work.'
+check_output "ignore case" "$expected" "$($WT_CMD grep -i parser)"
+check_output "case sensitive" '2026-01-19 | note "Parser done, reviews pending"' "$($WT_CMD grep 'Parser done')"
+expected='2026-01-19 | 09:00 -> 11:00 | Work: 1h:45m | pause 10:00 "review call"
+2026-01-20 | 09:00 -> ..... | Work: 0h:30m | task "Code review"
+2 cycles, 2h:15m of work.'
+check_output "pauses and today" "$expected" "$($WT_CMD grep 'review (call|$)|Code')"
+check_output "location" "2026-01-19 | 09:00 -> 11:00 | Work: 1h:45m | location @home" "$($WT_CMD grep '^home$' | head -1)"
+check_output "range" "Nothing matching parser in 2026-01-20." "$($WT_CMD grep parser --range 2026-01-20)"
+check_output "invalid pattern" "Invalid pattern: error parsing regexp: missing closing ): \`(\`" "$($WT_CMD grep '(' 2>&1)"
+
+###############################################################################
+# Test 113: Open data and reports
+###############################################################################
+print_test "113" "Open data and reports"
+setup_test
+
+export WT_OPENER="echo opening"
+mock_time "2026-01-20 09:00"
+run_wt new
+check_output "no report yet" "No daily report yet at $WT_ROOT/.out/daily-reports. Days are added by wt new, wt reset, and wt close." "$($WT_CMD open report 2>&1)"
+check_output "no day yet" "No work recorded today." "$($WT_CMD open today 2>&1)"
+run_wt start -m "Parser rewrite"
+mock_time "2026-01-20 10:00"
+check_output "folder" "opening $WT_ROOT/.out" "$($WT_CMD open)"
+check_output "today" "opening $WT_ROOT/.out/today/days/2026-01-20.html" "$($WT_CMD open today)"
+check_output "today rendered" "1" "$(grep -c 'Parser rewrite' "$WT_ROOT/.out/today/days/2026-01-20.html")"
+check_output "invalid target" "Invalid target: logs. Use folder, report, today." "$($WT_CMD open logs 2>&1)"
+check_output "opener fails" "Couldn't open $WT_ROOT/.out with false: exit status 1. Set WT_OPENER to the command to use." "$(WT_OPENER=false $WT_CMD open 2>&1)"
+unset WT_OPENER
+
+###############################################################################
+# Test 114: Linear issue time tracking
+###############################################################################
+print_test "114" "Linear issue time tracking"
+setup_test
+
+port=$((20000 + RANDOM % 10000))
+posted="$WT_ROOT/.out/linear"
+python3 - "$port" "$posted" <<'PY' &
+import json, sys
+from http.server import BaseHTTPRequestHandler, HTTPServer
+
+class Handler(BaseHTTPRequestHandler):
+    def do_POST(self):
+        request = json.loads(self.rfile.read(int(self.headers["Content-Length"])))
+        variables = request["variables"]
+        if self.headers["Authorization"] != "lin_api_123":
+            result = {"errors": [{"message": "Authentication required"}]}
+        elif "issue(" in request["query"]:
+            if variables["id"] == "ENG-404":
+                result = {"errors": [{"message": "Entity not found: Issue"}]}
+            else:
+                result = {"data": {"issue": {"id": "uuid-" + variables["id"]}}}
+        else:
+            with open(sys.argv[2], "a") as f:
+                f.write(f"{variables['issueId']} | {variables['body']}\n")
+            result = {"data": {"commentCreate": {"success": True}}}
+        data = json.dumps(result).encode()
+        self.send_response(200)
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    def log_message(self, *args):
+        pass
+
+HTTPServer(("127.0.0.1", int(sys.argv[1])), Handler).serve_forever()
+PY
+linear_pid=$!
+wait_for_port "$port"
+export WT_LINEAR_API="http://127.0.0.1:$port" WT_LINEAR_TOKEN=lin_api_123
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+# Without a key in the task, the branch of the checked-out repo links the cycle
+mkdir -p "$WT_ROOT/.git"
+echo "ref: refs/heads/feature/ops-7-deploy-script" > "$WT_ROOT/.git/HEAD"
+run_wt start
+mock_time "2026-01-20 09:45"
+check_output "posted from branch" "Timer stopped.
+Posted 0h:45m to OPS-7." "$($WT_CMD stop)"
+check_output "branch key becomes the task" "1" "$($WT_CMD report --range today --group-by task | grep -c "OPS-7")"
+
+mock_time "2026-01-20 10:00"
+run_wt start -m "ENG-42 parser rewrite, utf-8 input"
+mock_time "2026-01-20 11:30"
+check_output "posted on stop" "Timer stopped.
+Posted 1h:30m to ENG-42." "$($WT_CMD stop)"
+
+expected_posted="uuid-OPS-7 | Tracked 0h:45m with wt (2026-01-20 09:00-09:45).
+uuid-ENG-42 | Tracked 1h:30m with wt (2026-01-20 10:00-11:30)."
+check_output "comments" "$expected_posted" "$(cat "$posted")"
+
+# A failed post doesn't fail the stop
+mock_time "2026-01-20 12:00"
+run_wt start -m "ENG-404 missing"
+mock_time "2026-01-20 12:30"
+check_output "unknown issue" "Timer stopped.
+Not posted to ENG-404: Linear: Entity not found: Issue" "$($WT_CMD stop)"
+check_output "stopped anyway" "stopped" "$($WT_CMD status)"
+
+# Mods replay stops on a scratch copy, which posts nothing
+run_wt mod 2 drop
+check_output "nothing posted by mods" "2" "$(wc -l < "$posted" | tr -d ' ')"
+
+rm -rf "$WT_ROOT/.git"
+unset WT_LINEAR_API WT_LINEAR_TOKEN
+kill "$linear_pid"
+wait "$linear_pid" 2> /dev/null || true
+
+###############################################################################
+# Test 115: Named timers
+###############################################################################
+print_test "115" "Named timers"
+setup_test
+
+check_output "no timers" "No timers yet. Run 'wt new' for the root's timer, or 'wt --timer <name> new' for a named one." "$($WT_CMD list)"
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+run_wt --timer clientA new
+run_wt --timer clientA start -m "API review"
+WT_TIMER=client-b run_wt new
+mock_time "2026-01-20 10:30"
+run_wt --timer clientA pause
+check_output "timers apart" "running paused stopped" "$($WT_CMD status) $($WT_CMD --timer clientA status) $(WT_TIMER=client-b $WT_CMD status)"
+check_output "own files" "1 1 1" "$(ls "$WT_ROOT/.out/timers/clientA" | grep -c '^wt.json$') $(ls "$WT_ROOT/.out/timers/clientA" | grep -c '^debug-log$') $(ls "$WT_ROOT/.out/timers/client-b" | grep -c '^wt.json$')"
+
+expected_list="  default  | running | Work: 1h:30m
+  client-b | stopped | Work: 0h:00m
+* clientA  | paused  | Work: 1h:30m | API review"
+check_output "list" "$expected_list" "$($WT_CMD --timer clientA list)"
+
+# Each timer writes its own daily report
+mock_time "2026-01-21 08:00"
+run_wt --timer clientA new
+check_output "own daily report" "1 0" "$(grep -c '2026-01-20' "$WT_ROOT/.out/timers/clientA/daily-reports") $(ls "$WT_ROOT/.out" | grep -c '^daily-reports$')"
+check_output "env" "export WT_TIMER='clientA'" "$($WT_CMD --timer clientA env "$WT_ROOT" | grep WT_TIMER)"
+
+check_output "invalid name" "Invalid timer name: ../x. Use letters, digits, - and _ (and not \"default\")." "$($WT_CMD --timer ../x status 2>&1)"
+
+###############################################################################
+# Test 116: Past days from the archive
+###############################################################################
+print_test "116" "Past days from the archive"
+setup_test
+
+mock_time "2026-01-19 09:00"
+run_wt new
+run_wt start -m "Parser"
+mock_time "2026-01-19 10:30"
+run_wt pause
+mock_time "2026-01-19 10:45"
+run_wt start
+mock_time "2026-01-19 12:00"
+run_wt stop
+mock_time "2026-01-20 08:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:00"
+
+check_output "report yesterday" "$($WT_CMD report history -n 1)" "$($WT_CMD report --date yesterday)"
+check_output "report by date" "$($WT_CMD report --date yesterday)" "$($WT_CMD report --date 2026-01-19)"
+check_output "today is the current timer" "$($WT_CMD report)" "$($WT_CMD report --date today)"
+check_output "log of a past day" "1,work,Work,2026-01-19 09:00,2026-01-19 12:00,165,15,165,false," "$($WT_CMD log --date 2026-01-19 --format csv | grep ',work,')"
+check_output "log pauses of a past day" "1" "$($WT_CMD log --date 2026-01-19 --pauses | grep -c '10:30')"
+check_output "no such day" "No archived day 2026-01-10." "$($WT_CMD report --date 2026-01-10 2>&1)"
+check_output "not with ranges" "--date reports on one day; it doesn't work with --range, --group-by, or --period." "$($WT_CMD report --date 2026-01-19 --range lastweek 2>&1)"
+
+###############################################################################
+# Test 117: JSON output
+###############################################################################
+print_test "117" "JSON output"
+setup_test
+
+json_field() {
+    python3 -c 'import json, sys; v = json.load(sys.stdin)
+for key in sys.argv[1].split("."): v = v[int(key)] if isinstance(v, list) else v[key]
+print(v)' "$1"
+}
+
+check_output "status without a timer" "stopped" "$($WT_CMD --json status | json_field status)"
+
+export WT_RATE="60 EUR"
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start -m "Parser"
+mock_time "2026-01-20 10:00"
+run_wt pause
+mock_time "2026-01-20 10:10"
+check_output "check state" "paused 2026-01-20 09:00 2026-01-20 10:00 60 10 Parser" "$($WT_CMD --json check | python3 -c 'import json, sys; s = json.load(sys.stdin)
+print(s["status"], s["cycle_start"], s["pause_start"], s["cycle_minutes"], s["totals"]["paused_minutes"], s["task"])')"
+check_output "check line included" "$($WT_CMD check)" "$($WT_CMD --json check | json_field check)"
+check_output "default check" "paused" "$($WT_CMD --json | json_field status)"
+check_output "status" "2026-01-20 09:00" "$($WT_CMD status --json | json_field day_start)"
+
+mock_time "2026-01-20 10:20"
+run_wt stop
+check_output "report" "2026-01-20 2026-01-20 09:00 2026-01-20 10:20 60 60 EUR 1" "$($WT_CMD --json report | python3 -c 'import json, sys; r = json.load(sys.stdin)
+print(r["date"], r["start"], r["end"], r["totals"]["work_minutes"], r["earned"], r["currency"], len(r["entries"]))')"
+check_output "report entry pauses" "2026-01-20 10:00" "$($WT_CMD --json report | json_field entries.0.pauses.0.start)"
+check_output "log" "$($WT_CMD log --format json)" "$($WT_CMD --json log)"
+unset WT_RATE
+
+check_output "log format clash" "--json and --format csv don't go together." "$($WT_CMD --json log --format csv 2>&1)"
+check_output "report range" "--json reports on one day. For a range, use 'wt export --range <range>', which writes JSON." "$($WT_CMD --json report --range lastweek 2>&1)"
+check_output "other commands" "--json works with check, status, report, and log." "$($WT_CMD --json start 2>&1)"
+check_output "text unchanged" "stopped" "$($WT_CMD status)"
+
+###############################################################################
+# Test 118: Config file
+###############################################################################
+print_test "118" "Config file"
+setup_test
+
+check_output "empty config" "No settings in $WT_ROOT/.out/config. Add one with: wt config set <KEY> <value>" "$($WT_CMD config get)"
+check_output "set" "Config: WT_DAILY_GOAL=0800" "$($WT_CMD config set WT_DAILY_GOAL 0800)"
+run_wt config set WT_MODE normal
+check_output "list" "WT_DAILY_GOAL=0800
+WT_MODE=normal" "$($WT_CMD config get)"
+check_output "get" "0800" "$($WT_CMD config get WT_DAILY_GOAL)"
+
+# The first timer takes WT_MODE, and settings apply like exported ones
+mock_time "2026-01-20 09:00"
+run_wt new
+check_output "mode from config" "normal" "$($WT_CMD mode)"
+run_wt start
+mock_time "2026-01-20 10:00"
+check_output "goal from config" "1h 00m RUNNING (1h 00m) ETA 17:00" "$($WT_CMD check)"
+
+# The environment and the active profile win
+check_output "environment wins" "0800
+(the environment overrides it with 0700)" "$(WT_DAILY_GOAL=0700 $WT_CMD config get WT_DAILY_GOAL)"
+run_wt profile set short WT_DAILY_GOAL 0400
+check_output "profile wins" "1h 00m RUNNING (1h 00m) ETA 13:00" "$($WT_CMD --profile short check)"
+
+# WT_CONFIG points at a config file elsewhere
+echo "WT_DAILY_GOAL=0200" > "$WT_ROOT/.out/shared-config"
+check_output "config elsewhere" "1h 00m RUNNING (1h 00m) ETA 11:00" "$(WT_CONFIG="$WT_ROOT/.out/shared-config" $WT_CMD check)"
+
+check_output "unset" "Config: WT_DAILY_GOAL removed." "$($WT_CMD config unset WT_DAILY_GOAL)"
+check_output "unset missing" "WT_DAILY_GOAL isn't set in $WT_ROOT/.out/config." "$($WT_CMD config get WT_DAILY_GOAL 2>&1)"
+check_output "invalid mode" "Invalid WT_MODE: loud. Use silent, normal, or verbose." "$($WT_CMD config set WT_MODE loud 2>&1)"
+check_output "env only" "WT_ROOT cannot be set in the config file; set it in the environment." "$($WT_CMD config set WT_ROOT /tmp 2>&1)"
+check_output "not a setting" "Invalid setting: GOAL. Settings are WT_* variables, e.g. WT_DAILY_GOAL" "$($WT_CMD config set GOAL 0800 2>&1)"
+
+###############################################################################
+# Test 119: Pomodoro mode
+###############################################################################
+print_test "119" "Pomodoro mode"
+setup_test
+
+export WT_POMODORO=1 WT_POMODORO_LONG_EVERY=2
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+run_wt start
+mock_time "2026-01-20 09:10"
+check_output "counts down" "0h 15m left RUNNING (0h 10m)" "$($WT_CMD check)"
+check_output "target in json" "25" "$($WT_CMD --json check | python3 -c 'import json, sys; print(json.load(sys.stdin)["cycle_target"])')"
+mock_time "2026-01-20 09:28"
+check_output "overrun" "0h 03m over RUNNING (0h 28m) [!] take a break: wt next" "$($WT_CMD check)"
+check_output "remind" "Pomodoro 1 is over (0h 28m) - 'wt next' starts the break." "$($WT_CMD remind)"
+
+check_output "next starts a short break" "Timer stopped.
+Pomodoro 1 done. Take a short break (0h:05m), until 09:33; 'wt next' starts the next one." "$($WT_CMD next)"
+mock_time "2026-01-20 09:31"
+check_output "break counts down" "--:-- STOPPED (0h 28m) break 0h 02m left" "$($WT_CMD check)"
+mock_time "2026-01-20 09:35"
+check_output "break over" "--:-- STOPPED (0h 28m) [!] break over: wt next" "$($WT_CMD check)"
+run_wt next
+check_output "next starts the next pomodoro" "running" "$($WT_CMD status)"
+
+mock_time "2026-01-20 10:00"
+check_output "second is followed by a long break" "Timer stopped.
+Pomodoro 2 done. Take a long break (0h:15m), until 10:15; 'wt next' starts the next one." "$($WT_CMD next)"
+
+# Lengths come from settings, and a preset's targets win
+export WT_POMODORO_WORK=50 WT_PRESET_SPRINT="15/3"
+mock_time "2026-01-20 10:15"
+run_wt start
+mock_time "2026-01-20 10:20"
+check_output "own length" "0h 45m left RUNNING (0h 58m)" "$($WT_CMD check)"
+run_wt next
+mock_time "2026-01-20 10:25"
+run_wt start --preset sprint
+mock_time "2026-01-20 10:30"
+check_output "preset wins" "0h 10m left RUNNING (1h 03m)" "$($WT_CMD check)"
+unset WT_POMODORO WT_POMODORO_LONG_EVERY WT_POMODORO_WORK WT_PRESET_SPRINT
+check_output "off again" "0h 05m RUNNING (1h 03m)" "$($WT_CMD check)"
+
+###############################################################################
+# Test 120: Notifications when a pomodoro or break is over
+###############################################################################
+print_test "120" "Notifications when a pomodoro or break is over"
+setup_test
+
+notified="$WT_ROOT/.out/notified"
+export WT_POMODORO=1
+export WT_NOTIFY_COMMAND="sh -c 'echo \"\$1 | \$2\" >> $notified' notify {title} \"{body}\""
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:20"
+check_output "nothing before the target" "" "$($WT_CMD remind --notify)"
+check_output "no notification" "no" "$([ -e "$notified" ] && echo yes || echo no)"
+
+mock_time "2026-01-20 09:25"
+run_wt daemon start --interval 1h
+sleep 0.3
+run_wt daemon stop
+check_output "daemon notifies at the work target" "wt | Pomodoro 1 is over (0h 25m) - 'wt next' starts the break." "$(cat "$notified")"
+
+rm -f "$notified"
+run_wt next
+mock_time "2026-01-20 09:31"
+run_wt remind --notify
+check_output "break over" "wt | Break is over (0h 06m) - 'wt next' starts pomodoro 2." "$(cat "$notified")"
+
+unset WT_POMODORO WT_NOTIFY_COMMAND
+
+###############################################################################
+# Test 121: Commands through the daemon socket
+###############################################################################
+print_test "121" "Commands through the daemon socket"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+actual_error=$($WT_CMD --remote daemon status 2>&1 || true)
+check_output "needs a running daemon" "Daemon not running. Start it with 'wt daemon start'." "$actual_error"
+
+run_wt daemon start --interval 1h
+check_output "socket is the owner's only" "600" "$(stat -c %a "$WT_ROOT/.out/daemon.sock")"
+check_output "start through the daemon" "Starting timer." "$($WT_CMD --remote daemon start)"
+check_output "status through the daemon" "running" "$(WT_REMOTE=daemon $WT_CMD status)"
+check_output "saved for local commands" "running" "$($WT_CMD status)"
+check_output "json check" "running" "$($WT_CMD --remote daemon --json check | python3 -c 'import json, sys; print(json.load(sys.stdin)["status"])')"
+check_output "state held in memory" "Timer held in memory, disk reads: 1" "$($WT_CMD daemon status | sed -n 2p)"
+mock_time "2026-01-20 09:30"
+run_wt pause
+check_output "paused locally" "paused" "$($WT_CMD --remote daemon status)"
+check_output "read again after a local change" "Timer held in memory, disk reads: 2" "$($WT_CMD daemon status | sed -n 2p)"
+run_wt start
+actual_error=$($WT_CMD --remote daemon report 2>&1 || true)
+check_output "other commands refuse" "'wt report' isn't available with --remote. Remote commands: check, status, log, start, pause, stop, next, toggle" "$actual_error"
+run_wt --remote daemon stop
+check_output "stopped through the daemon" "stopped" "$($WT_CMD status)"
+run_wt daemon stop
+
+###############################################################################
+# Test 122: Undo
+###############################################################################
+print_test "122" "Undo"
+setup_test
+
+work_total() {
+    $WT_CMD --json status | python3 -c 'import json, sys; print(json.load(sys.stdin)["totals"]["work_minutes"])'
+}
+
+mock_time "2026-01-20 09:00"
+run_wt new
+check_output "nothing to undo" "Nothing to undo." "$($WT_CMD undo)"
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+check_output "undo a stop" "Undid 'wt stop' (10:00); the timer is running." "$($WT_CMD undo)"
+mock_time "2026-01-20 10:30"
+check_output "still the same cycle" "1h 30m RUNNING (1h 30m) [!] take a break: wt next" "$($WT_CMD check)"
+
+run_wt stop
+run_wt mod 1 sub 60
+check_output "mod applied" "30" "$(work_total)"
+check_output "undo a mod" "Undid 'wt mod' (10:30); the timer is stopped." "$($WT_CMD undo)"
+check_output "work restored" "90" "$(work_total)"
+check_output "undo logged" "Undid 'wt mod' of 2026-01-20 10:30" "$(grep -o "Undid 'wt mod' of [0-9: -]*[0-9]" "$WT_ROOT/.out/debug-log" | tail -1)"
+
+# Other changes to the timeline are undone too, and don't clear the journal
+mock_time "2026-01-20 10:32"
+run_wt start
+mock_time "2026-01-20 10:40"
+run_wt stop
+run_wt tidy
+check_output "undo a tidy" "Undid 'wt tidy' (10:40); the timer is stopped." "$($WT_CMD undo)"
+check_output "break restored" "1" "$($WT_CMD log | grep -c Break)"
+run_wt plan 4x50
+check_output "journal kept" "Undo:
+  1. wt plan (2026-01-20 10:40)
+  2. wt stop (2026-01-20 10:40)
+  3. wt start (2026-01-20 10:32)" "$($WT_CMD undo --list | head -4)"
+
+run_wt start
+run_wt reset
+check_output "reset can't be undone" "Nothing to undo." "$($WT_CMD undo)"
+
+###############################################################################
+# Test 123: Multi-level undo and redo
+###############################################################################
+print_test "123" "Multi-level undo and redo"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt start
+mock_time "2026-01-20 09:30"
+run_wt pause
+mock_time "2026-01-20 09:40"
+run_wt start
+mock_time "2026-01-20 10:00"
+run_wt stop
+check_output "list, next first" "Undo:
+  1. wt stop (2026-01-20 10:00)
+  2. wt start (2026-01-20 09:40)
+  3. wt pause (2026-01-20 09:30)
+  4. wt start (2026-01-20 09:00)" "$($WT_CMD undo --list)"
+
+run_wt undo
+check_output "undo again" "Undid 'wt start' (09:40); the timer is paused." "$($WT_CMD undo)"
+check_output "redo" "Redid 'wt start' (09:40); the timer is running." "$($WT_CMD redo)"
+check_output "both sides listed" "Undo:
+  1. wt start (2026-01-20 09:40)
+  2. wt pause (2026-01-20 09:30)
+  3. wt start (2026-01-20 09:00)
+Redo:
+  1. wt stop (2026-01-20 10:00)" "$($WT_CMD undo --list)"
+check_output "redo to the end" "Redid 'wt stop' (10:00); the timer is stopped." "$($WT_CMD redo)"
+check_output "nothing to redo" "Nothing to redo." "$($WT_CMD redo)"
+
+run_wt undo
+mock_time "2026-01-20 10:10"
+run_wt pause
+check_output "a new change clears redo" "Nothing to redo." "$($WT_CMD redo)"
+
+export WT_UNDO_LIMIT=2
+run_wt start
+check_output "bounded" "2" "$($WT_CMD undo --list | grep -c '^  [0-9]')"
+unset WT_UNDO_LIMIT
+run_wt reset
+check_output "empty list" "Nothing to undo or redo." "$($WT_CMD undo --list)"
+
+###############################################################################
+# Test 124: Cycle tags, notes, and grouping by tag
+###############################################################################
+print_test "124" "Cycle tags, notes, and grouping by tag"
+setup_test
+
+export WT_PRESET_DEEP="50/10 +focus"
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+check_output "needs a cycle" "No running cycle to tag. Start the timer first." "$($WT_CMD tag billing)"
+run_wt start --preset deep
+check_output "tag the cycle" "Cycle tags: +focus +billing +client-a" "$($WT_CMD tag billing +client-a)"
+check_output "note is mark" "Marked 09:00: refactoring parser" "$($WT_CMD note "refactoring parser")"
+check_output "remove" "Cycle tags: +focus +billing" "$($WT_CMD tag --remove client-a)"
+actual_error=$($WT_CMD tag --remove focus 2>&1 || true)
+check_output "preset tags stay" "+focus is a tag of preset deep. Use 'wt start --preset none' to drop it." "$actual_error"
+mock_time "2026-01-20 10:00"
+check_output "log shows the active cycle's tags" "01. [09:00 => .....] Work: 1h:00m (1h:00m) +focus +billing" "$($WT_CMD log)"
+run_wt stop
+
+mock_time "2026-01-20 10:30"
+run_wt start
+mock_time "2026-01-20 11:00"
+check_output "only that cycle" "03. [10:30 => .....] Work: 0h:30m (1h:30m) +focus" "$($WT_CMD log | tail -1)"
+run_wt stop
+run_wt start --preset none
+mock_time "2026-01-20 11:15"
+run_wt stop
+check_output "group by tag" "(no tag) | Work: 0h:15m | Break: 0h:00m | Paused: 0h:00m | Total: 0h:15m | Days: 1
+billing  | Work: 1h:00m | Break: 0h:30m | Paused: 0h:00m | Total: 1h:30m | Days: 1
+focus    | Work: 1h:30m | Break: 0h:30m | Paused: 0h:00m | Total: 2h:00m | Days: 1
+Total    | Work: 1h:45m | Break: 0h:30m | Paused: 0h:00m | Total: 2h:15m | Days: 1" "$($WT_CMD report --group-by tag --range today)"
+unset WT_PRESET_DEEP
+
+###############################################################################
+# Test 125: Task switching and task list
+###############################################################################
+print_test "125" "Task switching and task list"
+setup_test
+
+mock_time "2026-01-20 09:00"
+run_wt new
+run_wt mode normal
+check_output "nothing yet" "No work recorded today. Start on a task with: wt task start \"fix login bug\"" "$($WT_CMD task list)"
+run_wt start
+mock_time "2026-01-20 09:15"
+check_output "claims an untasked cycle" "Cycle 1 is on \"fix login bug\" now." "$($WT_CMD task start fix login bug)"
+check_output "same task" "Already on \"fix login bug\"." "$($WT_CMD task start "fix login bug")"
+mock_time "2026-01-20 09:40"
+check_output "switch" "Timer stopped.
+Switched from \"fix login bug\" to \"write RFC\"." "$($WT_CMD task start "write RFC" --estimate 2h)"
+mock_time "2026-01-20 10:05"
+check_output "list" "  fix login bug | Work: 0h:40m | Cycles: 1
+* write RFC     | Work: 0h:25m | Cycles: 1 | Estimate: 2h:00m (20%)" "$($WT_CMD task list)"
+check_output "no break between" "03. [09:40 => .....] Work: 0h:25m (1h:05m) \"write RFC\"" "$($WT_CMD log | tail -1)"
+
+run_wt stop
+mock_time "2026-01-20 10:15"
+check_output "starts a stopped timer" "Starting timer." "$($WT_CMD task start "fix login bug")"
+mock_time "2026-01-20 10:45"
+check_output "totals per task" "  fix login bug | Work: 1h:10m | Cycles: 2
+  write RFC     | Work: 0h:25m | Cycles: 1 | Estimate: 2h:00m (20%)" "$(run_wt stop; $WT_CMD task list)"
+check_output "undoable" "Undid 'wt stop' (10:45); the timer is running." "$($WT_CMD undo)"
+actual_error=$($WT_CMD task begin x 2>&1 || true)
+check_output "unknown subcommand" "Unknown task command: begin. Use 'wt task start <task>' or 'wt task list'." "$actual_error"
+
 echo ""
 echo "=========================================="
 echo "Test Results"
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -20,7 +24,20 @@ const (
 	DebugLogName     = "debug-log"
 	DailyReportName  = "daily-reports"
 	DT_FORMAT        = "2006-01-02 15:04"
+	DATE_FORMAT      = "2006-01-02"
 	TIME_ONLY_FORMAT = "15:04"
+
+	DefaultLongSessionMinutes   = 90 // Running cycle length that triggers a warning in check
+	DefaultRemindRunningMinutes = 60 // Interval between reminders while running
+	DefaultRemindIdleMinutes    = 30 // Interval between reminders while paused or stopped mid-day
+	DefaultLunchMinutes         = 30 // Minimum break length classified as lunch
+	DefaultCondenseBreakMinutes = 5  // Breaks shorter than this are folded by `wt log --condensed`
+	DefaultBudgetWarnPercent    = 80 // Budget consumption that triggers a warning
+	DefaultDropGuardMinutes     = 60 // Work cycles longer than this are only dropped after confirmation
+
+	DefaultDebugLogMaxKB   = 1024 // Debug log size that triggers rotation
+	DefaultDebugLogMaxDays = 30   // Debug log age (oldest entry) that triggers rotation
+	DebugLogRotations      = 3    // Number of rotated debug logs kept (debug-log.1 ... debug-log.3)
 )
 
 // Status enum
@@ -39,9 +56,21 @@ const (
 
 // TimelineEntry represents a work or break cycle
 type TimelineEntry struct {
-	Type          string `json:"type"`                     // "work" or "break"
-	Minutes       int    `json:"minutes"`                  // Duration of actual work (excludes paused time) or break
-	PausedMinutes int    `json:"paused_minutes,omitempty"` // Time spent paused during this work cycle (only for work entries)
+	Type          string   `json:"type"`                     // "work" or "break"
+	Minutes       int      `json:"minutes"`                  // Duration of actual work (excludes paused time) or break
+	PausedMinutes int      `json:"paused_minutes,omitempty"` // Time spent paused during this work cycle (only for work entries)
+	Tags          []string `json:"tags,omitempty"`           // Tags of the preset active during this work cycle
+	Meetings      []string `json:"meetings,omitempty"`       // Titles of WT_CALENDAR events overlapping this work cycle
+	Location      string   `json:"location,omitempty"`       // Where this work cycle happened (home, office, ...)
+	Pauses        []Pause  `json:"pauses,omitempty"`         // Pauses of this work cycle, adding up to PausedMinutes, see pause.go
+	Kind          string   `json:"kind,omitempty"`           // Kind of break (short, lunch, errand), see breakkind.go
+	Marks         []Mark   `json:"marks,omitempty"`          // Markers recorded with wt mark, see mark.go
+	Task          string   `json:"task,omitempty"`           // Task worked on, named with wt start -m
+	Rating        int      `json:"rating,omitempty"`         // Energy/mood score from wt rate (1-5)
+	ID            string   `json:"id,omitempty"`             // Stable ID within the day (e1, e2, ...), see audit.go
+	Created       string   `json:"created,omitempty"`        // When the entry was first saved
+	Modified      string   `json:"modified,omitempty"`       // When the entry was last changed
+	Command       string   `json:"command,omitempty"`        // Command that created or last changed the entry
 }
 
 // ElapsedMinutes returns the elapsed clock time for this entry (work + paused for work entries)
@@ -59,20 +88,40 @@ func (e *TimelineEntry) Duration() int {
 
 // Timer represents the timer state
 type Timer struct {
-	Status          string          `json:"status"`            // Current state: "stopped", "running", or "paused"
-	PauseStartStr   string          `json:"pause_start_str"`   // When the current pause began (if paused)
-	StopDatetimeStr string          `json:"stop_datetime_str"` // Last stop time (used to calculate break duration)
-	PausedMinutes   int             `json:"paused_minutes"`    // Accumulated pause time in current active cycle
-	Mode            string          `json:"mode"`              // Output verbosity: "silent", "normal", or "verbose"
-	Timeline        []TimelineEntry `json:"timeline"`          // Completed work and break cycles
-	DayStart        string          `json:"day_start"`         // When the work day started (all timestamps computed from this)
+	Status          string          `json:"status"`                 // Current state: "stopped", "running", or "paused"
+	PauseStartStr   string          `json:"pause_start_str"`        // When the current pause began (if paused)
+	StopDatetimeStr string          `json:"stop_datetime_str"`      // Last stop time (used to calculate break duration)
+	PausedMinutes   int             `json:"paused_minutes"`         // Accumulated pause time in current active cycle
+	Mode            string          `json:"mode"`                   // Output verbosity: "silent", "normal", or "verbose"
+	Timeline        []TimelineEntry `json:"timeline"`               // Completed work and break cycles
+	DayStart        string          `json:"day_start"`              // When the work day started (all timestamps computed from this)
+	Profile         string          `json:"profile,omitempty"`      // Active profile when the day was archived
+	Preset          string          `json:"preset,omitempty"`       // Preset chosen with start --preset, applies until changed
+	Tags            []string        `json:"tags,omitempty"`         // Tags given to cycles while the preset is active
+	CycleTags       []string        `json:"cycle_tags,omitempty"`   // Tags of the active cycle only, see tag.go
+	Location        string          `json:"location,omitempty"`     // Location chosen with start @<place>, applies until changed
+	Closed          string          `json:"closed,omitempty"`       // When the day was finalized with wt close
+	Archive         string          `json:"archive,omitempty"`      // Archive file of the closed day
+	Amended         []string        `json:"amended,omitempty"`      // Forced changes after closing ("<time> wt mod ...")
+	Plan            []PlanBlock     `json:"plan,omitempty"`         // Work planned for the day with wt plan
+	Note            string          `json:"note,omitempty"`         // Retrospective note written when the day ended
+	LastID          int             `json:"last_id,omitempty"`      // Number of the last timeline entry ID given out
+	PauseReason     string          `json:"pause_reason,omitempty"` // Reason of the current pause
+	Pauses          []Pause         `json:"pauses,omitempty"`       // Ended pauses of the active cycle
+	Marks           []Mark          `json:"marks,omitempty"`        // Markers in the active cycle
+	Task            string          `json:"task,omitempty"`         // Task named with start -m, applies until changed
+	Estimates       map[string]int  `json:"estimates,omitempty"`    // Estimated minutes per task, see task.go
+	Rating          int             `json:"rating,omitempty"`       // Rating of the active cycle, see rating.go
+	LastCommand     string          `json:"last_command,omitempty"` // When the timer was last saved, see clockjump.go
+	ClockJumps      []ClockJump     `json:"clock_jumps,omitempty"`  // Clock jumps noticed between commands
 }
 
 // UnmarshalJSON implements custom unmarshaling for backward compatibility
 func (t *Timer) UnmarshalJSON(data []byte) error {
 	type Alias Timer
 	aux := &struct {
-		AccumulatedMinutes *int `json:"accumulated_minutes,omitempty"`
+		AccumulatedMinutes *int           `json:"accumulated_minutes,omitempty"`
+		PauseReasons       map[string]int `json:"pause_reasons,omitempty"`
 		*Alias
 	}{
 		Alias: (*Alias)(t),
@@ -87,6 +136,15 @@ func (t *Timer) UnmarshalJSON(data []byte) error {
 		t.PausedMinutes = *aux.AccumulatedMinutes
 	}
 
+	// Backward compatibility: pause_reasons held the ended pauses by reason.
+	// A running pause follows them.
+	if len(t.Pauses) == 0 && len(aux.PauseReasons) > 0 {
+		t.Pauses = legacyPauses(aux.PauseReasons)
+		if pauseStart, err := parseTime(t.PauseStartStr); err == nil && t.Status == StatusPaused {
+			t.Pauses = syncPauses(t.Pauses, t.PausedMinutes, deltaMinutes(t.CurrentCycleStart(), pauseStart))
+		}
+	}
+
 	return nil
 }
 
@@ -112,35 +170,159 @@ func (t *Timer) CompletedMinutes() int {
 	return total
 }
 
+// DayTotals holds aggregated minutes for a day, including the active cycle
+type DayTotals struct {
+	Work   int `json:"work_minutes"`   // Work time, excluding pauses
+	Break  int `json:"break_minutes"`  // Break time, excluding lunch
+	Lunch  int `json:"lunch_minutes"`  // Lunch breaks (by kind or WT_LUNCH_WINDOW)
+	Paused int `json:"paused_minutes"` // Time paused during work cycles
+}
+
+// Totals aggregates the timeline and the current running/paused cycle
+func (t *Timer) Totals() DayTotals {
+	var totals DayTotals
+
+	start, _ := parseTime(t.DayStart)
+	for _, entry := range t.Timeline {
+		if entry.Type == "work" {
+			totals.Work += entry.Minutes
+			totals.Paused += entry.PausedMinutes
+		} else if breakKind(start, entry) == BreakLunch {
+			totals.Lunch += entry.Minutes
+		} else {
+			totals.Break += entry.Minutes
+		}
+		start = start.Add(time.Duration(entry.Duration()) * time.Minute)
+	}
+
+	if t.Status == StatusRunning || t.Status == StatusPaused {
+		totals.Work += calculateCurrentMinutes(t)
+		totals.Paused += t.PausedMinutes
+		if t.Status == StatusPaused {
+			pauseStart, _ := parseTime(t.PauseStartStr)
+			totals.Paused += deltaMinutes(pauseStart, getCurrentTime())
+		}
+	}
+
+	return totals
+}
+
+// Add accumulates other into d
+func (d *DayTotals) Add(other DayTotals) {
+	d.Work += other.Work
+	d.Break += other.Break
+	d.Lunch += other.Lunch
+	d.Paused += other.Paused
+}
+
+// Total returns the elapsed time covered by the totals
+func (d DayTotals) Total() int {
+	return d.Work + d.Break + d.Lunch + d.Paused
+}
+
+// breakSummary formats the break (and lunch, if classification is enabled or
+// there was one) part of a report line
+func (d DayTotals) breakSummary() string {
+	summary := fmt.Sprintf("Break: %s", minutesToHourMinuteStr(d.Break))
+	if lunchWindowEnabled() || d.Lunch > 0 {
+		summary += fmt.Sprintf(" | Lunch: %s", minutesToHourMinuteStr(d.Lunch))
+	}
+	return summary
+}
+
 func main() {
+	err := newApp().Run(context.Background(), os.Args)
+	stopASCIIOutput()
+	if err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// newApp builds the command tree. Kept separate from main so commands can be
+// dispatched in-process (e.g. by replay).
+func newApp() *cli.Command {
 	app := &cli.Command{
 		Name:  "wt",
 		Usage: "Work timer for tracking pomodoro-style work/break cycles",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "timer", Usage: "Use this named timer instead of the root's own (default $WT_TIMER; see 'wt list')"},
+			&cli.StringFlag{Name: "profile", Usage: "Use the settings of this profile (see 'wt profile')"},
+			&cli.StringFlag{Name: "remote", Usage: "Control the timer of the 'wt serve' at this URL (or of the running daemon, with 'daemon') instead of the local one (default $WT_REMOTE)"},
+			&cli.BoolFlag{Name: "json", Usage: "Print check, status, report, and log as JSON"},
+			&cli.BoolFlag{Name: "read-only", Usage: "Don't write any file; refuse commands that change the timer (default $WT_READ_ONLY; always on for check and status)"},
+			&cli.BoolFlag{Name: "trace", Usage: "Log the command's trace to the debug log, even at another log level or read-only, like check (default $WT_TRACE)"},
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			// Before the profile, which is read from the timer's folder
+			if name := cmp.Or(cmd.String("timer"), timerName()); name != "" {
+				if err := validateTimerName(name); err != nil {
+					return ctx, err
+				}
+				os.Setenv("WT_TIMER", name)
+			}
+			if profile := cmd.String("profile"); profile != "" {
+				if _, err := readProfile(profile); err != nil {
+					return ctx, err
+				}
+				os.Setenv("WT_PROFILE", profile)
+			}
+			if remote := cmd.String("remote"); remote != "" {
+				os.Setenv("WT_REMOTE", remote)
+			}
+			if cmd.Bool("trace") {
+				os.Setenv("WT_TRACE", "1")
+			}
+			if cmd.Bool("read-only") || setting("WT_READ_ONLY") != "" {
+				readOnly.Store(true)
+			}
+			// Not while fixing the settings
+			if command := cmd.Args().First(); command != "profile" && command != "config" {
+				if err := checkRates(); err != nil {
+					return ctx, err
+				}
+			}
+			return ctx, startASCIIOutput()
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Default action when no command is provided
 			timer, err := load()
 			if err != nil {
 				fmt.Println(err)
-				os.Exit(1)
+				exit(1)
 			}
 			return checkCmd(timer)
 		},
 		Commands: []*cli.Command{
 			{
-				Name:        "start",
-				Usage:       "Starts a new timer or continues paused timer",
-				ArgsUsage:   "[time]",
-				Description: "Optionally provide time in HHMM format to backdate start (first cycle) or reduce previous break (subsequent cycles)",
+				Name:      "start",
+				Usage:     "Starts a new timer or continues paused timer",
+				ArgsUsage: "[time] [@location]",
+				Description: `Optionally provide time in HHMM or HH:MM format to backdate start (first cycle) or reduce previous break (subsequent cycles).
+   @location (e.g. @office) marks this and the following cycles of the day; the default is $WT_LOCATION_<MON..SUN> or $WT_LOCATION.
+   -m "write RFC" names the task of this and the following cycles; --estimate 2h makes wt report show actual vs. estimated work.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "preset", Usage: "Use a WT_PRESET_<NAME> preset (targets, tags, mode) from now on; 'none' clears it"},
+					&cli.BoolFlag{Name: "no-break", Usage: "Continue the last cycle as if it was never stopped, counting the time since as work"},
+					&cli.StringFlag{Name: "break", Usage: "Kind of the break since the stop: " + strings.Join(BreakKinds, ", ")},
+					&cli.StringFlag{Name: "message", Aliases: []string{"m"}, Usage: "Task of this and the following cycles; 'none' clears it"},
+					&cli.StringFlag{Name: "estimate", Usage: "Estimate of the task, e.g. 2h, 90m, 1h30m, or HHMM"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					timer, err := load()
 					if err != nil {
 						return err
 					}
-					startTime := ""
-					if cmd.Args().Len() > 0 {
-						startTime = cmd.Args().Get(0)
+					startTime, location := "", ""
+					for _, arg := range cmd.Args().Slice() {
+						if place, ok := strings.CutPrefix(arg, "@"); ok {
+							location = place
+						} else {
+							startTime = arg
+						}
 					}
-					return startCmd(timer, startTime)
+					return startCmd(timer, StartOptions{Time: startTime, Preset: cmd.String("preset"), Location: location, NoBreak: cmd.Bool("no-break"), Break: cmd.String("break"),
+						Task: cmd.String("message"), Estimate: cmd.String("estimate")})
 				},
 			},
 			{
@@ -158,7 +340,10 @@ func main() {
 				Name:        "pause",
 				Usage:       "Pauses currently running timer",
 				ArgsUsage:   "[time]",
-				Description: "Optionally provide time in HHMM format to add pause time",
+				Description: "Optionally provide time in HHMM or HH:MM format to add pause time",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "message", Aliases: []string{"m"}, Usage: "Reason for the pause (e.g. \"phone call\"), for the breakdown in log and report"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					timer, err := load()
 					if err != nil {
@@ -168,17 +353,27 @@ func main() {
 					if cmd.Args().Len() > 0 {
 						pauseTime = cmd.Args().Get(0)
 					}
-					return pauseCmd(timer, pauseTime)
+					return pauseCmd(timer, pauseTime, cmd.String("message"))
 				},
 			},
 			{
 				Name:  "check",
 				Usage: "Prints current and total time along with status",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "budget", Usage: "Also show budget consumption (requires WT_BUDGET and WT_RATE)"},
+					&cli.BoolFlag{Name: "trend", Usage: "Also show a sparkline of work time over the last 7 archived days"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					timer, err := load()
 					if err != nil {
 						return err
 					}
+					if cmd.Bool("budget") || cmd.Bool("trend") {
+						if jsonOutput {
+							return fmt.Errorf("--json shows the timer's state; it doesn't work with --budget or --trend.")
+						}
+						return checkDetailsCmd(timer, cmd.Bool("budget"), cmd.Bool("trend"))
+					}
 					return checkCmd(timer)
 				},
 			},
@@ -187,6 +382,22 @@ func main() {
 				Usage:       "Show log of timer activity",
 				ArgsUsage:   "[type]",
 				Description: "Defaults to info log. Use 'debug' to see command execution timestamps",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "since", Usage: "Only show entries ending after this clock time (HHMM or HH:MM)"},
+					&cli.StringFlag{Name: "until", Usage: "Only show entries starting before this clock time (HHMM or HH:MM)"},
+					&cli.StringFlag{Name: "type", Usage: "Only show 'work' or 'break' entries"},
+					&cli.IntFlag{Name: "last", Usage: "Only show the last N entries"},
+					&cli.StringFlag{Name: "format", Usage: "Output format: text, json, or csv"},
+					&cli.BoolFlag{Name: "condensed", Usage: "Fold short breaks and group consecutive work cycles"},
+					&cli.StringFlag{Name: "min-break", Usage: "With --condensed, fold breaks shorter than this (HHMM, default 5)"},
+					&cli.BoolFlag{Name: "gantt", Usage: "Draw the day as a bar per hour with work, paused, and break segments"},
+					&cli.BoolFlag{Name: "audit", Usage: "Show each entry's ID and when and by which command it was created and last changed"},
+					&cli.BoolFlag{Name: "pauses", Usage: "List each pause with its clock times and reason under its work cycle"},
+					&cli.BoolFlag{Name: "notes", Usage: "List the markers recorded with 'wt mark' under their work cycle"},
+					&cli.IntFlag{Name: "tail", Usage: "Debug log: only show the last N lines"},
+					&cli.BoolFlag{Name: "today", Usage: "Debug log: only show today's lines"},
+					&cli.StringFlag{Name: "date", Usage: "Show an archived day (yesterday, YYYY-MM-DD, or an archive name like YYYY-MM-DD.2)"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					timer, err := load()
 					if err != nil {
@@ -196,48 +407,264 @@ func main() {
 					if cmd.Args().Len() > 0 {
 						logType = cmd.Args().Get(0)
 					}
-					return historyCmd(timer, logType)
+					if date := cmd.String("date"); date != "" {
+						if logType == "debug" {
+							return fmt.Errorf("--date shows an archived day's timeline; the debug log has no archive. Use 'wt log debug' without it.")
+						}
+						day, restore, err := dayTimer(timer, date)
+						if err != nil {
+							return err
+						}
+						defer restore()
+						timer = day
+					}
+					minBreak := DefaultCondenseBreakMinutes
+					if value := cmd.String("min-break"); value != "" {
+						if err := validateTimeString(value); err != nil {
+							return err
+						}
+						minBreak, _ = stringTimeToMinutes(value)
+					}
+					opts := LogOptions{
+						Since: cmd.String("since"),
+						Until: cmd.String("until"),
+						Type:  cmd.String("type"),
+						Last:  int(cmd.Int("last")),
+
+						Format: cmd.String("format"),
+
+						Condensed: cmd.Bool("condensed"),
+						MinBreak:  minBreak,
+						Gantt:     cmd.Bool("gantt"),
+						Audit:     cmd.Bool("audit"),
+						Pauses:    cmd.Bool("pauses"),
+						Notes:     cmd.Bool("notes"),
+
+						Tail:  int(cmd.Int("tail")),
+						Today: cmd.Bool("today"),
+					}
+					return historyCmd(timer, logType, opts)
+				},
+			},
+			{
+				Name:      "open",
+				Usage:     "Open the .out folder, the daily report, or a page of today in the default application",
+				ArgsUsage: "[folder|report|today]",
+				Description: `folder (the default) is $WT_ROOT/.out, report the daily report file, and today
+   a page of the current day with its timeline, cycles, pauses, and markers
+   (written to .out/today). Set WT_OPENER to use another command than
+   xdg-open, open, or start, e.g. WT_OPENER=code.`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return openCmd(timer, cmd.Args().First())
+				},
+			},
+			{
+				Name:      "grep",
+				Usage:     "Search tasks, tags, markers, pause reasons, and notes across the archive",
+				ArgsUsage: "<pattern>",
+				Description: `Prints the work cycles whose task, tags, location, markers, or pause reasons
+   match the pattern (a regular expression), with their dates and lengths, and
+   the days whose retrospective note matches. Searches today and the whole
+   archive unless given --range.
+   Examples:
+     wt grep parser
+     wt grep -i 'review|rfc' --range lastmonth`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "range", Usage: "Only search a date range (lastweek, YYYY-MM-DD..YYYY-MM-DD, ...)"},
+					&cli.BoolFlag{Name: "ignore-case", Aliases: []string{"i"}, Usage: "Ignore case"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return grepCmd(timer, cmd.Args().First(), cmd.String("range"), cmd.Bool("ignore-case"))
 				},
 			},
 			{
 				Name:      "mod",
 				Usage:     "Modify timeline entries (work and break cycles)",
-				ArgsUsage: "[start|<num>] [drop|pause|<add|sub>] [time]",
+				ArgsUsage: "[start|<num>|<id>] [drop|pause|kind|<add|sub>] [time|kind]",
 				Description: `Modify day start time, cycle durations, or paused time.
    Examples:
      wt mod                           - Show usage help
      wt mod start sub 30              - Started 30min earlier
+     wt mod start date 2026-01-19     - The day belongs to Jan 19 (e.g. started after midnight)
      wt mod 3 add 15                  - Add 15min to cycle 3
+     wt mod 3 add 1:30                - Add 1h 30min to cycle 3 (same as 130)
      wt mod 5 pause add 10            - Add 10min paused time to cycle 5
-     wt mod 2 drop                    - Remove cycle 2`,
+     wt mod 2 drop                    - Remove cycle 2
+     wt mod --yes 1 drop              - Remove a long work cycle without asking
+     wt mod 4 kind lunch              - Break 4 was lunch ('auto' goes back to WT_LUNCH_WINDOW)
+     wt mod e7 add 10                 - Add 10min to the cycle with ID e7 (see 'wt log --audit')
+     wt mod --force 3 add 15          - Change a closed day (see 'wt close')
+     wt mod --date 2026-01-19 3 add 15 - Change an archived day
+     wt mod --file edits.wt           - Apply one mod per line, all or none (- reads stdin)`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "force", Usage: "Change a day finalized with 'wt close' (recorded)"},
+					&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "Drop a long work cycle without asking ($WT_DROP_GUARD)"},
+					&cli.StringFlag{Name: "date", Usage: "Change an archived day (YYYY-MM-DD) and its daily report line"},
+					&cli.StringFlag{Name: "file", Usage: "Apply the mods listed in a file, one per line, all or none (backup in .out/wt.json.bak)"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					timer, err := load()
-					if err != nil {
-						return err
-					}
-
 					args := cmd.Args().Slice()
+					if file := cmd.String("file"); file != "" {
+						if len(args) > 0 || cmd.String("date") != "" {
+							return fmt.Errorf("--file takes the mods from the file; it doesn't work with arguments or --date.")
+						}
+						timer, err := load()
+						if err != nil {
+							return err
+						}
+						return modFileCmd(timer, file, cmd.Bool("force"), cmd.Bool("yes"))
+					}
 					if len(args) == 0 {
 						return modListCmd()
 					}
-
-					if len(args) == 3 && args[0] == "start" {
-						return modStartCmd(timer, args[1], args[2])
+					if date := cmd.String("date"); date != "" {
+						current, _ := load()
+						return modArchivedDayCmd(current, date, args, cmd.Bool("force"), cmd.Bool("yes"))
 					}
 
-					if len(args) == 2 && args[1] == "drop" {
-						return modDropCmd(timer, args[0])
+					timer, err := load()
+					if err != nil {
+						return err
 					}
-
-					if len(args) == 4 && args[1] == "pause" {
-						return modPauseCmd(timer, args[0], args[2], args[3])
+					if timer.isClosed() {
+						return modClosedDayCmd(timer, args, cmd.Bool("force"), cmd.Bool("yes"))
 					}
-
-					if len(args) == 3 {
-						return modDurationCmd(timer, args[0], args[1], args[2])
+					return modCmd(timer, args, cmd.Bool("yes"))
+				},
+			},
+			{
+				Name:        "toggle",
+				Usage:       "Start, pause, or resume, whichever comes next",
+				Description: "Starts a stopped timer, pauses a running one, and resumes a paused one. Meant for a single\n   global keyboard shortcut or stream deck button.",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
 					}
-
-					return modListCmd()
+					return toggleCmd(timer)
+				},
+			},
+			{
+				Name:        "undo",
+				Usage:       "Revert the last start, stop, pause, next, toggle, mod, tag, or task",
+				Description: "Restores the timer as it was before the last start, stop, pause, next, toggle, mod, tag, or task,\n   e.g. after stopping by mistake or a wrong 'wt mod'. Repeat it to go further back (up to\n   $WT_UNDO_LIMIT changes, default 20), and use 'wt redo' to re-apply. Other changes (new,\n   reset, import, ...) can't be undone.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "list", Aliases: []string{"l"}, Usage: "Show the changes undo and redo would revert and re-apply"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Bool("list") {
+						return undoListCmd()
+					}
+					return undoCmd()
+				},
+			},
+			{
+				Name:  "redo",
+				Usage: "Re-apply the last change reverted by 'wt undo'",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return redoCmd()
+				},
+			},
+			{
+				Name:        "mark",
+				Aliases:     []string{"note"},
+				Usage:       "Record a timestamped marker in the current work cycle",
+				ArgsUsage:   "<text>",
+				Description: "Traces what happened within a long cycle without splitting it, e.g. wt mark \"finished parser rewrite\".\n   'wt log --notes' lists the markers, and exports include them.",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return markCmd(timer, strings.Join(cmd.Args().Slice(), " "))
+				},
+			},
+			{
+				Name:      "task",
+				Usage:     "Switch the work to a task, or list today's work per task",
+				ArgsUsage: "start <task> | list",
+				Description: `'wt task start' starts the timer on the task, gives it the current cycle if that
+   has no task yet, or ends a cycle on another task and starts the next one on it.
+   The task applies to the following cycles too, like 'wt start -m'.
+   'wt task list' shows the day's work and cycles per task, * marking the current one.
+   Examples:
+     wt task start "fix login bug"
+     wt task start "write RFC" --estimate 2h
+     wt task list`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "estimate", Usage: "Estimated work for the task (e.g. 2h, 90m), shown against the actual work"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					switch cmd.Args().Get(0) {
+					case "start":
+						return taskStartCmd(timer, strings.Join(cmd.Args().Tail(), " "), cmd.String("estimate"))
+					case "list", "":
+						return taskListCmd(timer)
+					}
+					return fmt.Errorf("Unknown task command: %s. Use 'wt task start <task>' or 'wt task list'.", cmd.Args().Get(0))
+				},
+			},
+			{
+				Name:        "tag",
+				Usage:       "Tag the current work cycle",
+				ArgsUsage:   "<tag> [tag...]",
+				Description: "Labels the running (or paused) cycle, e.g. wt tag billing, on top of the active preset's tags.\n   'wt log' shows the tags and 'wt report --group-by tag' sums up the work per tag.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "remove", Usage: "Remove the tags from the cycle instead"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return tagCmd(timer, cmd.Args().Slice(), cmd.Bool("remove"))
+				},
+			},
+			{
+				Name:        "rate",
+				Usage:       "Score the energy or mood of the current or last work cycle",
+				ArgsUsage:   "<1-5>",
+				Description: "1 is drained, 5 energized. Rates the running (or paused) cycle, or else the last work cycle.\n   'wt stats --ratings' shows the average ratings by time of day and cycle length.",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return rateCmd(timer, cmd.Args().Get(0))
+				},
+			},
+			{
+				Name:        "tidy",
+				Usage:       "Merge all short breaks between work cycles into the work",
+				Description: "Drops every break shorter than --min-break like 'wt mod <n> drop' would, in one pass, for days\n   fragmented by quick next/stop toggles. 'wt log --condensed' shows the same without changing the day.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "min-break", Usage: "Merge breaks shorter than this (HHMM, default 5)"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					minBreak := DefaultCondenseBreakMinutes
+					if value := cmd.String("min-break"); value != "" {
+						if err := validateTimeString(value); err != nil {
+							return err
+						}
+						minBreak, _ = stringTimeToMinutes(value)
+					}
+					return tidyCmd(timer, minBreak)
 				},
 			},
 			{
@@ -251,18 +678,34 @@ func main() {
 					return nextCmd(timer)
 				},
 			},
+			{
+				Name:  "close",
+				Usage: "Finalize the day: stop, write the daily report, and archive it read-only",
+				Description: `Use once the day has been reported (e.g. to payroll). Afterwards the day can
+   only be changed with 'wt mod --force', which is recorded with the archive.
+   Run 'wt new' to start the next day.`,
+				Flags: []cli.Flag{noteFlag()},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return closeCmd(timer, cmd.String("note"))
+				},
+			},
 			{
 				Name:  "reset",
 				Usage: "Stops and sets current and total timers to zero",
+				Flags: []cli.Flag{noteFlag()},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return resetCmd("Timer reset.")
+					return resetCmd("Timer reset.", cmd.String("note"))
 				},
 			},
 			{
 				Name:        "restart",
 				Usage:       "Reset and start new timer",
 				ArgsUsage:   "[time]",
-				Description: "Optionally provide time in HHMM format to backdate start",
+				Description: "Optionally provide time in HHMM or HH:MM format to backdate start",
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					startTime := ""
 					if cmd.Args().Len() > 0 {
@@ -274,8 +717,9 @@ func main() {
 			{
 				Name:  "new",
 				Usage: "Creates a new timer (alias for reset)",
+				Flags: []cli.Flag{noteFlag()},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return newCmd()
+					return newCmd(cmd.String("note"))
 				},
 			},
 			{
@@ -292,6 +736,13 @@ func main() {
 					return statusCmd()
 				},
 			},
+			{
+				Name:  "list",
+				Usage: "List the timers of the root (the root's own and those made with --timer) with their status and work today",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return listCmd()
+				},
+			},
 			{
 				Name:        "mode",
 				Usage:       "Change output verbosity",
@@ -310,101 +761,776 @@ func main() {
 				},
 			},
 			{
-				Name:        "report",
-				Usage:       "Print a one-line summary of the day's work",
-				Description: "Shows date, start time, end time, total work time, total break time, and total time",
+				Name:      "plan",
+				Usage:     "Plan the day's work by category, or show the plan against the actual work",
+				ArgsUsage: "[[Nx]HHMM [+tag]]... | clear",
+				Description: `Each block is a time, optionally repeated (4x50 is four 50 minute blocks) and
+   followed by the tag of the cycles it's meant for; a block without a tag is
+   any work. Setting a plan replaces the previous one. Without arguments, and
+   in 'wt report', shows planned vs. actual time per category.
+   Examples:
+     wt plan 4x50 +deepwork 2:00 +meeting
+     wt plan 6:00
+     wt plan clear`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return planCmd(timer, cmd.Args().Slice())
+				},
+			},
+			{
+				Name:  "report",
+				Usage: "Print a one-line summary of the day's work",
+				Description: `Shows date, start time, end time, total work time, total break time, and total time.
+   With --range or --group-by, sums up archived days instead, one line per group.
+   Examples:
+     wt report --range lastmonth --group-by week
+     wt report --range 2026-01-01..2026-03-31 --group-by project
+     wt report --period previous                  - For payroll, with $WT_PAY_PERIOD set
+     wt report history -n 10                      - The last 10 lines of the daily report file`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "range", Usage: "Report on a date range (thismonth by default with --group-by; lastweek, YYYY-MM-DD..YYYY-MM-DD, ...)"},
+					&cli.StringFlag{Name: "period", Usage: "Report on the current or previous pay period ($WT_PAY_PERIOD)"},
+					&cli.StringFlag{Name: "group-by", Usage: "Group a range report by day (default), week, month, tag, project, location, or task"},
+					&cli.StringFlag{Name: "date", Usage: "Report on an archived day (yesterday, YYYY-MM-DD, or an archive name like YYYY-MM-DD.2)"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					timer, err := load()
 					if err != nil {
 						return err
 					}
+					if date := cmd.String("date"); date != "" {
+						if cmd.String("range") != "" || cmd.String("group-by") != "" || cmd.String("period") != "" {
+							return fmt.Errorf("--date reports on one day; it doesn't work with --range, --group-by, or --period.")
+						}
+						day, restore, err := dayTimer(timer, date)
+						if err != nil {
+							return err
+						}
+						defer restore()
+						return reportCmd(day)
+					}
+					if jsonOutput && (cmd.String("range") != "" || cmd.String("group-by") != "" || cmd.String("period") != "") {
+						return fmt.Errorf("--json reports on one day. For a range, use 'wt export --range <range>', which writes JSON.")
+					}
+					if period := cmd.String("period"); period != "" {
+						return payPeriodReportCmd(timer, period, cmd.String("range"), cmd.String("group-by"))
+					}
+					if cmd.String("range") != "" || cmd.String("group-by") != "" {
+						dateRange := cmd.String("range")
+						if dateRange == "" {
+							dateRange = "thismonth"
+						}
+						return rangeReportCmd(timer, dateRange, cmd.String("group-by"))
+					}
 					return reportCmd(timer)
 				},
+				Commands: []*cli.Command{
+					{
+						Name:  "history",
+						Usage: "List the daily report file's days, newest first",
+						Flags: []cli.Flag{
+							&cli.IntFlag{Name: "n", Usage: "Only the newest N days (0 for all)"},
+							&cli.BoolFlag{Name: "fix", Usage: "Rewrite the file oldest first, once, if an older version kept it newest first"},
+						},
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							if cmd.Bool("fix") {
+								return reportHistoryFixCmd()
+							}
+							return reportHistoryCmd(int(cmd.Int("n")))
+						},
+					},
+				},
 			},
 			{
-				Name:  "debug",
-				Usage: "Prints debug info",
+				Name:        "week",
+				Usage:       "Summarize the current week",
+				Description: "Lists each day's work from the archive (days are archived on reset) and the current timer. --grid draws the week as a calendar, one column per day; --image draws the totals and a bar per day into a PNG.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "grid", Usage: "Draw a 7-column grid showing when during each day you worked"},
+					&cli.StringFlag{Name: "image", Usage: "Write the week's summary as a PNG to this file"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return debugCmd()
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return weekCmd(timer, cmd.Bool("grid"), cmd.String("image"))
 				},
 			},
 			{
-				Name:  "help",
-				Usage: "Show help",
+				Name:  "stats",
+				Usage: "Chart the work of recent days, or the trend over weeks",
+				Description: `Draws a bar per day of the last N days (today included) with the day's work,
+   and a marker where $WT_DAILY_GOAL is (on weekdays that aren't holidays).
+   --trend instead lists 4-week rolling averages of work per day, start time, and
+   break share for the last 8 weeks, with arrows for how they changed. --ratings
+   averages the ratings from 'wt rate' by time of day and cycle length.
+   Examples:
+     wt stats                 - The last 14 days
+     wt stats --chart 30      - The last 30 days
+     wt stats --trend         - Rolling averages
+     wt stats --ratings       - Ratings of the last 30 days (or --range)`,
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "chart", Value: DefaultChartDays, Usage: "Number of days to chart"},
+					&cli.BoolFlag{Name: "trend", Usage: "Show 4-week rolling averages instead of the chart"},
+					&cli.BoolFlag{Name: "ratings", Usage: "Show the average cycle ratings by time of day and length instead of the chart"},
+					&cli.StringFlag{Name: "range", Usage: "Range of --ratings (the last 30 days by default; see 'wt help compare')"},
+				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					return cli.ShowAppHelp(cmd)
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					modes := 0
+					for _, set := range []bool{cmd.IsSet("chart"), cmd.Bool("trend"), cmd.Bool("ratings")} {
+						if set {
+							modes++
+						}
+					}
+					if modes > 1 {
+						return fmt.Errorf("Use only one of --chart, --trend, and --ratings.")
+					}
+					if cmd.IsSet("range") && !cmd.Bool("ratings") {
+						return fmt.Errorf("--range only works with --ratings.")
+					}
+					if cmd.Bool("ratings") {
+						return statsRatingsCmd(timer, cmd.String("range"))
+					}
+					if cmd.Bool("trend") {
+						return statsTrendCmd(timer)
+					}
+					return statsChartCmd(timer, int(cmd.Int("chart")))
 				},
 			},
-		},
-	}
-
-	if err := app.Run(context.Background(), os.Args); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-}
-
-// Helper functions
-
-func getCurrentTime() time.Time {
-	mockTime := os.Getenv("WT_MOCK_TIME")
-	if mockTime != "" {
-		t, err := time.ParseInLocation(DT_FORMAT, mockTime, time.Local)
-		if err == nil {
-			return t
-		}
-	}
-	return time.Now()
-}
-
-// parseTime parses a datetime string in local timezone
-func parseTime(s string) (time.Time, error) {
-	return time.ParseInLocation(DT_FORMAT, s, time.Local)
-}
-
-func projectRootPath() (string, error) {
-	root := os.Getenv("WT_ROOT")
-	if root == "" {
-		return "", fmt.Errorf("Env $WT_ROOT not set.")
-	}
-	return root, nil
-}
-
-func outputFilePath() (string, error) {
-	root, err := projectRootPath()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(root, OutputFolder, OutputFileName), nil
-}
-
-func debugLogFilePath() (string, error) {
-	root, err := projectRootPath()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(root, OutputFolder, DebugLogName), nil
-}
+			{
+				Name:      "holidays",
+				Usage:     "List the public holidays from WT_HOLIDAYS",
+				ArgsUsage: "[year]",
+				Description: `WT_HOLIDAYS is a comma-separated list of country codes (AT, DE, FR, GB, US)
+   and/or .ics files. Holidays have no scheduled start and no daily goal.
+   Examples:
+     WT_HOLIDAYS=DE wt holidays 2027
+     WT_HOLIDAYS="US,~/company.ics" wt holidays`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					year := getCurrentTime().Year()
+					if cmd.Args().Len() > 0 {
+						parsed, err := strconv.Atoi(cmd.Args().First())
+						if err != nil {
+							return fmt.Errorf("Invalid year: %s", cmd.Args().First())
+						}
+						year = parsed
+					}
+					return holidaysCmd(year)
+				},
+			},
+			{
+				Name:  "compare",
+				Usage: "Compare work, breaks, and start times between two date ranges",
+				Description: `Ranges are today, yesterday, thisweek, lastweek, thismonth, lastmonth,
+   thisperiod, lastperiod (pay periods, see $WT_PAY_PERIOD), a date (YYYY-MM-DD),
+   or a span (YYYY-MM-DD..YYYY-MM-DD). Past days are read from the archive
+   written by reset.
+   Examples:
+     wt compare                                    - Last week vs. this week
+     wt compare --a 2026-01-05..2026-01-09 --b thisweek`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "a", Value: "lastweek", Usage: "First range"},
+					&cli.StringFlag{Name: "b", Value: "thisweek", Usage: "Second range"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return compareCmd(timer, cmd.String("a"), cmd.String("b"))
+				},
+			},
+			{
+				Name:  "prune",
+				Usage: "Delete or compress archived days and old debug logs",
+				Description: `Removes archived days and rotated debug logs older than the given age (d, w, m,
+   or y). With --compress, archived days are gzipped instead and stay readable.
+   Set WT_RETENTION (and WT_RETENTION_COMPRESS=1) to prune automatically on reset.
+   Examples:
+     wt prune --older-than 2y
+     wt prune --older-than 6m --compress
+     wt prune --older-than 90d --dry-run`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "older-than", Usage: "Age of the data to prune, e.g. 90d, 8w, 6m, 2y (default $WT_RETENTION)"},
+					&cli.BoolFlag{Name: "compress", Usage: "Gzip archived days instead of deleting them"},
+					&cli.BoolFlag{Name: "dry-run", Usage: "Only list what would be pruned"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return pruneCmd(cmd.String("older-than"), cmd.Bool("compress"), cmd.Bool("dry-run"))
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "Export tracked days in various formats",
+				ArgsUsage: "[format] [file|dir]",
+				Description: `Writes archived days (and today) in the range to stdout, a file, or (site) a directory.
+   Formats:` + exportFormatsHelp() + `
+   Examples:
+     wt export --range lastmonth
+     wt export csv --range thisweek --type work
+     wt export xlsx --range 2026-01-01..2026-06-30 --output h1.xlsx
+     wt export site ~/wt-site --range 2025-01-01..2026-12-31
+     wt export --anonymize > wt-data.json`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "range", Value: "thismonth", Usage: "Date range to export (see 'wt help compare')"},
+					&cli.StringFlag{Name: "type", Usage: "Only export 'work' or 'break' cycles"},
+					&cli.BoolFlag{Name: "anonymize", Usage: "Strip client-identifying data (profile names, earnings), keeping durations and structure"},
+					&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Write to this file (or directory, for site) instead of stdout; also given after the format"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return exportCmd(timer, cmd.Args().Get(0), ExportOptions{
+						Range:     cmd.String("range"),
+						Type:      cmd.String("type"),
+						Anonymize: cmd.Bool("anonymize"),
+						Output:    cmp.Or(cmd.Args().Get(1), cmd.String("output")),
+					})
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Import past days into the archive",
+				ArgsUsage: "<format> <file|->",
+				Description: `Reads days from a file (or stdin) and adds them to the archive, so they show
+   up in week, compare, report --range, and export. Days that are already
+   archived (or today's) are skipped.
+   Formats:` + importFormatsHelp() + `
+   Examples:
+     wt import json backup.json --dry-run
+     wt import timeclock ~/time.timeclock`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "dry-run", Usage: "Only show what would be imported"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() != 2 {
+						return fmt.Errorf("Usage: wt import <format> <file|->")
+					}
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return importCmd(timer, cmd.Args().Get(0), cmd.Args().Get(1), cmd.Bool("dry-run"))
+				},
+			},
+			{
+				Name:  "daemon",
+				Usage: "Run reminders, the schedule, and optionally the API in the background",
+				Description: `The daemon checks WT_SCHEDULE and reminders every --interval and logs them to
+   .out/daemon.log. It's controlled over a unix socket in .out.
+   Examples:
+     wt daemon start                 - Start in the background
+     wt daemon start --api 127.0.0.1:8788
+     wt daemon status                - PID, uptime, and enabled features
+     wt daemon logs -f               - Follow the daemon log
+     wt daemon run                   - Run in the foreground (e.g. under systemd)`,
+				Commands: []*cli.Command{
+					{
+						Name:  "run",
+						Usage: "Run the daemon in the foreground",
+						Flags: daemonFlags(),
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return daemonRunCmd(daemonOptions(cmd))
+						},
+					},
+					{
+						Name:  "start",
+						Usage: "Start the daemon in the background",
+						Flags: daemonFlags(),
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return daemonStartCmd(daemonOptions(cmd).args())
+						},
+					},
+					{
+						Name:  "status",
+						Usage: "Show whether the daemon runs, its uptime, and enabled features",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return daemonStatusCmd()
+						},
+					},
+					{
+						Name:  "stop",
+						Usage: "Stop the daemon",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return daemonStopCmd()
+						},
+					},
+					{
+						Name:  "restart",
+						Usage: "Restart the daemon with the same options",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return daemonRestartCmd()
+						},
+					},
+					{
+						Name:  "logs",
+						Usage: "Print (or follow) the daemon log",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{Name: "follow", Aliases: []string{"f"}, Usage: "Keep printing new lines"},
+							&cli.IntFlag{Name: "lines", Aliases: []string{"n"}, Usage: "Only the last N lines (0 for all)"},
+						},
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return daemonLogsCmd(int(cmd.Int("lines")), cmd.Bool("follow"))
+						},
+					},
+				},
+			},
+			{
+				Name:  "serve",
+				Usage: "Serve the timer as an HTTP JSON API",
+				Description: `Endpoints: GET /api/status, GET /api/log, GET /api/week, POST /api/start, /api/pause, /api/stop,
+   /api/next, /api/toggle, and GET /api/openapi.json (no token needed). Clients send "Authorization: Bearer <token>" with a token from
+   WT_API_TOKENS ("token:read" or "token:write" pairs). Without tokens, only
+   loopback addresses are allowed.
+   Examples:
+     wt serve                                          - http://127.0.0.1:8788, no auth
+     wt serve --ui                                     - Same, with a dashboard at http://127.0.0.1:8788/
+     WT_API_TOKENS="abc:read,xyz:write" wt serve --listen :8788 --tls`,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{Name: "listen", Value: DefaultServeListen, Usage: "Address to listen on"},
+					&cli.BoolFlag{Name: "openapi", Usage: "Print the API's OpenAPI 3 document instead of serving"},
+					&cli.BoolFlag{Name: "ui", Usage: "Also serve a web dashboard at /"},
+				}, tlsFlags()...),
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return serveCmd(cmd.String("listen"), tlsOptions(cmd), cmd.Bool("openapi"), cmd.Bool("ui"))
+				},
+			},
+			{
+				Name:  "server",
+				Usage: "Run a team server that collects members' submitted days",
+				Description: `Members authenticate with bearer tokens mapped to user names in WT_TEAM_TOKENS
+   ("alice:token1,bob:token2"). Submitted days are stored in .out/team.
+   Examples:
+     WT_TEAM_TOKENS="alice:s3cret,bob:hunter2" wt server --listen :8787`,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{Name: "listen", Value: DefaultTeamListen, Usage: "Address to listen on"},
+				}, tlsFlags()...),
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return serverCmd(cmd.String("listen"), tlsOptions(cmd))
+				},
+			},
+			{
+				Name:  "team",
+				Usage: "Submit your days to and report from the team server",
+				Description: `Uses WT_TEAM_SERVER (e.g. http://host:8787) and WT_TEAM_TOKEN.
+   Examples:
+     wt team submit                  - Submit this week's days (safe to repeat)
+     wt team report --week           - Everyone's work this week`,
+				Commands: []*cli.Command{
+					{
+						Name:  "submit",
+						Usage: "Submit days to the team server (earnings are not shared)",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "range", Value: "thisweek", Usage: "Days to submit (today, yesterday, thisweek, lastweek, thismonth, lastmonth, a date, or from..to)"},
+						},
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							timer, err := load()
+							if err != nil {
+								return err
+							}
+							return teamSubmitCmd(timer, cmd.String("range"))
+						},
+					},
+					{
+						Name:  "report",
+						Usage: "Show each member's work in a range",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "range", Value: "thisweek", Usage: "Range to report (today, yesterday, thisweek, lastweek, thismonth, lastmonth, a date, or from..to)"},
+							&cli.BoolFlag{Name: "week", Usage: "Shorthand for --range thisweek"},
+							&cli.BoolFlag{Name: "month", Usage: "Shorthand for --range thismonth"},
+						},
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							rangeName := cmd.String("range")
+							if cmd.Bool("week") {
+								rangeName = "thisweek"
+							} else if cmd.Bool("month") {
+								rangeName = "thismonth"
+							}
+							return teamReportCmd(rangeName)
+						},
+					},
+				},
+			},
+			{
+				Name:  "sync",
+				Usage: "Sync your days to other tools",
+				Commands: []*cli.Command{
+					{
+						Name:  "notion",
+						Usage: "Add or update a row per day in a Notion database",
+						Description: `Uses WT_NOTION_TOKEN (an internal integration's secret) and WT_NOTION_DATABASE
+   (the id of a database shared with the integration). The database needs the
+   properties Name (title), Date (date), Work and Break (numbers, in hours),
+   Tags (multi-select), and Notes (text). Rows already synced are updated, so
+   it's safe to repeat.
+   Examples:
+     wt sync notion                  - Sync this week's days
+     wt sync notion --range lastmonth --dry-run`,
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "range", Value: "thisweek", Usage: "Days to sync (today, yesterday, thisweek, lastweek, thismonth, lastmonth, a date, or from..to)"},
+							&cli.BoolFlag{Name: "dry-run", Usage: "Print the rows instead of syncing them"},
+						},
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							timer, err := load()
+							if err != nil {
+								return err
+							}
+							return syncNotionCmd(timer, cmd.String("range"), cmd.Bool("dry-run"))
+						},
+					},
+					{
+						Name:  "caldav",
+						Usage: "Push the work cycles as events to a CalDAV calendar",
+						Description: `Uses WT_CALDAV_URL (the calendar's collection URL, e.g. a Nextcloud
+   https://cloud.example.com/remote.php/dav/calendars/me/work/), WT_CALDAV_USER,
+   and WT_CALDAV_PASSWORD (preferably an app password). Events already pushed
+   are replaced, and those of cycles dropped since are deleted, so it's safe
+   to repeat.
+   Examples:
+     wt sync caldav                  - Push this week's cycles
+     wt sync caldav --range today --dry-run`,
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "range", Value: "thisweek", Usage: "Days to push (today, yesterday, thisweek, lastweek, thismonth, lastmonth, a date, or from..to)"},
+							&cli.BoolFlag{Name: "dry-run", Usage: "List the events instead of pushing them"},
+						},
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							timer, err := load()
+							if err != nil {
+								return err
+							}
+							return syncCalDAVCmd(timer, cmd.String("range"), cmd.Bool("dry-run"))
+						},
+					},
+				},
+			},
+			{
+				Name:      "replay",
+				Usage:     "Reconstruct timer state by re-executing the command journal",
+				ArgsUsage: " ",
+				Description: `Re-runs the commands recorded in the debug log since the last reset and shows
+   the resulting log and report. Useful to see how a total came about or to
+   recover a lost or broken wt.json.
+   Examples:
+     wt replay                        - Replay everything up to now
+     wt replay --until 12:00          - Show the state as it was at 12:00
+     wt replay --apply                - Overwrite wt.json with the replayed state`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "until", Usage: "Only replay commands up to this time (HHMM, HH:MM, or \"YYYY-MM-DD HH:MM\")"},
+					&cli.BoolFlag{Name: "apply", Usage: "Save the replayed state as the current timer"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return replayCmd(cmd.String("until"), cmd.Bool("apply"))
+				},
+			},
+			{
+				Name:      "selftest",
+				Usage:     "Check this build by running a scripted day with known totals",
+				ArgsUsage: " ",
+				Description: `Runs start, pause, next, stop, mod, and report over a made-up day in a
+   temporary folder with a mock clock, and compares the totals after each step
+   with the expected ones. Your timer and settings aren't touched. Exits with
+   an error if anything doesn't match.`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return selftestCmd()
+				},
+			},
+			{
+				Name:      "demo",
+				Usage:     "Fill a root with made-up days for trying reports and exports",
+				ArgsUsage: "[path]",
+				Description: `Runs a couple of weeks of realistic made-up work days (breaks, lunch,
+   pauses, tagged deep work and meetings) with the mock clock into a new
+   temporary root, or into path if it has no timer yet. The root gets a
+   'demo' profile with a rate, a daily goal, and presets.
+   Examples:
+     wt demo                                 - Into a temporary folder
+     wt demo --days 20 ~/wt-demo`,
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "days", Value: DefaultDemoDays, Usage: "Number of past weekdays to make up"},
+					&cli.IntFlag{Name: "seed", Value: 1, Usage: "Random seed; the same seed makes the same days"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() > 1 {
+						return fmt.Errorf("Usage: wt demo [path]")
+					}
+					return demoCmd(cmd.Args().First(), int(cmd.Int("days")), uint64(cmd.Int("seed")))
+				},
+			},
+			{
+				Name:      "tutorial",
+				Usage:     "Learn the basics on a made-up day",
+				ArgsUsage: " ",
+				Description: `Explains and runs new, start, check, stop, log, mod, and report over a
+   made-up day in a temporary folder with a mock clock. Press Enter to run
+   each command. Your timer and settings aren't touched.`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return tutorialCmd()
+				},
+			},
+			{
+				Name:      "simulate",
+				Usage:     "Show where the day would land after hypothetical commands",
+				ArgsUsage: "<step>...",
+				Description: `Applies each step ("HH:MM command [args]", or "now command [args]") to a copy
+   of the current timer and prints the resulting report. Nothing is saved.
+   Examples:
+     wt simulate "12:00 stop" "13:00 start" "18:00 stop"
+     wt simulate --log "now next" "17:30 stop"`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "log", Usage: "Also print the resulting log"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return simulateCmd(timer, cmd.Args().Slice(), cmd.Bool("log"))
+				},
+			},
+			{
+				Name:  "version",
+				Usage: "Print the version",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "check", Usage: "Also check GitHub for a newer release"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return versionCmd(cmd.Bool("check"))
+				},
+			},
+			{
+				Name:  "upgrade",
+				Usage: "Replace this binary with the latest release",
+				Description: `Downloads the latest release for this platform from GitHub, verifies it
+   against the release's checksums.txt, and replaces the running binary.
+   Development builds are only replaced with --force.`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "force", Usage: "Install the latest release even if it isn't newer"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return upgradeCmd(cmd.Bool("force"))
+				},
+			},
+			{
+				Name:      "env",
+				Usage:     "Print shell exports pointing wt at a root",
+				ArgsUsage: "[path]",
+				Description: `Prints WT_ROOT and the report file paths for the root at path as
+   'export' lines. Without a path, the root is the nearest folder at or above
+   the working directory with a timer, else $WT_ROOT.
+   Examples:
+     eval "$(wt env ~/work/projX)"
+     wt env > .envrc                         - For direnv`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() > 1 {
+						return fmt.Errorf("Usage: wt env [path]")
+					}
+					return envCmd(cmd.Args().First())
+				},
+			},
+			{
+				Name:  "profile",
+				Usage: "Manage named settings profiles",
+				Description: `Profiles bundle WT_* settings (e.g. WT_DAILY_GOAL, WT_LUNCH_WINDOW) so one
+   binary can behave differently per root or per invocation. Environment
+   variables still override profile values.
+   Examples:
+     wt profile                              - Show the active profile
+     wt profile list                         - List profiles (* marks the active one)
+     wt profile set freelance WT_DAILY_GOAL 600
+     wt profile use freelance                - Use a profile for this root ('none' clears)
+     wt --profile freelance report           - Use a profile for one command`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return profileShowCmd("")
+				},
+				Commands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "List profiles",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return profileListCmd()
+						},
+					},
+					{
+						Name:      "show",
+						Usage:     "Show a profile's settings",
+						ArgsUsage: "[name]",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return profileShowCmd(cmd.Args().Get(0))
+						},
+					},
+					{
+						Name:      "use",
+						Usage:     "Select the profile used by this root",
+						ArgsUsage: "<name|none>",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							if cmd.Args().Len() != 1 {
+								return fmt.Errorf("Usage: wt profile use <name|none>")
+							}
+							return profileUseCmd(cmd.Args().Get(0))
+						},
+					},
+					{
+						Name:      "set",
+						Usage:     "Set (or with an empty value, remove) a profile setting",
+						ArgsUsage: "<name> <KEY> [value]",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							if cmd.Args().Len() < 2 {
+								return fmt.Errorf("Usage: wt profile set <name> <KEY> [value]")
+							}
+							return profileSetCmd(cmd.Args().Get(0), cmd.Args().Get(1), cmd.Args().Get(2))
+						},
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Manage the defaults of WT_* settings in the config file",
+				Description: `The config file ($WT_ROOT/.out/config, or $WT_CONFIG) holds KEY=VALUE defaults for
+   WT_* settings. The active profile and environment variables override them.
+   Examples:
+     wt config get                           - List the config file's settings
+     wt config get WT_DAILY_GOAL
+     wt config set WT_MODE normal            - Mode of new timers
+     wt config set WT_SKIP_PROMPTS 1         - Never ask, take the default answer
+     wt config unset WT_DAILY_GOAL`,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return configGetCmd("")
+				},
+				Commands: []*cli.Command{
+					{
+						Name:      "get",
+						Usage:     "Print a setting of the config file, or all of them",
+						ArgsUsage: "[KEY]",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							return configGetCmd(cmd.Args().Get(0))
+						},
+					},
+					{
+						Name:      "set",
+						Usage:     "Set a setting in the config file",
+						ArgsUsage: "<KEY> <value>",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							if cmd.Args().Len() != 2 {
+								return fmt.Errorf("Usage: wt config set <KEY> <value>")
+							}
+							return configSetCmd(cmd.Args().Get(0), cmd.Args().Get(1))
+						},
+					},
+					{
+						Name:      "unset",
+						Usage:     "Remove a setting from the config file",
+						ArgsUsage: "<KEY>",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							if cmd.Args().Len() != 1 {
+								return fmt.Errorf("Usage: wt config unset <KEY>")
+							}
+							return configUnsetCmd(cmd.Args().Get(0))
+						},
+					},
+				},
+			},
+			{
+				Name:        "remind",
+				Usage:       "Print a reminder if the timer has been in its current state too long",
+				Description: "Prints nothing when no reminder is due. Intended for cron jobs or status bars, e.g. 'wt remind --notify'.\n   Also reminds (or, with WT_SCHEDULE_ACTION=start, starts) at the WT_SCHEDULE start times",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "notify", Usage: "Also send the reminder as a notification (desktop, or WT_NOTIFY_COMMAND)"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					timer, err := load()
+					if err != nil {
+						return err
+					}
+					return remindCmd(timer, cmd.Bool("notify"))
+				},
+			},
+			{
+				Name:  "debug",
+				Usage: "Prints debug info",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return debugCmd()
+				},
+			},
+			{
+				Name:  "help",
+				Usage: "Show help",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return cli.ShowAppHelp(cmd)
+				},
+			},
+		},
+	}
+	// Each wrapper goes around the ones before it
+	lockStateActions(app)
+	remoteActions(app)   // A remote command replaces the local action, lock included
+	traceActions(app)    // Times remote calls too; they read and write no local files
+	readOnlyActions(app) // Read-only commands' traces go through appendDebugEntry
+	jsonActions(app)
+	return app
+}
+
+// Helper functions
+
+// getCurrentTime returns the time on the clock (see clock.go)
+func getCurrentTime() time.Time {
+	return currentClock().Now()
+}
+
+// parseTime parses a datetime string in local timezone
+func parseTime(s string) (time.Time, error) {
+	return time.ParseInLocation(DT_FORMAT, s, time.Local)
+}
+
+func projectRootPath() (string, error) {
+	root := os.Getenv("WT_ROOT")
+	if root == "" {
+		return "", fmt.Errorf("Env $WT_ROOT not set.")
+	}
+	return root, nil
+}
+
+func outputFilePath() (string, error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, OutputFileName), nil
+}
+
+func debugLogFilePath() (string, error) {
+	folder, err := outputFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, DebugLogName), nil
+}
 
 func dailyReportFilePath() (string, error) {
 	// Prefer WT_REPORT_FILE if set
-	if reportFile := os.Getenv("WT_REPORT_FILE"); reportFile != "" {
+	if reportFile := setting("WT_REPORT_FILE"); reportFile != "" {
 		return reportFile, nil
 	}
 
-	root, err := projectRootPath()
+	folder, err := outputFolderPath()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(root, OutputFolder, DailyReportName), nil
+	return filepath.Join(folder, DailyReportName), nil
 }
 
+// outputFolderPath returns the folder of the selected timer (see timers.go)
 func outputFolderPath() (string, error) {
-	root, err := projectRootPath()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(root, OutputFolder), nil
+	return timerFolderPath(timerName())
 }
 
 func deltaMinutes(start, end time.Time) int {
@@ -423,9 +1549,21 @@ func minutesToHourMinuteStr(mins int) string {
 	return fmt.Sprintf("%dh:%02dm", h, m)
 }
 
+// timeDigits turns H:MM or HH:MM into HHMM digits. Anything else is returned
+// unchanged, for validation to reject.
+func timeDigits(timeStr string) string {
+	hours, minutes, ok := strings.Cut(timeStr, ":")
+	if !ok || len(hours) < 1 || len(hours) > 2 || len(minutes) != 2 {
+		return timeStr
+	}
+	return hours + minutes
+}
+
+// stringTimeToMinutes converts HHMM (or HH:MM) to minutes
 func stringTimeToMinutes(timeStr string) (int, error) {
+	timeStr = timeDigits(timeStr)
 	if !isDigits(timeStr) {
-		return 0, fmt.Errorf("Invalid time format. Should be digits only.")
+		return 0, fmt.Errorf("Invalid time format. Use HHMM or HH:MM.")
 	}
 
 	var hour, minute int
@@ -442,15 +1580,16 @@ func stringTimeToMinutes(timeStr string) (int, error) {
 		m, _ := strconv.Atoi(timeStr)
 		minute = m
 	default:
-		return 0, fmt.Errorf("Incorrect time format. Should be 1-4 digit HHMM.")
+		return 0, fmt.Errorf("Incorrect time format. Should be 1-4 digit HHMM or HH:MM.")
 	}
 
 	return hour*60 + minute, nil
 }
 
 func validateTimeString(timeStr string) error {
+	timeStr = timeDigits(timeStr)
 	if len(timeStr) < 1 || len(timeStr) > 4 || !isDigits(timeStr) {
-		return fmt.Errorf("Incorrect time format. Should be 1-4 digit HHMM.")
+		return fmt.Errorf("Incorrect time format. Should be 1-4 digit HHMM or HH:MM.")
 	}
 
 	if len(timeStr) >= 2 {
@@ -472,6 +1611,266 @@ func isDigits(s string) bool {
 	return true
 }
 
+// envMinutes reads an HHMM duration from the given setting (environment or profile),
+// falling back to defaultMinutes when unset or invalid.
+func envMinutes(name string, defaultMinutes int) int {
+	value := setting(name)
+	if value == "" {
+		return defaultMinutes
+	}
+	minutes, err := stringTimeToMinutes(value)
+	if err != nil {
+		return defaultMinutes
+	}
+	return minutes
+}
+
+// nonEmpty returns args without empty strings (for logging optional arguments)
+func nonEmpty(args ...string) []string {
+	var result []string
+	for _, arg := range args {
+		if arg != "" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// signedMinutes returns minutes, negated for the "sub" operation
+func signedMinutes(operation string, minutes int) int {
+	if operation == "sub" {
+		return -minutes
+	}
+	return minutes
+}
+
+// envInt reads an integer from the given setting (environment or profile),
+// falling back to defaultValue when unset or invalid.
+func envInt(name string, defaultValue int) int {
+	value, err := strconv.Atoi(setting(name))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// longSessionMinutes returns how long a cycle may run before check warns about it.
+// Configured via $WT_LONG_SESSION in HHMM format, 0 disables the warning.
+func longSessionMinutes() int {
+	return envMinutes("WT_LONG_SESSION", DefaultLongSessionMinutes)
+}
+
+// Rate is an hourly billing rate
+type Rate struct {
+	Amount   float64
+	Currency string
+}
+
+// hourlyRate parses $WT_RATE ("85 EUR", "120.50 USD", or just "85").
+// Set it per client profile to bill each client at its own rate.
+func hourlyRate() (Rate, bool) {
+	return parseRate(setting("WT_RATE"))
+}
+
+// parseRate parses an amount with an optional currency
+func parseRate(value string) (Rate, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Rate{}, false
+	}
+	amount, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || amount <= 0 {
+		return Rate{}, false
+	}
+	rate := Rate{Amount: amount}
+	if len(fields) == 2 {
+		rate.Currency = fields[1]
+	}
+	return rate, true
+}
+
+// Earnings returns the amount earned for the given work minutes
+func (r Rate) Earnings(minutes int) float64 {
+	return r.Amount * float64(minutes) / 60
+}
+
+// tagRateSetting names the setting with the rate of a tag: WT_RATE_CLIENT_A
+// for +client-a
+func tagRateSetting(tag string) string {
+	return "WT_RATE_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, tag)
+}
+
+// tagRates returns the WT_RATE_<TAG> settings of the environment, the
+// active profile, and the config, each with the value setting() picks
+func tagRates() map[string]Rate {
+	names := map[string]bool{}
+	for _, env := range os.Environ() {
+		names[strings.SplitN(env, "=", 2)[0]] = true
+	}
+	if profile := activeProfile(); profile != "" {
+		values, _ := readProfile(profile)
+		for name := range values {
+			names[name] = true
+		}
+	}
+	for name := range readConfig() {
+		names[name] = true
+	}
+	rates := map[string]Rate{}
+	for name := range names {
+		if !strings.HasPrefix(name, "WT_RATE_") {
+			continue
+		}
+		if rate, ok := parseRate(setting(name)); ok {
+			rates[name] = rate
+		}
+	}
+	return rates
+}
+
+// billingRate returns $WT_RATE, in the currency that all rates share. Tag
+// rates bill without $WT_RATE, leaving untagged work unbilled (Amount 0). ok
+// is false if no rate is set.
+func billingRate() (Rate, bool) {
+	rate, ok := hourlyRate()
+	for _, tagRate := range tagRates() {
+		rate.Currency = cmp.Or(rate.Currency, tagRate.Currency)
+		ok = true
+	}
+	return rate, ok
+}
+
+// checkRates refuses rates in different currencies, whose earnings can't be
+// added up. Rates without a currency are in that of the others.
+func checkRates() error {
+	currency, from := "", ""
+	if rate, ok := hourlyRate(); ok && rate.Currency != "" {
+		currency, from = rate.Currency, "WT_RATE"
+	}
+	rates := tagRates()
+	names := make([]string, 0, len(rates))
+	for name := range rates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		switch rate := rates[name]; {
+		case rate.Currency == "" || rate.Currency == currency:
+		case currency == "":
+			currency, from = rate.Currency, name
+		default:
+			return fmt.Errorf("%s is in %s, %s in %s. Use one currency for all rates.", name, rate.Currency, from, currency)
+		}
+	}
+	return nil
+}
+
+// cycleRate returns the rate of work with the given tags: that of the first
+// tag with a WT_RATE_<TAG> setting, else $WT_RATE, in the billingRate
+// currency. ok is false if the cycle isn't billed.
+func cycleRate(tags []string) (Rate, bool) {
+	rate, ok := billingRate()
+	if !ok {
+		return Rate{}, false
+	}
+	for _, tag := range tags {
+		if tagRate, ok := parseRate(setting(tagRateSetting(tag))); ok {
+			return Rate{Amount: tagRate.Amount, Currency: rate.Currency}, true
+		}
+	}
+	return rate, rate.Amount > 0
+}
+
+// dayEarnings adds up the earnings of the timer's work cycles, each at its
+// cycleRate. ok is false without any rate.
+func dayEarnings(timer *Timer) (earned float64, rate Rate, ok bool) {
+	if rate, ok = billingRate(); !ok {
+		return 0, Rate{}, false
+	}
+	for _, entry := range buildLogEntries(timer) {
+		if entry.Type != "work" {
+			continue
+		}
+		if cycle, ok := cycleRate(entry.Tags); ok {
+			earned += cycle.Earnings(entry.Minutes)
+		}
+	}
+	return earned, rate, true
+}
+
+// Format renders an amount in the rate's currency
+func (r Rate) Format(amount float64) string {
+	if r.Currency == "" {
+		return fmt.Sprintf("%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, r.Currency)
+}
+
+// dailyGoalMinutes returns today's work goal (see dailyGoalOn)
+func dailyGoalMinutes() int {
+	return dailyGoalOn(getCurrentTime())
+}
+
+// dailyGoalOn returns the work goal of day from $WT_DAILY_GOAL (HHMM), 0 if
+// unset or day is a holiday.
+func dailyGoalOn(day time.Time) int {
+	if holiday, _ := holidayOn(day); holiday != "" {
+		return 0
+	}
+	return envMinutes("WT_DAILY_GOAL", 0)
+}
+
+// goalETA returns "ETA HH:MM" for when the daily goal is reached, assuming
+// continuous work from now, "Goal reached" once it has been, or "" if no goal is set.
+func goalETA(workMinutes int) string {
+	goal := dailyGoalMinutes()
+	if goal <= 0 {
+		return ""
+	}
+	remaining := goal - workMinutes
+	if remaining <= 0 {
+		return "Goal reached"
+	}
+	eta := getCurrentTime().Add(time.Duration(remaining) * time.Minute)
+	return fmt.Sprintf("ETA %s", eta.Format(TIME_ONLY_FORMAT))
+}
+
+// lunchWindow parses $WT_LUNCH_WINDOW ("HHMM-HHMM" clock times) into minutes
+// since midnight. ok is false when lunch classification is not configured.
+func lunchWindow() (from, to int, ok bool) {
+	parts := strings.Split(setting("WT_LUNCH_WINDOW"), "-")
+	if len(parts) != 2 || validateTimeString(parts[0]) != nil || validateTimeString(parts[1]) != nil {
+		return 0, 0, false
+	}
+	from, _ = stringTimeToMinutes(parts[0])
+	to, _ = stringTimeToMinutes(parts[1])
+	return from, to, from < to
+}
+
+func lunchWindowEnabled() bool {
+	_, _, ok := lunchWindow()
+	return ok
+}
+
+// isLunchBreak reports whether a break starting at start qualifies as lunch:
+// it must start inside $WT_LUNCH_WINDOW and last at least $WT_LUNCH_MIN (default 30m).
+func isLunchBreak(start time.Time, minutes int) bool {
+	from, to, ok := lunchWindow()
+	if !ok || minutes < envMinutes("WT_LUNCH_MIN", DefaultLunchMinutes) {
+		return false
+	}
+	startOfDay := start.Hour()*60 + start.Minute()
+	return startOfDay >= from && startOfDay < to
+}
+
 func calculateCurrentMinutes(timer *Timer) int {
 	if timer.Status == StatusStopped {
 		return 0
@@ -496,6 +1895,48 @@ func calculateCurrentMinutes(timer *Timer) int {
 	return workMinutes
 }
 
+// reminderMessage returns the reminder that applies to the timer's current state,
+// or "" if none is due. Running cycles are reminded after $WT_REMIND_RUNNING,
+// paused and stopped (mid-day) timers after $WT_REMIND_IDLE. 0 disables either.
+func reminderMessage(timer *Timer) string {
+	now := getCurrentTime()
+
+	switch timer.Status {
+	case StatusRunning:
+		interval := workTargetMinutes(timer, envMinutes("WT_REMIND_RUNNING", DefaultRemindRunningMinutes))
+		minutes := calculateCurrentMinutes(timer)
+		if interval > 0 && minutes >= interval && pomodoroEnabled() {
+			return fmt.Sprintf("Pomodoro %d is over (%s) - 'wt next' starts the break.", pomodoros(timer)+1, hourMinuteStrFromMinutes(minutes))
+		}
+		if interval > 0 && minutes >= interval {
+			return fmt.Sprintf("%s on current cycle - consider a break.", hourMinuteStrFromMinutes(minutes))
+		}
+	case StatusPaused:
+		interval := envMinutes("WT_REMIND_IDLE", DefaultRemindIdleMinutes)
+		pauseStart, _ := parseTime(timer.PauseStartStr)
+		minutes := deltaMinutes(pauseStart, now)
+		if interval > 0 && minutes >= interval {
+			return fmt.Sprintf("Timer has been paused for %s - still on break?", hourMinuteStrFromMinutes(minutes))
+		}
+	case StatusStopped:
+		// Only remind mid-day: before the first start there's nothing to come back to
+		if timer.StopDatetimeStr == "" {
+			return ""
+		}
+		interval := breakTargetMinutes(timer, envMinutes("WT_REMIND_IDLE", DefaultRemindIdleMinutes))
+		stopDt, _ := parseTime(timer.StopDatetimeStr)
+		minutes := deltaMinutes(stopDt, now)
+		if interval > 0 && minutes >= interval && pomodoroEnabled() {
+			return fmt.Sprintf("Break is over (%s) - 'wt next' starts pomodoro %d.", hourMinuteStrFromMinutes(minutes), pomodoros(timer)+1)
+		}
+		if interval > 0 && minutes >= interval {
+			return fmt.Sprintf("Timer has been stopped for %s - still on break?", hourMinuteStrFromMinutes(minutes))
+		}
+	}
+
+	return ""
+}
+
 func printMessageIfNotSilent(timer *Timer, message string) {
 	if timer.Mode != ModeSilent {
 		fmt.Println(message)
@@ -509,7 +1950,7 @@ func printCheckIfVerbose(timer *Timer) {
 }
 
 func yesOrNoPrompt(msg string) bool {
-	if os.Getenv("WT_SKIP_PROMPTS") != "" {
+	if setting("WT_SKIP_PROMPTS") != "" {
 		return true
 	}
 
@@ -522,6 +1963,21 @@ func yesOrNoPrompt(msg string) bool {
 // File I/O functions
 
 func save(timer *Timer) error {
+	if readOnly.Load() {
+		return errReadOnly("not saving the timer")
+	}
+	if err := checkStrict(timer); err != nil {
+		return err
+	}
+	previous, _ := load() // nil for a new timer
+	if pendingClockJump != nil {
+		timer.ClockJumps = append(timer.ClockJumps, *pendingClockJump)
+		pendingClockJump = nil
+	}
+	timer.LastCommand = getCurrentTime().Format(DT_FORMAT)
+	syncTimelinePauses(timer)
+	stampEntries(timer, previous)
+
 	folderPath, err := outputFolderPath()
 	if err != nil {
 		return err
@@ -543,7 +1999,13 @@ func save(timer *Timer) error {
 		return err
 	}
 
-	return os.WriteFile(filePath, data, 0644)
+	if err := writeFile(filePath, data, 0644); err != nil {
+		return err
+	}
+	if stat, err := os.Stat(filePath); err == nil {
+		stateCache.put(filePath, data, false, stat)
+	}
+	return nil
 }
 
 func load() (*Timer, error) {
@@ -552,13 +2014,17 @@ func load() (*Timer, error) {
 		return nil, err
 	}
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	stat, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
 		return nil, fmt.Errorf("No timer exists.")
 	}
 
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
+	data, cached := stateCache.get(filePath)
+	if !cached {
+		if data, err = readFile(filePath); err != nil {
+			return nil, err
+		}
+		stateCache.put(filePath, data, true, stat)
 	}
 
 	var timer Timer
@@ -569,14 +2035,100 @@ func load() (*Timer, error) {
 	return &timer, nil
 }
 
-func logDebug(msg string) error {
+// DebugEntry is one structured line (JSON) in the debug log
+type DebugEntry struct {
+	Time    string         `json:"time"`              // When the command ran (DT_FORMAT)
+	Level   string         `json:"level"`             // "debug", "info", "warn", or "error"
+	Command string         `json:"command,omitempty"` // Command name, e.g. "start" or "mod"
+	Args    []string       `json:"args,omitempty"`    // Command arguments as given
+	Via     string         `json:"via,omitempty"`     // Parent command when run as part of another (stop via next)
+	Status  string         `json:"status,omitempty"`  // Timer status after the command
+	Minutes map[string]int `json:"minutes,omitempty"` // Durations affected by the command
+	Message string         `json:"message,omitempty"` // Warnings, errors, or legacy free-form lines
+
+	// Command traces (debug level, see trace.go)
+	DurationMs float64  `json:"duration_ms,omitempty"` // How long the command took
+	Read       []string `json:"read,omitempty"`        // Files it read
+	Wrote      []string `json:"wrote,omitempty"`       // Files it wrote
+}
+
+// Debug log levels, in increasing severity
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// commandVia names the command currently delegating to another (e.g. next -> stop),
+// so the nested entry can be told apart from a command the user ran.
+var commandVia string
+
+func levelRank(level string) int {
+	switch level {
+	case LevelDebug:
+		return 0
+	case LevelWarn:
+		return 2
+	case LevelError:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// logCommand records a state-changing command in the debug log
+func logCommand(timer *Timer, command string, args []string, minutes map[string]int) error {
+	auditCommand = strings.Join(append([]string{"wt", command}, args...), " ")
+	if commandVia != "" {
+		auditCommand = "wt " + commandVia
+	}
+	return writeDebugEntry(DebugEntry{
+		Level:   LevelInfo,
+		Command: command,
+		Args:    args,
+		Status:  timer.Status,
+		Minutes: minutes,
+	})
+}
+
+// logWarning records a command that was refused or had no effect
+func logWarning(timer *Timer, command string, args []string, message string) error {
+	return writeDebugEntry(DebugEntry{
+		Level:   LevelWarn,
+		Command: command,
+		Args:    args,
+		Status:  timer.Status,
+		Message: message,
+	})
+}
+
+// writeDebugEntry appends an entry to the debug log if its level is at or
+// above $WT_LOG_LEVEL (default info).
+func writeDebugEntry(entry DebugEntry) error {
+	if readOnly.Load() || levelRank(entry.Level) < levelRank(setting("WT_LOG_LEVEL")) {
+		return nil
+	}
+	return appendDebugEntry(entry)
+}
+
+// appendDebugEntry writes entry to the debug log whatever the level and mode
+func appendDebugEntry(entry DebugEntry) error {
 	filePath, err := debugLogFilePath()
 	if err != nil {
 		return err
 	}
 
-	timestamp := getCurrentTime().Format(DT_FORMAT)
-	logLine := fmt.Sprintf("[%s] %s\n", timestamp, msg)
+	if err := rotateDebugLogIfNeeded(filePath); err != nil {
+		return err
+	}
+
+	entry.Time = getCurrentTime().Format(DT_FORMAT)
+	entry.Via = commandVia
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
 
 	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -584,62 +2136,173 @@ func logDebug(msg string) error {
 	}
 	defer f.Close()
 
-	_, err = f.WriteString(logLine)
+	_, err = f.Write(append(data, '\n'))
 	return err
 }
 
-func saveDailyReport(timer *Timer) error {
-	if timer.DayStart == "" {
+// parseDebugLine reads a debug log line, accepting both JSON entries and the
+// legacy "[YYYY-MM-DD HH:MM] wt ..." format.
+func parseDebugLine(line string) (DebugEntry, bool) {
+	var entry DebugEntry
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return entry, false
+		}
+		return entry, true
+	}
+
+	if len(line) < len(DT_FORMAT)+2 || line[0] != '[' || line[len(DT_FORMAT)+1] != ']' {
+		return entry, false
+	}
+	entry.Time = line[1 : len(DT_FORMAT)+1]
+	entry.Level = LevelInfo
+	entry.Message = strings.TrimSpace(line[len(DT_FORMAT)+2:])
+	return entry, true
+}
+
+// formatDebugEntry renders an entry the way `wt log debug` shows it
+func formatDebugEntry(entry DebugEntry) string {
+	text := entry.Message
+	if entry.Command != "" {
+		text = strings.TrimSpace("wt " + entry.Command + " " + strings.Join(entry.Args, " "))
+		if entry.Via != "" {
+			text += fmt.Sprintf(" (via %s)", entry.Via)
+		}
+		if entry.Message != "" {
+			text += ": " + entry.Message
+		}
+		if entry.DurationMs > 0 {
+			text += " " + formatTrace(entry)
+		}
+	}
+
+	if entry.Level != LevelInfo && entry.Level != "" {
+		text = strings.ToUpper(entry.Level) + " " + text
+	}
+	return fmt.Sprintf("[%s] %s", entry.Time, text)
+}
+
+// rotateDebugLogIfNeeded shifts debug-log to debug-log.1 (and so on, keeping
+// DebugLogRotations files) once it exceeds $WT_DEBUG_LOG_MAX_KB or its oldest
+// entry is older than $WT_DEBUG_LOG_MAX_DAYS. A limit of 0 disables that check.
+func rotateDebugLogIfNeeded(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil || info.Size() == 0 {
 		return nil
 	}
 
-	// Calculate totals from timeline
-	totalWorkMins := 0
-	totalBreakMins := 0
-	totalPausedMins := 0
+	maxKB := envInt("WT_DEBUG_LOG_MAX_KB", DefaultDebugLogMaxKB)
+	tooBig := maxKB > 0 && info.Size() > int64(maxKB)*1024
 
-	for _, entry := range timer.Timeline {
-		if entry.Type == "work" {
-			totalWorkMins += entry.Minutes
-			totalPausedMins += entry.PausedMinutes
-		} else {
-			totalBreakMins += entry.Minutes
+	tooOld := false
+	if maxDays := envInt("WT_DEBUG_LOG_MAX_DAYS", DefaultDebugLogMaxDays); maxDays > 0 && !tooBig {
+		if oldest, ok := firstDebugLogTime(filePath); ok {
+			tooOld = getCurrentTime().Sub(oldest) > time.Duration(maxDays)*24*time.Hour
 		}
 	}
 
-	// Add current running/paused time if applicable
-	currentMins := 0
-	currentPausedMins := 0
-	if timer.Status == StatusRunning || timer.Status == StatusPaused {
-		currentMins = calculateCurrentMinutes(timer)
-		totalWorkMins += currentMins
+	if !tooBig && !tooOld {
+		return nil
+	}
 
-		// Add current cycle's paused time
-		currentPausedMins = timer.PausedMinutes
-		if timer.Status == StatusPaused {
-			pauseStart, _ := parseTime(timer.PauseStartStr)
-			currentPausedMins += deltaMinutes(pauseStart, getCurrentTime())
+	os.Remove(fmt.Sprintf("%s.%d", filePath, DebugLogRotations))
+	for i := DebugLogRotations - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", filePath, i), fmt.Sprintf("%s.%d", filePath, i+1))
+	}
+	return os.Rename(filePath, filePath+".1")
+}
+
+// firstDebugLogTime returns the timestamp of the oldest entry in the debug log
+func firstDebugLogTime(filePath string) (time.Time, bool) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return time.Time{}, false
+	}
+	return debugLineTime(scanner.Text())
+}
+
+// debugLineTime returns when a debug log line was written
+func debugLineTime(line string) (time.Time, bool) {
+	entry, ok := parseDebugLine(line)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := parseTime(entry.Time)
+	return t, err == nil
+}
+
+func saveDailyReport(timer *Timer) error {
+	if timer.DayStart == "" {
+		return nil
+	}
+
+	// Appended, so the file is never read or rewritten here; `wt report
+	// history` lists it newest first
+	filePath, err := dailyReportFilePath()
+	if err != nil {
+		return err
+	}
+	return appendFile(filePath, []byte(dailyReportLine(timer)+"\n"), 0644)
+}
+
+// sortDailyReport puts the daily report file's lines in date order, oldest
+// first, for `wt report history --fix`. Files written before lines were
+// appended have the newest line at the top, and appended lines after them
+// once upgraded. It returns false if the file was in order already.
+func sortDailyReport() (bool, error) {
+	filePath, err := dailyReportFilePath()
+	if err != nil {
+		return false, err
+	}
+	data, err := readFile(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	key := func(line string) string {
+		if summary, ok := parseDailyReportLine(line); ok {
+			return summary.Date + summary.Start
 		}
-		totalPausedMins += currentPausedMins
+		return ""
 	}
+	byDate := func(a, b string) int { return cmp.Compare(key(a), key(b)) }
+	if slices.IsSortedFunc(lines, byDate) {
+		return false, nil
+	}
+	slices.Reverse(lines) // Same-day lines of old files are newest first too
+	slices.SortStableFunc(lines, byDate)
+	return true, writeFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// dailyReportLine formats the timer's day as a line of the daily report file
+func dailyReportLine(timer *Timer) string {
+	totals := timer.Totals()
 
 	// Calculate end time (includes work + paused time for running/paused cycles)
 	startDt, _ := parseTime(timer.DayStart)
 	endDt := timer.CurrentCycleStart()
 
-	// Add current running time (work minutes + paused minutes = elapsed time)
+	// The active cycle (work + paused time) runs until now
 	if timer.Status == StatusRunning || timer.Status == StatusPaused {
-		endDt = endDt.Add(time.Duration(currentMins+currentPausedMins) * time.Minute)
+		endDt = getCurrentTime()
 	}
 
 	// Format output
 	dateStr := startDt.Format("2006-01-02")
 	startTime := startDt.Format(TIME_ONLY_FORMAT)
 	endTime := endDt.Format(TIME_ONLY_FORMAT)
-	workStr := minutesToHourMinuteStr(totalWorkMins)
-	breakStr := minutesToHourMinuteStr(totalBreakMins)
-	pausedStr := minutesToHourMinuteStr(totalPausedMins)
-	totalStr := minutesToHourMinuteStr(totalWorkMins + totalBreakMins + totalPausedMins)
+	workStr := minutesToHourMinuteStr(totals.Work)
+	pausedStr := minutesToHourMinuteStr(totals.Paused)
+	totalStr := minutesToHourMinuteStr(totals.Total())
 
 	// Check if crossed midnight
 	dayDiff := int(endDt.Sub(startDt).Hours() / 24)
@@ -648,43 +2311,269 @@ func saveDailyReport(timer *Timer) error {
 		dayIndicator = fmt.Sprintf(" [+%d day]", dayDiff)
 	}
 
-	reportLine := fmt.Sprintf("%s | %s -> %s | Work: %s | Break: %s | Paused: %s | Total: %s%s",
-		dateStr, startTime, endTime, workStr, breakStr, pausedStr, totalStr, dayIndicator)
-
-	// Prepend to daily report file (newest at top)
-	filePath, err := dailyReportFilePath()
+	earnedStr := ""
+	if earned, rate, ok := dayEarnings(timer); ok {
+		earnedStr = " | Earned: " + rate.Format(earned)
+	}
+
+	return fmt.Sprintf("%s | %s -> %s | Work: %s | %s | Paused: %s | Total: %s%s%s",
+		dateStr, startTime, endTime, workStr, totals.breakSummary(), pausedStr, totalStr, dayIndicator, earnedStr)
+}
+
+// DailySummary is a parsed line of the daily report file
+type DailySummary struct {
+	Date   string // YYYY-MM-DD
+	Start  string // HH:MM
+	End    string // HH:MM
+	Work   int
+	Break  int
+	Lunch  int
+	Paused int
+	Earned float64 // Only meaningful if HasEarned
+	// HasEarned is set when the line recorded earnings (a rate was configured)
+	HasEarned bool
+	Line      string // As written
+}
+
+// parseHourMinuteStr parses the "1h:30m" format produced by minutesToHourMinuteStr
+func parseHourMinuteStr(s string) (int, bool) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%dh:%dm", &h, &m); err != nil {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// parseDailyReportLine parses a line written by saveDailyReport
+func parseDailyReportLine(line string) (DailySummary, bool) {
+	parts := strings.Split(strings.TrimSpace(line), " | ")
+	if len(parts) < 3 {
+		return DailySummary{}, false
+	}
+
+	summary := DailySummary{Date: parts[0]}
+	if _, err := time.Parse("2006-01-02", summary.Date); err != nil {
+		return DailySummary{}, false
+	}
+	if start, end, ok := strings.Cut(parts[1], " -> "); ok {
+		summary.Start, summary.End = start, end
+	}
+
+	for _, part := range parts[2:] {
+		label, value, ok := strings.Cut(part, ": ")
+		if !ok {
+			continue
+		}
+		// Strip trailing markers such as " [+1 day]"
+		if idx := strings.Index(value, " ["); idx >= 0 {
+			value = value[:idx]
+		}
+		minutes, _ := parseHourMinuteStr(value)
+		switch label {
+		case "Work":
+			summary.Work = minutes
+		case "Break":
+			summary.Break = minutes
+		case "Lunch":
+			summary.Lunch = minutes
+		case "Paused":
+			summary.Paused = minutes
+		case "Earned":
+			if amount, err := strconv.ParseFloat(strings.Fields(value)[0], 64); err == nil {
+				summary.Earned = amount
+				summary.HasEarned = true
+			}
+		}
+	}
+
+	return summary, true
+}
+
+// readDailyReports returns all parsed lines of the daily report file, newest
+// first. The file is oldest first, but files written before lines were
+// appended have the newest line at the top (until `wt report history --fix`),
+// so they're sorted by date and start time.
+func readDailyReports() ([]DailySummary, error) {
+	filePath, err := dailyReportFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var summaries []DailySummary
+	for _, line := range strings.Split(string(data), "\n") {
+		if summary, ok := parseDailyReportLine(line); ok {
+			summary.Line = strings.TrimSpace(line)
+			summaries = append(summaries, summary)
+		}
+	}
+	slices.SortStableFunc(summaries, func(a, b DailySummary) int {
+		return cmp.Compare(b.Date+b.Start, a.Date+a.Start)
+	})
+	return summaries, nil
+}
+
+// reportHistoryCmd prints the daily report file's lines newest first, at
+// most limit of them (all for 0)
+func reportHistoryCmd(limit int) error {
+	summaries, err := readDailyReports()
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No days in the daily report yet. Days are added by wt new, wt reset, and wt close.")
+		return nil
+	}
+	if limit > 0 && limit < len(summaries) {
+		summaries = summaries[:limit]
+	}
+	for _, summary := range summaries {
+		fmt.Println(summary.Line)
+	}
+	return nil
+}
+
+// reportHistoryFixCmd puts the daily report file in date order
+func reportHistoryFixCmd() error {
+	sorted, err := sortDailyReport()
 	if err != nil {
 		return err
 	}
+	if !sorted {
+		fmt.Println("The daily report is in date order already.")
+		return nil
+	}
+	fmt.Println("Rewrote the daily report in date order, oldest first.")
+	return nil
+}
+
+// budgetStatus compares the value of all tracked work (daily reports plus
+// today) against $WT_BUDGET. Past days use their recorded earnings, falling
+// back to the current rate. The daily report file holds the whole root (or
+// profile, with its own WT_REPORT_FILE); with $WT_BUDGET_TAG only cycles with
+// that tag count, see taggedEarnings. ok is false unless both a budget and a
+// rate are set.
+func budgetStatus(timer *Timer) (used float64, budget Rate, ok bool) {
+	rate, hasRate := billingRate()
+	fields := strings.Fields(setting("WT_BUDGET"))
+	if !hasRate || len(fields) == 0 {
+		return 0, Rate{}, false
+	}
+	amount, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || amount <= 0 {
+		return 0, Rate{}, false
+	}
+	budget = Rate{Amount: amount, Currency: rate.Currency}
 
-	existingContent := ""
-	if data, err := os.ReadFile(filePath); err == nil {
-		existingContent = strings.TrimSpace(string(data))
+	if tag := strings.TrimPrefix(setting("WT_BUDGET_TAG"), "+"); tag != "" {
+		return taggedEarnings(timer, tag), budget, true
+	}
+	summaries, _ := readDailyReports()
+	for _, summary := range summaries {
+		if summary.HasEarned {
+			used += summary.Earned
+		} else {
+			used += rate.Earnings(summary.Work)
+		}
 	}
+	if earned, _, ok := dayEarnings(timer); ok && timer.DayStart != "" {
+		used += earned
+	}
+
+	return used, budget, true
+}
 
-	// Build final content: new line, then existing (if any)
-	finalContent := reportLine
-	if existingContent != "" {
-		finalContent = reportLine + "\n" + existingContent
+// taggedEarnings adds up the earnings of the work cycles with tag, of the
+// archived days (the daily report file doesn't know tags) and today
+func taggedEarnings(timer *Timer, tag string) float64 {
+	days := []*Timer{timer}
+	dayStart, err := parseTime(timer.DayStart)
+	if err != nil {
+		dayStart = getCurrentTime()
+	}
+	if archived, err := loadArchivedDays(time.Time{}, dayStart); err == nil {
+		days = append(archived, days...)
+	}
+	earned := 0.0
+	for _, day := range days {
+		if day.DayStart == "" {
+			continue
+		}
+		for _, entry := range buildLogEntries(day) {
+			if entry.Type != "work" || !slices.Contains(entry.Tags, tag) {
+				continue
+			}
+			if rate, ok := cycleRate(entry.Tags); ok {
+				earned += rate.Earnings(entry.Minutes)
+			}
+		}
 	}
-	finalContent += "\n"
+	return earned
+}
 
-	return os.WriteFile(filePath, []byte(finalContent), 0644)
+// budgetSummary renders budget consumption, with a warning marker once it
+// reaches $WT_BUDGET_WARN percent (default 80). Returns "" if no budget is set.
+func budgetSummary(timer *Timer) string {
+	used, budget, ok := budgetStatus(timer)
+	if !ok {
+		return ""
+	}
+	percent := int(used / budget.Amount * 100)
+	summary := fmt.Sprintf("Budget: %s / %s (%d%%)", budget.Format(used), budget.Format(budget.Amount), percent)
+	if percent >= 100 {
+		summary += " [!] budget exceeded"
+	} else if percent >= envInt("WT_BUDGET_WARN", DefaultBudgetWarnPercent) {
+		summary += " [!] approaching budget"
+	}
+	return summary
 }
 
 // Command implementations
 
-func startCmd(timer *Timer, startTime string) error {
-	if startTime != "" {
-		if err := validateTimeString(startTime); err != nil {
+// StartOptions are the arguments of startCmd
+type StartOptions struct {
+	Time     string // Backdate the first cycle, or shorten the break before this one (HHMM or HH:MM)
+	Preset   string // WT_PRESET_<NAME> to apply from now on
+	Location string // Location of this and the following cycles
+	NoBreak  bool   // Count the time since the stop as work instead of a break
+	Break    string // Kind of the break this start ends (short, lunch, errand)
+	Task     string // Task of this and the following cycles, "none" to clear it
+	Estimate string // Estimate of the task (2h, 90m, HHMM)
+}
+
+func startCmd(timer *Timer, opts StartOptions) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	if opts.Time != "" {
+		if err := validateTimeString(opts.Time); err != nil {
+			return err
+		}
+		if opts.NoBreak {
+			return fmt.Errorf("Use either a start time or --no-break.")
+		}
+	}
+	if opts.Break != "" {
+		if err := validateBreakKind(opts.Break); err != nil {
 			return err
 		}
+		if timer.Status != StatusStopped || timer.StopDatetimeStr == "" || opts.NoBreak {
+			return fmt.Errorf("No break to mark: --break sets the kind of the break a start after 'wt stop' records.")
+		}
 	}
 
 	message := ""
+	startMinutes := map[string]int{} // Durations affected, for the debug log
 	switch timer.Status {
 	case StatusRunning:
 		fmt.Println("Already running.")
+		logWarning(timer, "start", nonEmpty(opts.Time), "Already running.")
 		return nil
 	case StatusPaused:
 		message = "Resuming timer."
@@ -692,16 +2581,46 @@ func startCmd(timer *Timer, startTime string) error {
 		pauseStart, _ := parseTime(timer.PauseStartStr)
 		pauseDuration := deltaMinutes(pauseStart, getCurrentTime())
 		timer.PausedMinutes += pauseDuration
+		if pause := endPause(timer, getCurrentTime()); pause.Minutes() > 0 {
+			timer.Pauses = append(timer.Pauses, pause)
+		}
+		timer.PauseReason = ""
+		startMinutes["paused"] = pauseDuration
 	case StatusStopped:
 		message = "Starting timer."
 	}
 
+	logArgs := nonEmpty(opts.Time)
+	if opts.Preset != "" {
+		if err := applyPreset(timer, opts.Preset); err != nil {
+			return err
+		}
+		logArgs = append([]string{"--preset", opts.Preset}, logArgs...)
+	}
+	if opts.Location != "" {
+		timer.Location = opts.Location
+		logArgs = append(logArgs, "@"+opts.Location)
+	}
+	if opts.Task != "" || opts.Estimate != "" {
+		taskArgs, err := setTask(timer, opts.Task, opts.Estimate)
+		if err != nil {
+			return err
+		}
+		logArgs = append(logArgs, taskArgs...)
+	}
+	if opts.NoBreak {
+		logArgs = append([]string{"--no-break"}, logArgs...)
+	}
+	if opts.Break != "" {
+		logArgs = append([]string{"--break", opts.Break}, logArgs...)
+	}
+
 	// Track if this is first cycle (before adding break)
 	isFirstCycle := len(timer.Timeline) == 0
 
 	// If start_time is provided on subsequent cycle, validate break duration first
-	if startTime != "" && !isFirstCycle {
-		backdateMinutes, _ := stringTimeToMinutes(startTime)
+	if opts.Time != "" && !isFirstCycle {
+		backdateMinutes, _ := stringTimeToMinutes(opts.Time)
 		// Calculate what the break would be
 		if timer.StopDatetimeStr != "" {
 			breakStart, _ := parseTime(timer.StopDatetimeStr)
@@ -724,10 +2643,22 @@ func startCmd(timer *Timer, startTime string) error {
 	if timer.StopDatetimeStr != "" {
 		stopDt, _ := parseTime(timer.StopDatetimeStr)
 		breakMinutes := deltaMinutes(stopDt, getCurrentTime())
-		timer.Timeline = append(timer.Timeline, TimelineEntry{
-			Type:    "break",
-			Minutes: breakMinutes,
-		})
+		if n := len(timer.Timeline); opts.NoBreak && n > 0 && timer.Timeline[n-1].Type == "work" {
+			// The time since the stop was work too; stop merges the new cycle into this one
+			timer.Timeline[n-1].Minutes += breakMinutes
+			startMinutes["work"] = breakMinutes
+			message = fmt.Sprintf("Continuing cycle %d (%s since the stop counted as work).", n, minutesToHourMinuteStr(breakMinutes))
+		} else {
+			timer.Timeline = append(timer.Timeline, TimelineEntry{
+				Type:    "break",
+				Minutes: breakMinutes,
+				Kind:    opts.Break,
+			})
+			startMinutes["break"] = breakMinutes
+		}
+	}
+	if opts.Time != "" {
+		startMinutes["backdate"], _ = stringTimeToMinutes(opts.Time)
 	}
 
 	timer.StopDatetimeStr = ""
@@ -741,11 +2672,7 @@ func startCmd(timer *Timer, startTime string) error {
 
 	timer.Status = StatusRunning
 
-	startTimeLog := ""
-	if startTime != "" {
-		startTimeLog = " " + startTime
-	}
-	logDebug(fmt.Sprintf("wt start%s", startTimeLog))
+	logCommand(timer, "start", logArgs, startMinutes)
 
 	if err := save(timer); err != nil {
 		return err
@@ -755,8 +2682,8 @@ func startCmd(timer *Timer, startTime string) error {
 	printCheckIfVerbose(timer)
 
 	// Handle start_time parameter
-	if startTime != "" {
-		backdateMinutes, _ := stringTimeToMinutes(startTime)
+	if opts.Time != "" {
+		backdateMinutes, _ := stringTimeToMinutes(opts.Time)
 
 		if isFirstCycle {
 			// Backdate the day_start and pause_start_str
@@ -788,9 +2715,13 @@ func startCmd(timer *Timer, startTime string) error {
 }
 
 func stopCmd(timer *Timer) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
 	switch timer.Status {
 	case StatusStopped:
 		fmt.Println("Timer already stopped.")
+		logWarning(timer, "stop", nil, "Timer already stopped.")
 		return nil
 	case StatusRunning, StatusPaused:
 		now := getCurrentTime()
@@ -815,12 +2746,38 @@ func stopCmd(timer *Timer) error {
 			cycleMinutes = 0
 		}
 
+		// Cycles overlapping a calendar event count as meetings
+		tags := cycleTags(timer)
+		meetings, err := calendarMeetings(cycleStart, now)
+		if err != nil {
+			fmt.Printf("Calendar not read: %v\n", err)
+		}
+		if len(meetings) > 0 {
+			tags = mergeTags(tags, []string{MeetingTag})
+		}
+
+		pauses := currentPauses(timer)
+
+		// Cycles on a Linear issue are linked to it by their task
+		task := timer.Task
+		issue := linearIssue(task)
+		if task == "" {
+			task = issue
+		}
+
 		// If last entry is work (no break between), merge into it
 		mergedIntoExisting := false
 		if len(timer.Timeline) > 0 && timer.Timeline[len(timer.Timeline)-1].Type == "work" {
 			lastWork := &timer.Timeline[len(timer.Timeline)-1]
+			lastWork.Pauses = append(lastWork.Pauses, shiftPauses(pauses, lastWork.Duration())...)
+			lastWork.Marks = append(lastWork.Marks, shiftMarks(timer.Marks, lastWork.Duration())...)
 			lastWork.Minutes += cycleMinutes
 			lastWork.PausedMinutes += totalPaused
+			lastWork.Tags = mergeTags(lastWork.Tags, tags)
+			lastWork.Meetings = mergeTags(lastWork.Meetings, meetings)
+			lastWork.Location = cmp.Or(lastWork.Location, cycleLocation(timer, cycleStart))
+			lastWork.Task = cmp.Or(lastWork.Task, task)
+			lastWork.Rating = cmp.Or(timer.Rating, lastWork.Rating)
 			mergedIntoExisting = true
 		}
 
@@ -829,20 +2786,35 @@ func stopCmd(timer *Timer) error {
 				Type:          "work",
 				Minutes:       cycleMinutes,
 				PausedMinutes: totalPaused,
+				Tags:          mergeTags(nil, tags),
+				Meetings:      meetings,
+				Location:      cycleLocation(timer, cycleStart),
+				Pauses:        pauses,
+				Marks:         timer.Marks,
+				Task:          task,
+				Rating:        timer.Rating,
 			})
 		}
 
 		timer.StopDatetimeStr = stopTimeStr
 		timer.PauseStartStr = ""
 		timer.PausedMinutes = 0
+		timer.PauseReason, timer.Pauses, timer.Marks, timer.Rating, timer.CycleTags = "", nil, nil, 0, nil
 		timer.Status = StatusStopped
 
-		logDebug("wt stop")
+		logCommand(timer, "stop", nil, map[string]int{"work": cycleMinutes, "paused": totalPaused})
 		if err := save(timer); err != nil {
 			return err
 		}
 
 		printMessageIfNotSilent(timer, "Timer stopped.")
+		if issue != "" && cycleMinutes > 0 && !linearMuted {
+			if err := postLinearCycle(issue, cycleStart, now, cycleMinutes); err != nil {
+				fmt.Printf("Not posted to %s: %v\n", issue, err)
+			} else {
+				printMessageIfNotSilent(timer, fmt.Sprintf("Posted %s to %s.", minutesToHourMinuteStr(cycleMinutes), issue))
+			}
+		}
 		printCheckIfVerbose(timer)
 	default:
 		fmt.Printf("Unhandled status: %s\n", timer.Status)
@@ -851,13 +2823,18 @@ func stopCmd(timer *Timer) error {
 	return nil
 }
 
-func pauseCmd(timer *Timer, pauseTime string) error {
+func pauseCmd(timer *Timer, pauseTime, reason string) error {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
 	switch timer.Status {
 	case StatusPaused:
 		fmt.Println("Timer already paused.")
+		logWarning(timer, "pause", nonEmpty(pauseTime), "Timer already paused.")
 		return nil
 	case StatusStopped:
 		fmt.Println("Cannot pause stopped timer.")
+		logWarning(timer, "pause", nonEmpty(pauseTime), "Cannot pause stopped timer.")
 		return nil
 	case StatusRunning:
 		// Validate and handle optional pause time parameter
@@ -891,13 +2868,13 @@ func pauseCmd(timer *Timer, pauseTime string) error {
 			timer.PauseStartStr = now.Format(DT_FORMAT)
 		}
 		timer.Status = StatusPaused
+		timer.PauseReason = strings.TrimSpace(reason)
 
-		// Log command
-		pauseTimeLog := ""
-		if pauseTime != "" {
-			pauseTimeLog = fmt.Sprintf(" %s", pauseTime)
+		logArgs := nonEmpty(pauseTime)
+		if timer.PauseReason != "" {
+			logArgs = append([]string{"-m", timer.PauseReason}, logArgs...)
 		}
-		logDebug(fmt.Sprintf("wt pause%s", pauseTimeLog))
+		logCommand(timer, "pause", logArgs, map[string]int{"paused": additionalPause})
 		if err := save(timer); err != nil {
 			return err
 		}
@@ -907,56 +2884,543 @@ func pauseCmd(timer *Timer, pauseTime string) error {
 		if additionalPause > 0 {
 			message = fmt.Sprintf("Paused timer (added %dm pause time)", additionalPause)
 		}
-		printMessageIfNotSilent(timer, message)
-		printCheckIfVerbose(timer)
-	default:
-		return fmt.Errorf("Unhandled status: %s", timer.Status)
+		printMessageIfNotSilent(timer, message)
+		printCheckIfVerbose(timer)
+	default:
+		return fmt.Errorf("Unhandled status: %s", timer.Status)
+	}
+
+	return nil
+}
+
+// checkDetailsCmd prints the check line followed by the requested extra lines
+func checkDetailsCmd(timer *Timer, budget, trend bool) error {
+	if err := checkCmd(timer); err != nil {
+		return err
+	}
+	if budget {
+		if summary := budgetSummary(timer); summary != "" {
+			fmt.Println(summary)
+		} else {
+			fmt.Println("No budget configured. Set WT_BUDGET and WT_RATE.")
+		}
+	}
+	if trend {
+		summary, err := trendSummary(timer, TrendDays)
+		if err != nil {
+			return err
+		}
+		fmt.Println(summary)
+	}
+	return nil
+}
+
+func checkCmd(timer *Timer) error {
+	if jsonOutput {
+		return printJSON(timerStatus(timer))
+	}
+	line, err := checkLine(timer)
+	if err != nil {
+		return err
+	}
+	fmt.Println(line)
+	return nil
+}
+
+// checkLine returns the line `wt check` prints
+func checkLine(timer *Timer) (string, error) {
+	runningMinutes := 0
+	pausedMinutes := 0
+
+	if timer.Status == StatusRunning || timer.Status == StatusPaused {
+		runningMinutes = calculateCurrentMinutes(timer)
+		pausedMinutes = timer.PausedMinutes
+
+		if timer.Status == StatusPaused {
+			pauseStart, _ := parseTime(timer.PauseStartStr)
+			currentPause := deltaMinutes(pauseStart, getCurrentTime())
+			pausedMinutes += currentPause
+		}
+	}
+
+	totalMinutes := runningMinutes + timer.CompletedMinutes()
+
+	var runningStr string
+	switch timer.Status {
+	case StatusRunning, StatusPaused:
+		runningStr = hourMinuteStrFromMinutes(runningMinutes)
+		if target := workTargetMinutes(timer, 0); pomodoroEnabled() && target > 0 {
+			if runningMinutes <= target {
+				runningStr = hourMinuteStrFromMinutes(target-runningMinutes) + " left"
+			} else {
+				runningStr = hourMinuteStrFromMinutes(runningMinutes-target) + " over"
+			}
+		}
+	case StatusStopped:
+		runningStr = "--:--"
+	default:
+		return "", fmt.Errorf("Unhandled status: %s.", timer.Status)
+	}
+
+	statusStr := strings.ToUpper(timer.Status)
+	totalStr := hourMinuteStrFromMinutes(totalMinutes)
+
+	pausedStr := ""
+	if pausedMinutes > 0 {
+		pausedStr = fmt.Sprintf(" |%02dm|", pausedMinutes)
+	}
+
+	etaStr := ""
+	if eta := goalETA(totalMinutes); eta != "" {
+		etaStr = " " + eta
+	}
+
+	// Warn when the running cycle has gone too long without a break
+	warningStr := ""
+	threshold := workTargetMinutes(timer, longSessionMinutes())
+	if timer.Status == StatusRunning && threshold > 0 && runningMinutes >= threshold {
+		warningStr = " [!] take a break: wt next"
+	}
+	if left, ok := pomodoroBreakLeft(timer); ok && left > 0 {
+		warningStr = fmt.Sprintf(" break %s left", hourMinuteStrFromMinutes(left))
+	} else if ok {
+		warningStr = " [!] break over: wt next"
+	}
+
+	return fmt.Sprintf("%s %s%s (%s)%s%s", runningStr, statusStr, pausedStr, totalStr, etaStr, warningStr), nil
+}
+
+// LogEntry is a timeline entry with its computed clock times, as shown by `wt log`
+type LogEntry struct {
+	Num           int       // 1-based position in the timeline (the number used by mod)
+	Type          string    // "work" or "break"
+	Label         string    // Display label: "Work", "Break", "Lunch", or "Errand"
+	Start         time.Time // Calculated from DayStart + previous durations
+	End           time.Time // Start + duration (now for the active cycle)
+	Minutes       int       // Work or break minutes
+	PausedMinutes int       // Paused time (work entries only)
+	RunningTotal  int       // Work minutes up to and including this entry
+	Active        bool      // True for the current running/paused cycle
+	Status        string    // Timer status for the active cycle
+	Tags          []string  // Work cycle tags
+	Meetings      []string  // Calendar events the work cycle overlapped
+	Location      string    // Where the work cycle happened
+	Task          string    // Task of the work cycle
+	Rating        int       // Energy/mood score, 0 if not rated
+	Pauses        []Pause   // Pauses, in minutes from Start
+	Marks         []Mark    // Markers, in minutes from Start
+	ID            string    // Timeline entry ID, "" for the active cycle
+	Created       string    // Audit trail of the timeline entry
+	Modified      string
+	Command       string
+}
+
+// LogOptions narrows down which entries historyCmd prints
+type LogOptions struct {
+	Since string // Only entries ending after this clock time (HHMM or HH:MM)
+	Until string // Only entries starting before this clock time (HHMM or HH:MM)
+	Type  string // Only "work" or "break" entries
+	Last  int    // Only the last N entries (after other filters)
+
+	Format string // Output format: "text" (default), "json", or "csv"
+
+	Condensed bool // Fold short breaks and group consecutive work cycles
+	MinBreak  int  // Breaks shorter than this many minutes are folded when condensed
+	Gantt     bool // Draw the day as one bar per hour
+	Audit     bool // Show the entries' IDs and audit trail
+	Pauses    bool // List each pause under its work cycle
+	Notes     bool // List the markers under their work cycle
+
+	Tail  int  // Debug log: only the last N lines
+	Today bool // Debug log: only lines from today
+}
+
+// LogRecord is the machine-readable form of a LogEntry (`wt log --format json|csv`)
+type LogRecord struct {
+	Num           int            `json:"num"`
+	Type          string         `json:"type"`
+	Label         string         `json:"label"`
+	Start         string         `json:"start"`
+	End           string         `json:"end"`
+	Minutes       int            `json:"minutes"`
+	PausedMinutes int            `json:"paused_minutes"`
+	RunningTotal  int            `json:"running_total"`
+	Active        bool           `json:"active"`
+	Status        string         `json:"status,omitempty"`
+	Tags          []string       `json:"tags,omitempty"`
+	Meetings      []string       `json:"meetings,omitempty"`
+	Location      string         `json:"location,omitempty"`
+	Task          string         `json:"task,omitempty"`
+	Rating        int            `json:"rating,omitempty"`
+	Pauses        []PauseRecord  `json:"pauses,omitempty"`
+	PauseReasons  map[string]int `json:"pause_reasons,omitempty"` // Paused minutes by reason
+	Marks         []MarkRecord   `json:"marks,omitempty"`
+	ID            string         `json:"id,omitempty"`
+	Created       string         `json:"created,omitempty"`
+	Modified      string         `json:"modified,omitempty"`
+	Command       string         `json:"command,omitempty"`
+	Earned        float64        `json:"earned,omitempty"` // Work minutes at $WT_RATE, if set
+}
+
+// Record converts the entry to its machine-readable form
+func (e LogEntry) Record() LogRecord {
+	record := LogRecord{
+		Num:           e.Num,
+		Type:          e.Type,
+		Label:         e.Label,
+		Start:         e.Start.Format(DT_FORMAT),
+		End:           e.End.Format(DT_FORMAT),
+		Minutes:       e.Minutes,
+		PausedMinutes: e.PausedMinutes,
+		RunningTotal:  e.RunningTotal,
+		Active:        e.Active,
+		Status:        e.Status,
+		Tags:          e.Tags,
+		Meetings:      e.Meetings,
+		Location:      e.Location,
+		Task:          e.Task,
+		Rating:        e.Rating,
+		Pauses:        pauseRecords(e.Start, e.Pauses),
+		PauseReasons:  pauseReasons(e.Pauses),
+		Marks:         markRecords(e.Start, e.Marks),
+		ID:            e.ID,
+		Created:       e.Created,
+		Modified:      e.Modified,
+		Command:       e.Command,
+	}
+	if e.Type == "work" {
+		if rate, ok := cycleRate(e.Tags); ok {
+			record.Earned = math.Round(rate.Earnings(e.Minutes)*100) / 100
+		}
+	}
+	return record
+}
+
+// Entry converts a record back, e.g. to print a remote log (remote.go)
+func (r LogRecord) Entry() (LogEntry, error) {
+	start, err := parseTime(r.Start)
+	if err != nil {
+		return LogEntry{}, err
+	}
+	end, err := parseTime(r.End)
+	if err != nil {
+		return LogEntry{}, err
+	}
+	pauses, err := recordPauses(start, r.Pauses)
+	if err != nil {
+		return LogEntry{}, err
+	}
+	if len(pauses) == 0 {
+		pauses = legacyPauses(r.PauseReasons)
+	}
+	marks, err := recordMarks(start, r.Marks)
+	if err != nil {
+		return LogEntry{}, err
+	}
+	return LogEntry{
+		Num:           r.Num,
+		Type:          r.Type,
+		Label:         r.Label,
+		Start:         start,
+		End:           end,
+		Minutes:       r.Minutes,
+		PausedMinutes: r.PausedMinutes,
+		RunningTotal:  r.RunningTotal,
+		Active:        r.Active,
+		Status:        r.Status,
+		Tags:          r.Tags,
+		Meetings:      r.Meetings,
+		Location:      r.Location,
+		Task:          r.Task,
+		Rating:        r.Rating,
+		Pauses:        pauses,
+		Marks:         marks,
+		ID:            r.ID,
+		Created:       r.Created,
+		Modified:      r.Modified,
+		Command:       r.Command,
+	}, nil
+}
+
+// buildLogEntries computes the contiguous log from the timeline, including the active cycle
+func buildLogEntries(timer *Timer) []LogEntry {
+	var currentTime time.Time
+	if timer.DayStart != "" {
+		currentTime, _ = parseTime(timer.DayStart)
+	} else {
+		currentTime = getCurrentTime()
+	}
+
+	var entries []LogEntry
+	runningTotal := 0
+
+	for i, entry := range timer.Timeline {
+		endTime := currentTime.Add(time.Duration(entry.Duration()) * time.Minute)
+		logEntry := LogEntry{
+			Num:      i + 1,
+			Type:     entry.Type,
+			Start:    currentTime,
+			End:      endTime,
+			Minutes:  entry.Minutes,
+			ID:       entry.ID,
+			Created:  entry.Created,
+			Modified: entry.Modified,
+			Command:  entry.Command,
+		}
+
+		if entry.Type == "work" {
+			runningTotal += entry.Minutes
+			logEntry.Label = "Work"
+			logEntry.PausedMinutes = entry.PausedMinutes
+			logEntry.Tags = entry.Tags
+			logEntry.Meetings = entry.Meetings
+			logEntry.Location = entry.Location
+			logEntry.Task = entry.Task
+			logEntry.Rating = entry.Rating
+			logEntry.Pauses = entry.Pauses
+			logEntry.Marks = entry.Marks
+		} else {
+			logEntry.Label = breakLabel(breakKind(currentTime, entry))
+		}
+		logEntry.RunningTotal = runningTotal
+
+		entries = append(entries, logEntry)
+		currentTime = endTime
+	}
+
+	// If timer is running or paused, add the current active cycle
+	if timer.Status == StatusRunning || timer.Status == StatusPaused {
+		now := getCurrentTime()
+		currentMinutes := calculateCurrentMinutes(timer)
+
+		// Calculate paused minutes for current cycle
+		totalPaused := timer.PausedMinutes
+		if timer.Status == StatusPaused {
+			pauseStart, _ := parseTime(timer.PauseStartStr)
+			totalPaused += deltaMinutes(pauseStart, now)
+		}
+
+		entries = append(entries, LogEntry{
+			Num:           len(timer.Timeline) + 1,
+			Type:          "work",
+			Label:         "Work",
+			Start:         currentTime,
+			End:           now,
+			Minutes:       currentMinutes,
+			PausedMinutes: totalPaused,
+			RunningTotal:  runningTotal + currentMinutes,
+			Active:        true,
+			Status:        timer.Status,
+			Tags:          cycleTags(timer),
+			Location:      cycleLocation(timer, timer.CurrentCycleStart()),
+			Task:          timer.Task,
+			Rating:        timer.Rating,
+			Pauses:        currentPauses(timer),
+			Marks:         timer.Marks,
+		})
+	}
+
+	return entries
+}
+
+// filterLogEntries applies LogOptions to the computed log
+func filterLogEntries(timer *Timer, entries []LogEntry, opts LogOptions) ([]LogEntry, error) {
+	if opts.Type != "" && opts.Type != "work" && opts.Type != "break" {
+		return nil, fmt.Errorf("Invalid type: %s. Use 'work' or 'break'", opts.Type)
+	}
+	if opts.Last < 0 {
+		return nil, fmt.Errorf("Invalid --last value: %d", opts.Last)
+	}
+
+	var since, until time.Time
+	var err error
+	if opts.Since != "" {
+		if since, err = clockTimeOnDay(timer, opts.Since); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Until != "" {
+		if until, err = clockTimeOnDay(timer, opts.Until); err != nil {
+			return nil, err
+		}
+	}
+
+	var filtered []LogEntry
+	for _, entry := range entries {
+		if opts.Type != "" && entry.Type != opts.Type {
+			continue
+		}
+		// Keep entries overlapping the [since, until] window
+		if !since.IsZero() && !entry.End.After(since) {
+			continue
+		}
+		if !until.IsZero() && !entry.Start.Before(until) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+
+	if opts.Last > 0 && len(filtered) > opts.Last {
+		filtered = filtered[len(filtered)-opts.Last:]
+	}
+
+	return filtered, nil
+}
+
+// clockTimeOnDay parses a HHMM or HH:MM clock time as a time on the timer's day
+func clockTimeOnDay(timer *Timer, clock string) (time.Time, error) {
+	digits := strings.ReplaceAll(clock, ":", "")
+	if err := validateTimeString(digits); err != nil || len(digits) < 3 {
+		return time.Time{}, fmt.Errorf("Invalid clock time: %s. Use HHMM or HH:MM", clock)
+	}
+	minutes, _ := stringTimeToMinutes(digits)
+	if minutes >= 24*60 {
+		return time.Time{}, fmt.Errorf("Invalid clock time: %s. Use HHMM or HH:MM", clock)
+	}
+
+	day := getCurrentTime()
+	if timer.DayStart != "" {
+		day, _ = parseTime(timer.DayStart)
+	}
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return midnight.Add(time.Duration(minutes) * time.Minute), nil
+}
+
+// condensedBlock is a run of work cycles joined by short breaks in `wt log --condensed`
+type condensedBlock struct {
+	entries      []LogEntry
+	work         int
+	paused       int
+	foldedBreaks int
+}
+
+// condenseLogEntries groups consecutive work entries separated only by breaks
+// shorter than minBreak. Longer breaks are kept as their own blocks.
+func condenseLogEntries(entries []LogEntry, minBreak int) []condensedBlock {
+	var blocks []condensedBlock
+	var current *condensedBlock
+
+	flush := func() {
+		if current != nil {
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+
+	for i, entry := range entries {
+		contiguous := i == 0 || entries[i-1].Num == entry.Num-1
+
+		if entry.Type == "work" {
+			if current == nil || !contiguous {
+				flush()
+				current = &condensedBlock{}
+			}
+			current.entries = append(current.entries, entry)
+			current.work += entry.Minutes
+			current.paused += entry.PausedMinutes
+			continue
+		}
+
+		// Fold a short break only if work continues right after it
+		nextIsWork := i+1 < len(entries) && entries[i+1].Type == "work" && entries[i+1].Num == entry.Num+1
+		if current != nil && contiguous && nextIsWork && entry.Minutes < minBreak {
+			current.entries = append(current.entries, entry)
+			current.foldedBreaks += entry.Minutes
+			continue
+		}
+
+		flush()
+		blocks = append(blocks, condensedBlock{entries: []LogEntry{entry}})
+	}
+	flush()
+
+	return blocks
+}
+
+// formatCondensedBlock renders a block as a single `wt log --condensed` line
+func formatCondensedBlock(block condensedBlock) string {
+	if len(block.entries) == 1 {
+		return formatLogEntry(block.entries[0])
+	}
+
+	first := block.entries[0]
+	last := block.entries[len(block.entries)-1]
+
+	endStr := last.End.Format(TIME_ONLY_FORMAT)
+	if last.Active {
+		endStr = "....."
+	}
+
+	pausedStr := ""
+	if block.paused > 0 {
+		pausedStr = fmt.Sprintf(" |%02dm|", block.paused)
+	}
+
+	cycles := 0
+	for _, entry := range block.entries {
+		if entry.Type == "work" {
+			cycles++
+		}
 	}
 
-	return nil
+	return fmt.Sprintf("%02d-%02d. [%s => %s] Work: %s%s (%s) [%d cycles, %s in breaks]",
+		first.Num, last.Num, first.Start.Format(TIME_ONLY_FORMAT), endStr,
+		minutesToHourMinuteStr(block.work), pausedStr, minutesToHourMinuteStr(last.RunningTotal),
+		cycles, minutesToHourMinuteStr(block.foldedBreaks))
 }
 
-func checkCmd(timer *Timer) error {
-	runningMinutes := 0
-	pausedMinutes := 0
-
-	if timer.Status == StatusRunning || timer.Status == StatusPaused {
-		runningMinutes = calculateCurrentMinutes(timer)
-		pausedMinutes = timer.PausedMinutes
+// formatLogEntry renders a log entry as a single `wt log` line
+func formatLogEntry(entry LogEntry) string {
+	startTimeStr := entry.Start.Format(TIME_ONLY_FORMAT)
 
-		if timer.Status == StatusPaused {
-			pauseStart, _ := parseTime(timer.PauseStartStr)
-			currentPause := deltaMinutes(pauseStart, getCurrentTime())
-			pausedMinutes += currentPause
-		}
+	if entry.Type != "work" {
+		return fmt.Sprintf("%02d. [%s => %s] %s: %s",
+			entry.Num, startTimeStr, entry.End.Format(TIME_ONLY_FORMAT), entry.Label, minutesToHourMinuteStr(entry.Minutes))
 	}
 
-	totalMinutes := runningMinutes + timer.CompletedMinutes()
+	workStr := minutesToHourMinuteStr(entry.Minutes)
+	totalStr := minutesToHourMinuteStr(entry.RunningTotal)
 
-	var runningStr string
-	switch timer.Status {
-	case StatusRunning, StatusPaused:
-		runningStr = hourMinuteStrFromMinutes(runningMinutes)
-	case StatusStopped:
-		runningStr = "--:--"
-	default:
-		return fmt.Errorf("Unhandled status: %s.", timer.Status)
+	pausedStr := ""
+	if reasons := pauseReasons(entry.Pauses); entry.PausedMinutes > 0 && len(reasons) > 0 {
+		pausedStr = fmt.Sprintf(" |%02dm: %s|", entry.PausedMinutes,
+			formatPauseReasons(entry.PausedMinutes, reasons, func(m int) string { return fmt.Sprintf("%dm", m) }))
+	} else if entry.PausedMinutes > 0 {
+		pausedStr = fmt.Sprintf(" |%02dm|", entry.PausedMinutes)
 	}
 
-	statusStr := strings.ToUpper(timer.Status)
-	totalStr := hourMinuteStrFromMinutes(totalMinutes)
+	if entry.Active {
+		dayDiff := int(entry.End.Sub(entry.Start).Hours() / 24)
+		dayIndicator := ""
+		if dayDiff > 0 {
+			dayIndicator = fmt.Sprintf("  [+%d day]", dayDiff)
+		}
 
-	pausedStr := ""
-	if pausedMinutes > 0 {
-		pausedStr = fmt.Sprintf(" |%02dm|", pausedMinutes)
+		statusSuffix := ""
+		if entry.Status == StatusPaused {
+			statusSuffix = " (paused)"
+		}
+
+		return fmt.Sprintf("%02d. [%s => .....] Work%s: %s%s (%s)%s%s",
+			entry.Num, startTimeStr, statusSuffix, workStr, pausedStr, totalStr, dayIndicator, formatTask(entry.Task)+formatTags(entry.Tags)+formatLocation(entry.Location)+formatMeetings(entry.Meetings)+formatRating(entry.Rating))
 	}
 
-	fmt.Printf("%s %s%s (%s)\n", runningStr, statusStr, pausedStr, totalStr)
+	// Calculate day indicator for midnight crossing
+	startYear, startMonth, startDay := entry.Start.Date()
+	endYear, endMonth, endDay := entry.End.Date()
+	startDate := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, entry.Start.Location())
+	endDate := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, entry.End.Location())
+	dayDiff := int(endDate.Sub(startDate).Hours() / 24)
+	dayIndicator := ""
+	if dayDiff > 0 {
+		dayIndicator = fmt.Sprintf("  [+%d day]", dayDiff)
+	}
 
-	return nil
+	return fmt.Sprintf("%02d. [%s => %s] Work: %s%s (%s)%s%s",
+		entry.Num, startTimeStr, entry.End.Format(TIME_ONLY_FORMAT), workStr, pausedStr, totalStr, dayIndicator, formatTask(entry.Task)+formatTags(entry.Tags)+formatLocation(entry.Location)+formatMeetings(entry.Meetings)+formatRating(entry.Rating))
 }
 
-func historyCmd(timer *Timer, logType string) error {
+func historyCmd(timer *Timer, logType string, opts LogOptions) error {
 	validTypes := []string{"info", "debug"}
 	if logType != "" {
 		valid := false
@@ -972,168 +3436,168 @@ func historyCmd(timer *Timer, logType string) error {
 		}
 	}
 
+	if jsonOutput {
+		if opts.Format != "" && opts.Format != "json" {
+			return fmt.Errorf("--json and --format %s don't go together.", opts.Format)
+		}
+		opts.Format = "json"
+	}
+
 	// Debug log still reads from file
 	if logType == "debug" {
 		filePath, err := debugLogFilePath()
 		if err != nil {
 			return err
 		}
-		data, err := os.ReadFile(filePath)
+		data, err := readFile(filePath)
 		if err != nil {
 			return err
 		}
-		fmt.Print(string(data))
+
+		var lines []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if opts.Today {
+			today := getCurrentTime().Format("2006-01-02")
+			var todayLines []string
+			for _, line := range lines {
+				if t, ok := debugLineTime(line); ok && t.Format("2006-01-02") == today {
+					todayLines = append(todayLines, line)
+				}
+			}
+			lines = todayLines
+		}
+		if opts.Tail > 0 && len(lines) > opts.Tail {
+			lines = lines[len(lines)-opts.Tail:]
+		}
+
+		for _, line := range lines {
+			if opts.Format == "json" {
+				fmt.Println(line)
+			} else if entry, ok := parseDebugLine(line); ok {
+				fmt.Println(formatDebugEntry(entry))
+			} else {
+				fmt.Println(line)
+			}
+		}
 		return nil
 	}
 
+	if opts.Format != "" && opts.Format != "text" && opts.Format != "json" && opts.Format != "csv" {
+		return fmt.Errorf("Invalid format: %s. Use 'text', 'json', or 'csv'", opts.Format)
+	}
+	if opts.Condensed && opts.Format != "" && opts.Format != "text" {
+		return fmt.Errorf("--condensed only works with text output")
+	}
+	if opts.Gantt && (opts.Condensed || opts.Format != "" && opts.Format != "text") {
+		return fmt.Errorf("--gantt can't be combined with --condensed or --format")
+	}
+	if opts.Audit && (opts.Gantt || opts.Condensed || opts.Format != "" && opts.Format != "text") {
+		return fmt.Errorf("--audit can't be combined with --gantt, --condensed, or --format; --format json includes the audit fields")
+	}
+	if opts.Pauses && (opts.Gantt || opts.Condensed || opts.Audit || opts.Format != "" && opts.Format != "text") {
+		return fmt.Errorf("--pauses only works with the plain log; --gantt draws the pauses and --format json includes them")
+	}
+	if opts.Notes && (opts.Gantt || opts.Condensed || opts.Audit || opts.Format != "" && opts.Format != "text") {
+		return fmt.Errorf("--notes only works with the plain log; --format json includes the markers")
+	}
+
 	// Generate info-log on-the-fly from timeline
-	if len(timer.Timeline) == 0 && timer.Status == StatusStopped {
+	if len(timer.Timeline) == 0 && timer.Status == StatusStopped && (opts.Format == "" || opts.Format == "text") {
 		fmt.Println("No work cycles recorded.")
 		return nil
 	}
 
-	// Generate entries from timeline
-	var currentTime time.Time
-	if timer.DayStart != "" {
-		currentTime, _ = parseTime(timer.DayStart)
-	} else {
-		currentTime = getCurrentTime()
+	entries, err := filterLogEntries(timer, buildLogEntries(timer), opts)
+	if err != nil {
+		return err
 	}
 
-	runningTotal := 0
-	lineNum := 1
-
-	for _, entry := range timer.Timeline {
-		if entry.Type == "work" {
-			workMins := entry.Minutes
-			pausedMins := entry.PausedMinutes
-
-			startTime := currentTime
-			endTime := currentTime.Add(time.Duration(entry.Duration()) * time.Minute)
-
-			runningTotal += workMins
-
-			startTimeStr := startTime.Format(TIME_ONLY_FORMAT)
-			endTimeStr := endTime.Format(TIME_ONLY_FORMAT)
-			workStr := minutesToHourMinuteStr(workMins)
-			totalStr := minutesToHourMinuteStr(runningTotal)
+	switch opts.Format {
+	case "json":
+		records := []LogRecord{}
+		for _, entry := range entries {
+			records = append(records, entry.Record())
+		}
+		data, err := json.MarshalIndent(records, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "csv":
+		dialect, err := csvDialect()
+		if err != nil {
+			return err
+		}
+		_, billing := billingRate()
+		header := []string{"num", "type", "label", "start", "end", "minutes", "paused_minutes", "running_total", "active", "status"}
+		if billing {
+			header = append(header, "earned")
+		}
 
-			pausedStr := ""
-			if pausedMins > 0 {
-				pausedStr = fmt.Sprintf(" |%02dm|", pausedMins)
+		w := dialect.NewWriter(os.Stdout)
+		w.Write(header)
+		for _, entry := range entries {
+			r := entry.Record()
+			row := []string{
+				strconv.Itoa(r.Num), r.Type, r.Label, dialect.FormatTime(entry.Start), dialect.FormatTime(entry.End),
+				strconv.Itoa(r.Minutes), strconv.Itoa(r.PausedMinutes), strconv.Itoa(r.RunningTotal),
+				strconv.FormatBool(r.Active), r.Status,
 			}
-
-			// Calculate day indicator for midnight crossing
-			dayDiff := int(endTime.Sub(startTime.Truncate(24*time.Hour)).Hours()/24) - int(startTime.Sub(startTime.Truncate(24*time.Hour)).Hours()/24)
-			startYear, startMonth, startDay := startTime.Date()
-			endYear, endMonth, endDay := endTime.Date()
-			startDate := time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, startTime.Location())
-			endDate := time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, endTime.Location())
-			dayDiff = int(endDate.Sub(startDate).Hours() / 24)
-			dayIndicator := ""
-			if dayDiff > 0 {
-				dayIndicator = fmt.Sprintf("  [+%d day]", dayDiff)
+			if billing {
+				row = append(row, dialect.FormatAmount(r.Earned))
 			}
-
-			fmt.Printf("%02d. [%s => %s] Work: %s%s (%s)%s\n",
-				lineNum, startTimeStr, endTimeStr, workStr, pausedStr, totalStr, dayIndicator)
-
-			currentTime = endTime
-		} else {
-			breakMins := entry.Minutes
-			endTime := currentTime.Add(time.Duration(breakMins) * time.Minute)
-
-			startTimeStr := currentTime.Format(TIME_ONLY_FORMAT)
-			endTimeStr := endTime.Format(TIME_ONLY_FORMAT)
-			breakStr := minutesToHourMinuteStr(breakMins)
-
-			fmt.Printf("%02d. [%s => %s] Break: %s\n",
-				lineNum, startTimeStr, endTimeStr, breakStr)
-
-			currentTime = endTime
+			w.Write(row)
 		}
-
-		lineNum++
-	}
-
-	// If timer is running or paused, show current active cycle
-	if timer.Status == StatusRunning || timer.Status == StatusPaused {
-		currentMinutes := calculateCurrentMinutes(timer)
-		totalMinutes := currentMinutes + runningTotal
-
-		currentStr := minutesToHourMinuteStr(currentMinutes)
-		totalStr := minutesToHourMinuteStr(totalMinutes)
-
-		// Use calculated start time from timeline
-		startTimeOnly := currentTime.Format(TIME_ONLY_FORMAT)
-
-		now := getCurrentTime()
-		dayDiff := int(now.Sub(currentTime).Hours() / 24)
-		dayIndicator := ""
-		if dayDiff > 0 {
-			dayIndicator = fmt.Sprintf("  [+%d day]", dayDiff)
+		w.Flush()
+		return w.Error()
+	default:
+		if opts.Gantt {
+			fmt.Print(renderGantt(entries, useColor()))
+			return nil
 		}
-
-		// Calculate paused minutes for current cycle
-		totalPaused := timer.PausedMinutes
-		if timer.Status == StatusPaused {
-			pauseStart, _ := parseTime(timer.PauseStartStr)
-			currentPause := deltaMinutes(pauseStart, now)
-			totalPaused += currentPause
+		if opts.Audit {
+			for _, entry := range entries {
+				fmt.Println(formatAuditEntry(entry))
+			}
+			return nil
 		}
-
-		pausedStr := ""
-		if totalPaused > 0 {
-			pausedStr = fmt.Sprintf(" |%02dm|", totalPaused)
+		if opts.Condensed {
+			for _, block := range condenseLogEntries(entries, opts.MinBreak) {
+				fmt.Println(formatCondensedBlock(block))
+			}
+			return nil
 		}
-
-		statusSuffix := ""
-		if timer.Status == StatusPaused {
-			statusSuffix = " (paused)"
+		for _, entry := range entries {
+			fmt.Println(formatLogEntry(entry))
+			if opts.Pauses {
+				fmt.Print(formatPauses(entry))
+			}
+			if opts.Notes {
+				fmt.Print(formatMarks(entry))
+			}
+		}
+		for _, jump := range timer.ClockJumps {
+			fmt.Println(formatClockJump(jump))
 		}
-
-		fmt.Printf("%02d. [%s => .....] Work%s: %s%s (%s)%s\n",
-			lineNum, startTimeOnly, statusSuffix, currentStr, pausedStr, totalStr, dayIndicator)
 	}
 
 	return nil
 }
 
 func reportCmd(timer *Timer) error {
+	if jsonOutput {
+		return printJSON(dayReport(timer))
+	}
 	if timer.DayStart == "" {
 		fmt.Println("No work recorded today.")
 		return nil
 	}
 
-	// Calculate totals from timeline
-	totalWorkMins := 0
-	totalBreakMins := 0
-	totalPausedMins := 0
-
-	for _, entry := range timer.Timeline {
-		if entry.Type == "work" {
-			totalWorkMins += entry.Minutes
-			totalPausedMins += entry.PausedMinutes
-		} else {
-			totalBreakMins += entry.Minutes
-		}
-	}
-
-	// Add current running/paused time if applicable
-	currentMins := 0
-	if timer.Status == StatusRunning || timer.Status == StatusPaused {
-		currentMins = calculateCurrentMinutes(timer)
-		totalWorkMins += currentMins
-
-		// Add current cycle's paused time
-		if timer.Status == StatusPaused {
-			pauseStart, _ := parseTime(timer.PauseStartStr)
-			currentPause := deltaMinutes(pauseStart, getCurrentTime())
-			totalPausedMins += timer.PausedMinutes + currentPause
-		} else {
-			totalPausedMins += timer.PausedMinutes
-		}
-	}
+	totals := timer.Totals()
 
 	// Calculate end time
 	startDt, _ := parseTime(timer.DayStart)
@@ -1141,17 +3605,16 @@ func reportCmd(timer *Timer) error {
 
 	// Add current running time
 	if timer.Status == StatusRunning || timer.Status == StatusPaused {
-		endDt = endDt.Add(time.Duration(currentMins) * time.Minute)
+		endDt = endDt.Add(time.Duration(calculateCurrentMinutes(timer)) * time.Minute)
 	}
 
 	// Format output
 	dateStr := startDt.Format("2006-01-02")
 	startTime := startDt.Format(TIME_ONLY_FORMAT)
 	endTime := endDt.Format(TIME_ONLY_FORMAT)
-	workStr := minutesToHourMinuteStr(totalWorkMins)
-	breakStr := minutesToHourMinuteStr(totalBreakMins)
-	pausedStr := minutesToHourMinuteStr(totalPausedMins)
-	totalStr := minutesToHourMinuteStr(totalWorkMins + totalBreakMins + totalPausedMins)
+	workStr := minutesToHourMinuteStr(totals.Work)
+	pausedStr := minutesToHourMinuteStr(totals.Paused)
+	totalStr := minutesToHourMinuteStr(totals.Total())
 
 	// Check if crossed midnight
 	startYear, startMonth, startDay := startDt.Date()
@@ -1164,18 +3627,142 @@ func reportCmd(timer *Timer) error {
 		dayIndicator = fmt.Sprintf(" [+%d day]", dayDiff)
 	}
 
-	fmt.Printf("%s | %s -> %s | Work: %s | Break: %s | Paused: %s | Total: %s%s\n",
-		dateStr, startTime, endTime, workStr, breakStr, pausedStr, totalStr, dayIndicator)
+	earnedStr := ""
+	if earned, rate, ok := dayEarnings(timer); ok {
+		earnedStr = " | Earned: " + rate.Format(earned)
+	}
+
+	if budget := budgetSummary(timer); budget != "" {
+		earnedStr += " | " + budget
+	}
+
+	etaStr := ""
+	if eta := goalETA(totals.Work); eta != "" {
+		etaStr = " | " + eta
+	}
+
+	fmt.Printf("%s | %s -> %s | Work: %s | %s | Paused: %s | Total: %s%s%s%s\n",
+		dateStr, startTime, endTime, workStr, totals.breakSummary(), pausedStr, totalStr, dayIndicator, earnedStr, etaStr)
+	if summary := breakKindSummary(timer); summary != "" {
+		fmt.Println(summary)
+	}
+	if summary := pauseSummary(timer); summary != "" {
+		fmt.Println(summary)
+	}
+	for _, line := range planSummary(timer) {
+		fmt.Println(line)
+	}
+	for _, line := range estimateSummary(timer.Estimates, timer.taskTotals()) {
+		fmt.Println(line)
+	}
 
 	return nil
 }
 
+// modCmd dispatches a mod to the matching modification; yes drops a long work cycle without asking
+func modCmd(timer *Timer, args []string, yes bool) error {
+	if len(args) > 0 && isEntryID(args[0]) {
+		num, ok := entryNumber(timer, args[0])
+		if !ok {
+			fmt.Printf("No cycle with ID %s. 'wt log --audit' lists the IDs.\n", args[0])
+			return nil
+		}
+		args = append([]string{strconv.Itoa(num)}, args[1:]...)
+	}
+
+	if len(args) == 3 && args[0] == "start" && args[1] == "date" {
+		return modStartDateCmd(timer, args[2])
+	}
+
+	if len(args) == 3 && args[0] == "start" {
+		return modStartCmd(timer, args[1], args[2])
+	}
+
+	if len(args) == 2 && args[1] == "drop" {
+		return modDropCmd(timer, args[0], yes)
+	}
+
+	if len(args) == 3 && args[1] == "kind" {
+		return modBreakKindCmd(timer, args[0], args[2])
+	}
+
+	if len(args) == 4 && args[1] == "pause" {
+		return modPauseCmd(timer, args[0], args[2], args[3])
+	}
+
+	if len(args) == 3 {
+		return modDurationCmd(timer, args[0], args[1], args[2])
+	}
+
+	return modListCmd()
+}
+
 func modListCmd() error {
 	fmt.Println("Usage:")
 	fmt.Println("  wt mod start <add|sub> <time>       - adjust day start time")
+	fmt.Println("  wt mod start date <YYYY-MM-DD>      - move the day to another date")
 	fmt.Println("  wt mod <num> <add|sub> <time>       - adjust cycle duration")
 	fmt.Println("  wt mod <num> pause <add|sub> <time> - adjust paused time")
 	fmt.Println("  wt mod <num> drop                   - remove cycle")
+	fmt.Println("  wt mod <num> kind <kind|auto>       - set a break's kind (short, lunch, errand)")
+	fmt.Println("  <num> can also be a cycle ID (e.g. e7) from 'wt log --audit'")
+	return nil
+}
+
+// modStartDateCmd moves the day to another date, keeping its clock times
+func modStartDateCmd(timer *Timer, dateStr string) error {
+	if timer.DayStart == "" {
+		fmt.Println("No day_start to modify.")
+		return nil
+	}
+	if timer.isClosed() {
+		return fmt.Errorf("Day %s is closed and archived as %s. It can't be moved to another date.", timer.DayStart[:len(DATE_FORMAT)], timer.Archive)
+	}
+	if timer.Status != StatusStopped {
+		return fmt.Errorf("Stop the timer before moving the day to another date.")
+	}
+
+	date, err := time.ParseInLocation(DATE_FORMAT, dateStr, time.Local)
+	if err != nil {
+		return fmt.Errorf("Invalid date: %s. Use YYYY-MM-DD", dateStr)
+	}
+	dayStart, _ := parseTime(timer.DayStart)
+	from := time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(to.Sub(from).Hours() / 24)
+	if days == 0 {
+		printMessageIfNotSilent(timer, fmt.Sprintf("Day is already on %s.", dateStr))
+		return nil
+	}
+
+	move := func(value string) string {
+		t, err := parseTime(value)
+		if err != nil {
+			return value
+		}
+		return t.AddDate(0, 0, days).Format(DT_FORMAT)
+	}
+	end := dayStart
+	if stop, err := parseTime(timer.StopDatetimeStr); err == nil && stop.After(end) {
+		end = stop
+	}
+	if end.AddDate(0, 0, days).After(getCurrentTime()) {
+		return fmt.Errorf("Can't move the day to %s: it would end in the future.", dateStr)
+	}
+
+	timer.DayStart = move(timer.DayStart)
+	timer.StopDatetimeStr = move(timer.StopDatetimeStr)
+
+	logCommand(timer, "mod", []string{"start", "date", dateStr}, map[string]int{"days": days})
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	unit := "days"
+	if days == 1 || days == -1 {
+		unit = "day"
+	}
+	printMessageIfNotSilent(timer, fmt.Sprintf("Day moved to %s (%+d %s)", dateStr, days, unit))
 	return nil
 }
 
@@ -1189,8 +3776,8 @@ func modStartCmd(timer *Timer, operation, timeStr string) error {
 		return fmt.Errorf("Invalid operation: %s. Use 'add' or 'sub'", operation)
 	}
 
-	if !isDigits(timeStr) {
-		return fmt.Errorf("Invalid time format. Should be digits only.")
+	if !isDigits(timeDigits(timeStr)) {
+		return fmt.Errorf("Invalid time format. Use HHMM or HH:MM.")
 	}
 
 	minutes, err := stringTimeToMinutes(timeStr)
@@ -1232,7 +3819,7 @@ func modStartCmd(timer *Timer, operation, timeStr string) error {
 		}
 	}
 
-	logDebug(fmt.Sprintf("wt mod start %s %s", operation, timeStr))
+	logCommand(timer, "mod", []string{"start", operation, timeStr}, map[string]int{"day_start": signedMinutes(operation, minutes)})
 	if err := save(timer); err != nil {
 		return err
 	}
@@ -1272,8 +3859,8 @@ func modDurationCmd(timer *Timer, cycleNumStr, operation, timeStr string) error
 		return nil
 	}
 
-	if !isDigits(timeStr) {
-		fmt.Println("Invalid time format. Should be digits only.")
+	if !isDigits(timeDigits(timeStr)) {
+		fmt.Println("Invalid time format. Use HHMM or HH:MM.")
 		return nil
 	}
 
@@ -1297,7 +3884,7 @@ func modDurationCmd(timer *Timer, cycleNumStr, operation, timeStr string) error
 		entry.Minutes = newDuration
 	}
 
-	logDebug(fmt.Sprintf("wt mod %s %s %s", cycleNumStr, operation, timeStr))
+	logCommand(timer, "mod", []string{cycleNumStr, operation, timeStr}, map[string]int{"cycle": cycleNum, "duration": signedMinutes(operation, minutes)})
 	if err := save(timer); err != nil {
 		return err
 	}
@@ -1343,8 +3930,8 @@ func modPauseCmd(timer *Timer, cycleNumStr, operation, timeStr string) error {
 		return nil
 	}
 
-	if !isDigits(timeStr) {
-		fmt.Println("Invalid time format. Should be digits only.")
+	if !isDigits(timeDigits(timeStr)) {
+		fmt.Println("Invalid time format. Use HHMM or HH:MM.")
 		return nil
 	}
 
@@ -1366,7 +3953,7 @@ func modPauseCmd(timer *Timer, cycleNumStr, operation, timeStr string) error {
 			timer.PausedMinutes = newPaused
 		}
 
-		logDebug(fmt.Sprintf("wt mod %s pause %s %s", cycleNumStr, operation, timeStr))
+		logCommand(timer, "mod", []string{cycleNumStr, "pause", operation, timeStr}, map[string]int{"cycle": cycleNum, "paused": signedMinutes(operation, minutes)})
 		if err := save(timer); err != nil {
 			return err
 		}
@@ -1400,7 +3987,7 @@ func modPauseCmd(timer *Timer, cycleNumStr, operation, timeStr string) error {
 
 		entry.PausedMinutes = newPaused
 
-		logDebug(fmt.Sprintf("wt mod %s pause %s %s", cycleNumStr, operation, timeStr))
+		logCommand(timer, "mod", []string{cycleNumStr, "pause", operation, timeStr}, map[string]int{"cycle": cycleNum, "paused": signedMinutes(operation, minutes)})
 		if err := save(timer); err != nil {
 			return err
 		}
@@ -1415,7 +4002,7 @@ func modPauseCmd(timer *Timer, cycleNumStr, operation, timeStr string) error {
 	return nil
 }
 
-func modDropCmd(timer *Timer, cycleNumStr string) error {
+func modDropCmd(timer *Timer, cycleNumStr string, yes bool) error {
 	if !isDigits(cycleNumStr) {
 		fmt.Printf("Invalid cycle number: %s\n", cycleNumStr)
 		return nil
@@ -1427,7 +4014,36 @@ func modDropCmd(timer *Timer, cycleNumStr string) error {
 		return nil
 	}
 
-	entryIdx := cycleNum - 1
+	entry := timer.Timeline[cycleNum-1]
+	if guard := envMinutes("WT_DROP_GUARD", DefaultDropGuardMinutes); entry.Type == "work" && guard > 0 && entry.Minutes > guard && !yes {
+		preview := *timer
+		preview.Timeline = append([]TimelineEntry{}, timer.Timeline...)
+		dropEntry(&preview, cycleNum-1)
+		before, after := timer.Totals(), preview.Totals()
+		fmt.Printf("Cycle %d is %s of work. Dropping it changes work %s -> %s, breaks %s -> %s.\n", cycleNum, minutesToHourMinuteStr(entry.Minutes),
+			minutesToHourMinuteStr(before.Work), minutesToHourMinuteStr(after.Work),
+			minutesToHourMinuteStr(before.Break+before.Lunch), minutesToHourMinuteStr(after.Break+after.Lunch))
+		if !yesOrNoPrompt("Drop it?") {
+			fmt.Printf("Nothing was dropped. Use 'wt mod --yes %s drop' to drop it anyway.\n", cycleNumStr)
+			return nil
+		}
+	}
+	mergeMsg := dropEntry(timer, cycleNum-1)
+
+	logCommand(timer, "mod", []string{cycleNumStr, "drop"}, map[string]int{"cycle": cycleNum, "removed": entry.Duration()})
+	if err := save(timer); err != nil {
+		return err
+	}
+
+	printMessageIfNotSilent(timer, fmt.Sprintf("Removed cycle %d%s", cycleNum, mergeMsg))
+
+	return nil
+}
+
+// dropEntry removes the timeline entry at entryIdx. A break between work
+// cycles (or before the running one) merges them, and so does a work cycle
+// between breaks. Returns a note on the merge for the message, if any.
+func dropEntry(timer *Timer, entryIdx int) string {
 	entry := timer.Timeline[entryIdx]
 	entryType := entry.Type
 
@@ -1450,6 +4066,11 @@ func modDropCmd(timer *Timer, cycleNumStr string) error {
 			}
 
 			combinedPaused := prevWork.PausedMinutes + timer.PausedMinutes
+			// The running cycle now starts with the previous work cycle
+			timer.Pauses = append(prevWork.Pauses, shiftPauses(timer.Pauses, prevWork.Duration()+entry.Minutes)...)
+			timer.Marks = append(prevWork.Marks, shiftMarks(timer.Marks, prevWork.Duration()+entry.Minutes)...)
+			timer.Rating = cmp.Or(timer.Rating, prevWork.Rating)
+			timer.CycleTags = mergeTags(prevWork.Tags, timer.CycleTags)
 
 			// Remove the break and the previous work entry
 			timer.Timeline = append(timer.Timeline[:entryIdx-1], timer.Timeline[entryIdx+1:]...)
@@ -1477,6 +4098,9 @@ func modDropCmd(timer *Timer, cycleNumStr string) error {
 			mergedWorkMins := prevWork.Minutes + breakMins + nextWork.Minutes
 			mergedPausedMins := prevWork.PausedMinutes + nextWork.PausedMinutes
 
+			prevWork.Pauses = append(prevWork.Pauses, shiftPauses(nextWork.Pauses, prevWork.Duration()+breakMins)...)
+			prevWork.Marks = append(prevWork.Marks, shiftMarks(nextWork.Marks, prevWork.Duration()+breakMins)...)
+			prevWork.Rating = cmp.Or(prevWork.Rating, nextWork.Rating)
 			prevWork.Minutes = mergedWorkMins
 			prevWork.PausedMinutes = mergedPausedMins
 
@@ -1503,24 +4127,38 @@ func modDropCmd(timer *Timer, cycleNumStr string) error {
 			timer.Timeline = append(timer.Timeline[:entryIdx], timer.Timeline[entryIdx+1:]...)
 		}
 	}
+	return mergeMsg
+}
 
-	logDebug(fmt.Sprintf("wt mod %s drop", cycleNumStr))
-	if err := save(timer); err != nil {
+// toggleCmd starts a stopped timer, pauses a running one, and resumes a
+// paused one, for binding wt to a single shortcut or button
+func toggleCmd(timer *Timer) error {
+	if err := timer.requireOpen(); err != nil {
 		return err
 	}
-
-	printMessageIfNotSilent(timer, fmt.Sprintf("Removed cycle %d%s", cycleNum, mergeMsg))
-
-	return nil
+	commandVia = "toggle"
+	defer func() { commandVia = "" }()
+	if timer.Status == StatusRunning {
+		return pauseCmd(timer, "", "")
+	}
+	return startCmd(timer, StartOptions{})
 }
 
 func nextCmd(timer *Timer) error {
-	if err := stopCmd(timer); err != nil {
+	if err := timer.requireOpen(); err != nil {
+		return err
+	}
+	if pomodoroEnabled() {
+		return pomodoroNextCmd(timer)
+	}
+	commandVia = "next"
+	err := stopCmd(timer)
+	commandVia = ""
+	if err != nil {
 		return err
 	}
 
 	// Reload timer after stop
-	var err error
 	timer, err = load()
 	if err != nil {
 		return err
@@ -1541,7 +4179,7 @@ func nextCmd(timer *Timer) error {
 	timer.PausedMinutes = 0
 	timer.Status = StatusRunning
 
-	logDebug("wt next")
+	logCommand(timer, "next", nil, nil)
 	if err := save(timer); err != nil {
 		return err
 	}
@@ -1552,9 +4190,9 @@ func nextCmd(timer *Timer) error {
 	return nil
 }
 
-func resetCmd(msg string) error {
+func resetCmd(msg, note string) error {
 	var oldMode string
-	var dailyReportContent []byte
+	var retentionErr error
 
 	filePath, err := outputFilePath()
 	if err != nil {
@@ -1568,43 +4206,43 @@ func resetCmd(msg string) error {
 		}
 
 		if !yesOrNoPrompt("Reset timer?") {
-			os.Exit(0)
+			exit(0)
 		}
 
 		oldMode = oldTimer.Mode
-		saveDailyReport(oldTimer)
-
-		dailyReportPath, _ := dailyReportFilePath()
-		if data, err := os.ReadFile(dailyReportPath); err == nil {
-			dailyReportContent = data
+		if !oldTimer.isClosed() { // wt close already reported and archived the day
+			addRetroNote(oldTimer, note)
+			// The day is only in wt.json until both are written
+			if err := saveDailyReport(oldTimer); err != nil {
+				return err
+			}
+			if _, err := archiveDay(oldTimer); err != nil {
+				return err
+			}
 		}
+		retentionErr = applyRetention()
 	}
 
 	outputFolder, err := outputFolderPath()
 	if err != nil {
 		return err
 	}
-
-	if _, err := os.Stat(outputFolder); err == nil {
-		os.RemoveAll(outputFolder)
-	}
-
 	os.MkdirAll(outputFolder, 0755)
 
+	// Only the day's files are cleared; reports, profiles, etc. are kept
+	os.Remove(filePath)
 	debugPath, _ := debugLogFilePath()
-	os.Create(debugPath)
-
-	if dailyReportContent != nil {
-		dailyPath, _ := dailyReportFilePath()
-		os.WriteFile(dailyPath, dailyReportContent, 0644)
+	for i := 1; i <= DebugLogRotations; i++ {
+		os.Remove(fmt.Sprintf("%s.%d", debugPath, i))
 	}
+	os.Create(debugPath)
 
 	timer := &Timer{
 		Status:          StatusStopped,
 		PauseStartStr:   "",
 		StopDatetimeStr: "",
 		PausedMinutes:   0,
-		Mode:            ModeSilent,
+		Mode:            defaultMode(),
 		Timeline:        []TimelineEntry{},
 		DayStart:        "",
 	}
@@ -1613,11 +4251,18 @@ func resetCmd(msg string) error {
 		timer.Mode = oldMode
 	}
 
+	logCommand(timer, "reset", nil, nil)
+	if retentionErr != nil { // Into the new debug log
+		logWarning(timer, "reset", nil, retentionErr.Error())
+	}
 	if err := save(timer); err != nil {
 		return err
 	}
 
 	printMessageIfNotSilent(timer, msg)
+	if retentionErr != nil {
+		printMessageIfNotSilent(timer, retentionErr.Error())
+	}
 	printCheckIfVerbose(timer)
 
 	return nil
@@ -1630,7 +4275,7 @@ func restartCmd(startTime string) error {
 		}
 	}
 
-	if err := resetCmd("Timer reset."); err != nil {
+	if err := resetCmd("Timer reset.", ""); err != nil {
 		return err
 	}
 
@@ -1639,11 +4284,11 @@ func restartCmd(startTime string) error {
 		return err
 	}
 
-	return startCmd(timer, startTime)
+	return startCmd(timer, StartOptions{Time: startTime})
 }
 
-func newCmd() error {
-	return resetCmd("New timer initialized.")
+func newCmd(note string) error {
+	return resetCmd("New timer initialized.", note)
 }
 
 func removeCmd() error {
@@ -1653,22 +4298,32 @@ func removeCmd() error {
 	}
 
 	if !yesOrNoPrompt("Remove timer?") {
-		os.Exit(0)
+		exit(0)
 	}
 
 	// Save daily report before removing timer
-	saveDailyReport(timer)
+	if !timer.isClosed() {
+		saveDailyReport(timer)
+	}
 
 	filePath, _ := outputFilePath()
 	os.Remove(filePath)
 
 	debugPath, _ := debugLogFilePath()
 	os.Remove(debugPath)
+	for i := 1; i <= DebugLogRotations; i++ {
+		os.Remove(fmt.Sprintf("%s.%d", debugPath, i))
+	}
 
 	dailyPath, _ := dailyReportFilePath()
 	if _, err := os.Stat(dailyPath); err == nil {
 		os.Remove(dailyPath)
 	}
+	weeklyPath, _ := weeklyReportFilePath()
+	os.Remove(weeklyPath)
+
+	archivePath, _ := archiveFolderPath()
+	os.RemoveAll(archivePath)
 
 	printMessageIfNotSilent(timer, "Timer removed.")
 
@@ -1682,6 +4337,9 @@ func statusCmd() error {
 	}
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if jsonOutput {
+			return printJSON(timerStatus(&Timer{Status: StatusStopped}))
+		}
 		fmt.Println(StatusStopped)
 		return nil
 	}
@@ -1691,6 +4349,9 @@ func statusCmd() error {
 		return err
 	}
 
+	if jsonOutput {
+		return printJSON(timerStatus(timer))
+	}
 	fmt.Println(timer.Status)
 	return nil
 }
@@ -1707,6 +4368,7 @@ func modeCmd(mode string) error {
 	}
 
 	timer.Mode = mode
+	logCommand(timer, "mode", []string{mode}, nil)
 	if err := save(timer); err != nil {
 		return err
 	}
@@ -1716,6 +4378,24 @@ func modeCmd(mode string) error {
 	return nil
 }
 
+func remindCmd(timer *Timer, notify bool) error {
+	message, err := scheduleCmd(timer)
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		message = reminderMessage(timer)
+	}
+	if message == "" {
+		return nil
+	}
+	fmt.Println(message)
+	if notify {
+		return sendNotification(NotificationTitle, message)
+	}
+	return nil
+}
+
 func debugCmd() error {
 	filePath, err := outputFilePath()
 	if err != nil {